@@ -0,0 +1,272 @@
+package ginboot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// AuditChange is the value of a field before and after a write, nil when the
+// field was absent
+type AuditChange struct {
+	Old interface{} `json:"old,omitempty" bson:"old,omitempty"`
+	New interface{} `json:"new,omitempty" bson:"new,omitempty"`
+}
+
+// AuditEntry records who wrote which entity, how and when. Changes are keyed
+// by the entity's JSON field names.
+type AuditEntry struct {
+	ID         string                 `json:"id" bson:"_id" ginboot:"_id"`
+	UserID     string                 `json:"userId,omitempty" bson:"userId,omitempty"`
+	Action     string                 `json:"action" bson:"action"`
+	EntityType string                 `json:"entityType" bson:"entityType"`
+	EntityID   string                 `json:"entityId" bson:"entityId"`
+	Changes    map[string]AuditChange `json:"changes,omitempty" bson:"changes,omitempty"`
+	Timestamp  time.Time              `json:"timestamp" bson:"timestamp"`
+}
+
+// AuditSink stores audit entries, e.g. in a collection, a table or a log
+// stream
+type AuditSink interface {
+	Write(ctx context.Context, entry AuditEntry) error
+}
+
+// AuditSinkFunc adapts a function to the AuditSink interface
+type AuditSinkFunc func(ctx context.Context, entry AuditEntry) error
+
+func (f AuditSinkFunc) Write(ctx context.Context, entry AuditEntry) error {
+	return f(ctx, entry)
+}
+
+// RepositoryAuditSink stores entries with a repository, so they go to a
+// Mongo collection, a SQL table or a DynamoDB partition like any entity
+type RepositoryAuditSink struct {
+	repo GenericRepository[AuditEntry]
+}
+
+func NewRepositoryAuditSink(repo GenericRepository[AuditEntry]) *RepositoryAuditSink {
+	return &RepositoryAuditSink{repo: repo}
+}
+
+func (s *RepositoryAuditSink) Write(ctx context.Context, entry AuditEntry) error {
+	return s.repo.Save(ctx, entry)
+}
+
+// LogAuditSink writes entries as log lines. A nil logger writes through the
+// Logger of the write's context, so lines carry the request ID.
+type LogAuditSink struct {
+	logger Logger
+}
+
+func NewLogAuditSink(logger Logger) *LogAuditSink {
+	return &LogAuditSink{logger: logger}
+}
+
+func (s *LogAuditSink) Write(ctx context.Context, entry AuditEntry) error {
+	logger := s.logger
+	if logger == nil {
+		logger = LoggerFromContext(ctx)
+	}
+	logger.Info("ginboot: audit",
+		"audit_id", entry.ID,
+		"user_id", entry.UserID,
+		"action", entry.Action,
+		"entity_type", entry.EntityType,
+		"entity_id", entry.EntityID,
+		"changes", entry.Changes,
+	)
+	return nil
+}
+
+// AuditService records entity changes to its sinks. Enable it on a
+// repository with WithAudit.
+type AuditService struct {
+	sinks []AuditSink
+}
+
+func NewAuditService(sinks ...AuditSink) *AuditService {
+	return &AuditService{sinks: sinks}
+}
+
+// Record stamps the entry with an ID, the time and the user of ctx, set as
+// "user_id" by the auth middlewares, and writes it to every sink
+func (s *AuditService) Record(ctx context.Context, entry AuditEntry) error {
+	if entry.ID == "" {
+		entry.ID = uuid.NewString()
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+	if entry.UserID == "" {
+		entry.UserID, _ = ctx.Value("user_id").(string)
+	}
+	var errs []error
+	for _, sink := range s.sinks {
+		if err := sink.Write(ctx, entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithAudit records every Save, SaveOrUpdate, SaveAll, Update and Delete of
+// the repository with audit. Updates and deletes read the stored document
+// first to diff it. An audit error is returned though the write happened.
+// Writes made inside WithTransaction are not audited.
+func WithAudit[T any](repo GenericRepository[T], audit *AuditService) GenericRepository[T] {
+	return &auditedRepository[T]{GenericRepository: repo, audit: audit, entity: entityName[T]()}
+}
+
+type auditedRepository[T any] struct {
+	GenericRepository[T]
+	audit  *AuditService
+	entity string
+}
+
+func (r *auditedRepository[T]) Save(ctx context.Context, doc T) error {
+	if err := r.GenericRepository.Save(ctx, doc); err != nil {
+		return err
+	}
+	return r.record(ctx, AuditCreate, getDocumentID(doc), nil, doc)
+}
+
+func (r *auditedRepository[T]) SaveAll(ctx context.Context, docs []T) error {
+	if err := r.GenericRepository.SaveAll(ctx, docs); err != nil {
+		return err
+	}
+	var errs []error
+	for _, doc := range docs {
+		if err := r.record(ctx, AuditCreate, getDocumentID(doc), nil, doc); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *auditedRepository[T]) SaveOrUpdate(ctx context.Context, doc T) error {
+	id := getDocumentID(doc)
+	old, found, err := r.previous(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := r.GenericRepository.SaveOrUpdate(ctx, doc); err != nil {
+		return err
+	}
+	if !found {
+		return r.record(ctx, AuditCreate, id, nil, doc)
+	}
+	return r.record(ctx, AuditUpdate, id, old, doc)
+}
+
+func (r *auditedRepository[T]) Update(ctx context.Context, doc T) error {
+	id := getDocumentID(doc)
+	old, _, err := r.previous(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := r.GenericRepository.Update(ctx, doc); err != nil {
+		return err
+	}
+	return r.record(ctx, AuditUpdate, id, old, doc)
+}
+
+func (r *auditedRepository[T]) Delete(ctx context.Context, id string) error {
+	old, _, err := r.previous(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := r.GenericRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.record(ctx, AuditDelete, id, old, nil)
+}
+
+func (r *auditedRepository[T]) DeleteVersion(ctx context.Context, doc T) error {
+	id := getDocumentID(doc)
+	old, _, err := r.previous(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := r.GenericRepository.DeleteVersion(ctx, doc); err != nil {
+		return err
+	}
+	return r.record(ctx, AuditDelete, id, old, nil)
+}
+
+// previous returns the stored document, or nil when there is none
+func (r *auditedRepository[T]) previous(ctx context.Context, id string) (interface{}, bool, error) {
+	if id == "" {
+		return nil, false, nil
+	}
+	doc, err := r.GenericRepository.FindById(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return doc, true, nil
+}
+
+func (r *auditedRepository[T]) record(ctx context.Context, action, id string, before, after interface{}) error {
+	changes, err := auditChanges(before, after)
+	if err != nil {
+		return err
+	}
+	return r.audit.Record(ctx, AuditEntry{
+		Action:     action,
+		EntityType: r.entity,
+		EntityID:   id,
+		Changes:    changes,
+	})
+}
+
+// auditChanges diffs the JSON fields of two documents, either of which may be
+// nil
+func auditChanges(before, after interface{}) (map[string]AuditChange, error) {
+	oldFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+	changes := map[string]AuditChange{}
+	for key, value := range newFields {
+		if previous, ok := oldFields[key]; !ok || !reflect.DeepEqual(previous, value) {
+			changes[key] = AuditChange{Old: previous, New: value}
+		}
+	}
+	for key, value := range oldFields {
+		if _, ok := newFields[key]; !ok {
+			changes[key] = AuditChange{Old: value}
+		}
+	}
+	return changes, nil
+}
+
+func auditFields(doc interface{}) (map[string]interface{}, error) {
+	if doc == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
@@ -0,0 +1,77 @@
+package ginboot
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type auditedNote struct {
+	ID    string `json:"id" ginboot:"_id"`
+	Title string `json:"title"`
+	Body  string `json:"body,omitempty"`
+}
+
+func TestWithAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Set("user_id", "user-1")
+
+	entries := newMemoryRepository[AuditEntry]()
+	var logged []AuditEntry
+	audit := NewAuditService(NewRepositoryAuditSink(entries), AuditSinkFunc(func(ctx context.Context, entry AuditEntry) error {
+		logged = append(logged, entry)
+		return nil
+	}))
+	notes := WithAudit[auditedNote](newMemoryRepository[auditedNote](), audit)
+
+	assert.NoError(t, notes.Save(ctx, auditedNote{ID: "n1", Title: "Draft"}))
+	assert.NoError(t, notes.Update(ctx, auditedNote{ID: "n1", Title: "Final", Body: "text"}))
+	assert.NoError(t, notes.SaveOrUpdate(ctx, auditedNote{ID: "n2", Title: "Other"}))
+	assert.NoError(t, notes.Delete(ctx, "n1"))
+
+	stored, err := entries.FindAll(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, stored, 4)
+	assert.Equal(t, stored, logged)
+
+	created := logged[0]
+	assert.NotEmpty(t, created.ID)
+	assert.False(t, created.Timestamp.IsZero())
+	assert.Equal(t, "user-1", created.UserID)
+	assert.Equal(t, AuditCreate, created.Action)
+	assert.Equal(t, "auditedNote", created.EntityType)
+	assert.Equal(t, "n1", created.EntityID)
+	assert.Equal(t, AuditChange{New: "Draft"}, created.Changes["title"])
+
+	updated := logged[1]
+	assert.Equal(t, AuditUpdate, updated.Action)
+	assert.Equal(t, map[string]AuditChange{
+		"title": {Old: "Draft", New: "Final"},
+		"body":  {New: "text"},
+	}, updated.Changes)
+
+	assert.Equal(t, AuditCreate, logged[2].Action)
+	assert.Equal(t, "n2", logged[2].EntityID)
+
+	deleted := logged[3]
+	assert.Equal(t, AuditDelete, deleted.Action)
+	assert.Equal(t, AuditChange{Old: "Final"}, deleted.Changes["title"])
+}
+
+func TestWithAuditReportsSinkErrors(t *testing.T) {
+	audit := NewAuditService(AuditSinkFunc(func(ctx context.Context, entry AuditEntry) error {
+		return errors.New("sink down")
+	}))
+	repo := newMemoryRepository[auditedNote]()
+	notes := WithAudit[auditedNote](repo, audit)
+
+	err := notes.Save(context.Background(), auditedNote{ID: "n1", Title: "Draft"})
+	assert.EqualError(t, err, "sink down")
+	_, err = repo.FindById(context.Background(), "n1")
+	assert.NoError(t, err)
+}
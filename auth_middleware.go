@@ -0,0 +1,129 @@
+package ginboot
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	TokenMissing     = ApiError{"TOKEN_MISSING", "Authorization token is required"}
+	TokenInvalid     = ApiError{"TOKEN_INVALID", "Authorization token is invalid or expired"}
+	NotAuthenticated = ApiError{"NOT_AUTHENTICATED", "Authentication is required"}
+	Forbidden        = ApiError{"FORBIDDEN", "You do not have permission to access this resource"}
+)
+
+type JWTAuthConfig struct {
+	// Parse validates the token's signature and expiry
+	Parse func(token string) (*jwt.Token, error)
+	// Optional lets requests without a token through unauthenticated, while
+	// tokens that are present must still be valid
+	Optional bool
+	// Cookie accepts the auth cookie set by Context.SetAuthCookie from requests
+	// without an Authorization header. Browsers send the cookie on requests
+	// other sites trigger too, so only enable it for endpoints protected
+	// against CSRF.
+	Cookie bool
+}
+
+// DefaultJWTAuthConfig validates access tokens issued by GenerateTokens
+func DefaultJWTAuthConfig() JWTAuthConfig {
+	return JWTAuthConfig{Parse: ParseAccessToken}
+}
+
+// JWTAuthMiddleware authenticates requests with the bearer token (or the auth
+// cookie, when config.Cookie is set) and stores its sub, role and claims as user_id, role and claims in
+// the gin context, so ctx.GetAuthContext() works in handlers
+func JWTAuthMiddleware(config JWTAuthConfig) gin.HandlerFunc {
+	if config.Parse == nil {
+		config.Parse = ParseAccessToken
+	}
+	return func(c *gin.Context) {
+		tokenString := BearerToken(c)
+		if tokenString == "" && config.Cookie {
+			tokenString, _ = c.Cookie(AuthCookieName)
+		}
+		if tokenString == "" {
+			if config.Optional {
+				c.Next()
+				return
+			}
+			abortWithApiError(c, http.StatusUnauthorized, TokenMissing)
+			return
+		}
+
+		token, err := config.Parse(tokenString)
+		if err != nil || !token.Valid {
+			abortWithApiError(c, http.StatusUnauthorized, TokenInvalid)
+			return
+		}
+		claims, err := ExtractClaims(token)
+		if err != nil {
+			abortWithApiError(c, http.StatusUnauthorized, TokenInvalid)
+			return
+		}
+		userID, _ := claims["sub"].(string)
+		if userID == "" {
+			abortWithApiError(c, http.StatusUnauthorized, TokenInvalid)
+			return
+		}
+		role, _ := claims["role"].(string)
+
+		c.Set("user_id", userID)
+		c.Set("role", role)
+		c.Set("claims", map[string]interface{}(claims))
+		c.Next()
+	}
+}
+
+// BearerToken returns the token from the "Authorization: Bearer" header. The
+// auth cookie is only read when AuthCookieMiddleware copied it into the header.
+func BearerToken(c *gin.Context) string {
+	authHeader := c.GetHeader("Authorization")
+	if token, ok := strings.CutPrefix(authHeader, "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// AuthCookieMiddleware lets header based authentication accept the auth cookie
+// by copying it into the Authorization header when the header is absent. As
+// browsers send the cookie on requests other sites trigger too, only register
+// it on groups protected against CSRF.
+func AuthCookieMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token, err := c.Cookie(AuthCookieName); err == nil && token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequireRoles lets requests through when the authenticated user has one of
+// the roles, or any authenticated user when no roles are given. It runs after
+// the authentication middleware, e.g. JWTAuthMiddleware, and fails with 401
+// for anonymous requests and 403 for users without a required role.
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("user_id") == "" {
+			abortWithApiError(c, http.StatusUnauthorized, NotAuthenticated)
+			return
+		}
+		if len(roles) == 0 {
+			c.Next()
+			return
+		}
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		abortWithApiError(c, http.StatusForbidden, Forbidden)
+	}
+}
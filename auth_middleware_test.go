@@ -0,0 +1,146 @@
+package ginboot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthCookieMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		header        string
+		cookie        string
+		expectedToken string
+	}{
+		{name: "header only", header: "Bearer header-token", expectedToken: "header-token"},
+		{name: "cookie only", cookie: "cookie-token", expectedToken: "cookie-token"},
+		{name: "header wins", header: "Bearer header-token", cookie: "cookie-token", expectedToken: "header-token"},
+		{name: "neither", expectedToken: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.Use(AuthCookieMiddleware())
+			engine.GET("/", func(c *gin.Context) {
+				c.String(http.StatusOK, BearerToken(c))
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: AuthCookieName, Value: tt.cookie})
+			}
+			engine.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedToken, w.Body.String())
+		})
+	}
+}
+
+func TestJWTAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetSecretProvider(SecretProviderFunc(func(ctx context.Context, name string) (string, error) {
+		return name + "-secret", nil
+	}))
+	defer SetSecretProvider(EnvSecretProvider{})
+
+	accessToken, refreshToken, err := GenerateTokens("user-1", "admin")
+	assert.NoError(t, err)
+
+	newServer := func(config JWTAuthConfig) *Server {
+		server := &Server{engine: gin.New()}
+		server.Group("", JWTAuthMiddleware(config)).GET("/me", func(ctx *Context) (string, error) {
+			auth, err := ctx.GetAuthContext()
+			if err != nil {
+				return "", err
+			}
+			return auth.UserID + ":" + auth.Roles[0] + ":" + auth.Claims["iss"].(string), nil
+		})
+		return server
+	}
+	get := func(server *Server, authorization string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		server.engine.ServeHTTP(w, req)
+		return w
+	}
+
+	server := newServer(DefaultJWTAuthConfig())
+
+	w := get(server, "Bearer "+accessToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"user-1:admin:klass-lk"`, w.Body.String())
+
+	w = get(server, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "TOKEN_MISSING")
+
+	w = get(server, "Bearer "+refreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "TOKEN_INVALID")
+
+	optional := newServer(JWTAuthConfig{Optional: true})
+	assert.Equal(t, http.StatusUnauthorized, get(optional, "Bearer invalid").Code)
+	assert.NotContains(t, get(optional, "").Body.String(), "TOKEN_MISSING")
+
+	// the auth cookie is only accepted when enabled
+	withCookie := func(server *Server) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.AddCookie(&http.Cookie{Name: AuthCookieName, Value: accessToken})
+		server.engine.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusUnauthorized, withCookie(server))
+	assert.Equal(t, http.StatusOK, withCookie(newServer(JWTAuthConfig{Cookie: true})))
+}
+
+func TestRequireRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{engine: gin.New()}
+	group := server.Group("", func(c *gin.Context) {
+		if user := c.GetHeader("X-User"); user != "" {
+			c.Set("user_id", user)
+			c.Set("role", c.GetHeader("X-Role"))
+		}
+		c.Next()
+	})
+	ok := func() (string, error) { return "ok", nil }
+	group.GETAuth("/profile", ok)
+	group.DELETEAuth("/posts/:id", ok, "admin", "editor")
+
+	send := func(method, path, user, role string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-User", user)
+		req.Header.Set("X-Role", role)
+		server.engine.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, send(http.MethodGet, "/profile", "u1", "reader").Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodDelete, "/posts/1", "u1", "editor").Code)
+
+	w := send(http.MethodGet, "/profile", "", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t, `{"error_code":"NOT_AUTHENTICATED","message":"Authentication is required"}`, w.Body.String())
+
+	w = send(http.MethodDelete, "/posts/1", "u1", "reader")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "FORBIDDEN")
+}
@@ -0,0 +1,82 @@
+package ginboot
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ServiceUnavailable = ApiError{"SERVICE_UNAVAILABLE", "Service is temporarily unavailable, please retry later"}
+
+// RetryAfterError asks the client to back off. SendError answers it with its
+// Status and a Retry-After header, so every service signals backpressure the
+// same way. Build it with TooManyRequests or Unavailable.
+type RetryAfterError struct {
+	Status     int
+	RetryAfter time.Duration
+	Err        ApiError
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("%s (retry after %s)", e.Err.Error(), e.RetryAfter)
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// TooManyRequests answers 429 RATE_LIMITED, e.g. with the delay reported by a
+// RateLimiter
+func TooManyRequests(retryAfter time.Duration) *RetryAfterError {
+	return &RetryAfterError{Status: http.StatusTooManyRequests, RetryAfter: retryAfter, Err: RateLimited}
+}
+
+// Unavailable answers 503 SERVICE_UNAVAILABLE, e.g. while a circuit breaker is
+// open, with the time until it half-opens:
+//
+//	if breaker.Open() {
+//		return nil, ginboot.Unavailable(breaker.Remaining())
+//	}
+func Unavailable(retryAfter time.Duration) *RetryAfterError {
+	return &RetryAfterError{Status: http.StatusServiceUnavailable, RetryAfter: retryAfter, Err: ServiceUnavailable}
+}
+
+// SetRetryAfter sets the Retry-After header to the delay in whole seconds,
+// rounded up and at least one
+func SetRetryAfter(c *gin.Context, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+}
+
+// RateLimiter enforces a RateLimitConfig from code, e.g. to limit an action
+// rather than a route. The Key of the config is not used.
+type RateLimiter struct {
+	limiter *rateLimiter
+}
+
+func NewRateLimiter(config RateLimitConfig) *RateLimiter {
+	return &RateLimiter{limiter: &rateLimiter{config: rateLimitDefaults(config)}}
+}
+
+// Allow counts a request of key, returning a TooManyRequests error carrying
+// the time until key may retry once over the limit. Requests are let through
+// when the store fails.
+func (l *RateLimiter) Allow(ctx context.Context, key string) error {
+	allowed, _, retryAfter, err := l.limiter.take(ctx, key, time.Now())
+	if err != nil {
+		LoggerFromContext(ctx).Warn("ginboot: rate limit store failed", "error", err)
+		return nil
+	}
+	if !allowed {
+		return TooManyRequests(retryAfter)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package ginboot
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackpressureErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	exports := NewRateLimiter(RateLimitConfig{Limit: 1, Window: time.Minute})
+	server := &Server{engine: gin.New()}
+	group := server.Group("")
+	group.GET("/search", func() (string, error) {
+		return "", Unavailable(1500 * time.Millisecond)
+	})
+	group.POST("/exports", func(ctx *Context) (string, error) {
+		if err := exports.Allow(ctx, "user-1"); err != nil {
+			return "", err
+		}
+		return "started", nil
+	})
+
+	w := httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest("GET", "/search", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "SERVICE_UNAVAILABLE")
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest("POST", "/exports", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest("POST", "/exports", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "RATE_LIMITED")
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewRateLimiter(RateLimitConfig{Algorithm: TokenBucket, Limit: 1, Window: 10 * time.Second})
+
+	assert.NoError(t, limiter.Allow(ctx, "a"))
+	err := limiter.Allow(ctx, "a")
+	var retryErr *RetryAfterError
+	assert.True(t, errors.As(err, &retryErr))
+	assert.Equal(t, http.StatusTooManyRequests, retryErr.Status)
+	assert.InDelta(t, 10*time.Second, retryErr.RetryAfter, float64(time.Second))
+	assert.ErrorIs(t, err, RateLimited)
+
+	assert.NoError(t, limiter.Allow(ctx, "b"))
+}
@@ -0,0 +1,225 @@
+package ginboot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestTransformer rewrites the raw request body before it is bound, e.g. to
+// decompress, decrypt or unwrap a gateway's envelope. It may also adjust the
+// request headers, such as Content-Type.
+type RequestTransformer func(ctx *Context, body []byte) ([]byte, error)
+
+// ResponseTransformer rewrites the serialized response body, e.g. to wrap or
+// encrypt it. ctx.Writer.Status() is the response status; headers set on ctx
+// are sent with the transformed body.
+type ResponseTransformer func(ctx *Context, body []byte) ([]byte, error)
+
+var RequestTooLarge = ApiError{"REQUEST_TOO_LARGE", "Request body is too large"}
+
+// errRequestTooLarge is returned by transformers producing a body over the
+// limit of TransformRequestWithLimit
+var errRequestTooLarge = errors.New("request body is too large")
+
+// DefaultMaxTransformBytes is the request body size TransformRequest reads
+const DefaultMaxTransformBytes = 10 << 20
+
+const maxTransformBytesKey = "ginboot.max_transform_bytes"
+
+// TransformRequest runs the request body of a group or route through the
+// transformers, in order, before handlers bind it. Bodies are limited to
+// DefaultMaxTransformBytes, see TransformRequestWithLimit.
+func TransformRequest(transformers ...RequestTransformer) gin.HandlerFunc {
+	return TransformRequestWithLimit(DefaultMaxTransformBytes, transformers...)
+}
+
+// TransformRequestWithLimit is TransformRequest for bodies of up to maxBytes,
+// which also limits what DecompressRequest inflates. Larger bodies are
+// rejected with 413 REQUEST_TOO_LARGE. A failing transformer rejects the
+// request with its ApiError, or 400 INVALID_REQUEST.
+func TransformRequestWithLimit(maxBytes int64, transformers ...RequestTransformer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		body, err := readLimited(c.Request.Body, maxBytes)
+		if err == nil {
+			c.Set(maxTransformBytesKey, maxBytes)
+			ctx := NewContext(c)
+			for _, transform := range transformers {
+				if body, err = transform(ctx, body); err != nil {
+					break
+				}
+			}
+		}
+		if errors.Is(err, errRequestTooLarge) {
+			abortWithApiError(c, http.StatusRequestEntityTooLarge, RequestTooLarge)
+			return
+		}
+		if err != nil {
+			var apiErr ApiError
+			if !errors.As(err, &apiErr) {
+				err = InvalidRequest.New(err.Error())
+			}
+			SendError(c, err)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Request.ContentLength = int64(len(body))
+		c.Next()
+	}
+}
+
+// TransformResponse runs the response body of a group or route through the
+// transformers, in order, once the handler has written it. Responses are
+// buffered, so streamed responses are only sent when the handler returns.
+// Empty bodies are not transformed.
+func TransformResponse(transformers ...ResponseTransformer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = writer
+		c.Next()
+
+		body := writer.body.Bytes()
+		if writer.written && len(body) > 0 {
+			ctx := NewContext(c)
+			for _, transform := range transformers {
+				var err error
+				if body, err = transform(ctx, body); err != nil {
+					c.Writer = writer.ResponseWriter
+					LoggerFromContext(c.Request.Context()).Error("ginboot: transforming response failed", "error", err)
+					SendError(c, err)
+					return
+				}
+			}
+		}
+		c.Writer = writer.ResponseWriter
+		c.Writer.WriteHeader(writer.status)
+		if writer.written {
+			c.Header("Content-Length", strconv.Itoa(len(body)))
+			c.Writer.Write(body)
+		}
+	}
+}
+
+// bufferedWriter holds the response back until TransformResponse sends it
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(status int) {
+	if status > 0 && !w.written {
+		w.status = status
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+// Flush is a no-op, the body is sent once transformed
+func (w *bufferedWriter) Flush() {}
+
+// readLimited reads up to maxBytes, failing with errRequestTooLarge when there
+// is more
+func readLimited(reader io.Reader, maxBytes int64) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(reader, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxBytes {
+		return nil, errRequestTooLarge
+	}
+	return body, nil
+}
+
+// DecompressRequest inflates request bodies sent with Content-Encoding: gzip,
+// up to the limit of TransformRequestWithLimit, so small compressed bodies
+// cannot expand without bound
+func DecompressRequest() RequestTransformer {
+	return func(ctx *Context, body []byte) ([]byte, error) {
+		if !strings.EqualFold(ctx.GetHeader("Content-Encoding"), "gzip") {
+			return body, nil
+		}
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		maxBytes := ctx.GetInt64(maxTransformBytesKey)
+		if maxBytes <= 0 {
+			maxBytes = DefaultMaxTransformBytes
+		}
+		inflated, err := readLimited(reader, maxBytes)
+		if err != nil {
+			return nil, err
+		}
+		ctx.Request.Header.Del("Content-Encoding")
+		return inflated, nil
+	}
+}
+
+// UnwrapJSONEnvelope replaces a JSON request body by the value of its field,
+// e.g. {"data": {...}} by {...}
+func UnwrapJSONEnvelope(field string) RequestTransformer {
+	return func(ctx *Context, body []byte) ([]byte, error) {
+		var envelope map[string]json.RawMessage
+		if err := json.Unmarshal(body, &envelope); err != nil {
+			return nil, err
+		}
+		inner, ok := envelope[field]
+		if !ok {
+			return nil, errors.New("envelope has no " + field + " field")
+		}
+		return inner, nil
+	}
+}
+
+// WrapJSONEnvelope wraps JSON responses in an object holding them in field,
+// e.g. {...} becomes {"data": {...}}
+func WrapJSONEnvelope(field string) ResponseTransformer {
+	return func(ctx *Context, body []byte) ([]byte, error) {
+		if !strings.HasPrefix(ctx.Writer.Header().Get("Content-Type"), "application/json") {
+			return body, nil
+		}
+		return json.Marshal(map[string]json.RawMessage{field: body})
+	}
+}
@@ -0,0 +1,106 @@
+package ginboot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type transformedRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+func TestBodyTransform(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{engine: gin.New()}
+	gateway := server.Group("/gateway",
+		TransformRequest(DecompressRequest(), UnwrapJSONEnvelope("data")),
+		TransformResponse(WrapJSONEnvelope("data")))
+	gateway.POST("/greet", func(ctx *Context, req transformedRequest) (map[string]string, error) {
+		return map[string]string{"greeting": "hello " + req.Name}, nil
+	})
+	gateway.GET("/missing", func() (string, error) {
+		return "", ErrNotFound
+	})
+	server.Group("/upper", TransformResponse(func(ctx *Context, body []byte) ([]byte, error) {
+		if ctx.Writer.Status() != http.StatusOK {
+			return nil, errors.New("unexpected status")
+		}
+		return bytes.ToUpper(body), nil
+	})).GET("", func() (string, error) {
+		return "quiet", nil
+	})
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(`{"data":{"name":"ann"}}`))
+	gz.Close()
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/gateway/greet", &compressed)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	server.engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":{"greeting":"hello ann"}}`, w.Body.String())
+	assert.Equal(t, "33", w.Header().Get("Content-Length"))
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/gateway/greet", strings.NewReader(`{"name":"ann"}`))
+	req.Header.Set("Content-Type", "application/json")
+	server.engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "envelope has no data field")
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/gateway/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"data":{"error_code":"NOT_FOUND","message":"Resource not found"}}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/upper", nil))
+	assert.Equal(t, `"QUIET"`, w.Body.String())
+}
+
+func TestTransformRequestWithLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{engine: gin.New()}
+	server.Group("/small", TransformRequestWithLimit(128, DecompressRequest())).
+		POST("", func(ctx *Context, req transformedRequest) (string, error) {
+			return req.Name, nil
+		})
+	post := func(body []byte, gzipped bool) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/small", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if gzipped {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+		server.engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post([]byte(`{"name":"ann"}`), false)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = post([]byte(`{"name":"`+strings.Repeat("a", 128)+`"}`), false)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "REQUEST_TOO_LARGE")
+
+	// a small compressed body inflating past the limit
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(`{"name":"` + strings.Repeat("a", 10000) + `"}`))
+	gz.Close()
+	assert.Less(t, compressed.Len(), 128)
+	w = post(compressed.Bytes(), true)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
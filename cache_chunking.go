@@ -0,0 +1,206 @@
+package ginboot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultCacheChunkSize keeps each chunk under DynamoDB's 400KB item limit,
+// leaving room for the key and attributes
+const DefaultCacheChunkSize = 350 << 10
+
+// WithCacheChunking splits values larger than chunkSize, DefaultCacheChunkSize
+// when zero, across several entries of the cache and reassembles them on Get,
+// so backends with an item size limit can hold large pages. The value's key
+// holds a manifest, written after the chunks; a Get missing any chunk is a
+// miss. Chunks share the value's TTL and tags. Combine it with
+// WithCacheCompression to compress before splitting:
+//
+//	cache := ginboot.WithCacheCompression(
+//		ginboot.WithCacheChunking(backend, 0),
+//		ginboot.CacheCompressionConfig{CompressAbove: 1 << 10},
+//	)
+func WithCacheChunking(cache CacheService, chunkSize int) CacheService {
+	if chunkSize <= 0 {
+		chunkSize = DefaultCacheChunkSize
+	}
+	return &chunkedCache{next: cache, chunkSize: chunkSize}
+}
+
+// cacheManifestMagic prefixes the manifest entry of a chunked value
+var cacheManifestMagic = []byte("\x00gbm")
+
+// cacheManifest lists the chunks of a value. Chunk keys include a per-write
+// version, so concurrent writes of a key never mix their chunks.
+type cacheManifest struct {
+	Version string `json:"version"`
+	Chunks  int    `json:"chunks"`
+	Size    int    `json:"size"`
+}
+
+func (m cacheManifest) chunkKey(key string, i int) string {
+	return fmt.Sprintf("%s#chunk:%s:%d", key, m.Version, i)
+}
+
+func (m cacheManifest) chunkKeys(key string) []string {
+	keys := make([]string, m.Chunks)
+	for i := range keys {
+		keys[i] = m.chunkKey(key, i)
+	}
+	return keys
+}
+
+type chunkedCache struct {
+	next      CacheService
+	chunkSize int
+}
+
+func (c *chunkedCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	previous, _ := c.manifest(ctx, key)
+	if err := c.set(ctx, key, value, ttl, tags); err != nil {
+		return err
+	}
+	if previous != nil {
+		// best effort, the replaced chunks expire with their TTL otherwise
+		_ = c.next.Invalidate(ctx, previous.chunkKeys(key)...)
+	}
+	return nil
+}
+
+// SetIfAbsent writes the chunks of large values first and claims the key with
+// the manifest, removing the chunks when the key was taken
+func (c *chunkedCache) SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) (bool, error) {
+	if len(value) <= c.chunkSize {
+		return c.next.SetIfAbsent(ctx, key, value, ttl, tags...)
+	}
+	manifest, err := c.setChunks(ctx, key, value, ttl, tags)
+	if err != nil {
+		return false, err
+	}
+	stored, err := c.next.SetIfAbsent(ctx, key, manifest, ttl, tags...)
+	if err != nil || !stored {
+		// best effort, the chunks expire with their TTL otherwise
+		if manifest, _ := parseCacheManifest(manifest); manifest != nil {
+			_ = c.next.Invalidate(ctx, manifest.chunkKeys(key)...)
+		}
+	}
+	return stored, err
+}
+
+func (c *chunkedCache) set(ctx context.Context, key string, value []byte, ttl time.Duration, tags []string) error {
+	if len(value) <= c.chunkSize {
+		return c.next.Set(ctx, key, value, ttl, tags...)
+	}
+	manifest, err := c.setChunks(ctx, key, value, ttl, tags)
+	if err != nil {
+		return err
+	}
+	return c.next.Set(ctx, key, manifest, ttl, tags...)
+}
+
+// setChunks stores the chunks of value and returns the manifest entry listing
+// them
+func (c *chunkedCache) setChunks(ctx context.Context, key string, value []byte, ttl time.Duration, tags []string) ([]byte, error) {
+	manifest := cacheManifest{
+		Version: uuid.NewString(),
+		Chunks:  (len(value) + c.chunkSize - 1) / c.chunkSize,
+		Size:    len(value),
+	}
+	for i := 0; i < manifest.Chunks; i++ {
+		chunk := value[i*c.chunkSize : min((i+1)*c.chunkSize, len(value))]
+		if err := c.next.Set(ctx, manifest.chunkKey(key, i), chunk, ttl, tags...); err != nil {
+			return nil, err
+		}
+	}
+	encoded, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, cacheManifestMagic...), encoded...), nil
+}
+
+func (c *chunkedCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	entry, ok, err := c.next.Get(ctx, key)
+	if err != nil || !ok {
+		return entry, ok, err
+	}
+	manifest, err := parseCacheManifest(entry)
+	if err != nil {
+		return nil, false, err
+	}
+	if manifest == nil {
+		return entry, true, nil
+	}
+	value := make([]byte, 0, manifest.Size)
+	for i := 0; i < manifest.Chunks; i++ {
+		chunk, ok, err := c.next.Get(ctx, manifest.chunkKey(key, i))
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		value = append(value, chunk...)
+	}
+	if len(value) != manifest.Size {
+		return nil, false, nil
+	}
+	return value, true, nil
+}
+
+// Touch resets the TTL of the value and its chunks, reporting false when a
+// chunk is gone as Get would miss
+func (c *chunkedCache) Touch(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	manifest, err := c.manifest(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	touched, err := c.next.Touch(ctx, key, ttl)
+	if err != nil || !touched || manifest == nil {
+		return touched, err
+	}
+	for _, chunkKey := range manifest.chunkKeys(key) {
+		touched, err := c.next.Touch(ctx, chunkKey, ttl)
+		if err != nil || !touched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (c *chunkedCache) Invalidate(ctx context.Context, keys ...string) error {
+	all := append([]string{}, keys...)
+	for _, key := range keys {
+		if manifest, _ := c.manifest(ctx, key); manifest != nil {
+			all = append(all, manifest.chunkKeys(key)...)
+		}
+	}
+	return c.next.Invalidate(ctx, all...)
+}
+
+func (c *chunkedCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	return c.next.InvalidateTags(ctx, tags...)
+}
+
+// manifest returns the manifest stored at key, nil when the key holds a
+// plain value or nothing
+func (c *chunkedCache) manifest(ctx context.Context, key string) (*cacheManifest, error) {
+	entry, ok, err := c.next.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return parseCacheManifest(entry)
+}
+
+func parseCacheManifest(entry []byte) (*cacheManifest, error) {
+	if !bytes.HasPrefix(entry, cacheManifestMagic) {
+		return nil, nil
+	}
+	var manifest cacheManifest
+	if err := json.Unmarshal(entry[len(cacheManifestMagic):], &manifest); err != nil {
+		return nil, fmt.Errorf("reading cache manifest: %w", err)
+	}
+	return &manifest, nil
+}
@@ -0,0 +1,96 @@
+package ginboot
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheChunking(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryCacheService(DefaultInMemoryCacheConfig())
+	cache := WithCacheChunking(store, 100)
+	large := bytes.Repeat([]byte("0123456789"), 25)
+
+	t.Run("large values are split and reassembled", func(t *testing.T) {
+		assert.NoError(t, cache.Set(ctx, "page", large, time.Minute, "pages"))
+
+		value, ok, err := cache.Get(ctx, "page")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, large, value)
+
+		manifest, err := parseCacheManifest(mustGet(t, store, "page"))
+		assert.NoError(t, err)
+		assert.Equal(t, 3, manifest.Chunks)
+		for _, key := range manifest.chunkKeys("page") {
+			assert.LessOrEqual(t, len(mustGet(t, store, key)), 100)
+		}
+	})
+
+	t.Run("a missing chunk is a miss", func(t *testing.T) {
+		assert.NoError(t, cache.Set(ctx, "partial", large, time.Minute))
+		manifest, _ := parseCacheManifest(mustGet(t, store, "partial"))
+		assert.NoError(t, store.Invalidate(ctx, manifest.chunkKey("partial", 1)))
+
+		_, ok, err := cache.Get(ctx, "partial")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("overwriting and invalidating remove the chunks", func(t *testing.T) {
+		assert.NoError(t, cache.Set(ctx, "replaced", large, time.Minute))
+		manifest, _ := parseCacheManifest(mustGet(t, store, "replaced"))
+
+		assert.NoError(t, cache.Set(ctx, "replaced", []byte("small"), time.Minute))
+		value, ok, _ := cache.Get(ctx, "replaced")
+		assert.True(t, ok)
+		assert.Equal(t, []byte("small"), value)
+		_, ok, _ = store.Get(ctx, manifest.chunkKey("replaced", 0))
+		assert.False(t, ok)
+
+		assert.NoError(t, cache.Set(ctx, "replaced", large, time.Minute))
+		manifest, _ = parseCacheManifest(mustGet(t, store, "replaced"))
+		assert.NoError(t, cache.Invalidate(ctx, "replaced"))
+		_, ok, _ = store.Get(ctx, manifest.chunkKey("replaced", 2))
+		assert.False(t, ok)
+	})
+
+	t.Run("set if absent claims the key with the manifest", func(t *testing.T) {
+		stored, err := cache.SetIfAbsent(ctx, "claimed", large, time.Minute)
+		assert.NoError(t, err)
+		assert.True(t, stored)
+		entries := store.Len()
+
+		stored, err = cache.SetIfAbsent(ctx, "claimed", bytes.Repeat([]byte("x"), 250), time.Minute)
+		assert.NoError(t, err)
+		assert.False(t, stored)
+		assert.Equal(t, entries, store.Len(), "the chunks of the losing write are removed")
+		assert.Equal(t, large, mustGet(t, cache, "claimed"))
+	})
+
+	t.Run("touch extends the chunks", func(t *testing.T) {
+		assert.NoError(t, cache.Set(ctx, "short", large, 20*time.Millisecond))
+		touched, err := cache.Touch(ctx, "short", time.Minute)
+		assert.NoError(t, err)
+		assert.True(t, touched)
+		time.Sleep(30 * time.Millisecond)
+		assert.Equal(t, large, mustGet(t, cache, "short"))
+	})
+
+	t.Run("tags invalidate the chunks", func(t *testing.T) {
+		assert.NoError(t, cache.InvalidateTags(ctx, "pages"))
+		_, ok, _ := cache.Get(ctx, "page")
+		assert.False(t, ok)
+	})
+}
+
+func mustGet(t *testing.T, cache CacheService, key string) []byte {
+	value, ok, err := cache.Get(context.Background(), key)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	return value
+}
@@ -0,0 +1,146 @@
+package ginboot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+type CacheCompressionConfig struct {
+	// CompressAbove is the size in bytes from which values are gzipped;
+	// smaller values rarely shrink enough to be worth it
+	CompressAbove int
+	// MaxValueSize is the largest value in bytes, after compression, that is
+	// stored; larger values are skipped and the key invalidated. Zero means
+	// no limit.
+	MaxValueSize int
+}
+
+// DefaultCacheCompressionConfig compresses values from 1KB and skips values
+// over 350KB compressed, keeping entries under DynamoDB's 400KB item limit
+func DefaultCacheCompressionConfig() CacheCompressionConfig {
+	return CacheCompressionConfig{
+		CompressAbove: 1 << 10,
+		MaxValueSize:  350 << 10,
+	}
+}
+
+// WithCacheCompression gzips large values before storing them in cache and
+// skips values too large to store. Compressed entries are flagged, so values
+// stored before compression was enabled are still read.
+func WithCacheCompression(cache CacheService, config CacheCompressionConfig) CacheService {
+	return &compressedCache{next: cache, config: config}
+}
+
+// cacheEntryMagic prefixes values stored by WithCacheCompression, followed by
+// one of the encoding flags
+var cacheEntryMagic = []byte("\x00gbc")
+
+const (
+	cacheEntryRaw byte = iota
+	cacheEntryGzip
+)
+
+type compressedCache struct {
+	next   CacheService
+	config CacheCompressionConfig
+}
+
+func (c *compressedCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	entry, err := c.encode(value)
+	if err != nil {
+		return err
+	}
+	if c.config.MaxValueSize > 0 && len(entry) > c.config.MaxValueSize {
+		LoggerFromContext(ctx).Debug("ginboot: value too large to cache", "key", key, "size", len(entry))
+		// a stale value must not outlive the one that was not cached
+		return c.next.Invalidate(ctx, key)
+	}
+	return c.next.Set(ctx, key, entry, ttl, tags...)
+}
+
+func (c *compressedCache) SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) (bool, error) {
+	entry, err := c.encode(value)
+	if err != nil {
+		return false, err
+	}
+	if c.config.MaxValueSize > 0 && len(entry) > c.config.MaxValueSize {
+		return false, fmt.Errorf("ginboot: value of %d bytes is too large to cache", len(entry))
+	}
+	return c.next.SetIfAbsent(ctx, key, entry, ttl, tags...)
+}
+
+func (c *compressedCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	entry, ok, err := c.next.Get(ctx, key)
+	if err != nil || !ok {
+		return entry, ok, err
+	}
+	value, err := decodeCacheEntry(entry)
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (c *compressedCache) Touch(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return c.next.Touch(ctx, key, ttl)
+}
+
+func (c *compressedCache) Invalidate(ctx context.Context, keys ...string) error {
+	return c.next.Invalidate(ctx, keys...)
+}
+
+func (c *compressedCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	return c.next.InvalidateTags(ctx, tags...)
+}
+
+func (c *compressedCache) encode(value []byte) ([]byte, error) {
+	if len(value) < c.config.CompressAbove {
+		return flaggedCacheEntry(cacheEntryRaw, value), nil
+	}
+	var buf bytes.Buffer
+	buf.Write(cacheEntryMagic)
+	buf.WriteByte(cacheEntryGzip)
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(value); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	// incompressible values are kept as they are
+	if buf.Len() >= len(value)+len(cacheEntryMagic)+1 {
+		return flaggedCacheEntry(cacheEntryRaw, value), nil
+	}
+	return buf.Bytes(), nil
+}
+
+func flaggedCacheEntry(flag byte, value []byte) []byte {
+	entry := make([]byte, 0, len(cacheEntryMagic)+1+len(value))
+	entry = append(entry, cacheEntryMagic...)
+	entry = append(entry, flag)
+	return append(entry, value...)
+}
+
+func decodeCacheEntry(entry []byte) ([]byte, error) {
+	if !bytes.HasPrefix(entry, cacheEntryMagic) || len(entry) == len(cacheEntryMagic) {
+		return entry, nil
+	}
+	flag, value := entry[len(cacheEntryMagic)], entry[len(cacheEntryMagic)+1:]
+	switch flag {
+	case cacheEntryGzip:
+		reader, err := gzip.NewReader(bytes.NewReader(value))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return io.ReadAll(reader)
+	case cacheEntryRaw:
+		return value, nil
+	default:
+		return entry, nil
+	}
+}
@@ -0,0 +1,59 @@
+package ginboot
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheCompression(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryCacheService(DefaultInMemoryCacheConfig())
+	cache := WithCacheCompression(store, CacheCompressionConfig{CompressAbove: 64, MaxValueSize: 1024})
+
+	t.Run("small values are stored as is", func(t *testing.T) {
+		assert.NoError(t, cache.Set(ctx, "small", []byte("hello"), time.Minute))
+		value, ok, err := cache.Get(ctx, "small")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("hello"), value)
+	})
+
+	t.Run("large values are compressed", func(t *testing.T) {
+		large := bytes.Repeat([]byte(`{"name":"item"},`), 500)
+		assert.NoError(t, cache.Set(ctx, "large", large, time.Minute))
+
+		stored, ok, _ := store.Get(ctx, "large")
+		assert.True(t, ok)
+		assert.Less(t, len(stored), len(large)/10)
+
+		value, ok, err := cache.Get(ctx, "large")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, large, value)
+	})
+
+	t.Run("values over the limit are skipped", func(t *testing.T) {
+		assert.NoError(t, cache.Set(ctx, "random", []byte("previous"), time.Minute))
+
+		random := make([]byte, 4096)
+		rand.New(rand.NewSource(1)).Read(random)
+		assert.NoError(t, cache.Set(ctx, "random", random, time.Minute))
+
+		_, ok, err := cache.Get(ctx, "random")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("values stored without compression are read", func(t *testing.T) {
+		assert.NoError(t, store.Set(ctx, "legacy", []byte("plain"), time.Minute))
+		value, ok, err := cache.Get(ctx, "legacy")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("plain"), value)
+	})
+}
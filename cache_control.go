@@ -0,0 +1,87 @@
+package ginboot
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cache-Control directives for Context.CacheControl and CacheControlMiddleware
+const (
+	CachePublic         = "public"
+	CachePrivate        = "private"
+	CacheNoCache        = "no-cache"
+	CacheNoStore        = "no-store"
+	CacheImmutable      = "immutable"
+	CacheMustRevalidate = "must-revalidate"
+)
+
+// CacheControl sets the Cache-Control header of the response, e.g.
+// ctx.CacheControl(time.Hour, ginboot.CachePublic), overriding the route's
+// default. max-age is omitted with no-store.
+func (c *Context) CacheControl(maxAge time.Duration, directives ...string) {
+	c.Header("Cache-Control", cacheControlValue(maxAge, directives))
+}
+
+// CacheControlMiddleware sets a default Cache-Control header on the successful
+// responses of a route or group, e.g. a year and immutable for fingerprinted
+// static assets. Handlers override it with ctx.CacheControl; error responses
+// are never given the default.
+func CacheControlMiddleware(maxAge time.Duration, directives ...string) gin.HandlerFunc {
+	value := cacheControlValue(maxAge, directives)
+	return func(c *gin.Context) {
+		c.Writer = &cacheControlWriter{ResponseWriter: c.Writer, value: value}
+		c.Next()
+	}
+}
+
+func cacheControlValue(maxAge time.Duration, directives []string) string {
+	parts := append([]string{}, directives...)
+	for _, directive := range directives {
+		if directive == CacheNoStore {
+			return strings.Join(parts, ", ")
+		}
+	}
+	parts = append(parts, "max-age="+strconv.Itoa(int(maxAge.Seconds())))
+	return strings.Join(parts, ", ")
+}
+
+// cacheControlWriter adds the default header when the status is written,
+// unless the handler set one or the response is an error
+type cacheControlWriter struct {
+	gin.ResponseWriter
+	value   string
+	applied bool
+}
+
+func (w *cacheControlWriter) apply(status int) {
+	if w.applied {
+		return
+	}
+	w.applied = true
+	if status < 400 && w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", w.value)
+	}
+}
+
+func (w *cacheControlWriter) WriteHeader(status int) {
+	w.apply(status)
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *cacheControlWriter) WriteHeaderNow() {
+	w.apply(w.Status())
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *cacheControlWriter) Write(data []byte) (int, error) {
+	w.apply(w.Status())
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *cacheControlWriter) WriteString(s string) (int, error) {
+	w.apply(w.Status())
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,51 @@
+package ginboot
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheControl(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{engine: gin.New()}
+	assets := server.Group("/assets", CacheControlMiddleware(365*24*time.Hour, CachePublic, CacheImmutable))
+	assets.GET("/app.js", func() (string, error) {
+		return "app", nil
+	})
+	assets.GET("/missing.js", func() (string, error) {
+		return "", ErrNotFound
+	})
+	assets.GET("/manifest.json", func(ctx *Context) (string, error) {
+		ctx.CacheControl(0, CacheNoStore)
+		return "manifest", nil
+	})
+	server.Group("").GET("/profile", func(ctx *Context) (string, error) {
+		ctx.CacheControl(time.Minute, CachePrivate, CacheMustRevalidate)
+		return "profile", nil
+	})
+	server.Group("").GET("/fail", func(ctx *Context) (string, error) {
+		return "", errors.New("boom")
+	}, CacheControlMiddleware(time.Hour, CachePublic))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	assert.Equal(t, "public, immutable, max-age=31536000", get("/assets/app.js").Header().Get("Cache-Control"))
+	assert.Equal(t, "no-store", get("/assets/manifest.json").Header().Get("Cache-Control"))
+	assert.Equal(t, "private, must-revalidate, max-age=60", get("/profile").Header().Get("Cache-Control"))
+
+	w := get("/assets/missing.js")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("Cache-Control"))
+	assert.Empty(t, get("/fail").Header().Get("Cache-Control"))
+}
@@ -0,0 +1,248 @@
+package ginboot
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// CacheService stores cached values with a TTL. Tags group entries so related
+// values, e.g. every cached page of a collection, can be invalidated together.
+type CacheService interface {
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error
+	// SetIfAbsent stores the value only when the key is missing or expired and
+	// reports whether it did. Backends must check and store atomically, e.g.
+	// with Redis SET NX or a DynamoDB condition expression, as it is used to
+	// claim keys.
+	SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) (bool, error)
+	// Get returns the value and true, or false when the key is missing or expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Touch resets the TTL of an entry without rewriting it and reports whether
+	// the key was present, e.g. with Redis EXPIRE. A missing key stays missing,
+	// so touching cannot bring back an invalidated entry.
+	Touch(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	Invalidate(ctx context.Context, keys ...string) error
+	InvalidateTags(ctx context.Context, tags ...string) error
+}
+
+type InMemoryCacheConfig struct {
+	// MaxEntries and MaxMemory (bytes of keys and values) bound the cache;
+	// least recently used entries are evicted first. Zero means unbounded.
+	MaxEntries int
+	MaxMemory  int64
+	// SweepInterval is how often expired entries are removed on writes
+	SweepInterval time.Duration
+}
+
+// DefaultInMemoryCacheConfig allows 10000 entries and 64MB with a one minute sweep
+func DefaultInMemoryCacheConfig() InMemoryCacheConfig {
+	return InMemoryCacheConfig{
+		MaxEntries:    10000,
+		MaxMemory:     64 << 20,
+		SweepInterval: time.Minute,
+	}
+}
+
+// InMemoryCacheService is an LRU CacheService in process memory, suitable for
+// single instance deployments
+type InMemoryCacheService struct {
+	config    InMemoryCacheConfig
+	mu        sync.Mutex
+	entries   map[string]*list.Element
+	lru       *list.List
+	tags      map[string]map[string]struct{}
+	memory    int64
+	lastSweep time.Time
+}
+
+type cacheEntry struct {
+	key       string
+	value     []byte
+	tags      []string
+	expiresAt time.Time
+}
+
+func (e *cacheEntry) size() int64 {
+	return int64(len(e.key) + len(e.value))
+}
+
+func (e *cacheEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+func NewInMemoryCacheService(config InMemoryCacheConfig) *InMemoryCacheService {
+	return &InMemoryCacheService{
+		config:    config,
+		entries:   make(map[string]*list.Element),
+		lru:       list.New(),
+		tags:      make(map[string]map[string]struct{}),
+		lastSweep: time.Now(),
+	}
+}
+
+func (c *InMemoryCacheService) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value, ttl, tags)
+	return nil
+}
+
+func (c *InMemoryCacheService) SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok && !element.Value.(*cacheEntry).expired(time.Now()) {
+		return false, nil
+	}
+	c.set(key, value, ttl, tags)
+	return true, nil
+}
+
+// set stores an entry; c.mu must be held
+func (c *InMemoryCacheService) set(key string, value []byte, ttl time.Duration, tags []string) {
+	now := time.Now()
+	if c.config.SweepInterval > 0 && now.Sub(c.lastSweep) > c.config.SweepInterval {
+		c.sweep(now)
+	}
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+
+	// copies keep callers from changing the cached entry
+	entry := &cacheEntry{
+		key:   key,
+		value: append([]byte(nil), value...),
+		tags:  append([]string(nil), tags...),
+	}
+	if ttl > 0 {
+		entry.expiresAt = now.Add(ttl)
+	}
+	if c.config.MaxMemory > 0 && entry.size() > c.config.MaxMemory {
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(entry)
+	c.memory += entry.size()
+	for _, tag := range tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = make(map[string]struct{})
+		}
+		c.tags[tag][key] = struct{}{}
+	}
+
+	for (c.config.MaxEntries > 0 && c.lru.Len() > c.config.MaxEntries) ||
+		(c.config.MaxMemory > 0 && c.memory > c.config.MaxMemory) {
+		c.remove(c.lru.Back())
+	}
+}
+
+// Get returns a copy of the value, which the caller may modify
+func (c *InMemoryCacheService) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*cacheEntry)
+	if entry.expired(time.Now()) {
+		c.remove(element)
+		return nil, false, nil
+	}
+	c.lru.MoveToFront(element)
+	return append([]byte(nil), entry.value...), true, nil
+}
+
+func (c *InMemoryCacheService) Touch(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return false, nil
+	}
+	entry := element.Value.(*cacheEntry)
+	now := time.Now()
+	if entry.expired(now) {
+		c.remove(element)
+		return false, nil
+	}
+	entry.expiresAt = time.Time{}
+	if ttl > 0 {
+		entry.expiresAt = now.Add(ttl)
+	}
+	return true, nil
+}
+
+func (c *InMemoryCacheService) Invalidate(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := c.entries[key]; ok {
+			c.remove(element)
+		}
+	}
+	return nil
+}
+
+func (c *InMemoryCacheService) InvalidateTags(ctx context.Context, tags ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			if element, ok := c.entries[key]; ok {
+				c.remove(element)
+			}
+		}
+		delete(c.tags, tag)
+	}
+	return nil
+}
+
+// Len returns the number of cached entries, including expired ones not swept yet
+func (c *InMemoryCacheService) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+func (c *InMemoryCacheService) sweep(now time.Time) {
+	for element := c.lru.Back(); element != nil; {
+		previous := element.Prev()
+		if element.Value.(*cacheEntry).expired(now) {
+			c.remove(element)
+		}
+		element = previous
+	}
+	c.lastSweep = now
+}
+
+func (c *InMemoryCacheService) remove(element *list.Element) {
+	entry := c.lru.Remove(element).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.memory -= entry.size()
+	for _, tag := range entry.tags {
+		if keys, ok := c.tags[tag]; ok {
+			delete(keys, entry.key)
+			if len(keys) == 0 {
+				delete(c.tags, tag)
+			}
+		}
+	}
+}
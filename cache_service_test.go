@@ -0,0 +1,133 @@
+package ginboot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInMemoryCacheService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("get and expire", func(t *testing.T) {
+		cache := NewInMemoryCacheService(DefaultInMemoryCacheConfig())
+		assert.NoError(t, cache.Set(ctx, "a", []byte("1"), 20*time.Millisecond))
+		assert.NoError(t, cache.Set(ctx, "b", []byte("2"), 0))
+
+		value, found, err := cache.Get(ctx, "a")
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, []byte("1"), value)
+
+		time.Sleep(30 * time.Millisecond)
+		_, found, _ = cache.Get(ctx, "a")
+		assert.False(t, found)
+		_, found, _ = cache.Get(ctx, "b")
+		assert.True(t, found)
+	})
+
+	t.Run("evicts least recently used entries", func(t *testing.T) {
+		cache := NewInMemoryCacheService(InMemoryCacheConfig{MaxEntries: 2})
+		cache.Set(ctx, "a", []byte("1"), time.Minute)
+		cache.Set(ctx, "b", []byte("2"), time.Minute)
+		cache.Get(ctx, "a")
+		cache.Set(ctx, "c", []byte("3"), time.Minute)
+
+		_, found, _ := cache.Get(ctx, "b")
+		assert.False(t, found)
+		_, found, _ = cache.Get(ctx, "a")
+		assert.True(t, found)
+		assert.Equal(t, 2, cache.Len())
+	})
+
+	t.Run("evicts to stay within max memory", func(t *testing.T) {
+		cache := NewInMemoryCacheService(InMemoryCacheConfig{MaxMemory: 8})
+		cache.Set(ctx, "a", []byte("1234"), time.Minute)
+		cache.Set(ctx, "b", []byte("1234"), time.Minute)
+
+		_, found, _ := cache.Get(ctx, "a")
+		assert.False(t, found)
+		_, found, _ = cache.Get(ctx, "b")
+		assert.True(t, found)
+
+		cache.Set(ctx, "c", []byte("too large to cache"), time.Minute)
+		_, found, _ = cache.Get(ctx, "c")
+		assert.False(t, found)
+	})
+
+	t.Run("invalidates keys and tags", func(t *testing.T) {
+		cache := NewInMemoryCacheService(DefaultInMemoryCacheConfig())
+		cache.Set(ctx, "posts:1", []byte("1"), time.Minute, "posts")
+		cache.Set(ctx, "posts:2", []byte("2"), time.Minute, "posts")
+		cache.Set(ctx, "users:1", []byte("3"), time.Minute, "users")
+
+		assert.NoError(t, cache.InvalidateTags(ctx, "posts"))
+		_, found, _ := cache.Get(ctx, "posts:1")
+		assert.False(t, found)
+		_, found, _ = cache.Get(ctx, "posts:2")
+		assert.False(t, found)
+
+		assert.NoError(t, cache.Invalidate(ctx, "users:1"))
+		assert.Equal(t, 0, cache.Len())
+	})
+
+	t.Run("sets absent keys only", func(t *testing.T) {
+		cache := NewInMemoryCacheService(DefaultInMemoryCacheConfig())
+		stored, err := cache.SetIfAbsent(ctx, "claim", []byte("first"), 20*time.Millisecond)
+		assert.NoError(t, err)
+		assert.True(t, stored)
+		stored, _ = cache.SetIfAbsent(ctx, "claim", []byte("second"), time.Minute)
+		assert.False(t, stored)
+		value, _, _ := cache.Get(ctx, "claim")
+		assert.Equal(t, []byte("first"), value)
+
+		time.Sleep(30 * time.Millisecond)
+		stored, _ = cache.SetIfAbsent(ctx, "claim", []byte("third"), time.Minute)
+		assert.True(t, stored, "expired keys are absent")
+	})
+
+	t.Run("touch extends present keys only", func(t *testing.T) {
+		cache := NewInMemoryCacheService(DefaultInMemoryCacheConfig())
+		assert.NoError(t, cache.Set(ctx, "session", []byte("alice"), 20*time.Millisecond))
+		touched, err := cache.Touch(ctx, "session", time.Minute)
+		assert.NoError(t, err)
+		assert.True(t, touched)
+		time.Sleep(30 * time.Millisecond)
+		assert.Equal(t, []byte("alice"), mustGet(t, cache, "session"))
+
+		touched, _ = cache.Touch(ctx, "missing", time.Minute)
+		assert.False(t, touched)
+		_, ok, _ := cache.Get(ctx, "missing")
+		assert.False(t, ok)
+	})
+
+	t.Run("values are copied", func(t *testing.T) {
+		cache := NewInMemoryCacheService(DefaultInMemoryCacheConfig())
+		value := []byte("abc")
+		tags := []string{"posts"}
+		assert.NoError(t, cache.Set(ctx, "a", value, time.Minute, tags...))
+		value[0] = 'x'
+		tags[0] = "users"
+
+		got, _, _ := cache.Get(ctx, "a")
+		assert.Equal(t, []byte("abc"), got)
+		got[0] = 'y'
+		got, _, _ = cache.Get(ctx, "a")
+		assert.Equal(t, []byte("abc"), got)
+
+		assert.NoError(t, cache.InvalidateTags(ctx, "posts"))
+		_, found, _ := cache.Get(ctx, "a")
+		assert.False(t, found)
+	})
+
+	t.Run("sweeps expired entries on write", func(t *testing.T) {
+		cache := NewInMemoryCacheService(InMemoryCacheConfig{SweepInterval: 10 * time.Millisecond})
+		cache.Set(ctx, "a", []byte("1"), 5*time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+		cache.Set(ctx, "b", []byte("2"), time.Minute)
+
+		assert.Equal(t, 1, cache.Len())
+	})
+}
@@ -0,0 +1,156 @@
+package ginboot
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type SlidingExpirationConfig struct {
+	// SlideAll refreshes the TTL of every entry on access; otherwise only
+	// entries stored with SetSliding slide
+	SlideAll bool
+	// RefreshInterval is the least time between two refreshes of an entry by
+	// an instance, sparing a write on every read of a hot key. Zero refreshes
+	// on every Get.
+	RefreshInterval time.Duration
+}
+
+// DefaultSlidingExpirationConfig slides only entries stored with SetSliding,
+// refreshing them at most once a minute
+func DefaultSlidingExpirationConfig() SlidingExpirationConfig {
+	return SlidingExpirationConfig{RefreshInterval: time.Minute}
+}
+
+// SlidingCache is a CacheService where Get pushes back the expiry of sliding
+// entries, so session-like data stays cached while it is used and expires a
+// TTL after its last access. The TTL is stored with the value, so instances
+// sharing a backend slide the same entries. Refreshes Touch the entry rather
+// than rewrite it, so a Get racing an Invalidate cannot bring the entry back.
+// Entries stored without a TTL never slide.
+type SlidingCache struct {
+	next   CacheService
+	config SlidingExpirationConfig
+	// refreshed holds the keys refreshed within RefreshInterval
+	refreshed *InMemoryCacheService
+}
+
+// WithSlidingExpiration refreshes TTLs on access, see SlidingCache
+func WithSlidingExpiration(cache CacheService, config SlidingExpirationConfig) *SlidingCache {
+	return &SlidingCache{
+		next:      cache,
+		config:    config,
+		refreshed: NewInMemoryCacheService(DefaultInMemoryCacheConfig()),
+	}
+}
+
+// slidingEntryMagic prefixes sliding entries, followed by the length of the
+// JSON encoded slidingEntry and the value
+var slidingEntryMagic = []byte("\x00gbs")
+
+type slidingEntry struct {
+	TTL time.Duration `json:"ttl"`
+}
+
+func (c *SlidingCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	if !c.config.SlideAll {
+		return c.next.Set(ctx, key, value, ttl, tags...)
+	}
+	return c.SetSliding(ctx, key, value, ttl, tags...)
+}
+
+// SetIfAbsent stores the value like Set when the key is missing or expired
+func (c *SlidingCache) SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) (bool, error) {
+	if !c.config.SlideAll || ttl <= 0 {
+		return c.next.SetIfAbsent(ctx, key, value, ttl, tags...)
+	}
+	entry, err := encodeSlidingEntry(slidingEntry{TTL: ttl}, value)
+	if err != nil {
+		return false, err
+	}
+	return c.next.SetIfAbsent(ctx, key, entry, ttl, tags...)
+}
+
+// SetSliding stores a value whose TTL is refreshed on access, whatever the
+// SlideAll setting
+func (c *SlidingCache) SetSliding(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	if ttl <= 0 {
+		return c.next.Set(ctx, key, value, ttl, tags...)
+	}
+	entry, err := encodeSlidingEntry(slidingEntry{TTL: ttl}, value)
+	if err != nil {
+		return err
+	}
+	return c.next.Set(ctx, key, entry, ttl, tags...)
+}
+
+// Get returns the value and refreshes its TTL if it slides. A failed refresh
+// is logged and the value still returned.
+func (c *SlidingCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	stored, ok, err := c.next.Get(ctx, key)
+	if err != nil || !ok {
+		return stored, ok, err
+	}
+	entry, value, sliding, err := decodeSlidingEntry(stored)
+	if err != nil {
+		return nil, false, err
+	}
+	if !sliding {
+		return value, true, nil
+	}
+	if c.config.RefreshInterval > 0 {
+		if due, _ := c.refreshed.SetIfAbsent(ctx, key, nil, c.config.RefreshInterval); !due {
+			return value, true, nil
+		}
+	}
+	if _, err := c.next.Touch(ctx, key, entry.TTL); err != nil {
+		LoggerFromContext(ctx).Debug("ginboot: cache TTL refresh failed", "key", key, "error", err)
+	}
+	return value, true, nil
+}
+
+func (c *SlidingCache) Touch(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return c.next.Touch(ctx, key, ttl)
+}
+
+func (c *SlidingCache) Invalidate(ctx context.Context, keys ...string) error {
+	return c.next.Invalidate(ctx, keys...)
+}
+
+func (c *SlidingCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	return c.next.InvalidateTags(ctx, tags...)
+}
+
+func encodeSlidingEntry(entry slidingEntry, value []byte) ([]byte, error) {
+	header, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	stored := make([]byte, 0, len(slidingEntryMagic)+binary.MaxVarintLen64+len(header)+len(value))
+	stored = append(stored, slidingEntryMagic...)
+	stored = binary.AppendUvarint(stored, uint64(len(header)))
+	stored = append(stored, header...)
+	return append(stored, value...), nil
+}
+
+// decodeSlidingEntry splits a stored entry, returning values stored without
+// sliding unchanged
+func decodeSlidingEntry(stored []byte) (slidingEntry, []byte, bool, error) {
+	var entry slidingEntry
+	if !bytes.HasPrefix(stored, slidingEntryMagic) {
+		return entry, stored, false, nil
+	}
+	rest := stored[len(slidingEntryMagic):]
+	length, n := binary.Uvarint(rest)
+	if n <= 0 || uint64(len(rest)-n) < length {
+		return entry, nil, false, errors.New("ginboot: malformed sliding cache entry")
+	}
+	header, value := rest[n:n+int(length)], rest[n+int(length):]
+	if err := json.Unmarshal(header, &entry); err != nil {
+		return entry, nil, false, err
+	}
+	return entry, value, true, nil
+}
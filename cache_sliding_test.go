@@ -0,0 +1,105 @@
+package ginboot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlidingExpiration(t *testing.T) {
+	ctx := context.Background()
+	ttl := 50 * time.Millisecond
+
+	t.Run("sliding entries stay while accessed", func(t *testing.T) {
+		store := NewInMemoryCacheService(DefaultInMemoryCacheConfig())
+		cache := WithSlidingExpiration(store, SlidingExpirationConfig{})
+		assert.NoError(t, cache.SetSliding(ctx, "session", []byte("alice"), ttl, "sessions"))
+		assert.NoError(t, cache.Set(ctx, "page", []byte("fixed"), ttl))
+
+		for i := 0; i < 4; i++ {
+			time.Sleep(ttl / 2)
+			value, ok, err := cache.Get(ctx, "session")
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, []byte("alice"), value)
+		}
+		_, ok, _ := cache.Get(ctx, "page")
+		assert.False(t, ok)
+
+		// refreshed entries keep their tags
+		assert.NoError(t, cache.InvalidateTags(ctx, "sessions"))
+		_, ok, _ = cache.Get(ctx, "session")
+		assert.False(t, ok)
+	})
+
+	t.Run("SlideAll slides every entry", func(t *testing.T) {
+		store := NewInMemoryCacheService(DefaultInMemoryCacheConfig())
+		cache := WithSlidingExpiration(store, SlidingExpirationConfig{SlideAll: true})
+		assert.NoError(t, cache.Set(ctx, "page", []byte("warm"), ttl))
+
+		for i := 0; i < 4; i++ {
+			time.Sleep(ttl / 2)
+			_, ok, _ := cache.Get(ctx, "page")
+			assert.True(t, ok)
+		}
+		time.Sleep(ttl * 2)
+		_, ok, _ := cache.Get(ctx, "page")
+		assert.False(t, ok)
+	})
+
+	t.Run("refreshes are spaced by RefreshInterval", func(t *testing.T) {
+		store := NewInMemoryCacheService(DefaultInMemoryCacheConfig())
+		cache := WithSlidingExpiration(store, SlidingExpirationConfig{SlideAll: true, RefreshInterval: time.Hour})
+		assert.NoError(t, cache.Set(ctx, "page", []byte("warm"), ttl))
+
+		time.Sleep(ttl / 2)
+		_, ok, _ := cache.Get(ctx, "page")
+		assert.True(t, ok)
+		time.Sleep(ttl)
+		_, ok, _ = cache.Get(ctx, "page")
+		assert.False(t, ok)
+	})
+
+	t.Run("a refresh racing Invalidate does not restore the entry", func(t *testing.T) {
+		store := &invalidatingGetCache{CacheService: NewInMemoryCacheService(DefaultInMemoryCacheConfig())}
+		cache := WithSlidingExpiration(store, SlidingExpirationConfig{SlideAll: true})
+		assert.NoError(t, cache.Set(ctx, "page", []byte("warm"), time.Minute))
+
+		store.invalidate = true
+		value, ok, err := cache.Get(ctx, "page")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("warm"), value)
+
+		store.invalidate = false
+		_, ok, _ = cache.Get(ctx, "page")
+		assert.False(t, ok)
+	})
+
+	t.Run("values stored without sliding are read", func(t *testing.T) {
+		store := NewInMemoryCacheService(DefaultInMemoryCacheConfig())
+		cache := WithSlidingExpiration(store, DefaultSlidingExpirationConfig())
+		assert.NoError(t, store.Set(ctx, "legacy", []byte("plain"), time.Minute))
+		value, ok, err := cache.Get(ctx, "legacy")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("plain"), value)
+	})
+}
+
+// invalidatingGetCache invalidates the key right after reading it, as a
+// concurrent Invalidate between a Get and its refresh would
+type invalidatingGetCache struct {
+	CacheService
+	invalidate bool
+}
+
+func (c *invalidatingGetCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, ok, err := c.CacheService.Get(ctx, key)
+	if c.invalidate {
+		_ = c.CacheService.Invalidate(ctx, key)
+	}
+	return value, ok, err
+}
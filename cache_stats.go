@@ -0,0 +1,180 @@
+package ginboot
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxTrackedCacheKeys bounds the per-key stats kept by a StatsCache; keys
+// beyond it are only counted in the totals
+const maxTrackedCacheKeys = 10000
+
+// topCacheKeys is the number of most hit keys reported by CacheStats
+const topCacheKeys = 10
+
+// CacheKeyStats describes the use of a cached key since it was stored
+type CacheKeyStats struct {
+	Key      string    `json:"key"`
+	StoredAt time.Time `json:"storedAt"`
+	LastHit  time.Time `json:"lastHit"`
+	Hits     int64     `json:"hits"`
+	Size     int       `json:"size"`
+	tags     []string
+}
+
+// CacheStats aggregates the use of a cache since the process started
+type CacheStats struct {
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	Sets     int64   `json:"sets"`
+	HitRatio float64 `json:"hitRatio"`
+	// Keys and Size count the tracked keys currently stored and their bytes
+	Keys    int             `json:"keys"`
+	Size    int64           `json:"size"`
+	TopKeys []CacheKeyStats `json:"topKeys"`
+}
+
+// StatsCache is a CacheService recording hits, misses and per-key stats, to
+// tell whether a cache is worth it. Stats are kept in process memory, so each
+// instance reports its own use of a shared backend.
+type StatsCache struct {
+	next   CacheService
+	mu     sync.Mutex
+	keys   map[string]*CacheKeyStats
+	hits   int64
+	misses int64
+	sets   int64
+}
+
+// WithCacheStats records the use of the cache, see StatsCache
+func WithCacheStats(cache CacheService) *StatsCache {
+	return &StatsCache{next: cache, keys: map[string]*CacheKeyStats{}}
+}
+
+func (c *StatsCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	if err := c.next.Set(ctx, key, value, ttl, tags...); err != nil {
+		return err
+	}
+	c.recordSet(key, value, tags)
+	return nil
+}
+
+func (c *StatsCache) SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) (bool, error) {
+	stored, err := c.next.SetIfAbsent(ctx, key, value, ttl, tags...)
+	if err != nil || !stored {
+		return stored, err
+	}
+	c.recordSet(key, value, tags)
+	return true, nil
+}
+
+func (c *StatsCache) recordSet(key string, value []byte, tags []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sets++
+	if _, tracked := c.keys[key]; tracked || len(c.keys) < maxTrackedCacheKeys {
+		c.keys[key] = &CacheKeyStats{Key: key, StoredAt: time.Now(), Size: len(value), tags: tags}
+	}
+}
+
+func (c *StatsCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, ok, err := c.next.Get(ctx, key)
+	if err != nil {
+		return value, ok, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !ok {
+		c.misses++
+		// expired or evicted by the backend
+		delete(c.keys, key)
+		return value, ok, err
+	}
+	c.hits++
+	if stats, tracked := c.keys[key]; tracked {
+		stats.Hits++
+		stats.LastHit = time.Now()
+	}
+	return value, ok, err
+}
+
+func (c *StatsCache) Touch(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return c.next.Touch(ctx, key, ttl)
+}
+
+func (c *StatsCache) Invalidate(ctx context.Context, keys ...string) error {
+	err := c.next.Invalidate(ctx, keys...)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		delete(c.keys, key)
+	}
+	return err
+}
+
+func (c *StatsCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	err := c.next.InvalidateTags(ctx, tags...)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, stats := range c.keys {
+		if hasAnyTag(stats.tags, tags) {
+			delete(c.keys, key)
+		}
+	}
+	return err
+}
+
+func hasAnyTag(tags, wanted []string) bool {
+	for _, tag := range tags {
+		for _, w := range wanted {
+			if tag == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// KeyStats returns the stats of a stored key, false when it is not tracked
+func (c *StatsCache) KeyStats(key string) (CacheKeyStats, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats, ok := c.keys[key]
+	if !ok {
+		return CacheKeyStats{}, false
+	}
+	return *stats, true
+}
+
+// Stats returns the totals and the most hit keys
+func (c *StatsCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := CacheStats{Hits: c.hits, Misses: c.misses, Sets: c.sets, Keys: len(c.keys)}
+	if lookups := c.hits + c.misses; lookups > 0 {
+		stats.HitRatio = float64(c.hits) / float64(lookups)
+	}
+	keys := make([]CacheKeyStats, 0, len(c.keys))
+	for _, key := range c.keys {
+		stats.Size += int64(key.Size)
+		keys = append(keys, *key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Hits != keys[j].Hits {
+			return keys[i].Hits > keys[j].Hits
+		}
+		return keys[i].Key < keys[j].Key
+	})
+	stats.TopKeys = keys[:min(len(keys), topCacheKeys)]
+	return stats
+}
+
+// CacheStatsAdmin registers GET path, returning the cache's stats. Protect the
+// group, e.g. with RequireRoles.
+func CacheStatsAdmin(g *ControllerGroup, path string, cache *StatsCache) {
+	g.GET(path, func() (CacheStats, error) {
+		return cache.Stats(), nil
+	})
+}
@@ -0,0 +1,45 @@
+package ginboot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheStats(t *testing.T) {
+	ctx := context.Background()
+	cache := WithCacheStats(NewInMemoryCacheService(DefaultInMemoryCacheConfig()))
+
+	assert.NoError(t, cache.Set(ctx, "posts:1", []byte("first"), time.Minute, "posts"))
+	assert.NoError(t, cache.Set(ctx, "users:1", []byte("user"), time.Minute, "users"))
+	for i := 0; i < 3; i++ {
+		_, ok, err := cache.Get(ctx, "posts:1")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	}
+	cache.Get(ctx, "users:1")
+	cache.Get(ctx, "missing")
+
+	postStats, ok := cache.KeyStats("posts:1")
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), postStats.Hits)
+	assert.Equal(t, 5, postStats.Size)
+	assert.False(t, postStats.LastHit.IsZero())
+
+	stats := cache.Stats()
+	assert.Equal(t, int64(4), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, int64(2), stats.Sets)
+	assert.InDelta(t, 0.8, stats.HitRatio, 0.001)
+	assert.Equal(t, 2, stats.Keys)
+	assert.Equal(t, int64(9), stats.Size)
+	assert.Equal(t, "posts:1", stats.TopKeys[0].Key)
+
+	assert.NoError(t, cache.InvalidateTags(ctx, "posts"))
+	_, ok = cache.KeyStats("posts:1")
+	assert.False(t, ok)
+	assert.NoError(t, cache.Invalidate(ctx, "users:1"))
+	assert.Equal(t, 0, cache.Stats().Keys)
+}
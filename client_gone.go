@@ -0,0 +1,67 @@
+package ginboot
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// StatusClientClosedRequest is the non-standard status, borrowed from nginx,
+// recorded for requests whose client hung up before the response was written
+const StatusClientClosedRequest = 499
+
+var RequestCanceled = ApiError{"REQUEST_CANCELED", "Client closed the request"}
+
+// requestContext returns the context of the HTTP request, canceled when the
+// client disconnects
+func (c *Context) requestContext() context.Context {
+	if c.Request == nil {
+		return context.Background()
+	}
+	return c.Request.Context()
+}
+
+// Deadline, Done and Err follow the request context, so repositories and
+// cache services receiving the *Context stop once the client disconnects or
+// a Timeout passes, whatever the engine's ContextWithFallback
+
+func (c *Context) Deadline() (time.Time, bool) {
+	return c.requestContext().Deadline()
+}
+
+func (c *Context) Done() <-chan struct{} {
+	return c.requestContext().Done()
+}
+
+func (c *Context) Err() error {
+	return c.requestContext().Err()
+}
+
+// IsClientGone reports whether the client disconnected, so a handler can stop
+// work whose result nobody will read
+func (c *Context) IsClientGone() bool {
+	return errors.Is(c.requestContext().Err(), context.Canceled)
+}
+
+// OnClientGone calls fn in its own goroutine once the client disconnects,
+// e.g. to kill a long running query. Callbacks registered on the *Context
+// passed to a handler are stopped when the handler returns; call stop to
+// stop one earlier, or when registering from middleware.
+func (c *Context) OnClientGone(fn func()) (stop func() bool) {
+	stop = context.AfterFunc(c.requestContext(), func() {
+		if c.IsClientGone() {
+			fn()
+		}
+	})
+	c.clientGone = append(c.clientGone, stop)
+	return stop
+}
+
+// stopClientGone stops the callbacks registered with OnClientGone, before the
+// request context is canceled by the server finishing the request
+func (c *Context) stopClientGone() {
+	for _, stop := range c.clientGone {
+		stop()
+	}
+	c.clientGone = nil
+}
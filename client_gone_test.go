@@ -0,0 +1,58 @@
+package ginboot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext_ClientGone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// gin.New leaves ContextWithFallback off; cancellation reaches handlers anyway
+	server := &Server{engine: gin.New()}
+	group := server.Group("")
+	started := make(chan struct{})
+	var fired atomic.Bool
+	group.GET("/report", func(ctx *Context) (string, error) {
+		gone := make(chan struct{})
+		ctx.OnClientGone(func() { close(gone) })
+		close(started)
+		<-ctx.Done()
+		<-gone
+		assert.True(t, ctx.IsClientGone())
+		return "", ctx.Err()
+	})
+	group.GET("/quick", func(ctx *Context) (string, error) {
+		ctx.OnClientGone(func() { fired.Store(true) })
+		assert.False(t, ctx.IsClientGone())
+		return "done", nil
+	})
+
+	requestCtx, hangUp := context.WithCancel(context.Background())
+	w := httptest.NewRecorder()
+	served := make(chan struct{})
+	go func() {
+		server.engine.ServeHTTP(w, httptest.NewRequest("GET", "/report", nil).WithContext(requestCtx))
+		close(served)
+	}()
+	<-started
+	hangUp()
+	<-served
+	assert.Equal(t, StatusClientClosedRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "REQUEST_CANCELED")
+
+	requestCtx, finish := context.WithCancel(context.Background())
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest("GET", "/quick", nil).WithContext(requestCtx))
+	finish()
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, fired.Load())
+}
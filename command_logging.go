@@ -0,0 +1,182 @@
+package ginboot
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+type commandLoggingKey struct{}
+
+type CommandLoggingConfig struct {
+	// Always logs every command, e.g. enabled for a staging environment
+	Always bool
+	// Header enables logging for a single request
+	Header string
+	// Token must be the header's value so clients cannot enable logging at
+	// will; when empty any value enables it
+	Token string
+	// Logf writes the log lines, through the Logger of the command's context
+	// when nil
+	Logf func(format string, args ...interface{})
+}
+
+// DefaultCommandLoggingConfig enables logging per request with the
+// X-Debug-Commands header, or for every command when GINBOOT_LOG_COMMANDS=true
+func DefaultCommandLoggingConfig() CommandLoggingConfig {
+	return CommandLoggingConfig{
+		Always: os.Getenv("GINBOOT_LOG_COMMANDS") == "true",
+		Header: "X-Debug-Commands",
+	}
+}
+
+// CommandLogging enables database command logging for requests carrying the
+// configured header. Repository calls log their commands when they are given
+// the *Context, or a context derived from the request.
+func CommandLogging(config CommandLoggingConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if value := c.GetHeader(config.Header); value != "" && (config.Token == "" || value == config.Token) {
+			c.Request = c.Request.WithContext(WithCommandLogging(c.Request.Context()))
+		}
+		c.Next()
+	}
+}
+
+// WithCommandLogging returns a context whose database commands are logged
+func WithCommandLogging(ctx context.Context) context.Context {
+	return context.WithValue(ctx, commandLoggingKey{}, true)
+}
+
+func (config CommandLoggingConfig) logf(ctx context.Context, format string, args ...interface{}) {
+	if config.Logf == nil {
+		logf(ctx, format, args...)
+		return
+	}
+	config.Logf(format, args...)
+}
+
+func commandLoggingEnabled(ctx context.Context) bool {
+	enabled, _ := ctx.Value(commandLoggingKey{}).(bool)
+	return enabled
+}
+
+type startedCommand struct {
+	command string
+	start   time.Time
+}
+
+// NewMongoCommandMonitor returns a MongoDB command monitor that logs each
+// command with its parameters replaced by "?", its duration and the number of
+// documents returned or affected. Use it with MongoConfig.WithCommandMonitor.
+func NewMongoCommandMonitor(config CommandLoggingConfig) *event.CommandMonitor {
+	var started sync.Map
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			if !config.Always && !commandLoggingEnabled(ctx) {
+				return
+			}
+			started.Store(e.RequestID, startedCommand{
+				command: sanitizeCommand(e.Command),
+				start:   time.Now(),
+			})
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			value, ok := started.LoadAndDelete(e.RequestID)
+			if !ok {
+				return
+			}
+			command := value.(startedCommand)
+			config.logf(ctx, "ginboot: mongo %s.%s %s took %s, %d documents",
+				e.DatabaseName, e.CommandName, command.command, time.Since(command.start), resultCount(e.Reply))
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			value, ok := started.LoadAndDelete(e.RequestID)
+			if !ok {
+				return
+			}
+			command := value.(startedCommand)
+			config.logf(ctx, "ginboot: mongo %s.%s %s failed after %s: %s",
+				e.DatabaseName, e.CommandName, command.command, time.Since(command.start), e.Failure)
+		},
+	}
+}
+
+// commandMetadata are driver fields that say nothing about the query
+var commandMetadata = map[string]bool{
+	"lsid":            true,
+	"$clusterTime":    true,
+	"$db":             true,
+	"txnNumber":       true,
+	"$readPreference": true,
+}
+
+// sanitizeCommand renders the command as JSON with every value replaced by
+// "?", keeping field names and operators. The first element, the collection
+// name, is kept.
+func sanitizeCommand(command bson.Raw) string {
+	var document bson.D
+	if err := bson.Unmarshal(command, &document); err != nil {
+		return "?"
+	}
+	sanitized := make(bson.D, 0, len(document))
+	for i, element := range document {
+		if commandMetadata[element.Key] {
+			continue
+		}
+		if i > 0 {
+			element.Value = sanitizeValue(element.Value)
+		}
+		sanitized = append(sanitized, element)
+	}
+	encoded, err := bson.MarshalExtJSON(sanitized, false, false)
+	if err != nil {
+		return "?"
+	}
+	return string(encoded)
+}
+
+func sanitizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case bson.D:
+		sanitized := make(bson.D, len(v))
+		for i, element := range v {
+			sanitized[i] = bson.E{Key: element.Key, Value: sanitizeValue(element.Value)}
+		}
+		return sanitized
+	case bson.A:
+		sanitized := make(bson.A, len(v))
+		for i, item := range v {
+			sanitized[i] = sanitizeValue(item)
+		}
+		return sanitized
+	default:
+		return "?"
+	}
+}
+
+// resultCount returns the documents returned by a find or aggregate, or the
+// documents counted or affected by other commands
+func resultCount(reply bson.Raw) int {
+	if batch, ok := reply.Lookup("cursor", "firstBatch").ArrayOK(); ok {
+		values, _ := batch.Values()
+		return len(values)
+	}
+	if batch, ok := reply.Lookup("cursor", "nextBatch").ArrayOK(); ok {
+		values, _ := batch.Values()
+		return len(values)
+	}
+	n := reply.Lookup("n")
+	if count, ok := n.Int32OK(); ok {
+		return int(count)
+	}
+	if count, ok := n.Int64OK(); ok {
+		return int(count)
+	}
+	return 0
+}
@@ -0,0 +1,71 @@
+package ginboot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func TestCommandLogging(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	config := DefaultCommandLoggingConfig()
+	config.Token = "secret"
+	engine := gin.New()
+	engine.GET("/", CommandLogging(config), func(c *gin.Context) {
+		c.JSON(http.StatusOK, commandLoggingEnabled(c.Request.Context()))
+	})
+
+	send := func(value string) string {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if value != "" {
+			req.Header.Set("X-Debug-Commands", value)
+		}
+		engine.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	assert.Equal(t, "true", send("secret"))
+	assert.Equal(t, "false", send("guess"))
+	assert.Equal(t, "false", send(""))
+}
+
+func TestMongoCommandMonitor(t *testing.T) {
+	var logs []string
+	monitor := NewMongoCommandMonitor(CommandLoggingConfig{
+		Logf: func(format string, args ...interface{}) {
+			logs = append(logs, fmt.Sprintf(format, args...))
+		},
+	})
+
+	command, _ := bson.Marshal(bson.D{
+		{Key: "find", Value: "posts"},
+		{Key: "filter", Value: bson.D{{Key: "author", Value: "john@example.com"}, {Key: "likes", Value: bson.D{{Key: "$gt", Value: 10}}}}},
+		{Key: "lsid", Value: bson.D{{Key: "id", Value: "session"}}},
+	})
+	reply, _ := bson.Marshal(bson.D{{Key: "cursor", Value: bson.D{{Key: "firstBatch", Value: bson.A{bson.D{}, bson.D{}}}}}})
+	finished := event.CommandFinishedEvent{CommandName: "find", DatabaseName: "blog", RequestID: 1}
+
+	// commands of requests without logging enabled are skipped
+	monitor.Started(context.Background(), &event.CommandStartedEvent{Command: command, CommandName: "find", DatabaseName: "blog", RequestID: 1})
+	monitor.Succeeded(context.Background(), &event.CommandSucceededEvent{CommandFinishedEvent: finished, Reply: reply})
+	assert.Empty(t, logs)
+
+	ctx := WithCommandLogging(context.Background())
+	monitor.Started(ctx, &event.CommandStartedEvent{Command: command, CommandName: "find", DatabaseName: "blog", RequestID: 1})
+	monitor.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: finished, Reply: reply})
+
+	assert.Len(t, logs, 1)
+	assert.Contains(t, logs[0], `ginboot: mongo blog.find {"find":"posts","filter":{"author":"?","likes":{"$gt":"?"}}}`)
+	assert.Contains(t, logs[0], "2 documents")
+	assert.NotContains(t, logs[0], "john@example.com")
+	assert.NotContains(t, logs[0], "lsid")
+}
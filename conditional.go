@@ -0,0 +1,132 @@
+package ginboot
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	PreconditionFailed   = ApiError{"PRECONDITION_FAILED", "Resource was modified, fetch it again before updating"}
+	PreconditionRequired = ApiError{"PRECONDITION_REQUIRED", "If-Match header is required"}
+)
+
+// ErrPreconditionFailed is returned for an If-Match header that cannot match
+// a version. SendError answers it with 412 PRECONDITION_FAILED.
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// ifMatchKey marks requests whose version came from If-Match, so a version
+// conflict is answered with 412 instead of 409
+const ifMatchKey = "ginboot.if_match"
+
+// VersionETag returns the ETag of a document version, e.g. "3"
+func VersionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// DocumentETag returns the ETag of a document with a ginboot:"version" field.
+// Typed handlers set it on responses carrying such a document.
+func DocumentETag(doc interface{}) (string, bool) {
+	version, ok := documentVersion(doc)
+	if !ok {
+		return "", false
+	}
+	return VersionETag(version.current), true
+}
+
+// IfMatch returns the version of the request's If-Match header. ok is false
+// when the header is absent or *. Only the first ETag of a list is used, and
+// weak or foreign ETags fail with ErrPreconditionFailed, as they can never
+// match.
+func (c *Context) IfMatch() (version int64, ok bool, err error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, false, nil
+	}
+	etag, _, _ := strings.Cut(header, ",")
+	etag = strings.TrimSpace(etag)
+	if len(etag) < 2 || etag[0] != '"' || etag[len(etag)-1] != '"' {
+		return 0, false, ErrPreconditionFailed
+	}
+	version, err = strconv.ParseInt(etag[1:len(etag)-1], 10, 64)
+	if err != nil {
+		return 0, false, ErrPreconditionFailed
+	}
+	return version, true, nil
+}
+
+// ApplyIfMatch sets the version field of doc, a pointer, to the If-Match
+// version, so the repository's Update checks it against the stored document.
+// The version conflict is then answered with 412 PRECONDITION_FAILED. Typed
+// handlers do this for the request model of PUT and PATCH routes. As the
+// condition cannot be checked without a version, an If-Match header for a doc
+// without a ginboot:"version" field fails with ErrPreconditionFailed.
+func (c *Context) ApplyIfMatch(doc interface{}) error {
+	version, ok, err := c.IfMatch()
+	if err != nil || !ok {
+		return err
+	}
+	val := reflect.ValueOf(doc)
+	for val.Kind() == reflect.Ptr && !val.IsNil() && val.Elem().Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return ErrPreconditionFailed
+	}
+	current, versioned := documentVersion(val.Interface())
+	if !versioned {
+		return ErrPreconditionFailed
+	}
+	val.Elem().Field(current.field).SetInt(version)
+	c.Set(ifMatchKey, true)
+	return nil
+}
+
+// CheckIfMatch fails with ErrPreconditionFailed when the request has an
+// If-Match header and doc, the stored document, is unversioned or has another
+// version. Handlers call it before deleting; a delete conditioned on the
+// version with DeleteVersion, as CrudController.Delete does, also catches
+// writes made between reading and deleting.
+func (c *Context) CheckIfMatch(doc interface{}) error {
+	version, ok, err := c.IfMatch()
+	if err != nil || !ok {
+		return err
+	}
+	current, versioned := documentVersion(doc)
+	if !versioned || current.current != version {
+		return ErrPreconditionFailed
+	}
+	return nil
+}
+
+// RequireIfMatch rejects PUT, PATCH and DELETE requests without an If-Match
+// header with 428 PRECONDITION_REQUIRED, so clients cannot overwrite or delete
+// changes they have not seen. Typed handlers check it on PUT and PATCH; DELETE
+// handlers must check it themselves, as CrudController does.
+func RequireIfMatch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPut, http.MethodPatch, http.MethodDelete:
+			if c.GetHeader("If-Match") == "" {
+				abortWithApiError(c, http.StatusPreconditionRequired, PreconditionRequired)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// setDocumentETag sets the ETag of a versioned response, unless the handler
+// set one
+func setDocumentETag(c *gin.Context, response interface{}) {
+	if c.Writer.Header().Get("ETag") != "" {
+		return
+	}
+	if etag, ok := DocumentETag(response); ok {
+		c.Header("ETag", etag)
+	}
+}
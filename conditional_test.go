@@ -0,0 +1,112 @@
+package ginboot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := newMemoryRepository[crudItem]()
+	controller := NewCrudController[crudItem](repo).
+		WithIDGenerator(func() string { return "item-1" })
+
+	server := &Server{engine: gin.New()}
+	server.RegisterController("/items", controller)
+
+	send := func(method, path, body, ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		server.engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("POST", "/items", `{"name":"first"}`, "")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `"0"`, w.Header().Get("ETag"))
+
+	w = send("GET", "/items/item-1", "", "")
+	assert.Equal(t, `"0"`, w.Header().Get("ETag"))
+
+	// If-Match wins over the version of the body
+	w = send("PUT", "/items/item-1", `{"name":"renamed","version":7}`, `"0"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+
+	w = send("PUT", "/items/item-1", `{"name":"stale"}`, `"0"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Contains(t, w.Body.String(), PreconditionFailed.ErrorCode)
+
+	w = send("PUT", "/items/item-1", `{"name":"weak"}`, `W/"1"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	// without If-Match the body's version is checked as before
+	w = send("PUT", "/items/item-1", `{"name":"stale","version":0}`, "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = send("PUT", "/items/item-1", `{"name":"any"}`, "*")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	item, err := repo.FindById(context.Background(), "item-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "renamed", item.Name)
+
+	w = send("DELETE", "/items/item-1", "", `"0"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	w = send("DELETE", "/items/item-1", "", `"1"`)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestIfMatch_Unversioned(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type renameRequest struct {
+		Name string `json:"name"`
+	}
+	server := &Server{engine: gin.New()}
+	server.Group("").PUT("/names/:id", func(req renameRequest) (renameRequest, error) {
+		return req, nil
+	})
+
+	req := httptest.NewRequest("PUT", "/names/1", strings.NewReader(`{"name":"a"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1"`)
+	w := httptest.NewRecorder()
+	server.engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code, "an If-Match that cannot be checked is not ignored")
+}
+
+func TestRequireIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(RequireIfMatch())
+	engine.Any("/items/1", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for method, expected := range map[string]int{
+		http.MethodGet:    http.StatusNoContent,
+		http.MethodPut:    http.StatusPreconditionRequired,
+		http.MethodDelete: http.StatusPreconditionRequired,
+	} {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(method, "/items/1", nil))
+		assert.Equal(t, expected, w.Code, method)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/items/1", nil)
+	req.Header.Set("If-Match", `"1"`)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
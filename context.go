@@ -2,12 +2,27 @@ package ginboot
 
 import (
 	"errors"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"net/http"
+	"path"
+	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
+const (
+	proxyBasePathKey = "ginboot.proxy_base_path"
+	trustedProxyKey  = "ginboot.trusted_proxy"
+	memoKey          = "ginboot.memo"
+)
+
+// AuthCookieName is the cookie used by SetAuthCookie and AuthCookieMiddleware
+const AuthCookieName = "access_token"
+
 type AuthContext struct {
 	UserID    string
 	UserEmail string
@@ -18,6 +33,8 @@ type AuthContext struct {
 type Context struct {
 	*gin.Context
 	authContext *AuthContext
+	// clientGone stops the OnClientGone callbacks once the handler returned
+	clientGone []func() bool
 }
 
 func NewContext(c *gin.Context) *Context {
@@ -38,24 +55,154 @@ func (c *Context) GetAuthContext() (AuthContext, error) {
 		c.AbortWithStatus(http.StatusUnauthorized)
 		return AuthContext{}, errors.New("operation not permitted")
 	}
+	claims, _ := c.Get("claims")
+	claimsMap, _ := claims.(map[string]interface{})
 	return AuthContext{
 		UserID: userId.(string),
 		Roles:  []string{role.(string)},
+		Claims: claimsMap,
 	}, nil
 }
 
+// GetRequest binds the request body or query into request, along with path
+// parameters into uri tagged fields and the query string into form tagged
+// fields. Path parameters take precedence over body fields of the same name,
+// so a body cannot redirect a request to another resource. When binding fails it responds with 400, listing the
+// fields failing their binding rules as a ValidationError, aborts and returns
+// the error. application/x-protobuf and application/octet-stream bodies are
+// decoded into protobuf messages or []byte.
 func (c *Context) GetRequest(request interface{}) error {
+	if handled, err := bindBinary(c.Context, request); handled {
+		if err != nil {
+			return c.rejectRequest(request, err)
+		}
+		return nil
+	}
+	if err := bindParams(c.Context, request); err != nil {
+		return c.rejectRequest(request, err)
+	}
 	if err := c.ShouldBind(request); err != nil {
-		c.AbortWithStatus(http.StatusBadRequest)
-		return errors.New("bad request: " + err.Error())
+		return c.rejectRequest(request, err)
+	}
+	// the body may have overwritten path parameters; restore and validate them
+	mapped, err := bindPathParams(c.Context, request)
+	if err == nil && mapped {
+		err = binding.Validator.ValidateStruct(request)
+	}
+	if err != nil {
+		return c.rejectRequest(request, err)
 	}
 	return nil
 }
 
+// bindParams maps path parameters and, when the body is bound rather than
+// the query, the query string into request. Validation is left to the body
+// binding, which runs once every source is mapped.
+func bindParams(c *gin.Context, request interface{}) error {
+	if _, err := bindPathParams(c, request); err != nil {
+		return err
+	}
+	if binding.Default(c.Request.Method, c.ContentType()) != binding.Form && hasBindingTag(reflect.TypeOf(request), "form") {
+		if err := binding.MapFormWithTag(request, c.Request.URL.Query(), "form"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bindPathParams maps path parameters into uri tagged fields and reports
+// whether request has any
+func bindPathParams(c *gin.Context, request interface{}) (bool, error) {
+	if len(c.Params) == 0 || !hasBindingTag(reflect.TypeOf(request), "uri") {
+		return false, nil
+	}
+	params := make(map[string][]string, len(c.Params))
+	for _, param := range c.Params {
+		params[param.Key] = []string{param.Value}
+	}
+	return true, binding.MapFormWithTag(request, params, "uri")
+}
+
+type bindingTagKey struct {
+	typ reflect.Type
+	tag string
+}
+
+var bindingTags sync.Map
+
+// hasBindingTag reports whether the struct, or a struct it embeds, has fields
+// with the tag. Gin maps untagged fields by their Go name, so structs without
+// the tag are skipped rather than filled from unrelated parameters.
+func hasBindingTag(typ reflect.Type, tag string) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+	key := bindingTagKey{typ, tag}
+	if found, ok := bindingTags.Load(key); ok {
+		return found.(bool)
+	}
+	found := false
+	for i := 0; i < typ.NumField() && !found; i++ {
+		field := typ.Field(i)
+		_, tagged := field.Tag.Lookup(tag)
+		found = tagged || (field.Anonymous && hasBindingTag(field.Type, tag))
+	}
+	bindingTags.Store(key, found)
+	return found
+}
+
+// BindQuery binds only the query string into request, for handlers taking
+// *Context that read part of the request themselves. Failures are answered
+// like GetRequest's, with every failing field in one ValidationError.
+func (c *Context) BindQuery(request interface{}) error {
+	if err := c.ShouldBindWith(request, binding.Query); err != nil {
+		return c.rejectRequest(request, err)
+	}
+	return nil
+}
+
+// BindHeader binds request headers into request, using the header struct
+// tags. Failures are answered like GetRequest's.
+func (c *Context) BindHeader(request interface{}) error {
+	if err := c.ShouldBindWith(request, binding.Header); err != nil {
+		return c.rejectRequest(request, err)
+	}
+	return nil
+}
+
+// rejectRequest responds with 400 for a request failing to bind, aborts and
+// returns the error sent
+func (c *Context) rejectRequest(request interface{}, err error) error {
+	err = requestError(request, err)
+	SendError(c.Context, err)
+	c.Abort()
+	return err
+}
+
+const defaultSortKey = "ginboot.defaultSort"
+
+// DefaultSort sets the sort GetPageRequest uses for a group or route when the
+// client omits the sort parameter; direction is 1 for ascending and -1 for
+// descending
+func DefaultSort(field string, direction int) gin.HandlerFunc {
+	sort := SortField{Field: field, Direction: direction}
+	return func(c *gin.Context) {
+		c.Set(defaultSortKey, sort)
+		c.Next()
+	}
+}
+
+// GetPageRequest reads the page, size and sort query parameters. Sort by
+// several fields with repeated parameters, sort=created_at,desc&sort=name,asc,
+// or with prefixed fields, sort=-created_at,+name. Without a sort parameter,
+// the route's DefaultSort applies or the sort is left empty, for the
+// repository to order by the entity's DefaultSort.
 func (c *Context) GetPageRequest() PageRequest {
 	pageString := c.DefaultQuery("page", "1")
 	sizeString := c.DefaultQuery("size", "10")
-	sortString := c.DefaultQuery("sort", "_id,asc")
 	page, err := strconv.ParseInt(pageString, 10, 64)
 	if err != nil {
 		c.AbortWithStatus(http.StatusBadRequest)
@@ -64,40 +211,157 @@ func (c *Context) GetPageRequest() PageRequest {
 	if err != nil {
 		c.AbortWithStatus(http.StatusBadRequest)
 	}
-	sortSplit := strings.Split(sortString, ",")
-	var sort SortField
-	if len(sortSplit) > 1 {
-		direction := 1
-		if sortSplit[1] == "desc" {
-			direction = -1
-		}
-		sort = SortField{
-			Field:     sortSplit[0],
-			Direction: direction,
+	sorts := parseSort(c.QueryArray("sort"))
+	if len(sorts) == 0 {
+		value, _ := c.Get(defaultSortKey)
+		sort, _ := value.(SortField)
+		return PageRequest{Page: int(page), Size: int(size), Sort: sort}
+	}
+	pageRequest := PageRequest{Page: int(page), Size: int(size), Sort: sorts[0]}
+	if len(sorts) > 1 {
+		pageRequest.Sorts = sorts
+	}
+	return pageRequest
+}
+
+// parseSort parses sort parameters, each either "field,asc|desc" or a comma
+// separated list of fields prefixed with - for descending and + (or nothing)
+// for ascending
+func parseSort(values []string) []SortField {
+	var sorts []SortField
+	for _, value := range values {
+		parts := strings.Split(value, ",")
+		if len(parts) == 2 {
+			switch strings.ToLower(strings.TrimSpace(parts[1])) {
+			case "asc":
+				sorts = append(sorts, SortField{Field: strings.TrimSpace(parts[0]), Direction: 1})
+				continue
+			case "desc":
+				sorts = append(sorts, SortField{Field: strings.TrimSpace(parts[0]), Direction: -1})
+				continue
+			}
 		}
-	} else {
-		sort = SortField{
-			Field:     sortSplit[0],
-			Direction: 1,
+		for _, part := range parts {
+			// an unescaped + arrives as a space
+			part = strings.TrimSpace(part)
+			direction := 1
+			if strings.HasPrefix(part, "-") {
+				direction = -1
+			}
+			field := strings.TrimLeft(part, "+-")
+			if field != "" {
+				sorts = append(sorts, SortField{Field: field, Direction: direction})
+			}
 		}
 	}
+	return sorts
+}
+
+// ProxyBasePath returns the path prefix clients use to reach the server
+// through a proxy, e.g. "/prod" for an API Gateway stage. A prefix configured
+// with Server.SetProxyBasePath wins over the one detected from the Lambda event.
+func (c *Context) ProxyBasePath() string {
+	if basePath := c.GetString(proxyBasePathKey); basePath != "" {
+		return basePath
+	}
+	if c.Request == nil {
+		return ""
+	}
+	requestContext, ok := core.GetAPIGatewayContextFromContext(c.Request.Context())
+	if !ok || requestContext.Path == "" {
+		return ""
+	}
+	if strings.HasSuffix(requestContext.Path, c.Request.URL.Path) {
+		return strings.TrimSuffix(requestContext.Path, c.Request.URL.Path)
+	}
+	if requestContext.Stage != "" && requestContext.Stage != "$default" {
+		return "/" + requestContext.Stage
+	}
+	return ""
+}
+
+// PublicPath prefixes an absolute route path with the proxy base path so it
+// can be used in Location headers and links returned to clients.
+func (c *Context) PublicPath(routePath string) string {
+	basePath := c.ProxyBasePath()
+	if basePath == "" {
+		return routePath
+	}
+	return path.Join(basePath, routePath)
+}
 
-	return PageRequest{Page: int(page), Size: int(size), Sort: sort}
+// SetAuthCookie issues the access token as an HttpOnly, SameSite=Lax cookie so
+// browser clients never have to keep the JWT in script-accessible storage
+func (c *Context) SetAuthCookie(token string, maxAge time.Duration) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(AuthCookieName, token, int(maxAge.Seconds()), "/", "", c.isSecureRequest(), true)
+}
+
+// ClearAuthCookie expires the access token cookie
+func (c *Context) ClearAuthCookie() {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(AuthCookieName, "", -1, "/", "", c.isSecureRequest(), true)
+}
+
+// isSecureRequest reports whether the client connected over TLS, directly or
+// to a proxy set with Server.SetTrustedProxies
+func (c *Context) isSecureRequest() bool {
+	if c.Request.TLS != nil {
+		return true
+	}
+	return c.GetBool(trustedProxyKey) && c.GetHeader("X-Forwarded-Proto") == "https"
 }
 
 func (c *Context) SendError(err error) {
-	var customErr ApiError
-	if errors.As(err, &customErr) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error_code": customErr.ErrorCode,
-			"message":    customErr.Message,
-		})
-		return
-	}
-	// Handle other types of errors here
-	c.JSON(http.StatusInternalServerError, gin.H{
-		"error_code": "Internal Server Error",
-		"message":    "An unknown error occurred",
-	})
-	return
+	SendError(c.Context, err)
+}
+
+type requestMemo struct {
+	mu      sync.Mutex
+	entries map[string]*memoEntry
+}
+
+type memoEntry struct {
+	mu     sync.Mutex
+	loaded bool
+	value  interface{}
+}
+
+// Memo returns the value loaded for key earlier in the same request, or calls
+// loader and remembers its result, so middleware and handlers resolving the
+// same user, tenant or config don't each hit the database. Errors are not
+// remembered; the next call retries. Middleware can use it through
+// ginboot.NewContext(c).Memo. The Server gives every request its memo up
+// front; on engines without it the memo is created by the first call, which
+// must not run concurrently with others.
+func (c *Context) Memo(key string, loader func() (interface{}, error)) (interface{}, error) {
+	value, _ := c.Get(memoKey)
+	memo, ok := value.(*requestMemo)
+	if !ok {
+		memo = &requestMemo{}
+		c.Set(memoKey, memo)
+	}
+
+	memo.mu.Lock()
+	if memo.entries == nil {
+		memo.entries = make(map[string]*memoEntry)
+	}
+	entry, ok := memo.entries[key]
+	if !ok {
+		entry = &memoEntry{}
+		memo.entries[key] = entry
+	}
+	memo.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.loaded {
+		return entry.value, nil
+	}
+	loaded, err := loader()
+	if err != nil {
+		return nil, err
+	}
+	entry.value, entry.loaded = loaded, true
+	return loaded, nil
 }
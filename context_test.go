@@ -4,7 +4,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -117,6 +120,129 @@ func TestContext_GetRequest(t *testing.T) {
 	}
 }
 
+func TestContext_BindQueryAndHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type listQuery struct {
+		Status string `form:"status" binding:"required,oneof=draft published"`
+		Limit  int    `form:"limit" binding:"max=100"`
+	}
+	type tenantHeader struct {
+		Tenant string `header:"X-Tenant-ID" binding:"required"`
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/?status=draft&limit=5", nil)
+	c.Request.Header.Set("X-Tenant-ID", "acme")
+	ctx := NewContext(c)
+	var query listQuery
+	var header tenantHeader
+	assert.NoError(t, ctx.BindQuery(&query))
+	assert.NoError(t, ctx.BindHeader(&header))
+	assert.Equal(t, listQuery{Status: "draft", Limit: 5}, query)
+	assert.Equal(t, "acme", header.Tenant)
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/?status=archived&limit=500", nil)
+	err := NewContext(c).BindQuery(&query)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error_code":"VALIDATION_FAILED","message":"Request validation failed","errors":[
+		{"field":"status","rule":"oneof","param":"draft published","message":"must be one of draft, published"},
+		{"field":"limit","rule":"max","param":"100","message":"must be at most 100"}]}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	var missing tenantHeader
+	assert.Error(t, NewContext(c).BindHeader(&missing))
+	assert.Contains(t, w.Body.String(), `"field":"X-Tenant-ID"`)
+
+	// returning the error of a failed bind doesn't write a second response
+	server := &Server{engine: gin.New()}
+	server.Group("").GET("/posts", func(ctx *Context) (string, error) {
+		var query listQuery
+		if err := ctx.BindQuery(&query); err != nil {
+			return "", err
+		}
+		return query.Status, nil
+	})
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest("GET", "/posts", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error_code":"VALIDATION_FAILED","message":"Request validation failed","errors":[
+		{"field":"status","rule":"required","message":"is required"}]}`, w.Body.String())
+}
+
+func TestContext_GetRequestBindsPathAndQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type getPostRequest struct {
+		ID      int    `uri:"id" json:"-" binding:"required"`
+		Include string `form:"include"`
+	}
+	type updatePostRequest struct {
+		ID     int    `uri:"id" json:"-" binding:"required"`
+		Notify bool   `form:"notify" json:"-"`
+		Title  string `json:"title" binding:"required"`
+	}
+
+	server := &Server{engine: gin.New()}
+	group := server.Group("/posts")
+	group.GET("/:id", func(req getPostRequest) (getPostRequest, error) {
+		return req, nil
+	})
+	group.PUT("/:id", func(req updatePostRequest) (updatePostRequest, error) {
+		return req, nil
+	})
+
+	w := httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest("GET", "/posts/42?include=comments", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"Include":"comments"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest("GET", "/posts/abc", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var received updatePostRequest
+	group.PATCH("/:id", func(req updatePostRequest) (EmptyResponse, error) {
+		received = req
+		return EmptyResponse{}, nil
+	})
+	req := httptest.NewRequest("PATCH", "/posts/7?notify=true", strings.NewReader(`{"title":"Hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, updatePostRequest{ID: 7, Notify: true, Title: "Hello"}, received)
+
+	// a body field of the same name cannot override the path
+	type movePostRequest struct {
+		ID    string `uri:"id" json:"id" binding:"required"`
+		Title string `json:"title"`
+	}
+	group.POST("/:id/move", func(req movePostRequest) (movePostRequest, error) {
+		return req, nil
+	})
+	req = httptest.NewRequest("POST", "/posts/7/move", strings.NewReader(`{"id":"8","title":"Hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"id":"7","title":"Hello"}`, w.Body.String())
+
+	req = httptest.NewRequest("PUT", "/posts/7", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error_code":"VALIDATION_FAILED","message":"Request validation failed","errors":[
+		{"field":"title","rule":"required","message":"is required"}]}`, w.Body.String())
+}
+
 func TestContext_GetPageRequest(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -133,7 +259,7 @@ func TestContext_GetPageRequest(t *testing.T) {
 			queryParams:  map[string]string{},
 			expectedPage: 1,
 			expectedSize: 10,
-			expectedSort: SortField{Field: "_id", Direction: 1},
+			expectedSort: SortField{},
 		},
 		{
 			name: "custom values",
@@ -189,3 +315,160 @@ func TestContext_GetPageRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSort(t *testing.T) {
+	assert.Equal(t, []SortField{{Field: "createdAt", Direction: -1}, {Field: "name", Direction: 1}},
+		parseSort([]string{"createdAt,desc", "name,asc"}))
+	// sort=-createdAt,+name with the + unescaped
+	assert.Equal(t, []SortField{{Field: "createdAt", Direction: -1}, {Field: "name", Direction: 1}},
+		parseSort([]string{"-createdAt, name"}))
+	assert.Equal(t, []SortField{{Field: "name", Direction: 1}, {Field: "age", Direction: 1}},
+		parseSort([]string{"name,age"}))
+	assert.Equal(t, []SortField{{Field: "name", Direction: -1}}, parseSort([]string{"name,DESC"}))
+	assert.Nil(t, parseSort(nil))
+}
+
+func TestContext_GetPageRequestMultipleSorts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/?sort=-createdAt,%2Bname", nil)
+
+	result := NewContext(c).GetPageRequest()
+	assert.Equal(t, SortField{Field: "createdAt", Direction: -1}, result.Sort)
+	assert.Equal(t, []SortField{{Field: "createdAt", Direction: -1}, {Field: "name", Direction: 1}}, result.SortFields())
+}
+
+func TestContext_GetPageRequestDefaultSort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{engine: gin.New()}
+	server.engine.GET("/orders", DefaultSort("created_at", -1), func(c *gin.Context) {
+		c.JSON(http.StatusOK, NewContext(c).GetPageRequest().Sort)
+	})
+
+	w := httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest("GET", "/orders", nil))
+	assert.JSONEq(t, `{"field":"created_at","direction":-1}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest("GET", "/orders?sort=total,asc", nil))
+	assert.JSONEq(t, `{"field":"total","direction":1}`, w.Body.String())
+}
+
+func TestContext_AuthCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{engine: gin.New()}
+	assert.NoError(t, server.SetTrustedProxies("10.0.0.0/8", "192.0.2.1"))
+	assert.Error(t, server.SetTrustedProxies("not-an-ip"))
+	server.engine.Use(server.settingsMiddleware())
+	server.engine.POST("/login", func(c *gin.Context) {
+		NewContext(c).SetAuthCookie("token-value", time.Hour)
+	})
+	login := func(remoteAddr string) *http.Cookie {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/login", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-Proto", "https")
+		server.engine.ServeHTTP(w, req)
+		return w.Result().Cookies()[0]
+	}
+	// X-Forwarded-Proto is only honoured from trusted proxies
+	assert.True(t, login("10.1.2.3:4567").Secure)
+	assert.True(t, login("192.0.2.1:4567").Secure)
+	assert.False(t, login("203.0.113.9:4567").Secure)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/login", nil)
+	c.Set(trustedProxyKey, true)
+	c.Request.Header.Set("X-Forwarded-Proto", "https")
+
+	ctx := NewContext(c)
+	ctx.SetAuthCookie("token-value", time.Hour)
+
+	cookies := w.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, AuthCookieName, cookies[0].Name)
+	assert.Equal(t, "token-value", cookies[0].Value)
+	assert.Equal(t, 3600, cookies[0].MaxAge)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.True(t, cookies[0].Secure)
+	assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/logout", nil)
+	NewContext(c).ClearAuthCookie()
+
+	cookies = w.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, "", cookies[0].Value)
+	assert.True(t, cookies[0].MaxAge < 0)
+}
+
+func TestContext_Memo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	loads := 0
+	loadUser := func() (interface{}, error) {
+		loads++
+		return "user-1", nil
+	}
+
+	server := &Server{engine: gin.New()}
+	group := server.Group("", func(c *gin.Context) {
+		user, err := NewContext(c).Memo("user", loadUser)
+		assert.NoError(t, err)
+		assert.Equal(t, "user-1", user)
+		c.Next()
+	})
+	group.GET("/me", func(ctx *Context) (string, error) {
+		_, err := ctx.Memo("failing", func() (interface{}, error) {
+			return nil, assert.AnError
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		value, err := ctx.Memo("failing", func() (interface{}, error) {
+			return "retried", nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "retried", value)
+
+		user, err := ctx.Memo("user", loadUser)
+		if err != nil {
+			return "", err
+		}
+		return user.(string), nil
+	})
+
+	for i := 1; i <= 2; i++ {
+		w := httptest.NewRecorder()
+		server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/me", nil))
+		assert.Equal(t, `"user-1"`, w.Body.String())
+		// one load per request, shared by the middleware and the handler
+		assert.Equal(t, i, loads)
+	}
+
+	// the Server's memo is ready before the handler fans out
+	concurrent := &Server{engine: gin.New()}
+	concurrent.engine.Use(concurrent.settingsMiddleware())
+	var concurrentLoads int32
+	concurrent.Group("").GET("/feed", func(ctx *Context) (string, error) {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx.Memo("feed", func() (interface{}, error) {
+					atomic.AddInt32(&concurrentLoads, 1)
+					return "feed", nil
+				})
+			}()
+		}
+		wg.Wait()
+		return "ok", nil
+	})
+	concurrent.engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/feed", nil))
+	assert.Equal(t, int32(1), concurrentLoads)
+}
@@ -0,0 +1,135 @@
+package ginboot
+
+import (
+	"fmt"
+)
+
+// Operator is the comparison or logical operator of a Criteria
+type Operator string
+
+const (
+	OpEq       Operator = "eq"
+	OpNe       Operator = "ne"
+	OpGt       Operator = "gt"
+	OpGte      Operator = "gte"
+	OpLt       Operator = "lt"
+	OpLte      Operator = "lte"
+	OpIn       Operator = "in"
+	OpContains Operator = "contains"
+	OpAnd      Operator = "and"
+	OpOr       Operator = "or"
+)
+
+// Criteria is a backend independent filter. Repositories translate it to their
+// native query language, so the same criteria work on every backend.
+type Criteria struct {
+	Operator Operator
+	Field    string
+	Value    interface{}
+	// Criteria holds the operands of And and Or
+	Criteria []Criteria
+}
+
+func Eq(field string, value interface{}) Criteria {
+	return Criteria{Operator: OpEq, Field: field, Value: value}
+}
+
+func Ne(field string, value interface{}) Criteria {
+	return Criteria{Operator: OpNe, Field: field, Value: value}
+}
+
+func Gt(field string, value interface{}) Criteria {
+	return Criteria{Operator: OpGt, Field: field, Value: value}
+}
+
+func Gte(field string, value interface{}) Criteria {
+	return Criteria{Operator: OpGte, Field: field, Value: value}
+}
+
+func Lt(field string, value interface{}) Criteria {
+	return Criteria{Operator: OpLt, Field: field, Value: value}
+}
+
+func Lte(field string, value interface{}) Criteria {
+	return Criteria{Operator: OpLte, Field: field, Value: value}
+}
+
+// In matches documents whose field equals one of the values
+func In(field string, values ...interface{}) Criteria {
+	return Criteria{Operator: OpIn, Field: field, Value: values}
+}
+
+// Contains matches documents whose string field contains the substring
+func Contains(field string, substring string) Criteria {
+	return Criteria{Operator: OpContains, Field: field, Value: substring}
+}
+
+// And matches documents matching all criteria; And() with no criteria matches everything
+func And(criteria ...Criteria) Criteria {
+	return Criteria{Operator: OpAnd, Criteria: criteria}
+}
+
+// Or matches documents matching any of the criteria
+func Or(criteria ...Criteria) Criteria {
+	return Criteria{Operator: OpOr, Criteria: criteria}
+}
+
+// Validate reports criteria that no backend can translate
+func (c Criteria) Validate() error {
+	switch c.Operator {
+	case OpAnd:
+	case OpOr:
+		if len(c.Criteria) == 0 {
+			return fmt.Errorf("or requires at least one criteria")
+		}
+	case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn, OpContains:
+		if c.Field == "" {
+			return fmt.Errorf("%s requires a field", c.Operator)
+		}
+		if c.Operator == OpContains {
+			if _, ok := c.Value.(string); !ok {
+				return fmt.Errorf("contains on %s requires a string", c.Field)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported operator %q", c.Operator)
+	}
+	for _, child := range c.Criteria {
+		if err := child.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Query combines criteria with sorting and a result limit, e.g.
+//
+//	ginboot.Where(ginboot.Eq("author", id), ginboot.Gte("created_at", since)).
+//		Sort("created_at", -1).
+//		Limit(10)
+type Query struct {
+	Criteria Criteria
+	Sorts    []SortField
+	// MaxResults limits the number of results; zero means no limit
+	MaxResults int
+}
+
+// Where starts a query matching all of the criteria
+func Where(criteria ...Criteria) *Query {
+	if len(criteria) == 1 {
+		return &Query{Criteria: criteria[0]}
+	}
+	return &Query{Criteria: And(criteria...)}
+}
+
+// Sort adds a sort field; direction is 1 for ascending and -1 for descending
+func (q *Query) Sort(field string, direction int) *Query {
+	q.Sorts = append(q.Sorts, SortField{Field: field, Direction: direction})
+	return q
+}
+
+func (q *Query) Limit(limit int) *Query {
+	q.MaxResults = limit
+	return q
+}
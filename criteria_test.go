@@ -0,0 +1,72 @@
+package ginboot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCriteriaValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		criteria Criteria
+		wantErr  bool
+	}{
+		{"comparison", Gt("age", 18), false},
+		{"empty and matches everything", And(), false},
+		{"nested", And(Eq("status", "published"), Or(In("tag", "go", "mongo"), Contains("title", "gin"))), false},
+		{"missing field", Eq("", 1), true},
+		{"empty or", Or(), true},
+		{"nested error", And(Eq("a", 1), Lt("", 2)), true},
+		{"unknown operator", Criteria{Operator: "near", Field: "location"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.criteria.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	assert.Error(t, Criteria{Operator: OpContains, Field: "title", Value: 1}.Validate())
+}
+
+func TestWhere(t *testing.T) {
+	query := Where(Eq("author", "john")).Sort("created_at", -1).Limit(10)
+	assert.Equal(t, Eq("author", "john"), query.Criteria)
+	assert.Equal(t, []SortField{{Field: "created_at", Direction: -1}}, query.Sorts)
+	assert.Equal(t, 10, query.MaxResults)
+
+	query = Where(Eq("author", "john"), Gte("age", 18))
+	assert.Equal(t, And(Eq("author", "john"), Gte("age", 18)), query.Criteria)
+}
+
+func TestMongoFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		criteria Criteria
+		want     bson.M
+	}{
+		{"eq", Eq("author", "john"), bson.M{"author": "john"}},
+		{"gte", Gte("age", 18), bson.M{"age": bson.M{"$gte": 18}}},
+		{"in", In("tag", "go", "mongo"), bson.M{"tag": bson.M{"$in": []interface{}{"go", "mongo"}}}},
+		{"contains escapes the substring", Contains("title", "a.b"), bson.M{"title": bson.M{"$regex": `a\.b`}}},
+		{"empty and", And(), bson.M{}},
+		{"or", Or(Eq("a", 1), Ne("b", 2)), bson.M{"$or": bson.A{bson.M{"a": 1}, bson.M{"b": bson.M{"$ne": 2}}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, mongoFilter(tt.criteria))
+		})
+	}
+
+	filter, err := mongoQueryFilter(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{}, filter)
+}
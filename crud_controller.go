@@ -0,0 +1,159 @@
+package ginboot
+
+import (
+	"path"
+
+	"github.com/google/uuid"
+)
+
+// CrudController exposes a GenericRepository as a REST resource:
+//
+//	GET    /      paginated list, filtered through Filterable middleware
+//	GET    /:id   one document, 404 when missing
+//	POST   /      creates a validated document, 201 with a Location header
+//	PUT    /:id   replaces the document with the path's ID
+//	DELETE /:id   204, 412 when an If-Match header names another version
+//
+// Register it like any Controller, on a group carrying the resource's
+// middleware, and add custom routes to the same group.
+type CrudController[T any] struct {
+	repo       GenericRepository[T]
+	newID      func() string
+	beforeSave []func(ctx *Context, doc *T) error
+	afterWrite []func(ctx *Context, id string) error
+}
+
+// NewCrudController serves repo, giving created documents without an ID a
+// random UUID
+func NewCrudController[T any](repo GenericRepository[T]) *CrudController[T] {
+	return &CrudController[T]{repo: repo, newID: uuid.NewString}
+}
+
+// WithIDGenerator sets the function giving created documents their ID
+func (c *CrudController[T]) WithIDGenerator(newID func() string) *CrudController[T] {
+	c.newID = newID
+	return c
+}
+
+// BeforeSave adds a hook run before creating or replacing a document, e.g. to
+// stamp fields or check ownership. An error aborts the write and is sent to
+// the client.
+func (c *CrudController[T]) BeforeSave(hook func(ctx *Context, doc *T) error) *CrudController[T] {
+	c.beforeSave = append(c.beforeSave, hook)
+	return c
+}
+
+// AfterWrite adds a hook run once a document was created, replaced or
+// deleted. Errors are logged, as the write already happened.
+func (c *CrudController[T]) AfterWrite(hook func(ctx *Context, id string) error) *CrudController[T] {
+	c.afterWrite = append(c.afterWrite, hook)
+	return c
+}
+
+// InvalidateCache invalidates the tags in cache after every write, so cached
+// pages of the resource are not served stale
+func (c *CrudController[T]) InvalidateCache(cache CacheService, tags ...string) *CrudController[T] {
+	return c.AfterWrite(func(ctx *Context, id string) error {
+		return cache.InvalidateTags(ctx, tags...)
+	})
+}
+
+func (c *CrudController[T]) Register(group *ControllerGroup) {
+	group.GET("", c.List)
+	group.GET("/:id", c.Get)
+	group.POST("", c.Create)
+	group.PUT("/:id", c.Update)
+	group.DELETE("/:id", c.Delete)
+}
+
+func (c *CrudController[T]) List(ctx *Context, pageRequest PageRequest) (PageResponse[T], error) {
+	query, err := ctx.GetFilters()
+	if err != nil {
+		return PageResponse[T]{}, err
+	}
+	if query.Criteria.Operator == OpAnd && len(query.Criteria.Criteria) == 0 {
+		return c.repo.FindAllPaginated(ctx, pageRequest)
+	}
+	return c.repo.FindByQueryPaginated(ctx, pageRequest, query)
+}
+
+func (c *CrudController[T]) Get(ctx *Context) (T, error) {
+	return c.repo.FindById(ctx, ctx.Param("id"))
+}
+
+func (c *CrudController[T]) Create(ctx *Context, doc T) (Response[T], error) {
+	id := getDocumentID(&doc)
+	if id == "" {
+		id = c.newID()
+		setDocumentID(&doc, id)
+	}
+	if err := c.runBeforeSave(ctx, &doc); err != nil {
+		return Response[T]{}, err
+	}
+	if err := c.repo.Save(ctx, doc); err != nil {
+		return Response[T]{}, err
+	}
+	c.runAfterWrite(ctx, id)
+	return Created(doc, ctx.PublicPath(path.Join(ctx.Request.URL.Path, id))), nil
+}
+
+func (c *CrudController[T]) Update(ctx *Context, doc T) (T, error) {
+	id := ctx.Param("id")
+	setDocumentID(&doc, id)
+	if err := c.runBeforeSave(ctx, &doc); err != nil {
+		return doc, err
+	}
+	if err := c.repo.Update(ctx, doc); err != nil {
+		return doc, err
+	}
+	// the repository stored the incremented version
+	if version, versioned := documentVersion(doc); versioned {
+		if next, ok := version.next.(T); ok {
+			doc = next
+		}
+	}
+	c.runAfterWrite(ctx, id)
+	return doc, nil
+}
+
+func (c *CrudController[T]) Delete(ctx *Context) (Response[EmptyResponse], error) {
+	id := ctx.Param("id")
+	var doc T
+	setDocumentID(&doc, id)
+	if err := ctx.ApplyIfMatch(&doc); err != nil {
+		return Response[EmptyResponse]{}, err
+	}
+	if ctx.GetBool(ifMatchKey) {
+		// the version is checked by the delete itself, so a concurrent write
+		// between reading and deleting cannot slip through
+		if err := c.repo.DeleteVersion(ctx, doc); err != nil {
+			return Response[EmptyResponse]{}, err
+		}
+	} else {
+		if _, err := c.repo.FindById(ctx, id); err != nil {
+			return Response[EmptyResponse]{}, err
+		}
+		if err := c.repo.Delete(ctx, id); err != nil {
+			return Response[EmptyResponse]{}, err
+		}
+	}
+	c.runAfterWrite(ctx, id)
+	return NoContent[EmptyResponse](), nil
+}
+
+func (c *CrudController[T]) runBeforeSave(ctx *Context, doc *T) error {
+	for _, hook := range c.beforeSave {
+		if err := hook(ctx, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *CrudController[T]) runAfterWrite(ctx *Context, id string) {
+	for _, hook := range c.afterWrite {
+		if err := hook(ctx, id); err != nil {
+			ctx.Logger().Warn("ginboot: after write hook failed", "id", id, "error", err)
+		}
+	}
+}
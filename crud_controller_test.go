@@ -0,0 +1,119 @@
+package ginboot
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type crudItem struct {
+	ID      string `bson:"_id" ginboot:"_id" json:"id"`
+	Name    string `bson:"name" json:"name" binding:"required"`
+	Owner   string `bson:"owner" json:"owner"`
+	Version int64  `bson:"version" json:"version" ginboot:"version"`
+}
+
+func TestCrudController(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := newMemoryRepository[crudItem]()
+	cache := NewInMemoryCacheService(DefaultInMemoryCacheConfig())
+	ids := 0
+	controller := NewCrudController[crudItem](repo).
+		WithIDGenerator(func() string { ids++; return "item-" + strconv.Itoa(ids) }).
+		BeforeSave(func(ctx *Context, item *crudItem) error {
+			if item.Name == "forbidden" {
+				return errors.New("not allowed")
+			}
+			item.Owner = "alice"
+			return nil
+		}).
+		InvalidateCache(cache, "items")
+
+	server := &Server{engine: gin.New()}
+	server.RegisterController("/items", controller)
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.engine.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.NoError(t, cache.Set(context.Background(), "items:page:1", []byte("[]"), time.Minute, "items"))
+	w := send("POST", "/items", `{"name":"first"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/items/item-1", w.Header().Get("Location"))
+	assert.JSONEq(t, `{"id":"item-1","name":"first","owner":"alice","version":0}`, w.Body.String())
+	_, cached, _ := cache.Get(context.Background(), "items:page:1")
+	assert.False(t, cached)
+
+	w = send("POST", "/items", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), ValidationFailed.ErrorCode)
+	w = send("POST", "/items", `{"name":"forbidden"}`)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	w = send("GET", "/items/item-1", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"id":"item-1","name":"first","owner":"alice","version":0}`, w.Body.String())
+	w = send("GET", "/items/missing", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = send("PUT", "/items/item-1", `{"name":"renamed","version":0}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"id":"item-1","name":"renamed","owner":"alice","version":1}`, w.Body.String())
+	w = send("PUT", "/items/item-1", `{"name":"stale","version":0}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	send("POST", "/items", `{"name":"second"}`)
+	w = send("GET", "/items?page=1&size=1", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"totalElements":2`)
+
+	w = send("DELETE", "/items/item-1", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = send("DELETE", "/items/item-1", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// If-Match deletes only the named version
+	deleteVersion := func(path, etag string) int {
+		req := httptest.NewRequest("DELETE", path, nil)
+		req.Header.Set("If-Match", etag)
+		w := httptest.NewRecorder()
+		server.engine.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusPreconditionFailed, deleteVersion("/items/item-3", `"3"`))
+	assert.Equal(t, http.StatusNoContent, deleteVersion("/items/item-3", `"0"`))
+	assert.Equal(t, http.StatusNotFound, deleteVersion("/items/item-3", `"0"`))
+
+	// the Location header keeps the proxy base path
+	proxied := &Server{engine: gin.New()}
+	proxied.SetProxyBasePath("/prod")
+	proxied.engine.Use(proxied.settingsMiddleware())
+	proxied.RegisterController("/items", NewCrudController[crudItem](repo))
+	req := httptest.NewRequest("POST", "/items", strings.NewReader(`{"id":"item-9","name":"proxied"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	proxied.engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/prod/items/item-9", w.Header().Get("Location"))
+}
+
+func TestSetDocumentID(t *testing.T) {
+	item := crudItem{}
+	assert.True(t, setDocumentID(&item, "42"))
+	assert.Equal(t, "42", item.ID)
+	assert.False(t, setDocumentID(item, "43"))
+	assert.False(t, setDocumentID(&struct{ Name string }{}, "44"))
+}
@@ -9,6 +9,20 @@ type PageRequest struct {
 	Page int       `json:"page"`
 	Size int       `json:"size"`
 	Sort SortField `json:"sort"`
+	// Sorts holds every sort field, Sort being the first, when sorting by
+	// more than one
+	Sorts []SortField `json:"sorts,omitempty"`
+}
+
+// SortFields returns the fields to sort by, in order
+func (p PageRequest) SortFields() []SortField {
+	if len(p.Sorts) > 0 {
+		return p.Sorts
+	}
+	if p.Sort.Field != "" {
+		return []SortField{p.Sort}
+	}
+	return nil
 }
 
 type PageResponse[T interface{}] struct {
@@ -18,3 +32,29 @@ type PageResponse[T interface{}] struct {
 	TotalPages       int         `json:"totalPages"`
 	TotalElements    int         `json:"totalElements"`
 }
+
+func (p PageResponse[T]) pageContents() interface{} {
+	return p.Contents
+}
+
+func (p PageResponse[T]) pageInfo() (PageRequest, int, int) {
+	return p.Pageable, p.TotalElements, p.TotalPages
+}
+
+// DefaultSorter lets an entity choose how its pages are ordered when the page
+// request has no sort, e.g. SortField{Field: "created_at", Direction: -1}
+type DefaultSorter interface {
+	DefaultSort() SortField
+}
+
+// defaultSortOf returns the default sort declared by T, if any
+func defaultSortOf[T any]() (SortField, bool) {
+	var doc T
+	if sorter, ok := any(doc).(DefaultSorter); ok {
+		return sorter.DefaultSort(), true
+	}
+	if sorter, ok := any(&doc).(DefaultSorter); ok {
+		return sorter.DefaultSort(), true
+	}
+	return SortField{}, false
+}
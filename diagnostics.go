@@ -0,0 +1,153 @@
+package ginboot
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// processStart approximates when the process started, for the uptime
+var processStart = time.Now()
+
+// DiagnosticsSource reports the state of a component, such as a database
+// pool's stats or a cache's configuration, for Server.Diagnostics
+type DiagnosticsSource func(ctx context.Context) (interface{}, error)
+
+// Diagnostics describes a running server, to debug it where attaching a
+// profiler is impossible, e.g. on Lambda
+type Diagnostics struct {
+	GeneratedAt time.Time          `json:"generatedAt"`
+	Uptime      string             `json:"uptime"`
+	Runtime     Runtime            `json:"runtime"`
+	GoVersion   string             `json:"goVersion"`
+	Goroutines  int                `json:"goroutines"`
+	Memory      DiagnosticsMemory  `json:"memory"`
+	Settings    DiagnosticsConfig  `json:"settings"`
+	Middleware  []string           `json:"middleware"`
+	Routes      []DiagnosticsRoute `json:"routes"`
+	// Components holds the reports of the sources added with AddDiagnostics,
+	// or {"error": ...} for the sources that failed
+	Components map[string]interface{} `json:"components,omitempty"`
+}
+
+// DiagnosticsMemory is a summary of runtime.MemStats, in bytes
+type DiagnosticsMemory struct {
+	HeapAlloc   uint64 `json:"heapAlloc"`
+	HeapObjects uint64 `json:"heapObjects"`
+	TotalAlloc  uint64 `json:"totalAlloc"`
+	Sys         uint64 `json:"sys"`
+	NumGC       uint32 `json:"numGC"`
+	GCPause     string `json:"gcPause"`
+}
+
+// DiagnosticsConfig is the server's configuration
+type DiagnosticsConfig struct {
+	BasePath        string `json:"basePath,omitempty"`
+	ProxyBasePath   string `json:"proxyBasePath,omitempty"`
+	CORS            bool   `json:"cors"`
+	LambdaStreaming bool   `json:"lambdaStreaming"`
+	ShutdownTimeout string `json:"shutdownTimeout"`
+	WebSockets      bool   `json:"webSockets"`
+}
+
+// DiagnosticsRoute is a registered route and the name of its handler
+type DiagnosticsRoute struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Handler string `json:"handler"`
+}
+
+// AddDiagnostics adds a named source to the server's Diagnostics, e.g.
+//
+//	server.AddDiagnostics("postgres", func(ctx context.Context) (interface{}, error) {
+//		return db.Stats(), nil
+//	})
+func (s *Server) AddDiagnostics(name string, source DiagnosticsSource) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.diagnostics == nil {
+		s.diagnostics = map[string]DiagnosticsSource{}
+	}
+	s.diagnostics[name] = source
+	return s
+}
+
+// Diagnostics reports the server's routes, middleware and settings, the
+// process' goroutines and memory, and the sources added with AddDiagnostics
+func (s *Server) Diagnostics(ctx context.Context) Diagnostics {
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+
+	report := Diagnostics{
+		GeneratedAt: time.Now(),
+		Uptime:      time.Since(processStart).Round(time.Second).String(),
+		Runtime:     s.runtime,
+		GoVersion:   runtime.Version(),
+		Goroutines:  runtime.NumGoroutine(),
+		Memory: DiagnosticsMemory{
+			HeapAlloc:   memory.HeapAlloc,
+			HeapObjects: memory.HeapObjects,
+			TotalAlloc:  memory.TotalAlloc,
+			Sys:         memory.Sys,
+			NumGC:       memory.NumGC,
+			GCPause:     time.Duration(memory.PauseTotalNs).String(),
+		},
+		Settings: DiagnosticsConfig{
+			BasePath:        s.basePath,
+			ProxyBasePath:   s.proxyBasePath,
+			CORS:            s.corsConfig != nil,
+			LambdaStreaming: s.lambdaStreaming,
+			ShutdownTimeout: s.stopTimeout().String(),
+			WebSockets:      s.hub != nil,
+		},
+		Middleware: make([]string, 0, len(s.engine.Handlers)),
+		Routes:     []DiagnosticsRoute{},
+	}
+	for _, handler := range s.engine.Handlers {
+		report.Middleware = append(report.Middleware, handlerName(handler))
+	}
+	for _, route := range s.engine.Routes() {
+		report.Routes = append(report.Routes, DiagnosticsRoute{Method: route.Method, Path: route.Path, Handler: route.Handler})
+	}
+	sort.Slice(report.Routes, func(i, j int) bool {
+		if report.Routes[i].Path != report.Routes[j].Path {
+			return report.Routes[i].Path < report.Routes[j].Path
+		}
+		return report.Routes[i].Method < report.Routes[j].Method
+	})
+
+	s.mu.Lock()
+	sources := make(map[string]DiagnosticsSource, len(s.diagnostics))
+	for name, source := range s.diagnostics {
+		sources[name] = source
+	}
+	s.mu.Unlock()
+	if len(sources) > 0 {
+		report.Components = make(map[string]interface{}, len(sources))
+		for name, source := range sources {
+			component, err := source(ctx)
+			if err != nil {
+				component = map[string]string{"error": err.Error()}
+			}
+			report.Components[name] = component
+		}
+	}
+	return report
+}
+
+func handlerName(handler gin.HandlerFunc) string {
+	return runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+}
+
+// DiagnosticsAdmin registers GET path, returning the server's Diagnostics.
+// Protect the group, e.g. with RequireRoles, as the report reveals the
+// server's internals.
+func DiagnosticsAdmin(g *ControllerGroup, path string) {
+	g.GET(path, func(ctx *Context) (Diagnostics, error) {
+		return g.server.Diagnostics(ctx), nil
+	})
+}
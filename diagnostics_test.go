@@ -0,0 +1,48 @@
+package ginboot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerDiagnostics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{engine: gin.New(), basePath: "/api"}
+	server.engine.Use(gin.Recovery())
+	server.AddDiagnostics("db", func(ctx context.Context) (interface{}, error) {
+		return map[string]int{"openConnections": 3}, nil
+	})
+	server.AddDiagnostics("cache", func(ctx context.Context) (interface{}, error) {
+		return nil, errors.New("unreachable")
+	})
+	DiagnosticsAdmin(server.Group("/admin"), "/diagnostics")
+	server.Group("/users").GET("", func() ([]string, error) {
+		return []string{}, nil
+	})
+
+	w := httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest("GET", "/api/admin/diagnostics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var report Diagnostics
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Positive(t, report.Goroutines)
+	assert.Positive(t, report.Memory.Sys)
+	assert.Equal(t, "/api", report.Settings.BasePath)
+	assert.Equal(t, DefaultShutdownTimeout.String(), report.Settings.ShutdownTimeout)
+	assert.Len(t, report.Middleware, 1)
+	assert.Contains(t, report.Middleware[0], "gin.")
+	assert.Len(t, report.Routes, 2)
+	assert.Equal(t, "/api/admin/diagnostics", report.Routes[0].Path)
+	assert.Equal(t, "/api/users", report.Routes[1].Path)
+	assert.Equal(t, map[string]interface{}{"openConnections": float64(3)}, report.Components["db"])
+	assert.Equal(t, map[string]interface{}{"error": "unreachable"}, report.Components["cache"])
+}
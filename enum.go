@@ -0,0 +1,173 @@
+package ginboot
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// EnumValues defines the allowed values of an Enum
+type EnumValues interface {
+	Values() []string
+}
+
+// Enum is a string restricted to the values listed by D. Invalid values are
+// rejected when decoding JSON, query/form parameters, BSON and SQL columns,
+// and the binding:"enum" tag additionally requires the value to be set.
+//
+//	type PostStatus struct{}
+//
+//	func (PostStatus) Values() []string { return []string{"draft", "published"} }
+//
+//	type Post struct {
+//		Status ginboot.Enum[PostStatus] `json:"status" binding:"enum"`
+//	}
+type Enum[D EnumValues] struct {
+	value string
+}
+
+// ParseEnum returns the enum for value, or an error if it is not allowed
+func ParseEnum[D EnumValues](value string) (Enum[D], error) {
+	e := Enum[D]{value: value}
+	if !e.IsValid() {
+		var definition D
+		return Enum[D]{}, fmt.Errorf("invalid value %q, must be one of %s", value, strings.Join(definition.Values(), ", "))
+	}
+	return e, nil
+}
+
+// MustEnum is like ParseEnum but panics, for declaring enum constants
+func MustEnum[D EnumValues](value string) Enum[D] {
+	e, err := ParseEnum[D](value)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+func (e Enum[D]) String() string {
+	return e.value
+}
+
+// IsZero reports whether no value is set
+func (e Enum[D]) IsZero() bool {
+	return e.value == ""
+}
+
+// IsValid reports whether the value is one of the allowed values
+func (e Enum[D]) IsValid() bool {
+	var definition D
+	for _, value := range definition.Values() {
+		if value == e.value {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Enum[D]) set(value string) error {
+	if value == "" {
+		e.value = ""
+		return nil
+	}
+	parsed, err := ParseEnum[D](value)
+	if err != nil {
+		return err
+	}
+	*e = parsed
+	return nil
+}
+
+func (e Enum[D]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.value)
+}
+
+func (e *Enum[D]) UnmarshalJSON(data []byte) error {
+	var value *string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if value == nil {
+		e.value = ""
+		return nil
+	}
+	return e.set(*value)
+}
+
+func (e Enum[D]) MarshalText() ([]byte, error) {
+	return []byte(e.value), nil
+}
+
+func (e *Enum[D]) UnmarshalText(text []byte) error {
+	return e.set(string(text))
+}
+
+// UnmarshalParam is used by gin when binding query, form and URI parameters
+func (e *Enum[D]) UnmarshalParam(param string) error {
+	return e.set(param)
+}
+
+func (e Enum[D]) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if e.value == "" {
+		return bson.MarshalValue(nil)
+	}
+	return bson.MarshalValue(e.value)
+}
+
+func (e *Enum[D]) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	if t == bson.TypeNull || t == bson.TypeUndefined {
+		e.value = ""
+		return nil
+	}
+	value, ok := bson.RawValue{Type: t, Value: data}.StringValueOK()
+	if !ok {
+		return fmt.Errorf("cannot decode %s into an enum", t)
+	}
+	return e.set(value)
+}
+
+// Value implements driver.Valuer, storing the enum as its string or NULL
+func (e Enum[D]) Value() (driver.Value, error) {
+	if e.value == "" {
+		return nil, nil
+	}
+	return e.value, nil
+}
+
+// Scan implements sql.Scanner
+func (e *Enum[D]) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		e.value = ""
+		return nil
+	case string:
+		return e.set(v)
+	case []byte:
+		return e.set(string(v))
+	default:
+		return fmt.Errorf("cannot scan %T into an enum", src)
+	}
+}
+
+var registerValidationsOnce sync.Once
+
+// registerValidations adds ginboot's tags to gin's default validator
+func registerValidations() {
+	registerValidationsOnce.Do(func() {
+		validate, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		validate.RegisterValidation("enum", func(fl validator.FieldLevel) bool {
+			enum, ok := fl.Field().Interface().(interface{ IsValid() bool })
+			return ok && enum.IsValid()
+		})
+	})
+}
@@ -0,0 +1,110 @@
+package ginboot
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type testStatus struct{}
+
+func (testStatus) Values() []string {
+	return []string{"draft", "published"}
+}
+
+type testEnumDocument struct {
+	Status Enum[testStatus] `json:"status" bson:"status" form:"status" binding:"enum"`
+}
+
+func TestEnum_Parse(t *testing.T) {
+	status, err := ParseEnum[testStatus]("draft")
+	assert.NoError(t, err)
+	assert.Equal(t, "draft", status.String())
+	assert.True(t, status.IsValid())
+
+	_, err = ParseEnum[testStatus]("archived")
+	assert.EqualError(t, err, `invalid value "archived", must be one of draft, published`)
+
+	assert.Panics(t, func() { MustEnum[testStatus]("archived") })
+}
+
+func TestEnum_JSON(t *testing.T) {
+	var doc testEnumDocument
+	assert.NoError(t, json.Unmarshal([]byte(`{"status":"published"}`), &doc))
+	assert.Equal(t, MustEnum[testStatus]("published"), doc.Status)
+
+	encoded, err := json.Marshal(doc)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"status":"published"}`, string(encoded))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"status":"archived"}`), &doc))
+
+	doc = testEnumDocument{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"status":null}`), &doc))
+	assert.True(t, doc.Status.IsZero())
+}
+
+func TestEnum_BSON(t *testing.T) {
+	encoded, err := bson.Marshal(testEnumDocument{Status: MustEnum[testStatus]("draft")})
+	assert.NoError(t, err)
+
+	var decoded testEnumDocument
+	assert.NoError(t, bson.Unmarshal(encoded, &decoded))
+	assert.Equal(t, "draft", decoded.Status.String())
+
+	invalid, err := bson.Marshal(bson.M{"status": "archived"})
+	assert.NoError(t, err)
+	assert.Error(t, bson.Unmarshal(invalid, &decoded))
+}
+
+func TestEnum_SQL(t *testing.T) {
+	var status Enum[testStatus]
+	assert.NoError(t, status.Scan([]byte("published")))
+	value, err := status.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "published", value)
+
+	assert.Error(t, status.Scan("archived"))
+
+	assert.NoError(t, status.Scan(nil))
+	value, err = status.Value()
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+}
+
+func TestEnum_Binding(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registerValidations()
+
+	server := &Server{engine: gin.New()}
+	server.Group("/posts").POST("", func(request testEnumDocument) (testEnumDocument, error) {
+		return request, nil
+	})
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{name: "valid value", body: `{"status":"draft"}`, expectedCode: http.StatusOK},
+		{name: "invalid value", body: `{"status":"archived"}`, expectedCode: http.StatusBadRequest},
+		{name: "missing value", body: `{}`, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/posts", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			server.engine.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package ginboot
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
@@ -35,16 +36,90 @@ type ErrorResponse struct {
 }
 
 func SendError(c *gin.Context, err error) {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		writeError(c, http.StatusBadRequest, gin.H{
+			"error_code": ValidationFailed.ErrorCode,
+			"message":    ValidationFailed.Message,
+			"errors":     validationErr.Fields,
+		})
+		return
+	}
+	var retryErr *RetryAfterError
+	if errors.As(err, &retryErr) {
+		SetRetryAfter(c, retryErr.RetryAfter)
+		writeError(c, retryErr.Status, gin.H{
+			"error_code": retryErr.Err.ErrorCode,
+			"message":    retryErr.Err.Message,
+		})
+		return
+	}
 	var customErr ApiError
 	if errors.As(err, &customErr) {
-		c.JSON(http.StatusBadRequest, gin.H{
+		writeError(c, http.StatusBadRequest, gin.H{
 			"error_code": customErr.ErrorCode,
 			"message":    customErr.Message,
 		})
 		return
 	}
+	if errors.Is(err, ErrNotFound) {
+		writeError(c, http.StatusNotFound, gin.H{
+			"error_code": "NOT_FOUND",
+			"message":    "Resource not found",
+		})
+		return
+	}
+	if errors.Is(err, ErrDuplicateKey) {
+		message := "Resource already exists"
+		var duplicate *DuplicateKeyError
+		if errors.As(err, &duplicate) && duplicate.Field != "" {
+			message = duplicate.Field + " already exists"
+		}
+		writeError(c, http.StatusConflict, gin.H{
+			"error_code": "DUPLICATE_KEY",
+			"message":    message,
+		})
+		return
+	}
+	if errors.Is(err, ErrPreconditionFailed) || (errors.Is(err, ErrVersionConflict) && c.GetBool(ifMatchKey)) {
+		writeError(c, http.StatusPreconditionFailed, gin.H{
+			"error_code": PreconditionFailed.ErrorCode,
+			"message":    PreconditionFailed.Message,
+		})
+		return
+	}
+	if errors.Is(err, ErrVersionConflict) {
+		writeError(c, http.StatusConflict, gin.H{
+			"error_code": "VERSION_CONFLICT",
+			"message":    "Resource was modified concurrently",
+		})
+		return
+	}
+	if errors.Is(err, ErrReadOnly) {
+		writeError(c, http.StatusServiceUnavailable, gin.H{
+			"error_code": ServiceReadOnly.ErrorCode,
+			"message":    ServiceReadOnly.Message,
+		})
+		return
+	}
+	if errors.Is(err, context.Canceled) {
+		// the client hung up; nobody reads the body, but logs and metrics
+		// see the status
+		writeError(c, StatusClientClosedRequest, gin.H{
+			"error_code": RequestCanceled.ErrorCode,
+			"message":    RequestCanceled.Message,
+		})
+		return
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		writeError(c, http.StatusGatewayTimeout, gin.H{
+			"error_code": RequestTimedOut.ErrorCode,
+			"message":    RequestTimedOut.Message,
+		})
+		return
+	}
 	// Handle other types of errors here
-	c.JSON(http.StatusInternalServerError, gin.H{
+	writeError(c, http.StatusInternalServerError, gin.H{
 		"error_code": "Internal Server Error",
 		"message":    "An unknown error occurred",
 	})
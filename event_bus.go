@@ -0,0 +1,126 @@
+package ginboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"sync"
+)
+
+// EventBus dispatches application events in process, so a service can publish
+// e.g. PostCreated without knowing about cache invalidation, notifications or
+// indexing. Events are routed by their Go type.
+type EventBus struct {
+	mu       sync.RWMutex
+	handlers map[reflect.Type][]*eventSubscription
+	nextID   uint64
+	pending  sync.WaitGroup
+	// Logf reports errors and panics of async handlers, through the Logger of
+	// the publisher's context when nil
+	Logf func(format string, args ...interface{})
+}
+
+type eventSubscription struct {
+	id     uint64
+	async  bool
+	handle func(ctx context.Context, event interface{}) error
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{
+		handlers: make(map[reflect.Type][]*eventSubscription),
+	}
+}
+
+// Subscribe registers a handler run by Publish before it returns; its error is
+// returned by Publish. It returns a function removing the handler.
+func Subscribe[T any](bus *EventBus, handler func(ctx context.Context, event T) error) func() {
+	return bus.subscribe(eventType[T](), false, func(ctx context.Context, event interface{}) error {
+		return handler(ctx, event.(T))
+	})
+}
+
+// SubscribeAsync registers a handler run in its own goroutine, so slow work
+// such as sending emails doesn't delay the publisher. It runs without the
+// publisher's cancellation, and its errors are logged.
+func SubscribeAsync[T any](bus *EventBus, handler func(ctx context.Context, event T) error) func() {
+	return bus.subscribe(eventType[T](), true, func(ctx context.Context, event interface{}) error {
+		return handler(ctx, event.(T))
+	})
+}
+
+// Publish dispatches the event to the handlers subscribed to its type. A
+// failing or panicking handler doesn't keep the others from running; the
+// errors of the synchronous handlers are joined and returned.
+func Publish[T any](ctx context.Context, bus *EventBus, event T) error {
+	bus.mu.RLock()
+	subscriptions := bus.handlers[eventType[T]()]
+	bus.mu.RUnlock()
+
+	var errs []error
+	for _, subscription := range subscriptions {
+		if !subscription.async {
+			if err := safeHandle(ctx, subscription, event); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		bus.pending.Add(1)
+		go func(subscription *eventSubscription) {
+			defer bus.pending.Done()
+			if err := safeHandle(context.WithoutCancel(ctx), subscription, event); err != nil {
+				bus.logError(ctx, event, err)
+			}
+		}(subscription)
+	}
+	return errors.Join(errs...)
+}
+
+// Wait blocks until the running async handlers are done, e.g. before shutdown
+func (b *EventBus) Wait() {
+	b.pending.Wait()
+}
+
+func (b *EventBus) subscribe(typ reflect.Type, async bool, handle func(ctx context.Context, event interface{}) error) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	subscription := &eventSubscription{id: b.nextID, async: async, handle: handle}
+	b.handlers[typ] = append(b.handlers[typ], subscription)
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		subscriptions := b.handlers[typ]
+		for i, s := range subscriptions {
+			if s.id == subscription.id {
+				// copy so running Publish calls keep their snapshot
+				b.handlers[typ] = append(append([]*eventSubscription{}, subscriptions[:i]...), subscriptions[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func safeHandle(ctx context.Context, subscription *eventSubscription, event interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("event handler panicked: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return subscription.handle(ctx, event)
+}
+
+func eventType[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (bus *EventBus) logError(ctx context.Context, event interface{}, err error) {
+	if bus.Logf != nil {
+		bus.Logf("ginboot: handling %T: %v", event, err)
+		return
+	}
+	LoggerFromContext(ctx).Error("ginboot: async event handler failed", "event", fmt.Sprintf("%T", event), "error", err)
+}
@@ -0,0 +1,101 @@
+package ginboot
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type postCreated struct {
+	ID string
+}
+
+type postDeleted struct {
+	ID string
+}
+
+func TestEventBus(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Dispatch by type", func(t *testing.T) {
+		bus := NewEventBus()
+		var created, deleted []string
+		Subscribe(bus, func(ctx context.Context, event postCreated) error {
+			created = append(created, event.ID)
+			return nil
+		})
+		Subscribe(bus, func(ctx context.Context, event postDeleted) error {
+			deleted = append(deleted, event.ID)
+			return nil
+		})
+
+		assert.NoError(t, Publish(ctx, bus, postCreated{ID: "1"}))
+		assert.NoError(t, Publish(ctx, bus, postDeleted{ID: "2"}))
+		assert.Equal(t, []string{"1"}, created)
+		assert.Equal(t, []string{"2"}, deleted)
+	})
+
+	t.Run("Errors and panics are isolated", func(t *testing.T) {
+		bus := NewEventBus()
+		handled := false
+		Subscribe(bus, func(ctx context.Context, event postCreated) error {
+			return errors.New("index unavailable")
+		})
+		Subscribe(bus, func(ctx context.Context, event postCreated) error {
+			panic("boom")
+		})
+		Subscribe(bus, func(ctx context.Context, event postCreated) error {
+			handled = true
+			return nil
+		})
+
+		err := Publish(ctx, bus, postCreated{ID: "1"})
+		assert.ErrorContains(t, err, "index unavailable")
+		assert.ErrorContains(t, err, "event handler panicked: boom")
+		assert.True(t, handled)
+	})
+
+	t.Run("Async", func(t *testing.T) {
+		bus := NewEventBus()
+		var mu sync.Mutex
+		var logged []string
+		bus.Logf = func(format string, args ...interface{}) {
+			mu.Lock()
+			defer mu.Unlock()
+			logged = append(logged, format)
+		}
+		var received []string
+		SubscribeAsync(bus, func(ctx context.Context, event postCreated) error {
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, event.ID)
+			return nil
+		})
+		SubscribeAsync(bus, func(ctx context.Context, event postCreated) error {
+			return errors.New("mail server down")
+		})
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.NoError(t, Publish(cancelled, bus, postCreated{ID: "1"}))
+		bus.Wait()
+		assert.Equal(t, []string{"1"}, received)
+		assert.Len(t, logged, 1)
+	})
+
+	t.Run("Unsubscribe", func(t *testing.T) {
+		bus := NewEventBus()
+		calls := 0
+		unsubscribe := Subscribe(bus, func(ctx context.Context, event postCreated) error {
+			calls++
+			return nil
+		})
+		assert.NoError(t, Publish(ctx, bus, postCreated{}))
+		unsubscribe()
+		assert.NoError(t, Publish(ctx, bus, postCreated{}))
+		assert.Equal(t, 1, calls)
+	})
+}
@@ -0,0 +1,117 @@
+package ginboot
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventEnvelope wraps an event forwarded out of process
+type EventEnvelope struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	Timestamp time.Time   `json:"timestamp"`
+	Payload   interface{} `json:"payload"`
+}
+
+// EventPublisher sends events to a broker such as SNS or EventBridge
+type EventPublisher interface {
+	PublishEvent(ctx context.Context, envelope EventEnvelope) error
+}
+
+// EventPublisherFunc adapts a function to the EventPublisher interface
+type EventPublisherFunc func(ctx context.Context, envelope EventEnvelope) error
+
+func (f EventPublisherFunc) PublishEvent(ctx context.Context, envelope EventEnvelope) error {
+	return f(ctx, envelope)
+}
+
+type ForwardConfig struct {
+	// Type names the event in its envelope, the Go type name by default
+	Type string
+	// Async forwards the event from its own goroutine, so a broker outage
+	// doesn't fail Publish; errors are logged instead
+	Async bool
+}
+
+// Forward publishes every event of type T on the bus to the publisher, wrapped
+// in an EventEnvelope. It returns a function that stops forwarding.
+func Forward[T any](bus *EventBus, publisher EventPublisher, config ForwardConfig) func() {
+	if config.Type == "" {
+		config.Type = eventType[T]().Name()
+	}
+	forward := func(ctx context.Context, event T) error {
+		return publisher.PublishEvent(ctx, EventEnvelope{
+			ID:        uuid.New().String(),
+			Type:      config.Type,
+			Timestamp: time.Now().UTC(),
+			Payload:   event,
+		})
+	}
+	if config.Async {
+		return SubscribeAsync(bus, forward)
+	}
+	return Subscribe(bus, forward)
+}
+
+// SNSClient publishes a message to a topic. Adapt *sns.Client from
+// aws-sdk-go-v2 with a function setting TopicArn, Message and the attributes
+// as MessageAttributes.
+type SNSClient interface {
+	Publish(ctx context.Context, topicARN string, message string, attributes map[string]string) error
+}
+
+// SNSPublisher publishes envelopes as JSON messages to an SNS topic, with the
+// event type as the "type" message attribute for subscription filters
+type SNSPublisher struct {
+	Client   SNSClient
+	TopicARN string
+}
+
+func (p SNSPublisher) PublishEvent(ctx context.Context, envelope EventEnvelope) error {
+	message, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	return p.Client.Publish(ctx, p.TopicARN, string(message), map[string]string{"type": envelope.Type})
+}
+
+// EventBridgeEntry is an event put on an EventBridge bus
+type EventBridgeEntry struct {
+	EventBusName string
+	Source       string
+	DetailType   string
+	Detail       string
+	Time         time.Time
+}
+
+// EventBridgeClient puts an event on a bus. Adapt *eventbridge.Client from
+// aws-sdk-go-v2 with a function calling PutEvents with a single entry, which
+// must also fail when the response reports a FailedEntryCount.
+type EventBridgeClient interface {
+	PutEvent(ctx context.Context, entry EventBridgeEntry) error
+}
+
+// EventBridgePublisher puts envelopes on an EventBridge bus, with the event
+// type as the detail type for rules to match on
+type EventBridgePublisher struct {
+	Client       EventBridgeClient
+	EventBusName string
+	Source       string
+}
+
+func (p EventBridgePublisher) PublishEvent(ctx context.Context, envelope EventEnvelope) error {
+	detail, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	return p.Client.PutEvent(ctx, EventBridgeEntry{
+		EventBusName: p.EventBusName,
+		Source:       p.Source,
+		DetailType:   envelope.Type,
+		Detail:       string(detail),
+		Time:         envelope.Timestamp,
+	})
+}
@@ -0,0 +1,85 @@
+package ginboot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type snsClientFunc func(ctx context.Context, topicARN, message string, attributes map[string]string) error
+
+func (f snsClientFunc) Publish(ctx context.Context, topicARN, message string, attributes map[string]string) error {
+	return f(ctx, topicARN, message, attributes)
+}
+
+type eventBridgeClientFunc func(ctx context.Context, entry EventBridgeEntry) error
+
+func (f eventBridgeClientFunc) PutEvent(ctx context.Context, entry EventBridgeEntry) error {
+	return f(ctx, entry)
+}
+
+func TestForward(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("SNS", func(t *testing.T) {
+		bus := NewEventBus()
+		var topic, message string
+		var attributes map[string]string
+		Forward[postCreated](bus, SNSPublisher{
+			TopicARN: "arn:aws:sns:us-east-1:123456789012:posts",
+			Client: snsClientFunc(func(ctx context.Context, topicARN, msg string, attrs map[string]string) error {
+				topic, message, attributes = topicARN, msg, attrs
+				return nil
+			}),
+		}, ForwardConfig{})
+
+		assert.NoError(t, Publish(ctx, bus, postCreated{ID: "1"}))
+		assert.Equal(t, "arn:aws:sns:us-east-1:123456789012:posts", topic)
+		assert.Equal(t, map[string]string{"type": "postCreated"}, attributes)
+
+		var envelope map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(message), &envelope))
+		assert.Equal(t, "postCreated", envelope["type"])
+		assert.Equal(t, map[string]interface{}{"ID": "1"}, envelope["payload"])
+		assert.NotEmpty(t, envelope["id"])
+		assert.NotEmpty(t, envelope["timestamp"])
+	})
+
+	t.Run("EventBridge", func(t *testing.T) {
+		bus := NewEventBus()
+		var entries []EventBridgeEntry
+		Forward[postCreated](bus, EventBridgePublisher{
+			EventBusName: "app",
+			Source:       "posts",
+			Client: eventBridgeClientFunc(func(ctx context.Context, entry EventBridgeEntry) error {
+				entries = append(entries, entry)
+				return nil
+			}),
+		}, ForwardConfig{Type: "post.created"})
+
+		assert.NoError(t, Publish(ctx, bus, postCreated{ID: "1"}))
+		assert.Len(t, entries, 1)
+		assert.Equal(t, "app", entries[0].EventBusName)
+		assert.Equal(t, "posts", entries[0].Source)
+		assert.Equal(t, "post.created", entries[0].DetailType)
+		assert.Contains(t, entries[0].Detail, `"payload":{"ID":"1"}`)
+	})
+
+	t.Run("Async", func(t *testing.T) {
+		bus := NewEventBus()
+		bus.Logf = func(format string, args ...interface{}) {}
+		failing := EventPublisherFunc(func(ctx context.Context, envelope EventEnvelope) error {
+			return errors.New("broker unavailable")
+		})
+
+		Forward[postDeleted](bus, failing, ForwardConfig{})
+		assert.Error(t, Publish(ctx, bus, postDeleted{ID: "1"}))
+
+		Forward[postCreated](bus, failing, ForwardConfig{Async: true})
+		assert.NoError(t, Publish(ctx, bus, postCreated{ID: "1"}))
+		bus.Wait()
+	})
+}
@@ -35,23 +35,23 @@ func (c *PostController) Register(group *ginboot.ControllerGroup) {
 	}
 }
 
-func (c *PostController) CreatePost(request model.Post) (model.Post, error) {
-	return c.postService.CreatePost(request)
+func (c *PostController) CreatePost(ctx *ginboot.Context, request model.Post) (model.Post, error) {
+	return c.postService.CreatePost(ctx, request)
 }
 
 func (c *PostController) GetPost(ctx *ginboot.Context) (model.Post, error) {
 	id := ctx.Param("id")
-	return c.postService.GetPostById(id)
+	return c.postService.GetPostById(ctx, id)
 }
 
 func (c *PostController) UpdatePost(ctx *ginboot.Context, post model.Post) (model.Post, error) {
 	id := ctx.Param("id")
-	return post, c.postService.UpdatePost(id, post)
+	return post, c.postService.UpdatePost(ctx, id, post)
 }
 
 func (c *PostController) DeletePost(ctx *ginboot.Context) (ginboot.EmptyResponse, error) {
 	id := ctx.Param("id")
-	err := c.postService.DeletePost(id)
+	err := c.postService.DeletePost(ctx, id)
 	if err != nil {
 		return ginboot.EmptyResponse{}, err
 	}
@@ -64,7 +64,7 @@ func (c *PostController) GetPosts(ctx *ginboot.Context) (ginboot.PageResponse[mo
 	sortField := ctx.DefaultQuery("sort", "created_at")
 	sortDir, _ := strconv.Atoi(ctx.DefaultQuery("direction", "-1"))
 
-	return c.postService.GetPosts(page, size, ginboot.SortField{
+	return c.postService.GetPosts(ctx, page, size, ginboot.SortField{
 		Field:     sortField,
 		Direction: sortDir,
 	})
@@ -75,7 +75,7 @@ func (c *PostController) GetPostsByAuthor(ctx *ginboot.Context) (ginboot.PageRes
 	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
 	size, _ := strconv.Atoi(ctx.DefaultQuery("size", "10"))
 
-	return c.postService.GetPostsByAuthor(author, page, size)
+	return c.postService.GetPostsByAuthor(ctx, author, page, size)
 }
 
 func (c *PostController) GetPostsByTags(ctx *ginboot.Context) (ginboot.PageResponse[model.Post], error) {
@@ -84,5 +84,5 @@ func (c *PostController) GetPostsByTags(ctx *ginboot.Context) (ginboot.PageRespo
 	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
 	size, _ := strconv.Atoi(ctx.DefaultQuery("size", "10"))
 
-	return c.postService.GetPostsByTags(tags, page, size)
+	return c.postService.GetPostsByTags(ctx, tags, page, size)
 }
@@ -1,39 +1,12 @@
 package middleware
 
 import (
-	"net/http"
-	"strings"
-
 	"github.com/gin-gonic/gin"
+	"github.com/klass-lk/ginboot"
 )
 
+// AuthMiddleware validates the access token issued by ginboot.GenerateTokens
+// and exposes the user through ctx.GetAuthContext()
 func AuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
-			c.Abort()
-			return
-		}
-
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
-			c.Abort()
-			return
-		}
-
-		// In a real application, you would validate the token here
-		// For this example, we'll just check if it's not empty
-		token := parts[1]
-		if token == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
-		}
-
-		// You could set user information in the context here
-		c.Set("user", "example_user")
-		c.Next()
-	}
+	return ginboot.JWTAuthMiddleware(ginboot.DefaultJWTAuthConfig())
 }
@@ -10,6 +10,6 @@ type Post struct {
 	Content   string    `bson:"content" json:"content"`
 	Author    string    `bson:"author" json:"author"`
 	Tags      []string  `bson:"tags" json:"tags"`
-	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
-	UpdatedAt time.Time `bson:"updated_at" json:"updatedAt"`
+	CreatedAt time.Time `bson:"created_at" json:"createdAt" ginboot:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updatedAt" ginboot:"updated_at"`
 }
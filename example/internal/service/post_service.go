@@ -1,7 +1,7 @@
 package service
 
 import (
-	"time"
+	"context"
 
 	"github.com/klass-lk/ginboot"
 	"github.com/klass-lk/ginboot/example/internal/model"
@@ -19,46 +19,45 @@ func NewPostService(postRepo *repository.PostRepository) *PostService {
 	}
 }
 
-func (s *PostService) CreatePost(post model.Post) (model.Post, error) {
+func (s *PostService) CreatePost(ctx context.Context, post model.Post) (model.Post, error) {
 	post.ID = primitive.NewObjectID().Hex()
-	post.CreatedAt = time.Now()
-	post.UpdatedAt = time.Now()
 
-	err := s.postRepo.Save(post)
-	return post, err
+	// read back for the timestamps set by the repository
+	if err := s.postRepo.Save(ctx, post); err != nil {
+		return post, err
+	}
+	return s.postRepo.FindById(ctx, post.ID)
 }
 
-func (s *PostService) GetPostById(id string) (model.Post, error) {
-	return s.postRepo.FindById(id)
+func (s *PostService) GetPostById(ctx context.Context, id string) (model.Post, error) {
+	return s.postRepo.FindById(ctx, id)
 }
 
-func (s *PostService) UpdatePost(id string, post model.Post) error {
-	existingPost, err := s.postRepo.FindById(id)
+func (s *PostService) UpdatePost(ctx context.Context, id string, post model.Post) error {
+	existingPost, err := s.postRepo.FindById(ctx, id)
 	if err != nil {
 		return err
 	}
 
 	post.ID = existingPost.ID
-	post.CreatedAt = existingPost.CreatedAt
-	post.UpdatedAt = time.Now()
-
-	return s.postRepo.Update(post)
+	return s.postRepo.Update(ctx, post)
 }
 
-func (s *PostService) DeletePost(id string) error {
-	return s.postRepo.Delete(id)
+func (s *PostService) DeletePost(ctx context.Context, id string) error {
+	return s.postRepo.Delete(ctx, id)
 }
 
-func (s *PostService) GetPosts(page, size int, sort ginboot.SortField) (ginboot.PageResponse[model.Post], error) {
-	return s.postRepo.FindAllPaginated(ginboot.PageRequest{
+func (s *PostService) GetPosts(ctx context.Context, page, size int, sort ginboot.SortField) (ginboot.PageResponse[model.Post], error) {
+	return s.postRepo.FindAllPaginated(ctx, ginboot.PageRequest{
 		Page: page,
 		Size: size,
 		Sort: sort,
 	})
 }
 
-func (s *PostService) GetPostsByAuthor(author string, page, size int) (ginboot.PageResponse[model.Post], error) {
+func (s *PostService) GetPostsByAuthor(ctx context.Context, author string, page, size int) (ginboot.PageResponse[model.Post], error) {
 	return s.postRepo.FindByPaginated(
+		ctx,
 		ginboot.PageRequest{
 			Page: page,
 			Size: size,
@@ -69,8 +68,9 @@ func (s *PostService) GetPostsByAuthor(author string, page, size int) (ginboot.P
 	)
 }
 
-func (s *PostService) GetPostsByTags(tags []string, page, size int) (ginboot.PageResponse[model.Post], error) {
+func (s *PostService) GetPostsByTags(ctx context.Context, tags []string, page, size int) (ginboot.PageResponse[model.Post], error) {
 	return s.postRepo.FindByPaginated(
+		ctx,
 		ginboot.PageRequest{
 			Page: page,
 			Size: size,
@@ -1,58 +1,93 @@
 package ginboot
 
-// GenericRepository defines the interface for a generic repository with string IDs
-type GenericRepository[T any] interface {
+import "context"
+
+// ReadOnlyRepository defines the read side of a repository. It is implemented
+// by repositories bound to views and read models.
+// Every method takes the caller's context so request deadlines, cancellation
+// and tracing reach the database; *ginboot.Context can be passed directly.
+// Read methods accept QueryOptions such as ConsistentRead() or ForUpdate().
+type ReadOnlyRepository[T any] interface {
 	// FindById finds a document by its string ID
-	FindById(id string) (T, error)
+	FindById(ctx context.Context, id string, opts ...QueryOption) (T, error)
 
 	// FindAllById finds all documents with the given string IDs
-	FindAllById(ids []string) ([]T, error)
-
-	// Save saves a document
-	Save(doc T) error
-
-	// SaveOrUpdate saves or updates a document
-	SaveOrUpdate(doc T) error
-
-	// SaveAll saves multiple documents
-	SaveAll(docs []T) error
-
-	// Update updates an existing document
-	Update(doc T) error
-
-	// Delete deletes a document by its string ID
-	Delete(id string) error
+	FindAllById(ctx context.Context, ids []string, opts ...QueryOption) ([]T, error)
 
 	// FindOneBy finds a document by a field value
-	FindOneBy(field string, value interface{}) (T, error)
+	FindOneBy(ctx context.Context, field string, value interface{}, opts ...QueryOption) (T, error)
 
 	// FindOneByFilters finds a document by multiple filters
-	FindOneByFilters(filters map[string]interface{}) (T, error)
+	FindOneByFilters(ctx context.Context, filters map[string]interface{}, opts ...QueryOption) (T, error)
 
 	// FindBy finds documents by a field value
-	FindBy(field string, value interface{}) ([]T, error)
+	FindBy(ctx context.Context, field string, value interface{}, opts ...QueryOption) ([]T, error)
 
 	// FindByFilters finds documents by multiple filters
-	FindByFilters(filters map[string]interface{}) ([]T, error)
+	FindByFilters(ctx context.Context, filters map[string]interface{}, opts ...QueryOption) ([]T, error)
 
 	// FindAll finds all documents
-	FindAll(options ...interface{}) ([]T, error)
+	FindAll(ctx context.Context, opts ...QueryOption) ([]T, error)
 
 	// FindAllPaginated finds all documents with pagination
-	FindAllPaginated(pageRequest PageRequest) (PageResponse[T], error)
+	FindAllPaginated(ctx context.Context, pageRequest PageRequest, opts ...QueryOption) (PageResponse[T], error)
 
 	// FindByPaginated finds documents by filters with pagination
-	FindByPaginated(pageRequest PageRequest, filters map[string]interface{}) (PageResponse[T], error)
+	FindByPaginated(ctx context.Context, pageRequest PageRequest, filters map[string]interface{}, opts ...QueryOption) (PageResponse[T], error)
+
+	// FindByQuery finds documents matching a criteria query, sorted and limited as requested
+	FindByQuery(ctx context.Context, query *Query, opts ...QueryOption) ([]T, error)
+
+	// FindByQueryPaginated finds documents matching a criteria query with pagination
+	FindByQueryPaginated(ctx context.Context, pageRequest PageRequest, query *Query, opts ...QueryOption) (PageResponse[T], error)
 
 	// CountBy counts documents by a field value
-	CountBy(field string, value interface{}) (int64, error)
+	CountBy(ctx context.Context, field string, value interface{}) (int64, error)
 
 	// CountByFilters counts documents by multiple filters
-	CountByFilters(filters map[string]interface{}) (int64, error)
+	CountByFilters(ctx context.Context, filters map[string]interface{}) (int64, error)
+
+	// CountByQuery counts documents matching a criteria query
+	CountByQuery(ctx context.Context, query *Query) (int64, error)
 
 	// ExistsBy checks if a document exists by a field value
-	ExistsBy(field string, value interface{}) (bool, error)
+	ExistsBy(ctx context.Context, field string, value interface{}) (bool, error)
 
 	// ExistsByFilters checks if a document exists by multiple filters
-	ExistsByFilters(filters map[string]interface{}) (bool, error)
+	ExistsByFilters(ctx context.Context, filters map[string]interface{}) (bool, error)
+}
+
+// Tx is the context of a running transaction. Pass it as the context of
+// repository calls to make them part of the transaction.
+type Tx interface {
+	context.Context
+}
+
+// GenericRepository defines the interface for a generic repository with string IDs
+type GenericRepository[T any] interface {
+	ReadOnlyRepository[T]
+
+	// Save saves a document
+	Save(ctx context.Context, doc T) error
+
+	// SaveOrUpdate saves or updates a document
+	SaveOrUpdate(ctx context.Context, doc T) error
+
+	// SaveAll saves multiple documents
+	SaveAll(ctx context.Context, docs []T) error
+
+	// Update updates an existing document
+	Update(ctx context.Context, doc T) error
+
+	// Delete deletes a document by its string ID
+	Delete(ctx context.Context, id string) error
+
+	// DeleteVersion deletes the document with doc's ID when its stored version
+	// is doc's version, failing with ErrVersionConflict when it is not and with
+	// ErrNotFound when there is no document. Unversioned documents are deleted
+	// by ID.
+	DeleteVersion(ctx context.Context, doc T) error
+
+	// WithTransaction runs fn atomically; calls made with tx commit or roll back together
+	WithTransaction(ctx context.Context, fn func(tx Tx) error) error
 }
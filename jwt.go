@@ -1,11 +1,12 @@
 package ginboot
 
 import (
+	"crypto"
 	"errors"
 	"fmt"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/google/uuid"
-	"os"
+	"sync"
 	"time"
 )
 
@@ -14,55 +15,224 @@ type Claims struct {
 	jwt.StandardClaims
 }
 
-func GenerateTokens(userId string, role string) (string, string, error) {
-	var tokenSecret = os.Getenv("JWT_SECRET")
-	accessToken, err := generateJwtToken(userId, role, 24*time.Hour, tokenSecret)
+// JWTService issues and validates access and refresh tokens. Configure it
+// with the With methods:
+//
+//	tokens := ginboot.NewJWTService().
+//		WithSecrets(accessSecret, refreshSecret).
+//		WithIssuer("my-api").
+//		WithDurations(15*time.Minute, 7*24*time.Hour)
+type JWTService struct {
+	method          jwt.SigningMethod
+	accessKey       jwtKey
+	refreshKey      jwtKey
+	issuer          string
+	accessDuration  time.Duration
+	refreshDuration time.Duration
+	clockSkew       time.Duration
+	tokenStore      TokenStore
+}
+
+// jwtKey holds the keys tokens are signed and verified with, or the name of the
+// secret to resolve through the SecretProvider when none were configured
+type jwtKey struct {
+	secretName string
+	sign       interface{}
+	verify     interface{}
+}
+
+// NewJWTService returns a service signing with HS256 and the JWT_SECRET and
+// JWT_REFRESH_SECRET secrets of the SecretProvider, issuing access tokens valid
+// for 24 hours and refresh tokens valid for 30 days
+func NewJWTService() *JWTService {
+	return &JWTService{
+		method:          jwt.SigningMethodHS256,
+		accessKey:       jwtKey{secretName: "JWT_SECRET"},
+		refreshKey:      jwtKey{secretName: "JWT_REFRESH_SECRET"},
+		issuer:          "klass-lk",
+		accessDuration:  24 * time.Hour,
+		refreshDuration: 24 * 30 * time.Hour,
+	}
+}
+
+// WithSecrets sets the HMAC secrets of access and refresh tokens instead of
+// reading them from the SecretProvider on every call
+func (s *JWTService) WithSecrets(accessSecret, refreshSecret string) *JWTService {
+	s.accessKey = jwtKey{sign: []byte(accessSecret), verify: []byte(accessSecret)}
+	s.refreshKey = jwtKey{sign: []byte(refreshSecret), verify: []byte(refreshSecret)}
+	return s
+}
+
+// WithSigningKeys signs with an asymmetric method such as
+// jwt.SigningMethodRS256 with RSA keys, or jwt.SigningMethodES256 with ECDSA
+// keys. Keys may be any crypto.Signer, e.g. NewKMSSigner, so the private key
+// need not be in memory. Tokens are verified with their public keys.
+func (s *JWTService) WithSigningKeys(method jwt.SigningMethod, accessKey, refreshKey crypto.Signer) *JWTService {
+	s.method = method
+	s.accessKey = jwtKey{sign: accessKey, verify: accessKey.Public()}
+	s.refreshKey = jwtKey{sign: refreshKey, verify: refreshKey.Public()}
+	return s
+}
+
+// WithSigningMethod changes the HMAC method, e.g. to jwt.SigningMethodHS512
+func (s *JWTService) WithSigningMethod(method jwt.SigningMethod) *JWTService {
+	s.method = method
+	return s
+}
+
+// WithIssuer sets the iss claim of issued tokens, which parsed tokens must match
+func (s *JWTService) WithIssuer(issuer string) *JWTService {
+	s.issuer = issuer
+	return s
+}
+
+func (s *JWTService) WithDurations(access, refresh time.Duration) *JWTService {
+	s.accessDuration = access
+	s.refreshDuration = refresh
+	return s
+}
+
+// WithClockSkew tolerates clocks of the issuing and validating servers being
+// apart by up to skew when checking the exp, iat and nbf claims
+func (s *JWTService) WithClockSkew(skew time.Duration) *JWTService {
+	s.clockSkew = skew
+	return s
+}
+
+// WithTokenStore records issued refresh tokens, enabling IssueTokens,
+// RotateRefreshToken and revocation
+func (s *JWTService) WithTokenStore(store TokenStore) *JWTService {
+	s.tokenStore = store
+	return s
+}
+
+func (s *JWTService) GenerateTokens(userId string, role string) (string, string, error) {
+	accessToken, err := s.sign(s.newClaims(userId, role, s.accessDuration), s.accessKey)
 	if err != nil {
 		return "", "", err
 	}
-
-	var refreshTokenSecret = os.Getenv("JWT_REFRESH_SECRET")
-	refreshToken, err := generateJwtToken(userId, role, 24*30*time.Hour, refreshTokenSecret)
+	refreshToken, err := s.sign(s.newClaims(userId, role, s.refreshDuration), s.refreshKey)
 	if err != nil {
 		return "", "", err
 	}
 	return accessToken, refreshToken, nil
 }
 
-func generateJwtToken(userId string, role string, duration time.Duration, secretKey string) (string, error) {
-	var jwtKeyBytes = []byte(secretKey)
-	expirationTime := time.Now().Add(duration)
-	claims := &Claims{
+func (s *JWTService) ParseAccessToken(tokenString string) (*jwt.Token, error) {
+	return s.parse(tokenString, s.accessKey)
+}
+
+func (s *JWTService) ParseRefreshToken(tokenString string) (*jwt.Token, error) {
+	return s.parse(tokenString, s.refreshKey)
+}
+
+func (s *JWTService) keys(key jwtKey) (interface{}, interface{}, error) {
+	if key.sign != nil {
+		return key.sign, key.verify, nil
+	}
+	secret, err := getSecret(key.secretName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return []byte(secret), []byte(secret), nil
+}
+
+func (s *JWTService) newClaims(userId string, role string, duration time.Duration) *Claims {
+	now := time.Now()
+	return &Claims{
 		Role: role,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
+			ExpiresAt: now.Add(duration).Unix(),
 			Id:        uuid.New().String(),
-			IssuedAt:  time.Now().Unix(),
-			Issuer:    "klass-lk",
+			IssuedAt:  now.Unix(),
+			Issuer:    s.issuer,
 			Subject:   userId,
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtKeyBytes)
 }
 
-func ParseAccessToken(tokenString string) (*jwt.Token, error) {
-	var tokenSecret = os.Getenv("JWT_SECRET")
-	return parseJwtToken(tokenString, tokenSecret)
-}
-func ParseRefreshToken(tokenString string) (*jwt.Token, error) {
-	var tokenSecret = os.Getenv("JWT_REFRESH_SECRET")
-	return parseJwtToken(tokenString, tokenSecret)
+func (s *JWTService) sign(claims *Claims, key jwtKey) (string, error) {
+	signKey, _, err := s.keys(key)
+	if err != nil {
+		return "", err
+	}
+	method := s.method
+	if _, ok := signKey.(crypto.Signer); ok {
+		method = signerMethod{method}
+	}
+	token := jwt.NewWithClaims(method, claims)
+	return token.SignedString(signKey)
 }
 
-func parseJwtToken(tokenString string, secretKey string) (*jwt.Token, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+func (s *JWTService) parse(tokenString string, key jwtKey) (*jwt.Token, error) {
+	_, verifyKey, err := s.keys(key)
+	if err != nil {
+		return nil, err
+	}
+	// claims are validated below to apply the clock skew
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if token.Method.Alg() != s.method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(secretKey), nil
+		return verifyKey, nil
 	})
-	return token, err
+	if err != nil {
+		return token, err
+	}
+	if err := s.validateClaims(token.Claims.(jwt.MapClaims)); err != nil {
+		token.Valid = false
+		return token, err
+	}
+	return token, nil
+}
+
+func (s *JWTService) validateClaims(claims jwt.MapClaims) error {
+	now := time.Now()
+	if !claims.VerifyExpiresAt(now.Add(-s.clockSkew).Unix(), false) {
+		return jwt.NewValidationError("token is expired", jwt.ValidationErrorExpired)
+	}
+	if !claims.VerifyIssuedAt(now.Add(s.clockSkew).Unix(), false) {
+		return jwt.NewValidationError("token used before issued", jwt.ValidationErrorIssuedAt)
+	}
+	if !claims.VerifyNotBefore(now.Add(s.clockSkew).Unix(), false) {
+		return jwt.NewValidationError("token is not valid yet", jwt.ValidationErrorNotValidYet)
+	}
+	if s.issuer != "" && !claims.VerifyIssuer(s.issuer, true) {
+		return jwt.NewValidationError("token has an unexpected issuer", jwt.ValidationErrorIssuer)
+	}
+	return nil
+}
+
+var (
+	jwtServiceMu      sync.RWMutex
+	defaultJWTService = NewJWTService()
+)
+
+// SetJWTService replaces the service used by GenerateTokens, ParseAccessToken
+// and ParseRefreshToken, and so by DefaultJWTAuthConfig and SessionService
+func SetJWTService(service *JWTService) {
+	jwtServiceMu.Lock()
+	defer jwtServiceMu.Unlock()
+	defaultJWTService = service
+}
+
+func getJWTService() *JWTService {
+	jwtServiceMu.RLock()
+	defer jwtServiceMu.RUnlock()
+	return defaultJWTService
+}
+
+func GenerateTokens(userId string, role string) (string, string, error) {
+	return getJWTService().GenerateTokens(userId, role)
+}
+
+func ParseAccessToken(tokenString string) (*jwt.Token, error) {
+	return getJWTService().ParseAccessToken(tokenString)
+}
+
+func ParseRefreshToken(tokenString string) (*jwt.Token, error) {
+	return getJWTService().ParseRefreshToken(tokenString)
 }
 
 func ExtractClaims(token *jwt.Token) (jwt.MapClaims, error) {
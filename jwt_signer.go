@@ -0,0 +1,143 @@
+package ginboot
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// signerMethod signs tokens with any crypto.Signer, such as a key held in
+// AWS KMS, where jwt-go only accepts *rsa.PrivateKey and *ecdsa.PrivateKey.
+// Verification is left to the wrapped method, which takes the public key.
+type signerMethod struct {
+	jwt.SigningMethod
+}
+
+func (m signerMethod) Sign(signingString string, key interface{}) (string, error) {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	var opts crypto.SignerOpts
+	var ecdsaSize int
+	switch m.Alg() {
+	case "RS256", "ES256":
+		opts = crypto.SHA256
+	case "RS384", "ES384":
+		opts = crypto.SHA384
+	case "RS512", "ES512":
+		opts = crypto.SHA512
+	case "PS256":
+		opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+	case "PS384":
+		opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA384}
+	case "PS512":
+		opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA512}
+	default:
+		return "", fmt.Errorf("ginboot: signing method %s does not take a crypto.Signer", m.Alg())
+	}
+	switch m.Alg() {
+	case "ES256":
+		ecdsaSize = 32
+	case "ES384":
+		ecdsaSize = 48
+	case "ES512":
+		ecdsaSize = 66
+	}
+
+	hash := opts.HashFunc().New()
+	hash.Write([]byte(signingString))
+	signature, err := signer.Sign(rand.Reader, hash.Sum(nil), opts)
+	if err != nil {
+		return "", err
+	}
+	if ecdsaSize > 0 {
+		// signers return ASN.1 encoded ECDSA signatures, JWTs carry r and s
+		if signature, err = ecdsaRawSignature(signature, ecdsaSize); err != nil {
+			return "", err
+		}
+	}
+	return jwt.EncodeSegment(signature), nil
+}
+
+func ecdsaRawSignature(der []byte, size int) ([]byte, error) {
+	var parsed struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(der, &parsed); err != nil {
+		return nil, fmt.Errorf("ginboot: reading ECDSA signature: %w", err)
+	}
+	raw := make([]byte, 2*size)
+	parsed.R.FillBytes(raw[:size])
+	parsed.S.FillBytes(raw[size:])
+	return raw, nil
+}
+
+// KMSSignFunc signs a message digest with an asymmetric KMS key, e.g. by
+// calling the AWS KMS Sign API with MessageType DIGEST. algorithm is the KMS
+// signing algorithm, such as RSASSA_PKCS1_V1_5_SHA_256 or ECDSA_SHA_256.
+type KMSSignFunc func(ctx context.Context, keyID, algorithm string, digest []byte) ([]byte, error)
+
+// NewKMSSigner returns a crypto.Signer for WithSigningKeys whose private key
+// never leaves KMS. public is the key's public key, as returned by the KMS
+// GetPublicKey API and parsed with x509.ParsePKIXPublicKey. Each call of sign
+// gets a context timing out after five seconds, see WithTimeout.
+func NewKMSSigner(keyID string, public crypto.PublicKey, sign KMSSignFunc) *KMSSigner {
+	return &KMSSigner{keyID: keyID, public: public, sign: sign, timeout: 5 * time.Second}
+}
+
+// KMSSigner is a crypto.Signer calling KMS, see NewKMSSigner
+type KMSSigner struct {
+	keyID   string
+	public  crypto.PublicKey
+	sign    KMSSignFunc
+	timeout time.Duration
+}
+
+// WithTimeout sets how long a KMS call may take before its context is
+// cancelled, so an unreachable KMS fails token issuing instead of blocking it.
+// crypto.Signer passes no context, so this is the only deadline of the call.
+func (s *KMSSigner) WithTimeout(timeout time.Duration) *KMSSigner {
+	s.timeout = timeout
+	return s
+}
+
+func (s *KMSSigner) Public() crypto.PublicKey {
+	return s.public
+}
+
+func (s *KMSSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	var size string
+	switch opts.HashFunc() {
+	case crypto.SHA256:
+		size = "256"
+	case crypto.SHA384:
+		size = "384"
+	case crypto.SHA512:
+		size = "512"
+	default:
+		return nil, fmt.Errorf("ginboot: KMS cannot sign %v digests", opts.HashFunc())
+	}
+	var algorithm string
+	switch s.public.(type) {
+	case *rsa.PublicKey:
+		algorithm = "RSASSA_PKCS1_V1_5_SHA_" + size
+		if _, pss := opts.(*rsa.PSSOptions); pss {
+			algorithm = "RSASSA_PSS_SHA_" + size
+		}
+	case *ecdsa.PublicKey:
+		algorithm = "ECDSA_SHA_" + size
+	default:
+		return nil, fmt.Errorf("ginboot: unsupported KMS public key %T", s.public)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	return s.sign(ctx, s.keyID, algorithm, digest)
+}
@@ -0,0 +1,185 @@
+package ginboot
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokens_SecretProvider(t *testing.T) {
+	secrets := map[string]string{
+		"JWT_SECRET":         "access-secret",
+		"JWT_REFRESH_SECRET": "refresh-secret",
+	}
+	SetSecretProvider(SecretProviderFunc(func(ctx context.Context, name string) (string, error) {
+		return secrets[name], nil
+	}))
+	defer SetSecretProvider(EnvSecretProvider{})
+
+	accessToken, refreshToken, err := GenerateTokens("user-1", "admin")
+	assert.NoError(t, err)
+
+	token, err := ParseAccessToken(accessToken)
+	assert.NoError(t, err)
+	claims, err := ExtractClaims(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", ExtractUserId(claims))
+	assert.Equal(t, "admin", ExtractRole(claims))
+
+	_, err = ParseAccessToken(refreshToken)
+	assert.Error(t, err)
+	_, err = ParseRefreshToken(refreshToken)
+	assert.NoError(t, err)
+}
+
+func TestEnvSecretProvider_Missing(t *testing.T) {
+	_, err := EnvSecretProvider{}.GetSecret(context.Background(), "GINBOOT_MISSING_SECRET")
+	assert.Error(t, err)
+}
+
+func TestCachedSecretProvider(t *testing.T) {
+	calls := 0
+	provider := NewCachedSecretProvider(SecretProviderFunc(func(ctx context.Context, name string) (string, error) {
+		calls++
+		return "value", nil
+	}), time.Minute)
+
+	for i := 0; i < 3; i++ {
+		value, err := provider.GetSecret(context.Background(), "KEY")
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value)
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestJWTService(t *testing.T) {
+	t.Run("Secrets and issuer", func(t *testing.T) {
+		service := NewJWTService().
+			WithSecrets("access-secret", "refresh-secret").
+			WithIssuer("my-api").
+			WithDurations(time.Minute, time.Hour)
+
+		accessToken, refreshToken, err := service.GenerateTokens("user-1", "admin")
+		assert.NoError(t, err)
+
+		token, err := service.ParseAccessToken(accessToken)
+		assert.NoError(t, err)
+		claims, _ := ExtractClaims(token)
+		assert.Equal(t, "my-api", claims["iss"])
+		assert.InDelta(t, time.Now().Add(time.Minute).Unix(), claims["exp"], 2)
+
+		_, err = service.ParseAccessToken(refreshToken)
+		assert.Error(t, err)
+
+		other := NewJWTService().WithSecrets("access-secret", "refresh-secret").WithIssuer("other-api")
+		_, err = other.ParseAccessToken(accessToken)
+		assert.Error(t, err)
+	})
+
+	t.Run("RS256 and ES256", func(t *testing.T) {
+		rsaAccess, _ := rsa.GenerateKey(rand.Reader, 2048)
+		rsaRefresh, _ := rsa.GenerateKey(rand.Reader, 2048)
+		ecAccess, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		ecRefresh, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+		services := map[string]*JWTService{
+			"RS256": NewJWTService().WithSigningKeys(jwt.SigningMethodRS256, rsaAccess, rsaRefresh),
+			"ES256": NewJWTService().WithSigningKeys(jwt.SigningMethodES256, ecAccess, ecRefresh),
+		}
+		for alg, service := range services {
+			accessToken, refreshToken, err := service.GenerateTokens("user-1", "admin")
+			assert.NoError(t, err, alg)
+
+			token, err := service.ParseAccessToken(accessToken)
+			assert.NoError(t, err, alg)
+			assert.Equal(t, alg, token.Method.Alg())
+			_, err = service.ParseRefreshToken(refreshToken)
+			assert.NoError(t, err, alg)
+			_, err = service.ParseRefreshToken(accessToken)
+			assert.Error(t, err, alg)
+		}
+	})
+
+	t.Run("KMS signers", func(t *testing.T) {
+		rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+		ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		// the keys stay behind the sign functions, as they would in KMS
+		var algorithms []string
+		kms := func(key crypto.Signer) KMSSignFunc {
+			return func(ctx context.Context, keyID, algorithm string, digest []byte) ([]byte, error) {
+				algorithms = append(algorithms, algorithm)
+				var opts crypto.SignerOpts = crypto.SHA256
+				if algorithm == "RSASSA_PSS_SHA_256" {
+					opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+				}
+				return key.Sign(rand.Reader, digest, opts)
+			}
+		}
+		rsaSigner := NewKMSSigner("alias/jwt-rsa", rsaKey.Public(), kms(rsaKey))
+		ecSigner := NewKMSSigner("alias/jwt-ec", ecKey.Public(), kms(ecKey))
+
+		services := map[string]*JWTService{
+			"RS256": NewJWTService().WithSigningKeys(jwt.SigningMethodRS256, rsaSigner, rsaSigner),
+			"PS256": NewJWTService().WithSigningKeys(jwt.SigningMethodPS256, rsaSigner, rsaSigner),
+			"ES256": NewJWTService().WithSigningKeys(jwt.SigningMethodES256, ecSigner, ecSigner),
+		}
+		for alg, service := range services {
+			accessToken, _, err := service.GenerateTokens("user-1", "admin")
+			assert.NoError(t, err, alg)
+			token, err := service.ParseAccessToken(accessToken)
+			assert.NoError(t, err, alg)
+			assert.Equal(t, alg, token.Method.Alg())
+		}
+		assert.Contains(t, algorithms, "RSASSA_PKCS1_V1_5_SHA_256")
+		assert.Contains(t, algorithms, "RSASSA_PSS_SHA_256")
+		assert.Contains(t, algorithms, "ECDSA_SHA_256")
+
+		// a KMS that does not answer fails signing once the timeout passes
+		hanging := NewKMSSigner("alias/jwt-rsa", rsaKey.Public(), func(ctx context.Context, keyID, algorithm string, digest []byte) ([]byte, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}).WithTimeout(10 * time.Millisecond)
+		_, _, err := NewJWTService().WithSigningKeys(jwt.SigningMethodRS256, hanging, hanging).GenerateTokens("user-1", "admin")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Unexpected signing method", func(t *testing.T) {
+		key, _ := rsa.GenerateKey(rand.Reader, 2048)
+		accessToken, _, err := NewJWTService().WithSecrets("secret", "secret").GenerateTokens("user-1", "admin")
+		assert.NoError(t, err)
+
+		_, err = NewJWTService().WithSigningKeys(jwt.SigningMethodRS256, key, key).ParseAccessToken(accessToken)
+		assert.Error(t, err)
+	})
+
+	t.Run("Clock skew", func(t *testing.T) {
+		issuer := NewJWTService().WithSecrets("secret", "secret").WithDurations(-10*time.Second, time.Hour)
+		accessToken, _, err := issuer.GenerateTokens("user-1", "admin")
+		assert.NoError(t, err)
+
+		_, err = issuer.ParseAccessToken(accessToken)
+		assert.Error(t, err)
+
+		token, err := issuer.WithClockSkew(time.Minute).ParseAccessToken(accessToken)
+		assert.NoError(t, err)
+		assert.True(t, token.Valid)
+	})
+}
+
+func TestSetJWTService(t *testing.T) {
+	SetJWTService(NewJWTService().WithSecrets("access-secret", "refresh-secret"))
+	defer SetJWTService(NewJWTService())
+
+	accessToken, _, err := GenerateTokens("user-1", "admin")
+	assert.NoError(t, err)
+	_, err = ParseAccessToken(accessToken)
+	assert.NoError(t, err)
+}
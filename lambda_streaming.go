@@ -0,0 +1,130 @@
+package ginboot
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// WithLambdaStreaming serves Lambda Function URL invocations through the
+// response streaming API instead of buffering the whole response, so SSE and
+// large downloads work when running on Lambda.
+func (s *Server) WithLambdaStreaming() *Server {
+	s.lambdaStreaming = true
+	return s
+}
+
+func (s *Server) handleStreamingRequest(ctx context.Context, req events.LambdaFunctionURLRequest) (*events.LambdaFunctionURLStreamingResponse, error) {
+	httpReq, err := newStreamingHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	w := &lambdaStreamWriter{
+		header: make(http.Header),
+		body:   pw,
+		ready:  make(chan struct{}),
+	}
+
+	go func() {
+		defer func() {
+			w.WriteHeader(http.StatusOK)
+			pw.Close()
+		}()
+		s.engine.ServeHTTP(w, httpReq)
+	}()
+
+	// Wait until the handler commits its status and headers, the body is then
+	// streamed from the pipe as the handler keeps writing.
+	select {
+	case <-w.ready:
+	case <-ctx.Done():
+		pr.CloseWithError(ctx.Err())
+		return nil, ctx.Err()
+	}
+
+	return &events.LambdaFunctionURLStreamingResponse{
+		StatusCode: w.status,
+		Headers:    w.headers,
+		Cookies:    w.cookies,
+		Body:       pr,
+	}, nil
+}
+
+func newStreamingHTTPRequest(ctx context.Context, req events.LambdaFunctionURLRequest) (*http.Request, error) {
+	body := req.Body
+	if req.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = string(decoded)
+	}
+
+	url := req.RawPath
+	if req.RawQueryString != "" {
+		url += "?" + req.RawQueryString
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.RequestContext.HTTP.Method, url, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range req.Headers {
+		httpReq.Header.Set(key, value)
+	}
+	if len(req.Cookies) > 0 {
+		httpReq.Header.Set("Cookie", strings.Join(req.Cookies, "; "))
+	}
+	// ClientIP splits RemoteAddr into host and port, so it needs a port
+	httpReq.RemoteAddr = net.JoinHostPort(req.RequestContext.HTTP.SourceIP, "0")
+	return httpReq, nil
+}
+
+// lambdaStreamWriter is an http.ResponseWriter that forwards the body to the
+// Lambda streaming response as soon as the handler writes it.
+type lambdaStreamWriter struct {
+	header  http.Header
+	body    *io.PipeWriter
+	ready   chan struct{}
+	once    sync.Once
+	status  int
+	headers map[string]string
+	cookies []string
+}
+
+func (w *lambdaStreamWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *lambdaStreamWriter) WriteHeader(statusCode int) {
+	w.once.Do(func() {
+		w.status = statusCode
+		w.headers = make(map[string]string, len(w.header))
+		for key, values := range w.header {
+			if key == "Set-Cookie" {
+				w.cookies = append(w.cookies, values...)
+				continue
+			}
+			w.headers[key] = strings.Join(values, ",")
+		}
+		close(w.ready)
+	})
+}
+
+func (w *lambdaStreamWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(data)
+}
+
+// Flush commits the headers; written data is already handed to the pipe.
+func (w *lambdaStreamWriter) Flush() {
+	w.WriteHeader(http.StatusOK)
+}
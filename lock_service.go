@@ -0,0 +1,153 @@
+package ginboot
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrLockNotHeld is returned when renewing or releasing a lock the owner no
+// longer holds, because it expired and may have been taken by another owner
+var ErrLockNotHeld = errors.New("lock not held")
+
+// Lock records the current owner of a named lock. A lock whose ExpiresAt has
+// passed is free, so a crashed owner never holds it forever.
+type Lock struct {
+	ID        string    `bson:"_id" ginboot:"_id" json:"id" db:"id"`
+	Owner     string    `bson:"owner" json:"owner" db:"owner"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expiresAt" db:"expires_at"`
+	Version   int64     `bson:"version" json:"version" db:"version" ginboot:"version"`
+}
+
+// LockService hands out named locks with a TTL, for critical sections and
+// leader election across instances. Owners identify the holder, e.g. an
+// instance or job ID, and must renew long held locks before they expire.
+type LockService interface {
+	// Acquire takes the lock for ttl, reporting false when another owner
+	// holds it. Acquiring a lock already held by the owner renews it.
+	Acquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+	// Renew extends the owner's lock by ttl, failing with ErrLockNotHeld
+	Renew(ctx context.Context, name, owner string, ttl time.Duration) error
+	// Release frees the owner's lock, failing with ErrLockNotHeld
+	Release(ctx context.Context, name, owner string) error
+}
+
+// RepositoryLockService is a LockService backed by any GenericRepository, e.g.
+// NewMongoRepository[Lock](db, "locks"). Every write is conditional on the
+// lock's version, so concurrent owners never both succeed. Expiry relies on
+// the instances' clocks being roughly in sync.
+type RepositoryLockService struct {
+	repo GenericRepository[Lock]
+}
+
+func NewRepositoryLockService(repo GenericRepository[Lock]) *RepositoryLockService {
+	return &RepositoryLockService{repo: repo}
+}
+
+func (s *RepositoryLockService) Acquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	lock, found, err := s.find(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	if !found {
+		err := s.repo.Save(ctx, Lock{ID: name, Owner: owner, ExpiresAt: now.Add(ttl)})
+		if errors.Is(err, ErrDuplicateKey) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	if lock.Owner != owner && now.Before(lock.ExpiresAt) {
+		return false, nil
+	}
+	lock.Owner, lock.ExpiresAt = owner, now.Add(ttl)
+	if err := s.repo.Update(ctx, lock); err != nil {
+		if errors.Is(err, ErrVersionConflict) || errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *RepositoryLockService) Renew(ctx context.Context, name, owner string, ttl time.Duration) error {
+	return s.update(ctx, name, owner, func(lock *Lock) {
+		lock.ExpiresAt = time.Now().Add(ttl)
+	})
+}
+
+func (s *RepositoryLockService) Release(ctx context.Context, name, owner string) error {
+	// the lock is freed rather than deleted, as a delete can't be conditional
+	// on the version
+	return s.update(ctx, name, owner, func(lock *Lock) {
+		lock.Owner, lock.ExpiresAt = "", time.Time{}
+	})
+}
+
+func (s *RepositoryLockService) update(ctx context.Context, name, owner string, change func(lock *Lock)) error {
+	lock, found, err := s.find(ctx, name)
+	if err != nil {
+		return err
+	}
+	if !found || lock.Owner != owner || !time.Now().Before(lock.ExpiresAt) {
+		return ErrLockNotHeld
+	}
+	change(&lock)
+	if err := s.repo.Update(ctx, lock); err != nil {
+		if errors.Is(err, ErrVersionConflict) || errors.Is(err, ErrNotFound) {
+			return ErrLockNotHeld
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *RepositoryLockService) find(ctx context.Context, name string) (Lock, bool, error) {
+	locks, err := s.repo.FindAllById(ctx, []string{name})
+	if err != nil || len(locks) == 0 {
+		return Lock{}, false, err
+	}
+	return locks[0], true, nil
+}
+
+// WithLock runs fn while holding the named lock, renewing it every third of
+// ttl, and reports false without running fn when another owner holds it. The
+// context passed to fn is cancelled if the lock is lost, so fn must stop
+// writing once it is done.
+func WithLock(ctx context.Context, locks LockService, name string, ttl time.Duration, fn func(ctx context.Context) error) (bool, error) {
+	owner := uuid.NewString()
+	acquired, err := locks.Acquire(ctx, name, owner, ttl)
+	if err != nil || !acquired {
+		return false, err
+	}
+
+	lockCtx, cancel := context.WithCancel(ctx)
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-lockCtx.Done():
+				return
+			case <-ticker.C:
+				if err := locks.Renew(lockCtx, name, owner, ttl); err != nil && lockCtx.Err() == nil {
+					LoggerFromContext(ctx).Warn("ginboot: lost lock", "lock", name, "error", err)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	err = fn(lockCtx)
+	cancel()
+	<-renewed
+	if releaseErr := locks.Release(context.WithoutCancel(ctx), name, owner); releaseErr != nil && !errors.Is(releaseErr, ErrLockNotHeld) {
+		return true, errors.Join(err, releaseErr)
+	}
+	return true, err
+}
@@ -0,0 +1,91 @@
+package ginboot
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepositoryLockService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("acquire, renew and release", func(t *testing.T) {
+		locks := NewRepositoryLockService(newMemoryRepository[Lock]())
+
+		acquired, err := locks.Acquire(ctx, "report", "a", time.Minute)
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+		acquired, err = locks.Acquire(ctx, "report", "b", time.Minute)
+		assert.NoError(t, err)
+		assert.False(t, acquired)
+
+		assert.NoError(t, locks.Renew(ctx, "report", "a", time.Minute))
+		assert.ErrorIs(t, locks.Renew(ctx, "report", "b", time.Minute), ErrLockNotHeld)
+		assert.ErrorIs(t, locks.Release(ctx, "report", "b"), ErrLockNotHeld)
+
+		assert.NoError(t, locks.Release(ctx, "report", "a"))
+		assert.ErrorIs(t, locks.Release(ctx, "report", "a"), ErrLockNotHeld)
+		acquired, err = locks.Acquire(ctx, "report", "b", time.Minute)
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+	})
+
+	t.Run("expired locks can be taken over", func(t *testing.T) {
+		locks := NewRepositoryLockService(newMemoryRepository[Lock]())
+		acquired, _ := locks.Acquire(ctx, "report", "a", 10*time.Millisecond)
+		assert.True(t, acquired)
+
+		time.Sleep(20 * time.Millisecond)
+		acquired, err := locks.Acquire(ctx, "report", "b", time.Minute)
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+		assert.ErrorIs(t, locks.Renew(ctx, "report", "a", time.Minute), ErrLockNotHeld)
+	})
+
+	t.Run("one concurrent owner wins", func(t *testing.T) {
+		locks := NewRepositoryLockService(newMemoryRepository[Lock]())
+		acquired, _ := locks.Acquire(ctx, "report", "seed", time.Nanosecond)
+		assert.True(t, acquired)
+		time.Sleep(time.Millisecond)
+
+		var wins atomic.Int32
+		var wg sync.WaitGroup
+		for _, owner := range []string{"a", "b", "c", "d"} {
+			wg.Add(1)
+			go func(owner string) {
+				defer wg.Done()
+				if acquired, _ := locks.Acquire(ctx, "report", owner, time.Minute); acquired {
+					wins.Add(1)
+				}
+			}(owner)
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), wins.Load())
+	})
+}
+
+func TestWithLock(t *testing.T) {
+	ctx := context.Background()
+	locks := NewRepositoryLockService(newMemoryRepository[Lock]())
+
+	ran, err := WithLock(ctx, locks, "report", 30*time.Millisecond, func(ctx context.Context) error {
+		held, err := WithLock(ctx, locks, "report", time.Minute, func(context.Context) error { return nil })
+		assert.NoError(t, err)
+		assert.False(t, held)
+
+		// outlives the TTL, so the lock must be renewed
+		time.Sleep(60 * time.Millisecond)
+		assert.NoError(t, ctx.Err())
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, ran)
+
+	acquired, err := locks.Acquire(ctx, "report", "next", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+}
@@ -0,0 +1,144 @@
+package ginboot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Logger writes structured log lines. Arguments are alternating keys and
+// values, as with log/slog: logger.Info("user created", "user_id", id).
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+	// With returns a logger adding the key-value pairs to every line
+	With(args ...any) Logger
+}
+
+// NewSlogLogger adapts a *slog.Logger, e.g. one with a JSON handler, or a zap
+// logger through zapslog
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return slogLogger{logger}
+}
+
+type slogLogger struct {
+	*slog.Logger
+}
+
+func (l slogLogger) With(args ...any) Logger {
+	return slogLogger{l.Logger.With(args...)}
+}
+
+var (
+	loggerMu     sync.RWMutex
+	globalLogger Logger
+)
+
+// SetLogger replaces the logger used by ginboot and returned by
+// LoggerFromContext outside of requests. The default writes through
+// slog.Default().
+func SetLogger(l Logger) {
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	globalLogger = l
+}
+
+func getLogger() Logger {
+	loggerMu.RLock()
+	defer loggerMu.RUnlock()
+	if globalLogger == nil {
+		return slogLogger{slog.Default()}
+	}
+	return globalLogger
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a context carrying the logger
+func WithLogger(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// LoggerFromContext returns the request-scoped logger set by RequestLogging,
+// or the logger set with SetLogger. *Context works as the context.
+func LoggerFromContext(ctx context.Context) Logger {
+	if l, ok := ctx.Value(loggerKey{}).(Logger); ok {
+		return l
+	}
+	return getLogger()
+}
+
+// Logger returns the request-scoped logger, adding the request ID to every line
+func (c *Context) Logger() Logger {
+	return LoggerFromContext(c)
+}
+
+// logf writes a printf style line through the context's logger, for config
+// fields such as CommandLoggingConfig.Logf that predate Logger
+func logf(ctx context.Context, format string, args ...interface{}) {
+	LoggerFromContext(ctx).Info(fmt.Sprintf(format, args...))
+}
+
+type RequestLoggingConfig struct {
+	// Header carries the request ID. Incoming IDs are kept, e.g. from a load
+	// balancer, otherwise one is generated; it is returned in the response.
+	Header string
+	// SkipPaths are not logged, e.g. health checks
+	SkipPaths []string
+}
+
+// DefaultRequestLoggingConfig uses the X-Request-ID header
+func DefaultRequestLoggingConfig() RequestLoggingConfig {
+	return RequestLoggingConfig{Header: "X-Request-ID"}
+}
+
+// RequestLogging gives each request a logger with its request ID, returned by
+// ctx.Logger(), and logs every request with its status and latency. 5xx
+// responses are logged as errors.
+func RequestLogging(config RequestLoggingConfig) gin.HandlerFunc {
+	if config.Header == "" {
+		config.Header = DefaultRequestLoggingConfig().Header
+	}
+	skip := make(map[string]bool, len(config.SkipPaths))
+	for _, path := range config.SkipPaths {
+		skip[path] = true
+	}
+	return func(c *gin.Context) {
+		start := time.Now()
+		requestID := c.GetHeader(config.Header)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		c.Header(config.Header, requestID)
+		requestLogger := getLogger().With("request_id", requestID)
+		c.Request = c.Request.WithContext(WithLogger(c.Request.Context(), requestLogger))
+
+		c.Next()
+
+		if skip[c.Request.URL.Path] {
+			return
+		}
+		status := c.Writer.Status()
+		args := []any{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"latency", time.Since(start),
+		}
+		if len(c.Errors) > 0 {
+			args = append(args, "errors", c.Errors.String())
+		}
+		if status >= 500 {
+			requestLogger.Error("request", args...)
+		} else {
+			requestLogger.Info("request", args...)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package ginboot
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestLogging(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var logs bytes.Buffer
+	SetLogger(NewSlogLogger(slog.New(slog.NewJSONHandler(&logs, nil))))
+	defer SetLogger(nil)
+
+	server := &Server{engine: gin.New()}
+	server.engine.ContextWithFallback = true
+	server.Use(RequestLogging(RequestLoggingConfig{SkipPaths: []string{"/health"}}))
+	server.Group("").GET("/posts", func(ctx *Context) (string, error) {
+		ctx.Logger().Info("listing posts", "author", "ann")
+		return "ok", nil
+	})
+	server.Group("").GET("/health", func() (string, error) {
+		return "ok", nil
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	server.engine.ServeHTTP(w, req)
+	assert.Equal(t, "req-1", w.Header().Get("X-Request-ID"))
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.NotEmpty(t, w.Header().Get("X-Request-ID"))
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	assert.Len(t, lines, 2)
+	var handlerLine, requestLine map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &handlerLine))
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &requestLine))
+	assert.Equal(t, "listing posts", handlerLine["msg"])
+	assert.Equal(t, "req-1", handlerLine["request_id"])
+	assert.Equal(t, "ann", handlerLine["author"])
+	assert.Equal(t, "request", requestLine["msg"])
+	assert.Equal(t, "req-1", requestLine["request_id"])
+	assert.Equal(t, "/posts", requestLine["path"])
+	assert.Equal(t, float64(http.StatusOK), requestLine["status"])
+}
@@ -0,0 +1,117 @@
+package ginboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var AccountLocked = ApiError{"ACCOUNT_LOCKED", "Too many failed login attempts, try again later"}
+
+// loginAttemptRetries bounds how often RecordFailure retries after losing a
+// race with a concurrent failure of the same key
+const loginAttemptRetries = 10
+
+// LoginAttempt tracks consecutive failed logins for a key such as a username or IP
+type LoginAttempt struct {
+	ID          string    `bson:"_id" ginboot:"_id" json:"id" db:"id"`
+	Failures    int       `bson:"failures" json:"failures" db:"failures"`
+	LastFailure time.Time `bson:"last_failure" json:"lastFailure" db:"last_failure"`
+	LockedUntil time.Time `bson:"locked_until" json:"lockedUntil" db:"locked_until"`
+	Version     int64     `bson:"version" json:"version" db:"version" ginboot:"version"`
+}
+
+// LoginAttemptService locks an account after too many consecutive failures and
+// unlocks it again once the cool-down has passed
+type LoginAttemptService struct {
+	repo        GenericRepository[LoginAttempt]
+	maxFailures int
+	coolDown    time.Duration
+}
+
+func NewLoginAttemptService(repo GenericRepository[LoginAttempt], maxFailures int, coolDown time.Duration) *LoginAttemptService {
+	return &LoginAttemptService{
+		repo:        repo,
+		maxFailures: maxFailures,
+		coolDown:    coolDown,
+	}
+}
+
+// RecordFailure registers a failed login and locks the key once the maximum
+// number of failures is reached. Writes are conditional on the attempt's
+// version, so concurrent failures of a key are all counted.
+func (s *LoginAttemptService) RecordFailure(ctx context.Context, key string) error {
+	for i := 0; i < loginAttemptRetries; i++ {
+		attempt, found, err := s.find(ctx, key)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if !attempt.LockedUntil.IsZero() && now.After(attempt.LockedUntil) {
+			attempt.Failures, attempt.LockedUntil = 0, time.Time{}
+		}
+
+		attempt.Failures++
+		attempt.LastFailure = now
+		if attempt.Failures >= s.maxFailures {
+			attempt.LockedUntil = now.Add(s.coolDown)
+		}
+		if !found {
+			err = s.repo.Save(ctx, attempt)
+			if errors.Is(err, ErrDuplicateKey) {
+				continue
+			}
+			return err
+		}
+		err = s.repo.Update(ctx, attempt)
+		if errors.Is(err, ErrVersionConflict) || errors.Is(err, ErrNotFound) {
+			continue
+		}
+		return err
+	}
+	return fmt.Errorf("recording login failure of %s: %w", key, ErrVersionConflict)
+}
+
+// RecordSuccess clears the failure history of the key
+func (s *LoginAttemptService) RecordSuccess(ctx context.Context, key string) error {
+	return s.Unlock(ctx, key)
+}
+
+// IsLocked reports whether the key is currently locked out
+func (s *LoginAttemptService) IsLocked(ctx context.Context, key string) (bool, error) {
+	attempt, _, err := s.find(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	return time.Now().Before(attempt.LockedUntil), nil
+}
+
+// CheckLocked returns AccountLocked when the key is locked out, so login
+// handlers can bail out before verifying credentials
+func (s *LoginAttemptService) CheckLocked(ctx context.Context, key string) error {
+	locked, err := s.IsLocked(ctx, key)
+	if err != nil {
+		return err
+	}
+	if locked {
+		return AccountLocked
+	}
+	return nil
+}
+
+// Unlock removes any lock and failure history for the key
+func (s *LoginAttemptService) Unlock(ctx context.Context, key string) error {
+	return s.repo.Delete(ctx, key)
+}
+
+func (s *LoginAttemptService) find(ctx context.Context, key string) (LoginAttempt, bool, error) {
+	attempts, err := s.repo.FindAllById(ctx, []string{key})
+	if err != nil {
+		return LoginAttempt{}, false, err
+	}
+	if len(attempts) == 0 {
+		return LoginAttempt{ID: key}, false, nil
+	}
+	return attempts[0], true, nil
+}
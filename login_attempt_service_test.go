@@ -0,0 +1,80 @@
+package ginboot
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginAttemptService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("locks after max failures", func(t *testing.T) {
+		service := NewLoginAttemptService(newMemoryRepository[LoginAttempt](), 3, time.Minute)
+
+		for i := 0; i < 2; i++ {
+			assert.NoError(t, service.RecordFailure(ctx, "john"))
+		}
+		locked, err := service.IsLocked(ctx, "john")
+		assert.NoError(t, err)
+		assert.False(t, locked)
+
+		assert.NoError(t, service.RecordFailure(ctx, "john"))
+		locked, err = service.IsLocked(ctx, "john")
+		assert.NoError(t, err)
+		assert.True(t, locked)
+		assert.ErrorIs(t, service.CheckLocked(ctx, "john"), AccountLocked)
+		assert.NoError(t, service.CheckLocked(ctx, "jane"))
+	})
+
+	t.Run("success clears failures", func(t *testing.T) {
+		service := NewLoginAttemptService(newMemoryRepository[LoginAttempt](), 2, time.Minute)
+
+		assert.NoError(t, service.RecordFailure(ctx, "john"))
+		assert.NoError(t, service.RecordSuccess(ctx, "john"))
+		assert.NoError(t, service.RecordFailure(ctx, "john"))
+
+		locked, err := service.IsLocked(ctx, "john")
+		assert.NoError(t, err)
+		assert.False(t, locked)
+	})
+
+	t.Run("unlocks after cool-down", func(t *testing.T) {
+		service := NewLoginAttemptService(newMemoryRepository[LoginAttempt](), 1, time.Millisecond)
+
+		assert.NoError(t, service.RecordFailure(ctx, "john"))
+		time.Sleep(5 * time.Millisecond)
+
+		locked, err := service.IsLocked(ctx, "john")
+		assert.NoError(t, err)
+		assert.False(t, locked)
+
+		// the counter starts over once the previous lock expired
+		assert.NoError(t, service.RecordFailure(ctx, "john"))
+		locked, err = service.IsLocked(ctx, "john")
+		assert.NoError(t, err)
+		assert.True(t, locked)
+	})
+
+	t.Run("concurrent failures are all counted", func(t *testing.T) {
+		repo := newMemoryRepository[LoginAttempt]()
+		service := NewLoginAttemptService(repo, 100, time.Minute)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, service.RecordFailure(ctx, "john"))
+			}()
+		}
+		wg.Wait()
+
+		attempt, err := repo.FindById(ctx, "john")
+		assert.NoError(t, err)
+		assert.Equal(t, 5, attempt.Failures)
+	})
+}
@@ -0,0 +1,155 @@
+package ginboot
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var converters sync.Map
+
+// RegisterConverter registers how Map converts a field of type S into a field
+// of type D, e.g. primitive.ObjectID to string or time.Time to a formatted string
+func RegisterConverter[S, D any](convert func(S) D) {
+	key := [2]reflect.Type{reflect.TypeOf((*S)(nil)).Elem(), reflect.TypeOf((*D)(nil)).Elem()}
+	converters.Store(key, reflect.ValueOf(convert))
+}
+
+// Map copies the fields of an entity into a new DTO. Fields are matched by
+// name, or by the source field named in a map:"Field" tag on the DTO; map:"-"
+// leaves a DTO field untouched. Nested structs, pointers and slices are mapped
+// recursively and registered converters handle differing field types.
+func Map[S, D any](src S) (D, error) {
+	var dst D
+	err := mapValue(reflect.ValueOf(src), reflect.ValueOf(&dst).Elem(), reflect.TypeOf(&dst).Elem().Name())
+	return dst, err
+}
+
+// MapSlice maps every entity in the slice
+func MapSlice[S, D any](src []S) ([]D, error) {
+	dst := make([]D, 0, len(src))
+	for _, item := range src {
+		mapped, err := Map[S, D](item)
+		if err != nil {
+			return nil, err
+		}
+		dst = append(dst, mapped)
+	}
+	return dst, nil
+}
+
+// MapPage maps the contents of a page, keeping its paging metadata
+func MapPage[S, D any](src PageResponse[S]) (PageResponse[D], error) {
+	contents, err := MapSlice[S, D](src.Contents)
+	if err != nil {
+		return PageResponse[D]{}, err
+	}
+	return PageResponse[D]{
+		Contents:         contents,
+		NumberOfElements: src.NumberOfElements,
+		Pageable:         src.Pageable,
+		TotalPages:       src.TotalPages,
+		TotalElements:    src.TotalElements,
+	}, nil
+}
+
+func mapValue(src, dst reflect.Value, path string) error {
+	if !src.IsValid() {
+		return nil
+	}
+	if converter, ok := converters.Load([2]reflect.Type{src.Type(), dst.Type()}); ok {
+		dst.Set(converter.(reflect.Value).Call([]reflect.Value{src})[0])
+		return nil
+	}
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+
+	switch {
+	case src.Kind() == reflect.Ptr:
+		if src.IsNil() {
+			return nil
+		}
+		return mapValue(src.Elem(), dst, path)
+	case dst.Kind() == reflect.Ptr:
+		target := reflect.New(dst.Type().Elem())
+		if err := mapValue(src, target.Elem(), path); err != nil {
+			return err
+		}
+		dst.Set(target)
+		return nil
+	case src.Kind() == reflect.Struct && dst.Kind() == reflect.Struct:
+		return mapStruct(src, dst, path)
+	case src.Kind() == reflect.Slice && dst.Kind() == reflect.Slice:
+		if src.IsNil() {
+			return nil
+		}
+		items := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			if err := mapValue(src.Index(i), items.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(items)
+		return nil
+	case src.Kind() == dst.Kind() && src.Type().ConvertibleTo(dst.Type()):
+		// named types sharing an underlying kind, such as a Status string type
+		dst.Set(src.Convert(dst.Type()))
+		return nil
+	}
+	return fmt.Errorf("cannot map %s from %s to %s", path, src.Type(), dst.Type())
+}
+
+func mapStruct(src, dst reflect.Value, path string) error {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Tag.Get("map")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		source, found := fieldByPath(src, name)
+		if !found {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				if err := mapStruct(src, dst.Field(i), path); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := mapValue(source, dst.Field(i), path+"."+field.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldByPath resolves a field name, or a dotted path such as Author.Name
+func fieldByPath(v reflect.Value, path string) (reflect.Value, bool) {
+	for _, name := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, true
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+		field, ok := v.Type().FieldByName(name)
+		if !ok || !field.IsExported() {
+			return reflect.Value{}, false
+		}
+		v = v.FieldByIndex(field.Index)
+	}
+	return v, true
+}
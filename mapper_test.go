@@ -0,0 +1,93 @@
+package ginboot
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mapperStatus string
+
+type mapperAuthor struct {
+	Name     string
+	Password string
+}
+
+type mapperPost struct {
+	ID        int
+	Title     string
+	Status    mapperStatus
+	Author    *mapperAuthor
+	Tags      []string
+	CreatedAt time.Time
+}
+
+type mapperAuthorDTO struct {
+	Name string `json:"name"`
+}
+
+type mapperPostDTO struct {
+	ID         string           `json:"id"`
+	Title      string           `json:"title"`
+	Status     string           `json:"status"`
+	Author     mapperAuthorDTO  `json:"author"`
+	AuthorName string           `json:"authorName" map:"Author.Name"`
+	Tags       []string         `json:"tags"`
+	Internal   string           `json:"-" map:"-"`
+	CreatedAt  string           `json:"createdAt"`
+	Reviewer   *mapperAuthorDTO `json:"reviewer"`
+}
+
+func TestMap(t *testing.T) {
+	RegisterConverter(func(id int) string { return strconv.Itoa(id) })
+	RegisterConverter(func(at time.Time) string { return at.Format(time.RFC3339) })
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	post := mapperPost{
+		ID:        7,
+		Title:     "Hello",
+		Status:    "published",
+		Author:    &mapperAuthor{Name: "John", Password: "secret"},
+		Tags:      []string{"go"},
+		CreatedAt: createdAt,
+	}
+
+	t.Run("single entity", func(t *testing.T) {
+		dto, err := Map[mapperPost, mapperPostDTO](post)
+		assert.NoError(t, err)
+		assert.Equal(t, mapperPostDTO{
+			ID:         "7",
+			Title:      "Hello",
+			Status:     "published",
+			Author:     mapperAuthorDTO{Name: "John"},
+			AuthorName: "John",
+			Tags:       []string{"go"},
+			CreatedAt:  "2024-01-02T03:04:05Z",
+		}, dto)
+	})
+
+	t.Run("page", func(t *testing.T) {
+		page, err := MapPage[mapperPost, mapperPostDTO](PageResponse[mapperPost]{
+			Contents:         []mapperPost{post, {ID: 8, Title: "Nil author"}},
+			NumberOfElements: 2,
+			Pageable:         PageRequest{Page: 1, Size: 10},
+			TotalPages:       1,
+			TotalElements:    2,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, page.Contents, 2)
+		assert.Equal(t, "7", page.Contents[0].ID)
+		assert.Equal(t, "", page.Contents[1].AuthorName)
+		assert.Equal(t, PageRequest{Page: 1, Size: 10}, page.Pageable)
+		assert.Equal(t, 2, page.TotalElements)
+	})
+
+	t.Run("unmappable field", func(t *testing.T) {
+		type source struct{ Count int }
+		type target struct{ Count bool }
+		_, err := Map[source, target](source{Count: 1})
+		assert.EqualError(t, err, "cannot map target.Count from int to bool")
+	})
+}
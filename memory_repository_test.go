@@ -0,0 +1,248 @@
+package ginboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// memoryRepository is an in-memory GenericRepository used by unit tests of
+// services that are built on top of repositories
+type memoryRepository[T any] struct {
+	mu    sync.Mutex
+	items map[string]T
+	order []string
+}
+
+func newMemoryRepository[T any]() *memoryRepository[T] {
+	return &memoryRepository[T]{items: make(map[string]T)}
+}
+
+var errMemoryNotFound = fmt.Errorf("document %w", ErrNotFound)
+
+func (r *memoryRepository[T]) FindById(ctx context.Context, id string, _ ...QueryOption) (T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	doc, ok := r.items[id]
+	if !ok {
+		var zero T
+		return zero, errMemoryNotFound
+	}
+	return doc, nil
+}
+
+func (r *memoryRepository[T]) FindAllById(ctx context.Context, ids []string, _ ...QueryOption) ([]T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var results []T
+	for _, id := range ids {
+		if doc, ok := r.items[id]; ok {
+			results = append(results, doc)
+		}
+	}
+	return results, nil
+}
+
+func (r *memoryRepository[T]) Save(ctx context.Context, doc T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := getDocumentID(doc)
+	if _, ok := r.items[id]; ok {
+		return &DuplicateKeyError{Field: "_id", Err: errors.New(id)}
+	}
+	r.put(id, doc)
+	return nil
+}
+
+func (r *memoryRepository[T]) SaveOrUpdate(ctx context.Context, doc T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.put(getDocumentID(doc), doc)
+	return nil
+}
+
+func (r *memoryRepository[T]) SaveAll(ctx context.Context, docs []T) error {
+	for _, doc := range docs {
+		if err := r.SaveOrUpdate(ctx, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepository[T]) Update(ctx context.Context, doc T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := getDocumentID(doc)
+	stored, ok := r.items[id]
+	if !ok {
+		return errMemoryNotFound
+	}
+	// versioned documents are updated like MongoRepository.Update
+	if version, versioned := documentVersion(doc); versioned {
+		if current, _ := documentVersion(stored); current.current != version.current {
+			return ErrVersionConflict
+		}
+		version.commit(doc)
+		if next, ok := version.next.(T); ok {
+			doc = next
+		}
+	}
+	r.items[id] = doc
+	return nil
+}
+
+func (r *memoryRepository[T]) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.remove(id)
+	return nil
+}
+
+func (r *memoryRepository[T]) DeleteVersion(ctx context.Context, doc T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := getDocumentID(doc)
+	stored, ok := r.items[id]
+	if !ok {
+		return errMemoryNotFound
+	}
+	if version, versioned := documentVersion(doc); versioned {
+		if current, _ := documentVersion(stored); current.current != version.current {
+			return ErrVersionConflict
+		}
+	}
+	r.remove(id)
+	return nil
+}
+
+func (r *memoryRepository[T]) remove(id string) {
+	delete(r.items, id)
+	for i, existing := range r.order {
+		if existing == id {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// WithTransaction runs fn without isolation or rollback
+func (r *memoryRepository[T]) WithTransaction(ctx context.Context, fn func(tx Tx) error) error {
+	return fn(ctx)
+}
+
+func (r *memoryRepository[T]) FindOneBy(ctx context.Context, field string, value interface{}, _ ...QueryOption) (T, error) {
+	return r.FindOneByFilters(ctx, map[string]interface{}{field: value})
+}
+
+func (r *memoryRepository[T]) FindOneByFilters(ctx context.Context, filters map[string]interface{}, _ ...QueryOption) (T, error) {
+	results, _ := r.FindByFilters(ctx, filters)
+	if len(results) == 0 {
+		var zero T
+		return zero, errMemoryNotFound
+	}
+	return results[0], nil
+}
+
+func (r *memoryRepository[T]) FindBy(ctx context.Context, field string, value interface{}, _ ...QueryOption) ([]T, error) {
+	return r.FindByFilters(ctx, map[string]interface{}{field: value})
+}
+
+func (r *memoryRepository[T]) FindByFilters(ctx context.Context, filters map[string]interface{}, _ ...QueryOption) ([]T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var results []T
+	for _, id := range r.order {
+		doc := r.items[id]
+		if matchesFilters(doc, filters) {
+			results = append(results, doc)
+		}
+	}
+	return results, nil
+}
+
+func (r *memoryRepository[T]) FindAll(ctx context.Context, _ ...QueryOption) ([]T, error) {
+	return r.FindByFilters(ctx, nil)
+}
+
+func (r *memoryRepository[T]) FindAllPaginated(ctx context.Context, pageRequest PageRequest, _ ...QueryOption) (PageResponse[T], error) {
+	return r.FindByPaginated(ctx, pageRequest, nil)
+}
+
+func (r *memoryRepository[T]) FindByPaginated(ctx context.Context, pageRequest PageRequest, filters map[string]interface{}, _ ...QueryOption) (PageResponse[T], error) {
+	all, _ := r.FindByFilters(ctx, filters)
+	start := (pageRequest.Page - 1) * pageRequest.Size
+	if start > len(all) {
+		start = len(all)
+	}
+	end := start + pageRequest.Size
+	if end > len(all) {
+		end = len(all)
+	}
+	contents := all[start:end]
+	return PageResponse[T]{
+		Contents:         contents,
+		NumberOfElements: len(contents),
+		Pageable:         pageRequest,
+		TotalElements:    len(all),
+		TotalPages:       (len(all) + pageRequest.Size - 1) / pageRequest.Size,
+	}, nil
+}
+
+var errMemoryQuery = errors.New("criteria queries are not supported by the memory repository")
+
+func (r *memoryRepository[T]) FindByQuery(ctx context.Context, query *Query, _ ...QueryOption) ([]T, error) {
+	return nil, errMemoryQuery
+}
+
+func (r *memoryRepository[T]) FindByQueryPaginated(ctx context.Context, pageRequest PageRequest, query *Query, _ ...QueryOption) (PageResponse[T], error) {
+	return PageResponse[T]{}, errMemoryQuery
+}
+
+func (r *memoryRepository[T]) CountBy(ctx context.Context, field string, value interface{}) (int64, error) {
+	return r.CountByFilters(ctx, map[string]interface{}{field: value})
+}
+
+func (r *memoryRepository[T]) CountByFilters(ctx context.Context, filters map[string]interface{}) (int64, error) {
+	results, err := r.FindByFilters(ctx, filters)
+	return int64(len(results)), err
+}
+
+func (r *memoryRepository[T]) CountByQuery(ctx context.Context, query *Query) (int64, error) {
+	return 0, errMemoryQuery
+}
+
+func (r *memoryRepository[T]) ExistsBy(ctx context.Context, field string, value interface{}) (bool, error) {
+	count, err := r.CountBy(ctx, field, value)
+	return count > 0, err
+}
+
+func (r *memoryRepository[T]) ExistsByFilters(ctx context.Context, filters map[string]interface{}) (bool, error) {
+	count, err := r.CountByFilters(ctx, filters)
+	return count > 0, err
+}
+
+func (r *memoryRepository[T]) put(id string, doc T) {
+	if _, ok := r.items[id]; !ok {
+		r.order = append(r.order, id)
+	}
+	r.items[id] = doc
+}
+
+// matchesFilters compares filter values with the fields whose bson name (or Go
+// name) matches the filter key
+func matchesFilters(doc interface{}, filters map[string]interface{}) bool {
+	val := reflect.ValueOf(doc)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	for key, expected := range filters {
+		field, ok := fieldByBSONName(val, key)
+		if !ok || !reflect.DeepEqual(field.Interface(), expected) {
+			return false
+		}
+	}
+	return true
+}
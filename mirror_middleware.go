@@ -0,0 +1,141 @@
+package ginboot
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MirroredHeader marks requests sent to the shadow target, so it can avoid side
+// effects such as sending emails
+const MirroredHeader = "X-Mirrored-Request"
+
+type MirrorConfig struct {
+	// Upstream is the base URL the requests are mirrored to, e.g.
+	// http://shadow.internal:8080. Ignored when Handler is set.
+	Upstream string
+	// Handler receives mirrored requests in process instead of Upstream, e.g.
+	// the engine of a rewritten version of the API
+	Handler http.Handler
+	// Percentage of requests mirrored, from 0 to 100
+	Percentage float64
+	// Timeout bounds each mirrored request
+	Timeout time.Duration
+	// MaxBodyBytes skips mirroring requests with larger bodies, which would
+	// otherwise have to be buffered in memory
+	MaxBodyBytes int64
+	Client       *http.Client
+}
+
+// DefaultMirrorConfig mirrors 10% of requests with a five second timeout and
+// bodies up to 1MB; set Upstream or Handler
+func DefaultMirrorConfig() MirrorConfig {
+	return MirrorConfig{
+		Percentage:   10,
+		Timeout:      5 * time.Second,
+		MaxBodyBytes: 1 << 20,
+		Client:       http.DefaultClient,
+	}
+}
+
+// MirrorMiddleware asynchronously copies a percentage of requests to a shadow
+// upstream or handler and discards the responses, so a rewrite can be
+// validated against production traffic without affecting clients
+func MirrorMiddleware(config MirrorConfig) gin.HandlerFunc {
+	defaults := DefaultMirrorConfig()
+	if config.Client == nil {
+		config.Client = defaults.Client
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = defaults.MaxBodyBytes
+	}
+	return func(c *gin.Context) {
+		if c.GetHeader(MirroredHeader) != "" || rand.Float64()*100 >= config.Percentage {
+			c.Next()
+			return
+		}
+
+		body, ok := bufferBody(c.Request, config.MaxBodyBytes)
+		if ok {
+			mirror := cloneRequest(c.Request, body)
+			go sendMirror(config, mirror, body)
+		}
+		c.Next()
+	}
+}
+
+// bufferBody reads the request body so it can be sent twice, restoring it for
+// the handler. It reports false when the body exceeds maxBytes.
+func bufferBody(req *http.Request, maxBytes int64) ([]byte, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxBytes+1))
+	if err != nil || int64(len(body)) > maxBytes {
+		req.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), req.Body))
+		return nil, false
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, true
+}
+
+// cloneRequest copies what the mirror needs before the handler runs, as the
+// original request must not be used once the handler returns
+func cloneRequest(req *http.Request, body []byte) *http.Request {
+	mirror := req.Clone(context.Background())
+	mirror.Header.Set(MirroredHeader, "true")
+	mirror.Body = io.NopCloser(bytes.NewReader(body))
+	mirror.ContentLength = int64(len(body))
+	mirror.RequestURI = ""
+	return mirror
+}
+
+func sendMirror(config MirrorConfig, mirror *http.Request, body []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	defer cancel()
+	mirror = mirror.WithContext(ctx)
+
+	if config.Handler != nil {
+		config.Handler.ServeHTTP(discardResponseWriter{header: make(http.Header)}, mirror)
+		return
+	}
+
+	target := strings.TrimSuffix(config.Upstream, "/") + mirror.URL.RequestURI()
+	req, err := http.NewRequestWithContext(ctx, mirror.Method, target, bytes.NewReader(body))
+	if err != nil {
+		getLogger().Warn("ginboot: mirroring failed", "method", mirror.Method, "path", mirror.URL.Path, "error", err)
+		return
+	}
+	req.Header = mirror.Header
+	resp, err := config.Client.Do(req)
+	if err != nil {
+		getLogger().Warn("ginboot: mirroring failed", "method", mirror.Method, "path", mirror.URL.Path, "error", err)
+		return
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// discardResponseWriter drops everything an in-process shadow handler writes
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w discardResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w discardResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w discardResponseWriter) WriteHeader(int) {}
@@ -0,0 +1,92 @@
+package ginboot
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mirroredRequest struct {
+	method, uri, body, header string
+}
+
+func TestMirrorMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mirrored := make(chan mirroredRequest, 10)
+	record := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mirrored <- mirroredRequest{r.Method, r.URL.RequestURI(), string(body), r.Header.Get(MirroredHeader)}
+		w.WriteHeader(http.StatusTeapot)
+	})
+	upstream := httptest.NewServer(record)
+	defer upstream.Close()
+
+	newEngine := func(config MirrorConfig) *gin.Engine {
+		engine := gin.New()
+		engine.Use(MirrorMiddleware(config))
+		engine.POST("/posts", func(c *gin.Context) {
+			body, _ := io.ReadAll(c.Request.Body)
+			c.String(http.StatusCreated, string(body))
+		})
+		return engine
+	}
+	send := func(engine *gin.Engine, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/posts?draft=true", strings.NewReader(body)))
+		return w
+	}
+	receive := func() mirroredRequest {
+		select {
+		case request := <-mirrored:
+			return request
+		case <-time.After(time.Second):
+			t.Fatal("request was not mirrored")
+			return mirroredRequest{}
+		}
+	}
+
+	t.Run("upstream", func(t *testing.T) {
+		config := DefaultMirrorConfig()
+		config.Upstream = upstream.URL
+		config.Percentage = 100
+
+		w := send(newEngine(config), `{"title":"hello"}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, `{"title":"hello"}`, w.Body.String())
+		assert.Equal(t, mirroredRequest{"POST", "/posts?draft=true", `{"title":"hello"}`, "true"}, receive())
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		config := DefaultMirrorConfig()
+		config.Handler = record
+		config.Percentage = 100
+
+		send(newEngine(config), "in process")
+		assert.Equal(t, "in process", receive().body)
+	})
+
+	t.Run("skips", func(t *testing.T) {
+		config := DefaultMirrorConfig()
+		config.Handler = record
+		config.Percentage = 0
+		send(newEngine(config), "not sampled")
+
+		config.Percentage = 100
+		config.MaxBodyBytes = 4
+		w := send(newEngine(config), "too large")
+		assert.Equal(t, "too large", w.Body.String())
+
+		select {
+		case request := <-mirrored:
+			t.Fatalf("unexpected mirrored request %v", request)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -16,6 +17,7 @@ type MongoConfig struct {
 	Password string
 	Database string
 	Options  map[string]string
+	Monitor  *event.CommandMonitor
 }
 
 func NewMongoConfig() *MongoConfig {
@@ -48,6 +50,13 @@ func (c *MongoConfig) WithOption(key, value string) *MongoConfig {
 	return c
 }
 
+// WithCommandMonitor sets a command monitor on the client, e.g.
+// NewMongoCommandMonitor(DefaultCommandLoggingConfig())
+func (c *MongoConfig) WithCommandMonitor(monitor *event.CommandMonitor) *MongoConfig {
+	c.Monitor = monitor
+	return c
+}
+
 func (c *MongoConfig) BuildURI() string {
 	var auth string
 	if c.Username != "" && c.Password != "" {
@@ -80,6 +89,9 @@ func (c *MongoConfig) Connect() (*mongo.Database, error) {
 		ApplyURI(uri).
 		SetServerSelectionTimeout(10 * time.Second).
 		SetConnectTimeout(10 * time.Second)
+	if c.Monitor != nil {
+		clientOptions.SetMonitor(c.Monitor)
+	}
 
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
@@ -2,8 +2,30 @@ package ginboot
 
 import (
 	"reflect"
+	"strings"
+	"time"
 )
 
+// hasGinbootTag reports whether the comma-separated ginboot tag of field
+// contains option, e.g. ginboot:"created_at,index" has both
+func hasGinbootTag(field reflect.StructField, option string) bool {
+	for _, tag := range strings.Split(field.Tag.Get("ginboot"), ",") {
+		if tag == option {
+			return true
+		}
+	}
+	return false
+}
+
+// bsonFieldName returns the bson name of field, which defaults to its
+// lowercased Go name
+func bsonFieldName(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("bson"), ",")[0]; name != "" {
+		return name
+	}
+	return strings.ToLower(field.Name)
+}
+
 // getDocumentID returns the ID value of a document using reflection
 // It looks for a field tagged with `ginboot:"_id"` or falls back to a field named "ID"
 func getDocumentID(doc interface{}) string {
@@ -15,7 +37,7 @@ func getDocumentID(doc interface{}) string {
 	typ := val.Type()
 	for i := 0; i < typ.NumField(); i++ {
 		field := typ.Field(i)
-		if tag := field.Tag.Get("ginboot"); tag == "_id" {
+		if hasGinbootTag(field, "_id") {
 			return val.Field(i).String()
 		}
 	}
@@ -27,3 +49,170 @@ func getDocumentID(doc interface{}) string {
 
 	return ""
 }
+
+// setDocumentID sets the ID field found like getDocumentID's, reporting false
+// when doc is not a pointer to a struct with a string ID field
+func setDocumentID(doc interface{}, id string) bool {
+	val := reflect.ValueOf(doc)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	val = val.Elem()
+
+	idField := val.FieldByName("Id")
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		if hasGinbootTag(typ.Field(i), "_id") {
+			idField = val.Field(i)
+			break
+		}
+	}
+	if !idField.IsValid() || idField.Kind() != reflect.String || !idField.CanSet() {
+		return false
+	}
+	idField.SetString(id)
+	return true
+}
+
+// versionedDocument describes a document with a field tagged ginboot:"version"
+// used for optimistic locking
+type versionedDocument struct {
+	// key is the bson name of the version field
+	key string
+	// current is the version the stored document is expected to have
+	current int64
+	// next is a copy of the document carrying the incremented version
+	next interface{}
+	// field is the index of the version field
+	field int
+}
+
+// documentVersion returns the version information of doc, or false when doc
+// has no integer field tagged ginboot:"version"
+func documentVersion(doc interface{}) (versionedDocument, bool) {
+	val := reflect.ValueOf(doc)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return versionedDocument{}, false
+	}
+
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !hasGinbootTag(field, "version") {
+			continue
+		}
+		switch field.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		default:
+			return versionedDocument{}, false
+		}
+
+		key := bsonFieldName(field)
+		next := reflect.New(typ).Elem()
+		next.Set(val)
+		next.Field(i).SetInt(val.Field(i).Int() + 1)
+		return versionedDocument{key: key, current: val.Field(i).Int(), next: next.Interface(), field: i}, true
+	}
+	return versionedDocument{}, false
+}
+
+// commit copies the incremented version back into doc when it is a pointer,
+// so callers can keep updating the same value
+func (v versionedDocument) commit(doc interface{}) {
+	val := reflect.ValueOf(doc)
+	if val.Kind() == reflect.Ptr && !val.IsNil() {
+		val.Elem().Field(v.field).SetInt(v.current + 1)
+	}
+}
+
+// fieldByBSONName returns the field whose bson name, or Go name, is name
+func fieldByBSONName(val reflect.Value, name string) (reflect.Value, bool) {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := strings.Split(field.Tag.Get("bson"), ",")[0]
+		if tag == name || field.Name == name {
+			return val.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// documentTimestamps describes the time.Time fields tagged
+// ginboot:"created_at" and ginboot:"updated_at", whose indexes are -1 when
+// absent
+type documentTimestamps struct {
+	created, updated int
+	// createdKey is the bson name of the created_at field
+	createdKey string
+}
+
+// timestampsOf returns the timestamp fields of doc, or false when it has none
+func timestampsOf(doc interface{}) (documentTimestamps, bool) {
+	typ := reflect.TypeOf(doc)
+	if typ == nil {
+		return documentTimestamps{}, false
+	}
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return documentTimestamps{}, false
+	}
+
+	timestamps := documentTimestamps{created: -1, updated: -1}
+	timeType := reflect.TypeOf(time.Time{})
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Type != timeType {
+			continue
+		}
+		switch {
+		case hasGinbootTag(field, "created_at"):
+			timestamps.created = i
+			timestamps.createdKey = bsonFieldName(field)
+		case hasGinbootTag(field, "updated_at"):
+			timestamps.updated = i
+		}
+	}
+	return timestamps, timestamps.created >= 0 || timestamps.updated >= 0
+}
+
+// createdAt returns the creation time set on doc, zero when unset
+func (t documentTimestamps) createdAt(doc interface{}) time.Time {
+	if t.created < 0 {
+		return time.Time{}
+	}
+	return reflect.Indirect(reflect.ValueOf(doc)).Field(t.created).Interface().(time.Time)
+}
+
+// stampTimestamps sets updated_at to now and an unset created_at to
+// createdAt. Pointers are stamped in place, so callers see the times written;
+// other documents are stamped on a copy.
+func stampTimestamps[T any](doc T, timestamps documentTimestamps, createdAt, now time.Time) T {
+	val := reflect.ValueOf(doc)
+	pointer := val.Kind() == reflect.Ptr
+	if pointer {
+		if val.IsNil() {
+			return doc
+		}
+		val = val.Elem()
+	} else {
+		stamped := reflect.New(val.Type()).Elem()
+		stamped.Set(val)
+		val = stamped
+	}
+	if timestamps.created >= 0 && val.Field(timestamps.created).Interface().(time.Time).IsZero() {
+		val.Field(timestamps.created).Set(reflect.ValueOf(createdAt))
+	}
+	if timestamps.updated >= 0 {
+		val.Field(timestamps.updated).Set(reflect.ValueOf(now))
+	}
+	if pointer {
+		return doc
+	}
+	return val.Interface().(T)
+}
@@ -0,0 +1,59 @@
+package ginboot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentVersion(t *testing.T) {
+	type article struct {
+		ID       string `ginboot:"_id"`
+		Revision int    `bson:"rev,omitempty" ginboot:"version"`
+	}
+
+	doc := article{ID: "1", Revision: 3}
+	version, ok := documentVersion(doc)
+	assert.True(t, ok)
+	assert.Equal(t, "rev", version.key)
+	assert.Equal(t, int64(3), version.current)
+	assert.Equal(t, article{ID: "1", Revision: 4}, version.next)
+
+	version.commit(doc)
+	assert.Equal(t, 3, doc.Revision)
+	version.commit(&doc)
+	assert.Equal(t, 4, doc.Revision)
+
+	_, ok = documentVersion(TestDocument{ID: "1"})
+	assert.False(t, ok)
+}
+
+func TestStampTimestamps(t *testing.T) {
+	type stampedDocument struct {
+		ID        string    `ginboot:"_id"`
+		CreatedAt time.Time `bson:"created" ginboot:"created_at"`
+		UpdatedAt time.Time `ginboot:"updated_at"`
+	}
+
+	timestamps, ok := timestampsOf(stampedDocument{})
+	assert.True(t, ok)
+	assert.Equal(t, "created", timestamps.createdKey)
+	_, ok = timestampsOf(TestDocument{})
+	assert.False(t, ok)
+
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	doc := stampedDocument{ID: "1"}
+	stamped := stampTimestamps(doc, timestamps, created, now)
+	assert.Equal(t, stampedDocument{ID: "1", CreatedAt: created, UpdatedAt: now}, stamped)
+	assert.True(t, doc.CreatedAt.IsZero())
+	assert.Equal(t, created, timestamps.createdAt(stamped))
+
+	// a set creation time is kept, and pointers are stamped in place
+	pointer := &stampedDocument{ID: "2", CreatedAt: created}
+	stampTimestamps(pointer, timestamps, now, now)
+	assert.Equal(t, created, pointer.CreatedAt)
+	assert.Equal(t, now, pointer.UpdatedAt)
+}
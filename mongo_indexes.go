@@ -0,0 +1,105 @@
+package ginboot
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureIndexes creates the indexes declared by the ginboot tags of T:
+//
+//	type User struct {
+//		ID       string    `bson:"_id" ginboot:"_id"`
+//		Email    string    `bson:"email" ginboot:"unique"`
+//		TenantID string    `bson:"tenantId" ginboot:"index=tenant_created"`
+//		Created  time.Time `bson:"created" ginboot:"created_at,index=tenant_created,desc"`
+//		Bio      string    `bson:"bio" ginboot:"text"`
+//	}
+//
+// index and unique create a single field index. index=name and unique=name
+// add the field to the compound index name, in the order of the fields. desc
+// makes the field's key descending, and text fields share the collection's
+// text index. Existing indexes are kept, so it is safe to run on every start,
+// e.g. server.OnStart(users.EnsureIndexes).
+func (r *MongoRepository[T]) EnsureIndexes(ctx context.Context) error {
+	models, err := mongoIndexModels(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil || len(models) == 0 {
+		return err
+	}
+	if _, err := r.collection.Indexes().CreateMany(ctx, models); err != nil {
+		return fmt.Errorf("ginboot: ensuring indexes of %s: %w", r.collection.Name(), NormalizeError(err))
+	}
+	return nil
+}
+
+// compoundIndex collects the keys of an index=name or unique=name group
+type compoundIndex struct {
+	name   string
+	keys   bson.D
+	unique bool
+}
+
+// mongoIndexModels returns the indexes declared by the ginboot tags of typ
+func mongoIndexModels(typ reflect.Type) ([]mongo.IndexModel, error) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	var models []mongo.IndexModel
+	var text bson.D
+	var compounds []*compoundIndex
+	byName := map[string]*compoundIndex{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		key := bsonFieldName(field)
+		order := 1
+		if hasGinbootTag(field, "desc") {
+			order = -1
+		}
+		for _, tag := range strings.Split(field.Tag.Get("ginboot"), ",") {
+			kind, group, grouped := strings.Cut(tag, "=")
+			switch {
+			case kind == "text" && !grouped:
+				text = append(text, bson.E{Key: key, Value: "text"})
+			case (kind == "index" || kind == "unique") && !grouped:
+				model := mongo.IndexModel{Keys: bson.D{{Key: key, Value: order}}}
+				if kind == "unique" {
+					model.Options = options.Index().SetUnique(true)
+				}
+				models = append(models, model)
+			case (kind == "index" || kind == "unique") && grouped:
+				if group == "" {
+					return nil, fmt.Errorf("ginboot: %s.%s: %s needs an index name", typ.Name(), field.Name, tag)
+				}
+				compound, ok := byName[group]
+				if !ok {
+					compound = &compoundIndex{name: group}
+					byName[group] = compound
+					compounds = append(compounds, compound)
+				}
+				compound.keys = append(compound.keys, bson.E{Key: key, Value: order})
+				compound.unique = compound.unique || kind == "unique"
+			}
+		}
+	}
+
+	for _, compound := range compounds {
+		indexOptions := options.Index().SetName(compound.name)
+		if compound.unique {
+			indexOptions.SetUnique(true)
+		}
+		models = append(models, mongo.IndexModel{Keys: compound.keys, Options: indexOptions})
+	}
+	if len(text) > 0 {
+		models = append(models, mongo.IndexModel{Keys: text})
+	}
+	return models, nil
+}
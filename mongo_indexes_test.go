@@ -0,0 +1,50 @@
+package ginboot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type indexedDocument struct {
+	ID       string    `bson:"_id" ginboot:"_id"`
+	Email    string    `bson:"email" ginboot:"unique"`
+	Name     string    `bson:"name" ginboot:"index,desc"`
+	TenantID string    `bson:"tenantId" ginboot:"unique=tenant_slug"`
+	Slug     string    `ginboot:"index=tenant_slug"`
+	Title    string    `bson:"title" ginboot:"text"`
+	Body     string    `bson:"body" ginboot:"text"`
+	Created  time.Time `bson:"created" ginboot:"created_at,index"`
+	Notes    string    `bson:"notes"`
+}
+
+func TestMongoIndexModels(t *testing.T) {
+	models, err := mongoIndexModels(reflect.TypeOf(&indexedDocument{}))
+	assert.NoError(t, err)
+	assert.Len(t, models, 5)
+
+	assert.Equal(t, bson.D{{Key: "email", Value: 1}}, models[0].Keys)
+	assert.True(t, *models[0].Options.Unique)
+	assert.Equal(t, bson.D{{Key: "name", Value: -1}}, models[1].Keys)
+	assert.Nil(t, models[1].Options)
+	assert.Equal(t, bson.D{{Key: "created", Value: 1}}, models[2].Keys)
+
+	assert.Equal(t, bson.D{{Key: "tenantId", Value: 1}, {Key: "slug", Value: 1}}, models[3].Keys)
+	assert.Equal(t, "tenant_slug", *models[3].Options.Name)
+	assert.True(t, *models[3].Options.Unique)
+
+	assert.Equal(t, bson.D{{Key: "title", Value: "text"}, {Key: "body", Value: "text"}}, models[4].Keys)
+
+	// tags combine with the other ginboot tags
+	timestamps, ok := timestampsOf(indexedDocument{})
+	assert.True(t, ok)
+	assert.Equal(t, "created", timestamps.createdKey)
+
+	_, err = mongoIndexModels(reflect.TypeOf(struct {
+		Name string `ginboot:"index="`
+	}{}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,88 @@
+package ginboot
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoIterator decodes the documents of a cursor one at a time. It is a
+// ValueIterator, so Context.StreamJSONArray can stream it:
+//
+//	for orders.Next(ctx) {
+//		order := orders.Current()
+//	}
+//	if err := orders.Err(); err != nil { ... }
+type MongoIterator[T any] struct {
+	cursor  *mongo.Cursor
+	current T
+	err     error
+}
+
+// Iterate returns the documents matching the query as an iterator, for
+// results too large for FindByQuery. Unlike the Find methods it sets no
+// timeout of its own; close the iterator when done.
+func (r *MongoRepository[T]) Iterate(ctx context.Context, query *Query, opts ...QueryOption) (*MongoIterator[T], error) {
+	filter, err := mongoQueryFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	queryOptions := NewQueryOptions(opts...)
+	collection, err := r.collectionFor(queryOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	findOpts := options.Find()
+	if query != nil {
+		if len(query.Sorts) > 0 {
+			findOpts.SetSort(mongoSort(query.Sorts))
+		}
+		if query.MaxResults > 0 {
+			findOpts.SetLimit(int64(query.MaxResults))
+		}
+	}
+
+	cursor, err := collection.Find(ctx, filter, append(queryOptions.FindOptions, findOpts)...)
+	if err != nil {
+		return nil, NormalizeError(err)
+	}
+	return &MongoIterator[T]{cursor: cursor}, nil
+}
+
+// Next decodes the next document, reporting false at the end of the results
+// or on an error
+func (it *MongoIterator[T]) Next(ctx context.Context) bool {
+	if it.err != nil || !it.cursor.Next(ctx) {
+		return false
+	}
+	var doc T
+	if err := it.cursor.Decode(&doc); err != nil {
+		it.err = err
+		return false
+	}
+	it.current = doc
+	return true
+}
+
+// Current returns the document decoded by the last Next
+func (it *MongoIterator[T]) Current() T {
+	return it.current
+}
+
+// Value returns Current, for ValueIterator
+func (it *MongoIterator[T]) Value() interface{} {
+	return it.current
+}
+
+func (it *MongoIterator[T]) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	return NormalizeError(it.cursor.Err())
+}
+
+func (it *MongoIterator[T]) Close(ctx context.Context) error {
+	return it.cursor.Close(ctx)
+}
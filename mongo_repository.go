@@ -2,12 +2,17 @@ package ginboot
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math"
+	"regexp"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 type MongoRepository[T interface{}] struct {
@@ -20,23 +25,32 @@ func NewMongoRepository[T interface{}](db *mongo.Database, collectionName string
 	}
 }
 
-func (r *MongoRepository[T]) FindById(id string) (T, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *MongoRepository[T]) FindById(ctx context.Context, id string, opts ...QueryOption) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	var result T
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&result)
+	collection, err := r.collectionFor(NewQueryOptions(opts...))
 	if err != nil {
 		return result, err
 	}
+	err = collection.FindOne(ctx, bson.M{"_id": id}).Decode(&result)
+	if err != nil {
+		return result, NormalizeError(err)
+	}
 	return result, nil
 }
 
-func (r *MongoRepository[T]) FindAllById(ids []string) ([]T, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *MongoRepository[T]) FindAllById(ctx context.Context, ids []string, opts ...QueryOption) ([]T, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
+	queryOptions := NewQueryOptions(opts...)
+	collection, err := r.collectionFor(queryOptions)
+	if err != nil {
+		return nil, err
+	}
 	filter := bson.M{"_id": bson.M{"$in": ids}}
-	cursor, err := r.collection.Find(ctx, filter)
+	cursor, err := collection.Find(ctx, filter, queryOptions.FindOptions...)
 	if err != nil {
 		return nil, err
 	}
@@ -48,78 +62,259 @@ func (r *MongoRepository[T]) FindAllById(ids []string) ([]T, error) {
 	return results, nil
 }
 
-func (r *MongoRepository[T]) Save(doc T) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *MongoRepository[T]) Save(ctx context.Context, doc T) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-	_, err := r.collection.InsertOne(ctx, doc)
-	return err
+	doc, err := r.stamp(ctx, doc, false)
+	if err != nil {
+		return err
+	}
+	_, err = r.collection.InsertOne(ctx, doc)
+	return NormalizeError(err)
+}
+
+// stamp sets the fields of doc tagged ginboot:"created_at", when unset, and
+// ginboot:"updated_at" to the current time. A replacement without a creation
+// time keeps the stored one.
+func (r *MongoRepository[T]) stamp(ctx context.Context, doc T, replacing bool) (T, error) {
+	stamped, err := r.stampAll(ctx, []T{doc}, replacing)
+	if err != nil {
+		return doc, err
+	}
+	return stamped[0], nil
+}
+
+// stampAll stamps the timestamps of docs. When replacing, documents without a
+// created_at keep the stored one, read for the whole batch in one query.
+func (r *MongoRepository[T]) stampAll(ctx context.Context, docs []T, replacing bool) ([]T, error) {
+	if len(docs) == 0 {
+		return docs, nil
+	}
+	timestamps, ok := timestampsOf(docs[0])
+	if !ok {
+		return docs, nil
+	}
+	// MongoDB stores milliseconds, so callers see the times as read back
+	now := time.Now().Truncate(time.Millisecond)
+	var stored map[string]time.Time
+	if replacing && timestamps.created >= 0 {
+		var ids []string
+		for _, doc := range docs {
+			if timestamps.createdAt(doc).IsZero() {
+				ids = append(ids, getDocumentID(doc))
+			}
+		}
+		if len(ids) > 0 {
+			var err error
+			if stored, err = r.storedCreatedAt(ctx, timestamps.createdKey, ids); err != nil {
+				return nil, err
+			}
+		}
+	}
+	stamped := make([]T, len(docs))
+	for i, doc := range docs {
+		createdAt, ok := stored[getDocumentID(doc)]
+		if !ok {
+			createdAt = now
+		}
+		stamped[i] = stampTimestamps(doc, timestamps, createdAt, now)
+	}
+	return stamped, nil
 }
 
-func (r *MongoRepository[T]) SaveOrUpdate(doc T) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// storedCreatedAt returns the created_at, stored under key, of the stored
+// documents among ids
+func (r *MongoRepository[T]) storedCreatedAt(ctx context.Context, key string, ids []string) (map[string]time.Time, error) {
+	projection := options.Find().SetProjection(bson.M{key: 1})
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, projection)
+	if err != nil {
+		return nil, NormalizeError(err)
+	}
+	defer cursor.Close(ctx)
+	stored := make(map[string]time.Time, len(ids))
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		id, _ := doc["_id"].(string)
+		if storedAt, ok := doc[key].(primitive.DateTime); ok {
+			stored[id] = storedAt.Time()
+		}
+	}
+	return stored, NormalizeError(cursor.Err())
+}
+
+// SaveOrUpdate inserts or replaces the document. Documents with a
+// ginboot:"version" field are only replaced when the stored version matches,
+// and fail with ErrVersionConflict otherwise.
+func (r *MongoRepository[T]) SaveOrUpdate(ctx context.Context, doc T) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": getDocumentID(doc)}, doc, options.Replace().SetUpsert(true))
-	return err
+
+	doc, err := r.stamp(ctx, doc, true)
+	if err != nil {
+		return err
+	}
+	id := getDocumentID(doc)
+	version, versioned := documentVersion(doc)
+	if !versioned {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": id}, doc, options.Replace().SetUpsert(true))
+		return NormalizeError(err)
+	}
+
+	// a stored document with another version makes the upsert insert a second
+	// document with the same _id, which fails with a duplicate key error
+	filter := bson.M{"_id": id, version.key: version.current}
+	_, err = r.collection.ReplaceOne(ctx, filter, version.next, options.Replace().SetUpsert(true))
+	if err != nil {
+		return versionConflict(NormalizeError(err))
+	}
+	version.commit(doc)
+	return nil
 }
 
-func (r *MongoRepository[T]) SaveAll(docs []T) error {
+func (r *MongoRepository[T]) SaveAll(ctx context.Context, docs []T) error {
 	if len(docs) == 0 {
 		return nil
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
+	stamped, err := r.stampAll(ctx, docs, true)
+	if err != nil {
+		return err
+	}
 	var operations []mongo.WriteModel
-	for _, doc := range docs {
-		operation := mongo.NewReplaceOneModel().SetFilter(bson.M{"_id": getDocumentID(doc)}).SetReplacement(doc).SetUpsert(true)
+	for _, doc := range stamped {
+		filter := bson.M{"_id": getDocumentID(doc)}
+		var replacement interface{} = doc
+		if version, versioned := documentVersion(doc); versioned {
+			filter[version.key] = version.current
+			replacement = version.next
+		}
+		operation := mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(replacement).SetUpsert(true)
 		operations = append(operations, operation)
 	}
-	_, err := r.collection.BulkWrite(ctx, operations)
-	return err
+	if _, err := r.collection.BulkWrite(ctx, operations); err != nil {
+		return versionConflict(NormalizeError(err))
+	}
+	for _, doc := range docs {
+		if version, versioned := documentVersion(doc); versioned {
+			version.commit(doc)
+		}
+	}
+	return nil
 }
 
-func (r *MongoRepository[T]) Update(doc T) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// Update replaces an existing document. Documents with a ginboot:"version"
+// field fail with ErrVersionConflict when the stored version differs and with
+// ErrNotFound when there is no stored document; on success the stored version
+// is incremented, and so is the caller's when doc is a pointer.
+func (r *MongoRepository[T]) Update(ctx context.Context, doc T) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": getDocumentID(doc)}, doc)
-	return err
+
+	doc, err := r.stamp(ctx, doc, true)
+	if err != nil {
+		return err
+	}
+	id := getDocumentID(doc)
+	version, versioned := documentVersion(doc)
+	if !versioned {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": id}, doc)
+		return NormalizeError(err)
+	}
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": id, version.key: version.current}, version.next)
+	if err != nil {
+		return NormalizeError(err)
+	}
+	if result.MatchedCount == 0 {
+		return r.versionConflict(ctx, id)
+	}
+	version.commit(doc)
+	return nil
 }
 
-func (r *MongoRepository[T]) Delete(id string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// versionConflict tells why a write conditioned on a version matched nothing
+func (r *MongoRepository[T]) versionConflict(ctx context.Context, id string) error {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return NormalizeError(err)
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return ErrVersionConflict
+}
+
+func (r *MongoRepository[T]) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
-	return err
+	return NormalizeError(err)
 }
 
-func (r *MongoRepository[T]) FindOneBy(field string, value interface{}) (T, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *MongoRepository[T]) DeleteVersion(ctx context.Context, doc T) error {
+	version, versioned := documentVersion(doc)
+	if !versioned {
+		return r.Delete(ctx, getDocumentID(doc))
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	id := getDocumentID(doc)
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, version.key: version.current})
+	if err != nil {
+		return NormalizeError(err)
+	}
+	if result.DeletedCount == 0 {
+		return r.versionConflict(ctx, id)
+	}
+	return nil
+}
+
+func (r *MongoRepository[T]) FindOneBy(ctx context.Context, field string, value interface{}, opts ...QueryOption) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	var result T
-	err := r.collection.FindOne(ctx, bson.M{field: value}).Decode(&result)
+	collection, err := r.collectionFor(NewQueryOptions(opts...))
 	if err != nil {
 		return result, err
 	}
+	err = collection.FindOne(ctx, bson.M{field: value}).Decode(&result)
+	if err != nil {
+		return result, NormalizeError(err)
+	}
 	return result, nil
 }
 
-func (r *MongoRepository[T]) FindOneByFilters(filters map[string]interface{}) (T, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *MongoRepository[T]) FindOneByFilters(ctx context.Context, filters map[string]interface{}, opts ...QueryOption) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	var result T
-	err := r.collection.FindOne(ctx, filters).Decode(&result)
+	collection, err := r.collectionFor(NewQueryOptions(opts...))
 	if err != nil {
 		return result, err
 	}
+	err = collection.FindOne(ctx, filters).Decode(&result)
+	if err != nil {
+		return result, NormalizeError(err)
+	}
 	return result, nil
 }
 
-func (r *MongoRepository[T]) FindBy(field string, value interface{}) ([]T, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *MongoRepository[T]) FindBy(ctx context.Context, field string, value interface{}, opts ...QueryOption) ([]T, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	cursor, err := r.collection.Find(ctx, bson.M{field: value})
+	queryOptions := NewQueryOptions(opts...)
+	collection, err := r.collectionFor(queryOptions)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := collection.Find(ctx, bson.M{field: value}, queryOptions.FindOptions...)
 	if err != nil {
 		return nil, err
 	}
@@ -132,11 +327,16 @@ func (r *MongoRepository[T]) FindBy(field string, value interface{}) ([]T, error
 	return results, nil
 }
 
-func (r *MongoRepository[T]) FindByFilters(filters map[string]interface{}) ([]T, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *MongoRepository[T]) FindByFilters(ctx context.Context, filters map[string]interface{}, opts ...QueryOption) ([]T, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	cursor, err := r.collection.Find(ctx, filters)
+	queryOptions := NewQueryOptions(opts...)
+	collection, err := r.collectionFor(queryOptions)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := collection.Find(ctx, filters, queryOptions.FindOptions...)
 	if err != nil {
 		return nil, err
 	}
@@ -149,18 +349,17 @@ func (r *MongoRepository[T]) FindByFilters(filters map[string]interface{}) ([]T,
 	return results, nil
 }
 
-func (r *MongoRepository[T]) FindAll(findOpts ...interface{}) ([]T, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *MongoRepository[T]) FindAll(ctx context.Context, opts ...QueryOption) ([]T, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	var mongoFindOpts []*options.FindOptions
-	for _, opt := range findOpts {
-		if fo, ok := opt.(*options.FindOptions); ok {
-			mongoFindOpts = append(mongoFindOpts, fo)
-		}
+	queryOptions := NewQueryOptions(opts...)
+	collection, err := r.collectionFor(queryOptions)
+	if err != nil {
+		return nil, err
 	}
 
-	cursor, err := r.collection.Find(ctx, bson.M{}, mongoFindOpts...)
+	cursor, err := collection.Find(ctx, bson.M{}, queryOptions.FindOptions...)
 	if err != nil {
 		return nil, err
 	}
@@ -173,31 +372,29 @@ func (r *MongoRepository[T]) FindAll(findOpts ...interface{}) ([]T, error) {
 	return results, nil
 }
 
-func (r *MongoRepository[T]) FindAllPaginated(pageRequest PageRequest) (PageResponse[T], error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *MongoRepository[T]) FindAllPaginated(ctx context.Context, pageRequest PageRequest, opts ...QueryOption) (PageResponse[T], error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	skip := int64((pageRequest.Page - 1) * pageRequest.Size)
 	limit := int64(pageRequest.Size)
 
-	total, err := r.collection.CountDocuments(ctx, bson.M{})
+	queryOptions := NewQueryOptions(opts...)
+	collection, err := r.collectionFor(queryOptions)
+	if err != nil {
+		return PageResponse[T]{}, err
+	}
+
+	total, err := collection.CountDocuments(ctx, bson.M{})
 	if err != nil {
 		return PageResponse[T]{}, err
 	}
 
-	opts := options.Find().
+	findOpts := options.Find().
 		SetSkip(skip).
 		SetLimit(limit)
 
-	if pageRequest.Sort.Field != "" {
-		direction := 1
-		if pageRequest.Sort.Direction < 0 {
-			direction = -1
-		}
-		opts.SetSort(bson.D{{Key: pageRequest.Sort.Field, Value: direction}})
-	}
-
-	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	cursor, err := collection.Find(ctx, bson.M{}, pageFindOptions[T](pageRequest, queryOptions.FindOptions, findOpts)...)
 	if err != nil {
 		return PageResponse[T]{}, err
 	}
@@ -219,31 +416,29 @@ func (r *MongoRepository[T]) FindAllPaginated(pageRequest PageRequest) (PageResp
 	}, nil
 }
 
-func (r *MongoRepository[T]) FindByPaginated(pageRequest PageRequest, filters map[string]interface{}) (PageResponse[T], error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *MongoRepository[T]) FindByPaginated(ctx context.Context, pageRequest PageRequest, filters map[string]interface{}, opts ...QueryOption) (PageResponse[T], error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	skip := int64((pageRequest.Page - 1) * pageRequest.Size)
 	limit := int64(pageRequest.Size)
 
-	total, err := r.collection.CountDocuments(ctx, filters)
+	queryOptions := NewQueryOptions(opts...)
+	collection, err := r.collectionFor(queryOptions)
 	if err != nil {
 		return PageResponse[T]{}, err
 	}
 
-	opts := options.Find().
+	total, err := collection.CountDocuments(ctx, filters)
+	if err != nil {
+		return PageResponse[T]{}, err
+	}
+
+	findOpts := options.Find().
 		SetSkip(skip).
 		SetLimit(limit)
 
-	if pageRequest.Sort.Field != "" {
-		direction := 1
-		if pageRequest.Sort.Direction < 0 {
-			direction = -1
-		}
-		opts.SetSort(bson.D{{Key: pageRequest.Sort.Field, Value: direction}})
-	}
-
-	cursor, err := r.collection.Find(ctx, filters, opts)
+	cursor, err := collection.Find(ctx, filters, pageFindOptions[T](pageRequest, queryOptions.FindOptions, findOpts)...)
 	if err != nil {
 		return PageResponse[T]{}, err
 	}
@@ -265,28 +460,194 @@ func (r *MongoRepository[T]) FindByPaginated(pageRequest PageRequest, filters ma
 	}, nil
 }
 
-func (r *MongoRepository[T]) CountBy(field string, value interface{}) (int64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *MongoRepository[T]) CountBy(ctx context.Context, field string, value interface{}) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 	return r.collection.CountDocuments(ctx, bson.M{field: value})
 }
 
-func (r *MongoRepository[T]) CountByFilters(filters map[string]interface{}) (int64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *MongoRepository[T]) CountByFilters(ctx context.Context, filters map[string]interface{}) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 	return r.collection.CountDocuments(ctx, filters)
 }
 
-func (r *MongoRepository[T]) ExistsBy(field string, value interface{}) (bool, error) {
-	count, err := r.CountBy(field, value)
+func (r *MongoRepository[T]) ExistsBy(ctx context.Context, field string, value interface{}) (bool, error) {
+	count, err := r.CountBy(ctx, field, value)
 	return count > 0, err
 }
 
-func (r *MongoRepository[T]) ExistsByFilters(filters map[string]interface{}) (bool, error) {
-	count, err := r.CountByFilters(filters)
+func (r *MongoRepository[T]) ExistsByFilters(ctx context.Context, filters map[string]interface{}) (bool, error) {
+	count, err := r.CountByFilters(ctx, filters)
 	return count > 0, err
 }
 
+func (r *MongoRepository[T]) FindByQuery(ctx context.Context, query *Query, opts ...QueryOption) ([]T, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter, err := mongoQueryFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	queryOptions := NewQueryOptions(opts...)
+	collection, err := r.collectionFor(queryOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	findOpts := options.Find()
+	if query != nil {
+		if len(query.Sorts) > 0 {
+			findOpts.SetSort(mongoSort(query.Sorts))
+		}
+		if query.MaxResults > 0 {
+			findOpts.SetLimit(int64(query.MaxResults))
+		}
+	}
+
+	cursor, err := collection.Find(ctx, filter, append(queryOptions.FindOptions, findOpts)...)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []T
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// FindByQueryPaginated pages through the documents matching the query. The
+// page request's sort takes precedence over the query's; its limit is ignored.
+func (r *MongoRepository[T]) FindByQueryPaginated(ctx context.Context, pageRequest PageRequest, query *Query, opts ...QueryOption) (PageResponse[T], error) {
+	filter, err := mongoQueryFilter(query)
+	if err != nil {
+		return PageResponse[T]{}, err
+	}
+	if len(pageRequest.SortFields()) == 0 && query != nil && len(query.Sorts) > 0 {
+		opts = append(opts, WithFindOptions(options.Find().SetSort(mongoSort(query.Sorts))))
+	}
+	return r.FindByPaginated(ctx, pageRequest, filter, opts...)
+}
+
+func (r *MongoRepository[T]) CountByQuery(ctx context.Context, query *Query) (int64, error) {
+	filter, err := mongoQueryFilter(query)
+	if err != nil {
+		return 0, err
+	}
+	return r.CountByFilters(ctx, filter)
+}
+
+// WithTransaction runs fn in a MongoDB transaction, committing when fn returns
+// nil and aborting otherwise. Repository calls made with tx as their context
+// take part in the transaction, including calls on other repositories of the
+// same client. Transactions require a replica set or sharded cluster.
+func (r *MongoRepository[T]) WithTransaction(ctx context.Context, fn func(tx Tx) error) error {
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+	return NormalizeError(err)
+}
+
+// collectionFor returns the collection configured with the read preference and
+// read concern requested for a call
+func (r *MongoRepository[T]) collectionFor(queryOptions QueryOptions) (*mongo.Collection, error) {
+	readPreference := queryOptions.ReadPreference
+	if readPreference == nil && queryOptions.ConsistentRead {
+		readPreference = readpref.Primary()
+	}
+	if readPreference == nil && queryOptions.ReadConcern == nil {
+		return r.collection, nil
+	}
+
+	collectionOptions := options.Collection()
+	if readPreference != nil {
+		collectionOptions.SetReadPreference(readPreference)
+	}
+	if queryOptions.ReadConcern != nil {
+		collectionOptions.SetReadConcern(queryOptions.ReadConcern)
+	}
+	return r.collection.Clone(collectionOptions)
+}
+
 func (r *MongoRepository[T]) Query() *mongo.Collection {
 	return r.collection
 }
+
+// versionConflict reports a duplicate _id raised by a versioned upsert as a
+// version conflict; other errors are returned unchanged
+func versionConflict(err error) error {
+	var duplicate *DuplicateKeyError
+	if errors.As(err, &duplicate) && duplicate.Field == "_id" {
+		return fmt.Errorf("%w: %v", ErrVersionConflict, duplicate.Err)
+	}
+	return err
+}
+
+func mongoQueryFilter(query *Query) (bson.M, error) {
+	if query == nil {
+		return bson.M{}, nil
+	}
+	if err := query.Criteria.Validate(); err != nil {
+		return nil, err
+	}
+	return mongoFilter(query.Criteria), nil
+}
+
+// mongoFilter translates validated criteria to a MongoDB filter
+func mongoFilter(c Criteria) bson.M {
+	switch c.Operator {
+	case OpAnd, OpOr:
+		if len(c.Criteria) == 0 {
+			return bson.M{}
+		}
+		operands := make(bson.A, len(c.Criteria))
+		for i, child := range c.Criteria {
+			operands[i] = mongoFilter(child)
+		}
+		return bson.M{"$" + string(c.Operator): operands}
+	case OpEq:
+		return bson.M{c.Field: c.Value}
+	case OpContains:
+		return bson.M{c.Field: bson.M{"$regex": regexp.QuoteMeta(c.Value.(string))}}
+	default:
+		return bson.M{c.Field: bson.M{fmt.Sprintf("$%s", c.Operator): c.Value}}
+	}
+}
+
+// pageFindOptions sorts a page by the request's sort fields or, when it has
+// none, by T's DefaultSort or _id. The default goes before the caller's find
+// options, so a sort passed with WithFindOptions, like a query's, takes
+// precedence over it.
+func pageFindOptions[T any](pageRequest PageRequest, callerOptions []*options.FindOptions, findOpts *options.FindOptions) []*options.FindOptions {
+	if sorts := pageRequest.SortFields(); len(sorts) > 0 {
+		findOpts.SetSort(mongoSort(sorts))
+		return append(callerOptions, findOpts)
+	}
+	sort, ok := defaultSortOf[T]()
+	if !ok {
+		sort = SortField{Field: "_id", Direction: 1}
+	}
+	defaults := options.Find().SetSort(mongoSort([]SortField{sort}))
+	return append(append([]*options.FindOptions{defaults}, callerOptions...), findOpts)
+}
+
+func mongoSort(sorts []SortField) bson.D {
+	sort := make(bson.D, 0, len(sorts))
+	for _, field := range sorts {
+		direction := 1
+		if field.Direction < 0 {
+			direction = -1
+		}
+		sort = append(sort, bson.E{Key: field.Field, Value: direction})
+	}
+	return sort
+}
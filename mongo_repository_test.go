@@ -2,7 +2,9 @@ package ginboot
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,7 +12,12 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 // TestDocument is a sample document for testing
@@ -21,6 +28,40 @@ type TestDocument struct {
 	CreatedAt time.Time `bson:"created_at"`
 }
 
+type versionedTestDocument struct {
+	ID      string `bson:"_id" ginboot:"_id"`
+	Name    string `bson:"name"`
+	Version int64  `bson:"version" ginboot:"version"`
+}
+
+type sortedTestDocument struct {
+	ID        string    `bson:"_id" ginboot:"_id"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+func (sortedTestDocument) DefaultSort() SortField {
+	return SortField{Field: "created_at", Direction: -1}
+}
+
+func TestPageFindOptions(t *testing.T) {
+	sortOf := func(opts []*options.FindOptions) interface{} {
+		return options.MergeFindOptions(opts...).Sort
+	}
+
+	requested := PageRequest{Page: 1, Size: 10, Sort: SortField{Field: "name", Direction: 1}}
+	assert.Equal(t, bson.D{{Key: "name", Value: 1}}, sortOf(pageFindOptions[sortedTestDocument](requested, nil, options.Find())))
+
+	multiple := PageRequest{Page: 1, Size: 10, Sorts: []SortField{{Field: "age", Direction: -1}, {Field: "name", Direction: 1}}}
+	assert.Equal(t, bson.D{{Key: "age", Value: -1}, {Key: "name", Value: 1}}, sortOf(pageFindOptions[sortedTestDocument](multiple, nil, options.Find())))
+
+	unsorted := PageRequest{Page: 1, Size: 10}
+	assert.Equal(t, bson.D{{Key: "created_at", Value: -1}}, sortOf(pageFindOptions[sortedTestDocument](unsorted, nil, options.Find())))
+	assert.Equal(t, bson.D{{Key: "_id", Value: 1}}, sortOf(pageFindOptions[TestDocument](unsorted, nil, options.Find())))
+
+	querySort := []*options.FindOptions{options.Find().SetSort(bson.D{{Key: "age", Value: 1}})}
+	assert.Equal(t, bson.D{{Key: "age", Value: 1}}, sortOf(pageFindOptions[sortedTestDocument](unsorted, querySort, options.Find())))
+}
+
 // setupTestContainer creates a MongoDB test container
 func setupTestContainer(t *testing.T) (testcontainers.Container, *MongoConfig, error) {
 	ctx := context.Background()
@@ -91,6 +132,7 @@ func TestMongoRepository(t *testing.T) {
 
 	// Create repository with explicit collection name
 	repo := NewMongoRepository[TestDocument](db, "test_documents")
+	ctx := context.Background()
 
 	// Test cases
 	t.Run("Save and FindById", func(t *testing.T) {
@@ -102,11 +144,11 @@ func TestMongoRepository(t *testing.T) {
 		}
 
 		// Save document
-		err := repo.Save(doc)
+		err := repo.Save(ctx, doc)
 		assert.NoError(t, err)
 
 		// Find document by ID
-		found, err := repo.FindById(doc.ID)
+		found, err := repo.FindById(ctx, doc.ID)
 		assert.NoError(t, err)
 		assert.Equal(t, doc.Name, found.Name)
 		assert.Equal(t, doc.Age, found.Age)
@@ -131,12 +173,12 @@ func TestMongoRepository(t *testing.T) {
 
 		// Save all documents
 		for _, doc := range docs {
-			err := repo.Save(doc)
+			err := repo.Save(ctx, doc)
 			assert.NoError(t, err)
 		}
 
 		// Find all documents
-		found, err := repo.FindAll()
+		found, err := repo.FindAll(ctx)
 		assert.NoError(t, err)
 		assert.GreaterOrEqual(t, len(found), 2)
 	})
@@ -150,16 +192,16 @@ func TestMongoRepository(t *testing.T) {
 		}
 
 		// Save initial document
-		err := repo.Save(doc)
+		err := repo.Save(ctx, doc)
 		assert.NoError(t, err)
 
 		// Update document
 		doc.Age = 29
-		err = repo.Update(doc)
+		err = repo.Update(ctx, doc)
 		assert.NoError(t, err)
 
 		// Verify update
-		found, err := repo.FindById(doc.ID)
+		found, err := repo.FindById(ctx, doc.ID)
 		assert.NoError(t, err)
 		assert.Equal(t, 29, found.Age)
 	})
@@ -173,16 +215,16 @@ func TestMongoRepository(t *testing.T) {
 		}
 
 		// Save document
-		err := repo.Save(doc)
+		err := repo.Save(ctx, doc)
 		assert.NoError(t, err)
 
 		// Delete document
-		err = repo.Delete(doc.ID)
+		err = repo.Delete(ctx, doc.ID)
 		assert.NoError(t, err)
 
 		// Verify deletion
-		_, err = repo.FindById(doc.ID)
-		assert.Error(t, err)
+		_, err = repo.FindById(ctx, doc.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
 	})
 
 	t.Run("FindBy", func(t *testing.T) {
@@ -204,12 +246,12 @@ func TestMongoRepository(t *testing.T) {
 
 		// Save documents
 		for _, doc := range docs {
-			err := repo.Save(doc)
+			err := repo.Save(ctx, doc)
 			assert.NoError(t, err)
 		}
 
 		// Find by age
-		found, err := repo.FindBy("age", 50)
+		found, err := repo.FindBy(ctx, "age", 50)
 		assert.NoError(t, err)
 		assert.Equal(t, 2, len(found))
 		assert.Equal(t, 50, found[0].Age)
@@ -225,11 +267,11 @@ func TestMongoRepository(t *testing.T) {
 		}
 
 		// Save document
-		err := repo.Save(doc)
+		err := repo.Save(ctx, doc)
 		assert.NoError(t, err)
 
 		// Find one by name
-		found, err := repo.FindOneBy("name", "Unique Name")
+		found, err := repo.FindOneBy(ctx, "name", "Unique Name")
 		assert.NoError(t, err)
 		assert.Equal(t, doc.Name, found.Name)
 		assert.Equal(t, doc.Age, found.Age)
@@ -244,7 +286,7 @@ func TestMongoRepository(t *testing.T) {
 				Age:       20 + i,
 				CreatedAt: time.Now(),
 			}
-			err := repo.Save(doc)
+			err := repo.Save(ctx, doc)
 			assert.NoError(t, err)
 		}
 
@@ -258,10 +300,265 @@ func TestMongoRepository(t *testing.T) {
 			},
 		}
 
-		response, err := repo.FindAllPaginated(pageRequest)
+		response, err := repo.FindAllPaginated(ctx, pageRequest)
 		assert.NoError(t, err)
 		assert.Equal(t, 5, len(response.Contents))
 		assert.True(t, response.TotalPages > 1)
 		assert.True(t, response.TotalElements >= 20)
 	})
+
+	t.Run("QueryOptions", func(t *testing.T) {
+		doc := TestDocument{
+			ID:        primitive.NewObjectID().Hex(),
+			Name:      "Consistent Reader",
+			Age:       99,
+			CreatedAt: time.Now(),
+		}
+		err := repo.Save(ctx, doc)
+		assert.NoError(t, err)
+
+		found, err := repo.FindById(ctx, doc.ID, ConsistentRead(), WithReadConcern(readconcern.Majority()))
+		assert.NoError(t, err)
+		assert.Equal(t, doc.Name, found.Name)
+
+		oldest, err := repo.FindAll(ctx, WithReadPreference(readpref.PrimaryPreferred()),
+			WithFindOptions(options.Find().SetSort(bson.D{{Key: "age", Value: -1}}).SetLimit(1)))
+		assert.NoError(t, err)
+		assert.Len(t, oldest, 1)
+		assert.Equal(t, doc.ID, oldest[0].ID)
+	})
+
+	t.Run("Named", func(t *testing.T) {
+		RegisterQuery("testDocumentsOlderThan", mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"age": bson.M{"$gt": Param("age")}}}},
+			{{Key: "$sort", Value: bson.D{{Key: "age", Value: 1}}}},
+		})
+
+		var found []TestDocument
+		err := repo.Named(ctx, "testDocumentsOlderThan", Params{"age": 40}, &found)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, found)
+		for _, doc := range found {
+			assert.Greater(t, doc.Age, 40)
+		}
+	})
+
+	t.Run("View", func(t *testing.T) {
+		pipeline := mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"age": bson.M{"$gte": 90}}}},
+		}
+		assert.NoError(t, EnsureMongoView(ctx, db, "senior_test_documents", "test_documents", pipeline))
+		// updating an existing view succeeds as well
+		assert.NoError(t, EnsureMongoView(ctx, db, "senior_test_documents", "test_documents", pipeline))
+
+		view := NewMongoViewRepository[TestDocument](db, "senior_test_documents")
+		found, err := view.FindAll(ctx)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, found)
+		for _, doc := range found {
+			assert.GreaterOrEqual(t, doc.Age, 90)
+		}
+
+		count, err := view.CountBy(ctx, "name", "Consistent Reader")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("Criteria", func(t *testing.T) {
+		for i, name := range []string{"Criteria (a)", "Criteria (b)", "Criteria (c)"} {
+			err := repo.Save(ctx, TestDocument{
+				ID:        primitive.NewObjectID().Hex(),
+				Name:      name,
+				Age:       70 + i,
+				CreatedAt: time.Now(),
+			})
+			assert.NoError(t, err)
+		}
+
+		query := Where(Contains("name", "Criteria ("), Or(Gte("age", 71), Eq("name", "Criteria (a)"))).
+			Sort("age", -1).
+			Limit(2)
+		found, err := repo.FindByQuery(ctx, query)
+		assert.NoError(t, err)
+		assert.Len(t, found, 2)
+		assert.Equal(t, "Criteria (c)", found[0].Name)
+		assert.Equal(t, "Criteria (b)", found[1].Name)
+
+		count, err := repo.CountByQuery(ctx, Where(Contains("name", "Criteria ("), Ne("age", 70)))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+
+		page, err := repo.FindByQueryPaginated(ctx, PageRequest{Page: 1, Size: 2}, Where(In("age", 70, 72)).Sort("age", 1))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, page.TotalElements)
+		assert.Equal(t, "Criteria (a)", page.Contents[0].Name)
+
+		_, err = repo.FindByQuery(ctx, Where(Or()))
+		assert.Error(t, err)
+	})
+
+	t.Run("Transaction", func(t *testing.T) {
+		first := TestDocument{ID: primitive.NewObjectID().Hex(), Name: "Tx First", Age: 1}
+		second := TestDocument{ID: primitive.NewObjectID().Hex(), Name: "Tx Second", Age: 2}
+		failure := errors.New("rollback")
+
+		err := repo.WithTransaction(ctx, func(tx Tx) error {
+			if err := repo.Save(tx, first); err != nil {
+				return err
+			}
+			if err := repo.Save(tx, second); err != nil {
+				return err
+			}
+			return failure
+		})
+		var commandErr mongo.CommandError
+		if errors.As(err, &commandErr) && commandErr.Code == 20 {
+			t.Skip("transactions require a replica set")
+		}
+		assert.ErrorIs(t, err, failure)
+		_, err = repo.FindById(ctx, first.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		err = repo.WithTransaction(ctx, func(tx Tx) error {
+			if err := repo.Save(tx, first); err != nil {
+				return err
+			}
+			return repo.Save(tx, second)
+		})
+		assert.NoError(t, err)
+		found, err := repo.FindAllById(ctx, []string{first.ID, second.ID})
+		assert.NoError(t, err)
+		assert.Len(t, found, 2)
+	})
+
+	t.Run("Optimistic locking", func(t *testing.T) {
+		versioned := NewMongoRepository[*versionedTestDocument](db, "versioned_documents")
+		doc := &versionedTestDocument{ID: primitive.NewObjectID().Hex(), Name: "v1"}
+		assert.NoError(t, versioned.SaveOrUpdate(ctx, doc))
+		assert.Equal(t, int64(1), doc.Version)
+
+		stale := *doc
+		doc.Name = "v2"
+		assert.NoError(t, versioned.Update(ctx, doc))
+		assert.Equal(t, int64(2), doc.Version)
+
+		stale.Name = "lost update"
+		assert.ErrorIs(t, versioned.Update(ctx, &stale), ErrVersionConflict)
+		assert.ErrorIs(t, versioned.SaveOrUpdate(ctx, &stale), ErrVersionConflict)
+
+		found, err := versioned.FindById(ctx, doc.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "v2", found.Name)
+		assert.Equal(t, int64(2), found.Version)
+
+		missing := &versionedTestDocument{ID: primitive.NewObjectID().Hex()}
+		assert.ErrorIs(t, versioned.Update(ctx, missing), ErrNotFound)
+	})
+
+	t.Run("Timestamps", func(t *testing.T) {
+		type stampedDocument struct {
+			ID        string    `bson:"_id" ginboot:"_id"`
+			Name      string    `bson:"name"`
+			CreatedAt time.Time `bson:"created_at" ginboot:"created_at"`
+			UpdatedAt time.Time `bson:"updated_at" ginboot:"updated_at"`
+		}
+		stamped := NewMongoRepository[*stampedDocument](db, "stamped_documents")
+
+		doc := &stampedDocument{ID: primitive.NewObjectID().Hex(), Name: "first"}
+		assert.NoError(t, stamped.Save(ctx, doc))
+		assert.False(t, doc.CreatedAt.IsZero())
+		assert.Equal(t, doc.CreatedAt, doc.UpdatedAt)
+		created := doc.CreatedAt
+
+		time.Sleep(5 * time.Millisecond)
+		// a replacement without a creation time keeps the stored one
+		update := &stampedDocument{ID: doc.ID, Name: "second"}
+		assert.NoError(t, stamped.Update(ctx, update))
+		assert.True(t, created.Equal(update.CreatedAt))
+		assert.True(t, update.UpdatedAt.After(created))
+
+		found, err := stamped.FindById(ctx, doc.ID)
+		assert.NoError(t, err)
+		assert.True(t, created.Equal(found.CreatedAt))
+		assert.True(t, update.UpdatedAt.Equal(found.UpdatedAt))
+
+		// SaveAll keeps the stored creation times of existing documents
+		fresh := &stampedDocument{ID: primitive.NewObjectID().Hex(), Name: "fresh"}
+		replaced := &stampedDocument{ID: doc.ID, Name: "third"}
+		assert.NoError(t, stamped.SaveAll(ctx, []*stampedDocument{replaced, fresh}))
+		assert.True(t, created.Equal(replaced.CreatedAt))
+		assert.True(t, fresh.CreatedAt.After(created))
+	})
+
+	t.Run("Indexes", func(t *testing.T) {
+		type indexedUser struct {
+			ID    string `bson:"_id" ginboot:"_id"`
+			Email string `bson:"email" ginboot:"unique"`
+			Bio   string `bson:"bio" ginboot:"text"`
+		}
+		users := NewMongoRepository[indexedUser](db, "indexed_users")
+		assert.NoError(t, users.EnsureIndexes(ctx))
+		// existing indexes are kept
+		assert.NoError(t, users.EnsureIndexes(ctx))
+
+		assert.NoError(t, users.Save(ctx, indexedUser{ID: "u1", Email: "a@example.com"}))
+		err := users.Save(ctx, indexedUser{ID: "u2", Email: "a@example.com"})
+		assert.ErrorIs(t, err, ErrDuplicateKey)
+	})
+
+	t.Run("Iterate", func(t *testing.T) {
+		iterated := NewMongoRepository[TestDocument](db, "iterated_documents")
+		for i := 0; i < 5; i++ {
+			assert.NoError(t, iterated.Save(ctx, TestDocument{ID: fmt.Sprintf("doc-%d", i), Age: i}))
+		}
+
+		docs, err := iterated.Iterate(ctx, Where(Gte("age", 2)).Sort("age", -1))
+		assert.NoError(t, err)
+		var ages []int
+		for docs.Next(ctx) {
+			ages = append(ages, docs.Current().Age)
+		}
+		assert.NoError(t, docs.Err())
+		assert.NoError(t, docs.Close(ctx))
+		assert.Equal(t, []int{4, 3, 2}, ages)
+	})
+
+	t.Run("Sequences", func(t *testing.T) {
+		sequences := NewMongoSequenceService(db, "sequences")
+
+		values := make(chan int64, 20)
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				value, err := sequences.NextVal(ctx, "invoice")
+				assert.NoError(t, err)
+				values <- value
+			}()
+		}
+		wg.Wait()
+		close(values)
+
+		seen := map[int64]bool{}
+		for value := range values {
+			seen[value] = true
+		}
+		assert.Len(t, seen, 20)
+		for value := int64(1); value <= 20; value++ {
+			assert.True(t, seen[value])
+		}
+
+		value, err := sequences.NextVal(ctx, "order")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), value)
+	})
+
+	t.Run("Cancelled context", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := repo.FindAll(cancelled)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
 }
@@ -0,0 +1,40 @@
+package ginboot
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// namespaceExists is the MongoDB error code returned when creating a view
+// or collection that already exists
+const namespaceExists = 48
+
+// EnsureMongoView creates a read-only view over the source collection defined
+// by an aggregation pipeline, or updates the pipeline of an existing view.
+// Call it at startup before using NewMongoViewRepository.
+func EnsureMongoView(ctx context.Context, db *mongo.Database, viewName, source string, pipeline mongo.Pipeline) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	err := db.CreateView(ctx, viewName, source, pipeline)
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) && commandErr.Code == namespaceExists {
+		return db.RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: viewName},
+			{Key: "viewOn", Value: source},
+			{Key: "pipeline", Value: pipeline},
+		}).Err()
+	}
+	return err
+}
+
+// NewMongoViewRepository returns a read-only repository over a MongoDB view
+// (or any collection maintained elsewhere, such as a $merge read model), so
+// reporting endpoints get repository ergonomics without write methods.
+func NewMongoViewRepository[T interface{}](db *mongo.Database, viewName string) ReadOnlyRepository[T] {
+	return NewMongoRepository[T](db, viewName)
+}
@@ -0,0 +1,251 @@
+package ginboot
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Params are the arguments of a named query
+type Params map[string]interface{}
+
+// Param is a placeholder in a registered query that is replaced by the value
+// of the parameter with the same name when the query runs
+type Param string
+
+// MongoPipelineBuilder builds an aggregation pipeline from the call's params,
+// for queries whose shape depends on the parameters
+type MongoPipelineBuilder func(params Params) (mongo.Pipeline, error)
+
+// SQLQuery is a named SQL statement whose :name placeholders are bound to the
+// call's params, e.g. "SELECT * FROM posts WHERE author = :author". Text in
+// quotes and PostgreSQL :: casts are not placeholders.
+type SQLQuery string
+
+var namedQueries sync.Map
+
+// RegisterQuery registers a named query at startup, keeping raw queries out of
+// service code. MongoRepository runs a mongo.Pipeline, possibly containing
+// Param placeholders, or a MongoPipelineBuilder; SQLRepository runs an
+// SQLQuery. Registering a name twice panics.
+func RegisterQuery(name string, query interface{}) {
+	if _, loaded := namedQueries.LoadOrStore(name, query); loaded {
+		panic("named query " + name + " is already registered")
+	}
+}
+
+func lookupQuery(name string) (interface{}, error) {
+	query, ok := namedQueries.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("named query %s is not registered", name)
+	}
+	return query, nil
+}
+
+// Named runs the registered aggregation pipeline and decodes the results into
+// out, which must be a pointer to a slice
+func (r *MongoRepository[T]) Named(ctx context.Context, name string, params Params, out interface{}) error {
+	query, err := lookupQuery(name)
+	if err != nil {
+		return err
+	}
+
+	var pipeline interface{}
+	switch q := query.(type) {
+	case MongoPipelineBuilder:
+		pipeline, err = q(params)
+	case func(params Params) (mongo.Pipeline, error):
+		pipeline, err = q(params)
+	case mongo.Pipeline, bson.A, []interface{}:
+		pipeline, err = bindNamedParams(q, params)
+	default:
+		return fmt.Errorf("named query %s is not a MongoDB pipeline", name)
+	}
+	if err != nil {
+		return fmt.Errorf("named query %s: %w", name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	return cursor.All(ctx, out)
+}
+
+// bindNamedParams returns a copy of value with every Param replaced by its value
+func bindNamedParams(value interface{}, params Params) (interface{}, error) {
+	switch v := value.(type) {
+	case Param:
+		bound, ok := params[string(v)]
+		if !ok {
+			return nil, fmt.Errorf("missing parameter %s", string(v))
+		}
+		return bound, nil
+	case mongo.Pipeline:
+		pipeline := make(mongo.Pipeline, len(v))
+		for i, stage := range v {
+			bound, err := bindNamedParams(stage, params)
+			if err != nil {
+				return nil, err
+			}
+			pipeline[i] = bound.(bson.D)
+		}
+		return pipeline, nil
+	case bson.D:
+		document := make(bson.D, len(v))
+		for i, element := range v {
+			bound, err := bindNamedParams(element.Value, params)
+			if err != nil {
+				return nil, err
+			}
+			document[i] = bson.E{Key: element.Key, Value: bound}
+		}
+		return document, nil
+	case bson.M:
+		return bindMap(v, params)
+	case map[string]interface{}:
+		return bindMap(v, params)
+	case bson.A:
+		return bindSlice(v, params)
+	case []interface{}:
+		return bindSlice(v, params)
+	default:
+		return value, nil
+	}
+}
+
+func bindMap(m map[string]interface{}, params Params) (bson.M, error) {
+	bound := make(bson.M, len(m))
+	for key, value := range m {
+		boundValue, err := bindNamedParams(value, params)
+		if err != nil {
+			return nil, err
+		}
+		bound[key] = boundValue
+	}
+	return bound, nil
+}
+
+func bindSlice(values []interface{}, params Params) (bson.A, error) {
+	bound := make(bson.A, len(values))
+	for i, value := range values {
+		boundValue, err := bindNamedParams(value, params)
+		if err != nil {
+			return nil, err
+		}
+		bound[i] = boundValue
+	}
+	return bound, nil
+}
+
+// Named runs the registered SQLQuery and scans the rows into out, which must be
+// a pointer to a slice of structs or struct pointers, see ScanSQLRow. It runs
+// in the transaction of ctx, if any.
+func (r *SQLRepository[T]) Named(ctx context.Context, name string, params Params, out interface{}) error {
+	query, err := lookupQuery(name)
+	if err != nil {
+		return err
+	}
+	q, ok := query.(SQLQuery)
+	if !ok {
+		return fmt.Errorf("named query %s is not an SQL query", name)
+	}
+	slice := reflect.ValueOf(out)
+	if slice.Kind() != reflect.Ptr || slice.IsNil() || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("ginboot: named query results must be a pointer to a slice, got %T", out)
+	}
+	if _, err := sqlColumns(slice.Elem().Type().Elem()); err != nil {
+		return err
+	}
+	statement, args, err := bindSQLParams(string(q), params, r.dialect)
+	if err != nil {
+		return fmt.Errorf("named query %s: %w", name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	rows, err := r.executor(ctx).QueryContext(ctx, statement, args...)
+	if err != nil {
+		return NormalizeError(err)
+	}
+	defer rows.Close()
+	return NormalizeError(scanSQLRowsInto(rows, slice.Elem()))
+}
+
+// bindSQLParams replaces the :name placeholders of query with the dialect's
+// bind parameters, returning the statement and its arguments in order
+func bindSQLParams(query string, params Params, dialect SQLDialect) (string, []interface{}, error) {
+	var statement strings.Builder
+	var args []interface{}
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == ':' && i+1 < len(query) && query[i+1] == ':':
+			statement.WriteString("::")
+			i++
+			continue
+		case c == ':' && i+1 < len(query) && isSQLParamStart(query[i+1]):
+			end := i + 1
+			for end < len(query) && (isSQLParamStart(query[end]) || query[end] >= '0' && query[end] <= '9') {
+				end++
+			}
+			name := query[i+1 : end]
+			value, ok := params[name]
+			if !ok {
+				return "", nil, fmt.Errorf("missing parameter %s", name)
+			}
+			args = append(args, value)
+			statement.WriteString(dialect.Placeholder(len(args)))
+			i = end - 1
+			continue
+		}
+		statement.WriteByte(c)
+	}
+	return statement.String(), args, nil
+}
+
+func isSQLParamStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// scanSQLRowsInto scans the remaining rows into slice, replacing its elements
+func scanSQLRowsInto(rows *sql.Rows, slice reflect.Value) error {
+	elem := slice.Type().Elem()
+	results := reflect.MakeSlice(slice.Type(), 0, 0)
+	for rows.Next() {
+		item := reflect.New(elem).Elem()
+		target := item.Addr()
+		if elem.Kind() == reflect.Ptr {
+			item.Set(reflect.New(elem.Elem()))
+			target = item
+		}
+		if err := ScanSQLRow(rows, target.Interface()); err != nil {
+			return err
+		}
+		results = reflect.Append(results, item)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	slice.Set(results)
+	return nil
+}
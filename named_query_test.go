@@ -0,0 +1,80 @@
+package ginboot
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestBindParams(t *testing.T) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"author": Param("author"), "tags": bson.M{"$in": bson.A{Param("tag"), "go"}}}}},
+		{{Key: "$limit", Value: Param("limit")}},
+	}
+
+	bound, err := bindNamedParams(pipeline, Params{"author": "john", "tag": "news", "limit": 5})
+	assert.NoError(t, err)
+	assert.Equal(t, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"author": "john", "tags": bson.M{"$in": bson.A{"news", "go"}}}}},
+		{{Key: "$limit", Value: 5}},
+	}, bound)
+
+	// the registered pipeline is left untouched
+	assert.Equal(t, Param("limit"), pipeline[1][0].Value)
+
+	_, err = bindNamedParams(pipeline, Params{"author": "john"})
+	assert.EqualError(t, err, "missing parameter tag")
+}
+
+func TestRegisterQuery(t *testing.T) {
+	RegisterQuery("testRegisterQuery", mongo.Pipeline{})
+	assert.Panics(t, func() { RegisterQuery("testRegisterQuery", mongo.Pipeline{}) })
+
+	repo := &MongoRepository[TestDocument]{}
+	var out []TestDocument
+	assert.EqualError(t, repo.Named(context.Background(), "unknownQuery", nil, &out), "named query unknownQuery is not registered")
+
+	RegisterQuery("testUnsupportedQuery", "SELECT 1")
+	assert.EqualError(t, repo.Named(context.Background(), "testUnsupportedQuery", nil, &out), "named query testUnsupportedQuery is not a MongoDB pipeline")
+}
+
+func TestBindSQLParams(t *testing.T) {
+	query := `SELECT id::text, ':skipped' FROM posts WHERE author = :author AND tag IN (:tag, :tag) LIMIT :limit`
+	statement, args, err := bindSQLParams(query, Params{"author": "john", "tag": "news", "limit": 5}, PostgresDialect{})
+	assert.NoError(t, err)
+	assert.Equal(t, `SELECT id::text, ':skipped' FROM posts WHERE author = $1 AND tag IN ($2, $3) LIMIT $4`, statement)
+	assert.Equal(t, []interface{}{"john", "news", "news", 5}, args)
+
+	statement, _, err = bindSQLParams(query, Params{"author": "john", "tag": "news", "limit": 5}, MySQLDialect{})
+	assert.NoError(t, err)
+	assert.Equal(t, `SELECT id::text, ':skipped' FROM posts WHERE author = ? AND tag IN (?, ?) LIMIT ?`, statement)
+
+	_, _, err = bindSQLParams(query, Params{"author": "john"}, PostgresDialect{})
+	assert.EqualError(t, err, "missing parameter tag")
+}
+
+func TestSQLRepository_Named(t *testing.T) {
+	ctx := context.Background()
+	db, state := openRecordingDB(t)
+	repo := mustSQLRepository[sqlPost](t, db, PostgresDialect{}, "posts")
+
+	RegisterQuery("testSQLRecentPosts", SQLQuery(`SELECT id, title FROM posts WHERE title LIKE :prefix LIMIT :limit`))
+	state.rows = []fakeRows{{columns: []string{"id", "title"}, values: [][]driver.Value{{"p1", "Go"}, {"p2", "Gin"}}}}
+	var posts []*sqlPost
+	assert.NoError(t, repo.Named(ctx, "testSQLRecentPosts", Params{"prefix": "G%", "limit": 2}, &posts))
+	assert.Equal(t, []*sqlPost{{ID: "p1", Title: "Go"}, {ID: "p2", Title: "Gin"}}, posts)
+	assert.Equal(t, []recordedStatement{{
+		query: `SELECT id, title FROM posts WHERE title LIKE $1 LIMIT $2`,
+		args:  []interface{}{"G%", int64(2)},
+	}}, state.statements)
+
+	var titles []string
+	assert.EqualError(t, repo.Named(ctx, "testSQLRecentPosts", Params{"prefix": "G%", "limit": 2}, &titles), "ginboot: string is not a struct")
+
+	RegisterQuery("testSQLPipeline", mongo.Pipeline{})
+	assert.EqualError(t, repo.Named(ctx, "testSQLPipeline", nil, &posts), "named query testSQLPipeline is not an SQL query")
+}
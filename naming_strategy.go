@@ -0,0 +1,210 @@
+package ginboot
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const namingStrategyKey = "ginboot.naming_strategy"
+
+// NamingStrategy renames the JSON keys of the struct fields in typed handler
+// responses, so a single wire format is enforced whatever the struct tags say.
+// The keys of maps are data and are left as they are.
+type NamingStrategy func(name string) string
+
+// SnakeCase turns createdAt, CreatedAt or created-at into created_at
+func SnakeCase(name string) string {
+	prefix, words := splitWords(name)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return prefix + strings.Join(words, "_")
+}
+
+// CamelCase turns created_at, CreatedAt or created-at into createdAt
+func CamelCase(name string) string {
+	prefix, words := splitWords(name)
+	for i, word := range words {
+		word = strings.ToLower(word)
+		if i > 0 {
+			runes := []rune(word)
+			runes[0] = unicode.ToUpper(runes[0])
+			word = string(runes)
+		}
+		words[i] = word
+	}
+	return prefix + strings.Join(words, "")
+}
+
+// splitWords splits a name on separators and case changes, keeping leading
+// underscores such as in HAL's _links as a prefix
+func splitWords(name string) (string, []string) {
+	trimmed := strings.TrimLeft(name, "_")
+	prefix := name[:len(name)-len(trimmed)]
+
+	runes := []rune(trimmed)
+	var words []string
+	start := 0
+	for i, r := range runes {
+		if r == '_' || r == '-' || r == ' ' {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+			continue
+		}
+		if i > start && unicode.IsUpper(r) {
+			previous := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return prefix, words
+}
+
+func namingStrategyFor(ctx *Context) NamingStrategy {
+	if value, ok := ctx.Get(namingStrategyKey); ok {
+		if naming, ok := value.(NamingStrategy); ok {
+			return naming
+		}
+	}
+	return nil
+}
+
+// documentKey names a key of the documents built by ginboot, such as
+// totalElements, with the naming strategy when one is set
+func documentKey(naming NamingStrategy, key string) string {
+	if naming == nil {
+		return key
+	}
+	return naming(key)
+}
+
+// renameFields renames the keys of decoded, the JSON form of value, that come
+// from struct fields. Map keys are data rather than names and are kept, as are
+// the contents of values with their own JSON encoding.
+func renameFields(value interface{}, decoded interface{}, naming NamingStrategy) interface{} {
+	return renameValue(reflect.ValueOf(value), decoded, naming)
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func renameValue(v reflect.Value, decoded interface{}, naming NamingStrategy) interface{} {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return decoded
+		}
+		v = v.Elem()
+	}
+	if v.Type().Implements(jsonMarshalerType) || reflect.PointerTo(v.Type()).Implements(jsonMarshalerType) ||
+		v.Type().Implements(textMarshalerType) || reflect.PointerTo(v.Type()).Implements(textMarshalerType) {
+		return decoded
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		object, ok := decoded.(map[string]interface{})
+		if !ok {
+			return decoded
+		}
+		fields := map[string]reflect.Value{}
+		collectJSONFields(v, fields)
+		renamed := make(map[string]interface{}, len(object))
+		for key, item := range object {
+			field, ok := fields[key]
+			if !ok {
+				renamed[key] = item
+				continue
+			}
+			renamed[naming(key)] = renameValue(field, item, naming)
+		}
+		return renamed
+	case reflect.Map:
+		object, ok := decoded.(map[string]interface{})
+		if !ok {
+			return decoded
+		}
+		for iter := v.MapRange(); iter.Next(); {
+			key, ok := jsonMapKey(iter.Key())
+			if item, found := object[key]; ok && found {
+				object[key] = renameValue(iter.Value(), item, naming)
+			}
+		}
+		return object
+	case reflect.Slice, reflect.Array:
+		items, ok := decoded.([]interface{})
+		if !ok || len(items) != v.Len() {
+			return decoded
+		}
+		for i := range items {
+			items[i] = renameValue(v.Index(i), items[i], naming)
+		}
+		return items
+	default:
+		return decoded
+	}
+}
+
+// collectJSONFields maps the JSON keys of a struct to its field values, with
+// the fields of embedded structs promoted unless shadowed, as encoding/json does
+func collectJSONFields(v reflect.Value, fields map[string]reflect.Value) {
+	var embedded []reflect.Value
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.Anonymous && tag == "" {
+			inner := v.Field(i)
+			for inner.Kind() == reflect.Ptr && !inner.IsNil() {
+				inner = inner.Elem()
+			}
+			if inner.Kind() == reflect.Struct {
+				embedded = append(embedded, inner)
+				continue
+			}
+		}
+		if name := jsonFieldName(field); name != "" {
+			fields[name] = v.Field(i)
+		}
+	}
+	for _, inner := range embedded {
+		promoted := map[string]reflect.Value{}
+		collectJSONFields(inner, promoted)
+		for name, value := range promoted {
+			if _, shadowed := fields[name]; !shadowed {
+				fields[name] = value
+			}
+		}
+	}
+}
+
+// jsonMapKey returns the JSON object key encoding/json writes for a map key
+func jsonMapKey(key reflect.Value) (string, bool) {
+	if key.Kind() == reflect.String {
+		return key.String(), true
+	}
+	if marshaler, ok := key.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		return string(text), err == nil
+	}
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), true
+	}
+	return "", false
+}
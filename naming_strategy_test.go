@@ -0,0 +1,89 @@
+package ginboot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamingStrategies(t *testing.T) {
+	tests := []struct {
+		name  string
+		snake string
+		camel string
+	}{
+		{"createdAt", "created_at", "createdAt"},
+		{"CreatedAt", "created_at", "createdAt"},
+		{"created_at", "created_at", "createdAt"},
+		{"created-at", "created_at", "createdAt"},
+		{"userID", "user_id", "userId"},
+		{"HTTPServer", "http_server", "httpServer"},
+		{"address2Line", "address2_line", "address2Line"},
+		{"_links", "_links", "_links"},
+		{"id", "id", "id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.snake, SnakeCase(tt.name))
+			assert.Equal(t, tt.camel, CamelCase(tt.name))
+		})
+	}
+}
+
+type namingAuthor struct {
+	DisplayName string `json:"displayName"`
+}
+
+type namingPost struct {
+	ID        string `ginboot:"_id" json:"id"`
+	PostTitle string `json:"postTitle"`
+	AuthorID  string `json:"author_id"`
+	ViewCount int64
+	// map keys are data, only the fields of the values are renamed
+	Authors map[string]namingAuthor `json:"authors,omitempty"`
+	Labels  map[string]interface{}  `json:"labels,omitempty"`
+}
+
+func TestServer_SetNamingStrategy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{engine: gin.New()}
+	server.SetNamingStrategy(SnakeCase)
+	server.engine.Use(server.settingsMiddleware())
+
+	post := namingPost{ID: "1", PostTitle: "Hello", AuthorID: "u1", ViewCount: 9007199254740993}
+	group := server.Group("")
+	group.GET("/post", func() (namingPost, error) {
+		return post, nil
+	}, SparseFieldsets())
+	group.GET("/posts", func() (PageResponse[namingPost], error) {
+		return PageResponse[namingPost]{Contents: []namingPost{post}, Pageable: PageRequest{Page: 1, Size: 1}, TotalPages: 1, TotalElements: 1}, nil
+	}, UseSerializer(JSONAPISerializer{}))
+
+	get := func(target string) string {
+		w := httptest.NewRecorder()
+		server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	assert.JSONEq(t, `{"id":"1","post_title":"Hello","author_id":"u1","view_count":9007199254740993}`, get("/post"))
+	assert.JSONEq(t, `{"post_title":"Hello"}`, get("/post?fields=post_title"))
+
+	body := get("/posts")
+	assert.Contains(t, body, `"post_title":"Hello"`)
+	assert.Contains(t, body, `"total_elements":1`)
+
+	post.Authors = map[string]namingAuthor{"mainAuthor": {DisplayName: "Ada"}}
+	post.Labels = map[string]interface{}{"colorScheme": "dark", "nestedMap": map[string]string{"innerKey": "x"}}
+	assert.JSONEq(t, `{"id":"1","post_title":"Hello","author_id":"u1","view_count":9007199254740993,
+		"authors":{"mainAuthor":{"display_name":"Ada"}},
+		"labels":{"colorScheme":"dark","nestedMap":{"innerKey":"x"}}}`, get("/post"))
+	body = get("/posts")
+	assert.Contains(t, body, `"mainAuthor":{"display_name":"Ada"}`)
+	assert.Contains(t, body, `"colorScheme":"dark"`)
+}
@@ -0,0 +1,51 @@
+package ginboot
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// CheckUnique fails with a *DuplicateKeyError when another document has the
+// same values as doc for all of the natural key fields, named by their bson
+// names, e.g. CheckUnique(ctx, users, user, "email"). SendError answers it with
+// 409 DUPLICATE_KEY. Call it once per key to check several keys.
+func CheckUnique[T any](ctx context.Context, repo ReadOnlyRepository[T], doc T, fields ...string) error {
+	val := reflect.ValueOf(doc)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return fmt.Errorf("ginboot: natural keys need a struct document, got %T", doc)
+	}
+	filters := make(map[string]interface{}, len(fields))
+	for _, name := range fields {
+		field, ok := fieldByBSONName(val, name)
+		if !ok {
+			return fmt.Errorf("ginboot: %T has no field %s", doc, name)
+		}
+		filters[name] = field.Interface()
+	}
+
+	exists, err := repo.ExistsByFilters(ctx, filters)
+	if err != nil {
+		return err
+	}
+	if exists {
+		key := strings.Join(fields, ", ")
+		return &DuplicateKeyError{Field: key, Err: fmt.Errorf("a document with this %s exists", key)}
+	}
+	return nil
+}
+
+// SaveUnique saves doc unless CheckUnique finds a document with the same
+// natural key. A concurrent insert can still slip in between the check and the
+// save; back the key with a unique index, whose violation is reported as
+// ErrDuplicateKey as well.
+func SaveUnique[T any](ctx context.Context, repo GenericRepository[T], doc T, fields ...string) error {
+	if err := CheckUnique[T](ctx, repo, doc, fields...); err != nil {
+		return err
+	}
+	return repo.Save(ctx, doc)
+}
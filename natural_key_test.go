@@ -0,0 +1,51 @@
+package ginboot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type keyedDocument struct {
+	ID     string `bson:"_id" ginboot:"_id"`
+	Tenant string `bson:"tenant"`
+	Slug   string `bson:"slug"`
+}
+
+func TestSaveUnique(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository[keyedDocument]()
+
+	assert.NoError(t, SaveUnique[keyedDocument](ctx, repo, keyedDocument{ID: "1", Tenant: "acme", Slug: "hello"}, "tenant", "slug"))
+	assert.NoError(t, SaveUnique[keyedDocument](ctx, repo, keyedDocument{ID: "2", Tenant: "globex", Slug: "hello"}, "tenant", "slug"))
+
+	err := SaveUnique[keyedDocument](ctx, repo, keyedDocument{ID: "3", Tenant: "acme", Slug: "hello"}, "tenant", "slug")
+	assert.ErrorIs(t, err, ErrDuplicateKey)
+	var duplicate *DuplicateKeyError
+	assert.ErrorAs(t, err, &duplicate)
+	assert.Equal(t, "tenant, slug", duplicate.Field)
+	_, err = repo.FindById(ctx, "3")
+	assert.Error(t, err)
+
+	assert.Error(t, CheckUnique[keyedDocument](ctx, repo, keyedDocument{}, "missing"))
+}
+
+func TestSaveUnique_Conflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := newMemoryRepository[keyedDocument]()
+	repo.put("1", keyedDocument{ID: "1", Slug: "hello"})
+	server := &Server{engine: gin.New()}
+	server.Group("").POST("/docs", func(ctx *Context) (EmptyResponse, error) {
+		return EmptyResponse{}, SaveUnique[keyedDocument](ctx, repo, keyedDocument{ID: "2", Slug: "hello"}, "slug")
+	})
+
+	w := httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/docs", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.JSONEq(t, `{"error_code":"DUPLICATE_KEY","message":"slug already exists"}`, w.Body.String())
+}
@@ -0,0 +1,97 @@
+package ginboot
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	NonceMissing    = ApiError{"NONCE_MISSING", "Request nonce and timestamp are required"}
+	NonceExpired    = ApiError{"NONCE_EXPIRED", "Request timestamp is outside the allowed window"}
+	RequestReplayed = ApiError{"REQUEST_REPLAYED", "Request nonce has already been used"}
+)
+
+type NonceConfig struct {
+	// Cache remembers used nonces for twice the window. It must keep them that
+	// long: a cache that evicts entries early, such as a bounded LRU, lets an
+	// evicted nonce be replayed. Share a backend between instances so a nonce
+	// cannot be replayed on another one.
+	Cache CacheService
+	// Window is how far the request timestamp may deviate from the server clock;
+	// nonces are remembered for twice this long
+	Window          time.Duration
+	NonceHeader     string
+	TimestampHeader string
+}
+
+// DefaultNonceConfig uses X-Nonce and X-Timestamp (unix seconds) headers with
+// a five minute window and an unbounded in-memory cache, which only drops
+// nonces once they expire
+func DefaultNonceConfig() NonceConfig {
+	return NonceConfig{
+		Cache:           NewInMemoryCacheService(InMemoryCacheConfig{SweepInterval: time.Minute}),
+		Window:          5 * time.Minute,
+		NonceHeader:     "X-Nonce",
+		TimestampHeader: "X-Timestamp",
+	}
+}
+
+// NonceMiddleware rejects requests whose nonce was already seen within the
+// window, blocking replays of signed requests to sensitive endpoints. Zero
+// fields of config are taken from DefaultNonceConfig.
+func NonceMiddleware(config NonceConfig) gin.HandlerFunc {
+	defaults := DefaultNonceConfig()
+	if config.Cache == nil {
+		config.Cache = defaults.Cache
+	}
+	if config.Window <= 0 {
+		config.Window = defaults.Window
+	}
+	if config.NonceHeader == "" {
+		config.NonceHeader = defaults.NonceHeader
+	}
+	if config.TimestampHeader == "" {
+		config.TimestampHeader = defaults.TimestampHeader
+	}
+
+	return func(c *gin.Context) {
+		nonce := c.GetHeader(config.NonceHeader)
+		timestamp, err := strconv.ParseInt(c.GetHeader(config.TimestampHeader), 10, 64)
+		if nonce == "" || err != nil {
+			abortWithApiError(c, http.StatusBadRequest, NonceMissing)
+			return
+		}
+
+		skew := time.Since(time.Unix(timestamp, 0))
+		if skew > config.Window || skew < -config.Window {
+			abortWithApiError(c, http.StatusUnauthorized, NonceExpired)
+			return
+		}
+
+		// claiming the nonce is atomic, so concurrent replays cannot both pass
+		fresh, err := config.Cache.SetIfAbsent(c.Request.Context(), "nonce:"+nonce, nil, 2*config.Window)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, ErrorResponse{
+				ErrorCode: "Internal Server Error",
+				Message:   "An unknown error occurred",
+			})
+			return
+		}
+		if !fresh {
+			abortWithApiError(c, http.StatusConflict, RequestReplayed)
+			return
+		}
+		c.Next()
+	}
+}
+
+func abortWithApiError(c *gin.Context, status int, apiErr ApiError) {
+	writeError(c, status, gin.H{
+		"error_code": apiErr.ErrorCode,
+		"message":    apiErr.Message,
+	})
+	c.Abort()
+}
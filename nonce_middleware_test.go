@@ -0,0 +1,72 @@
+package ginboot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNonceMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.POST("/transfer", NonceMiddleware(DefaultNonceConfig()), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	send := func(nonce string, timestamp time.Time) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/transfer", nil)
+		if nonce != "" {
+			req.Header.Set("X-Nonce", nonce)
+		}
+		req.Header.Set("X-Timestamp", strconv.FormatInt(timestamp.Unix(), 10))
+		engine.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send("n-1", time.Now()))
+	assert.Equal(t, http.StatusConflict, send("n-1", time.Now()))
+	assert.Equal(t, http.StatusOK, send("n-2", time.Now()))
+	assert.Equal(t, http.StatusBadRequest, send("", time.Now()))
+	assert.Equal(t, http.StatusUnauthorized, send("n-3", time.Now().Add(-time.Hour)))
+
+	// instances sharing a cache share the nonces
+	shared := DefaultNonceConfig()
+	other := gin.New()
+	other.POST("/transfer", NonceMiddleware(shared), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	engine = gin.New()
+	engine.POST("/transfer", NonceMiddleware(shared), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	assert.Equal(t, http.StatusOK, send("n-4", time.Now()))
+	engine = other
+	assert.Equal(t, http.StatusConflict, send("n-4", time.Now()))
+}
+
+func TestNonceMiddleware_ZeroConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.POST("/transfer", NonceMiddleware(NonceConfig{}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	send := func() int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/transfer", nil)
+		req.Header.Set("X-Nonce", "n-1")
+		req.Header.Set("X-Timestamp", strconv.FormatInt(time.Now().Unix(), 10))
+		engine.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, send())
+	assert.Equal(t, http.StatusConflict, send())
+}
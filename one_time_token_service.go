@@ -0,0 +1,132 @@
+package ginboot
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+const (
+	PurposePasswordReset     = "password_reset"
+	PurposeEmailVerification = "email_verification"
+)
+
+var InvalidToken = ApiError{"INVALID_TOKEN", "Token is invalid or has expired"}
+
+// OneTimeToken is the stored form of a one-time token. Only the SHA-256 hash of
+// the token is persisted, the plain value is handed to the user once.
+type OneTimeToken struct {
+	ID        string    `bson:"_id" ginboot:"_id" json:"id" db:"id"`
+	Purpose   string    `bson:"purpose" json:"purpose" db:"purpose"`
+	Subject   string    `bson:"subject" json:"subject" db:"subject"`
+	CreatedAt time.Time `bson:"created_at" json:"createdAt" db:"created_at"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expiresAt" db:"expires_at"`
+	// Consumed marks a used token until it is deleted
+	Consumed bool `bson:"consumed" json:"consumed" db:"consumed"`
+	// Version makes consuming a token a compare-and-set
+	Version int64 `bson:"version" ginboot:"version" json:"version" db:"version"`
+}
+
+// TokenNotifier delivers a newly issued token to its subject, typically by
+// sending an email with a reset or verification link
+type TokenNotifier interface {
+	Notify(ctx context.Context, purpose, subject, token string) error
+}
+
+// TokenNotifierFunc adapts a function to the TokenNotifier interface
+type TokenNotifierFunc func(ctx context.Context, purpose, subject, token string) error
+
+func (f TokenNotifierFunc) Notify(ctx context.Context, purpose, subject, token string) error {
+	return f(ctx, purpose, subject, token)
+}
+
+// OneTimeTokenService issues short lived, single use tokens for flows such as
+// password resets and email verification
+type OneTimeTokenService struct {
+	repo     GenericRepository[OneTimeToken]
+	ttl      time.Duration
+	notifier TokenNotifier
+}
+
+func NewOneTimeTokenService(repo GenericRepository[OneTimeToken], ttl time.Duration) *OneTimeTokenService {
+	return &OneTimeTokenService{
+		repo: repo,
+		ttl:  ttl,
+	}
+}
+
+// WithNotifier sets the notifier that Issue hands new tokens to
+func (s *OneTimeTokenService) WithNotifier(notifier TokenNotifier) *OneTimeTokenService {
+	s.notifier = notifier
+	return s
+}
+
+// Issue generates a token for the subject, stores its hash and passes the plain
+// token to the notifier when one is configured
+func (s *OneTimeTokenService) Issue(ctx context.Context, purpose, subject string) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	now := time.Now()
+	err := s.repo.Save(ctx, OneTimeToken{
+		ID:        hashToken(token),
+		Purpose:   purpose,
+		Subject:   subject,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if s.notifier != nil {
+		if err := s.notifier.Notify(ctx, purpose, subject, token); err != nil {
+			return "", err
+		}
+	}
+	return token, nil
+}
+
+// Consume validates the token for the given purpose, invalidates it and
+// returns the subject it was issued for. Of concurrent calls with the same
+// token only one succeeds.
+func (s *OneTimeTokenService) Consume(ctx context.Context, purpose, token string) (string, error) {
+	id := hashToken(token)
+	stored, err := s.repo.FindById(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return "", InvalidToken
+	}
+	if err != nil {
+		return "", err
+	}
+	if stored.Purpose != purpose || stored.Consumed || time.Now().After(stored.ExpiresAt) {
+		return "", InvalidToken
+	}
+
+	// the update only succeeds while the version is the one checked above, so
+	// a token consumed or replaced meanwhile is rejected
+	stored.Consumed = true
+	if err := s.repo.Update(ctx, stored); err != nil {
+		if errors.Is(err, ErrVersionConflict) || errors.Is(err, ErrNotFound) {
+			return "", InvalidToken
+		}
+		return "", err
+	}
+	// the token is already unusable, deleting it only keeps the store small
+	if err := s.repo.Delete(ctx, id); err != nil {
+		LoggerFromContext(ctx).Warn("ginboot: failed to delete consumed token", "error", err)
+	}
+	return stored.Subject, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
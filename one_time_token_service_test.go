@@ -0,0 +1,76 @@
+package ginboot
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOneTimeTokenService(t *testing.T) {
+	t.Run("issue and consume", func(t *testing.T) {
+		repo := newMemoryRepository[OneTimeToken]()
+		var delivered string
+		service := NewOneTimeTokenService(repo, time.Hour).WithNotifier(
+			TokenNotifierFunc(func(ctx context.Context, purpose, subject, token string) error {
+				assert.Equal(t, PurposePasswordReset, purpose)
+				assert.Equal(t, "john@example.com", subject)
+				delivered = token
+				return nil
+			}))
+
+		token, err := service.Issue(context.Background(), PurposePasswordReset, "john@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, token, delivered)
+
+		stored, err := repo.FindAll(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, stored, 1)
+		assert.NotEqual(t, token, stored[0].ID)
+
+		_, err = service.Consume(context.Background(), PurposeEmailVerification, token)
+		assert.ErrorIs(t, err, InvalidToken)
+
+		subject, err := service.Consume(context.Background(), PurposePasswordReset, token)
+		assert.NoError(t, err)
+		assert.Equal(t, "john@example.com", subject)
+
+		_, err = service.Consume(context.Background(), PurposePasswordReset, token)
+		assert.ErrorIs(t, err, InvalidToken)
+	})
+
+	t.Run("concurrent consume", func(t *testing.T) {
+		service := NewOneTimeTokenService(newMemoryRepository[OneTimeToken](), time.Hour)
+		token, err := service.Issue(context.Background(), PurposePasswordReset, "john@example.com")
+		assert.NoError(t, err)
+
+		var wg sync.WaitGroup
+		var consumed atomic.Int32
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := service.Consume(context.Background(), PurposePasswordReset, token); err == nil {
+					consumed.Add(1)
+				} else {
+					assert.ErrorIs(t, err, InvalidToken)
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), consumed.Load())
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		service := NewOneTimeTokenService(newMemoryRepository[OneTimeToken](), -time.Second)
+
+		token, err := service.Issue(context.Background(), PurposePasswordReset, "john@example.com")
+		assert.NoError(t, err)
+
+		_, err = service.Consume(context.Background(), PurposePasswordReset, token)
+		assert.ErrorIs(t, err, InvalidToken)
+	})
+}
@@ -1,11 +1,11 @@
 package ginboot
 
 import (
+	"context"
 	"crypto/sha512"
 	"crypto/subtle"
 	"encoding/base64"
 	"golang.org/x/crypto/pbkdf2"
-	"os"
 	"strconv"
 )
 
@@ -16,18 +16,33 @@ type PBKDF2Encoder struct {
 }
 
 func NewPBKDF2Encoder() *PBKDF2Encoder {
-	secret := os.Getenv("PBKDF2_ENCODER_SECRET")
-	iteration, err := strconv.ParseInt(os.Getenv("PBKDF2_ENCODER_ITERATION"), 10, 64)
+	secret, err := getSecret("PBKDF2_ENCODER_SECRET")
+	if err != nil {
+		panic("ENCODER_SECRET_MISSING")
+	}
+	iterationValue, _ := getSecret("PBKDF2_ENCODER_ITERATION")
+	iteration, err := strconv.ParseInt(iterationValue, 10, 64)
 	if err != nil {
 		panic("ENCODER_ITERATIONS_MISSING")
 	}
-	keyLength, err := strconv.ParseInt(os.Getenv("PBKDF2_ENCODER_KEY_LENGTH"), 10, 64)
+	keyLengthValue, _ := getSecret("PBKDF2_ENCODER_KEY_LENGTH")
+	keyLength, err := strconv.ParseInt(keyLengthValue, 10, 64)
 	if err != nil {
 		panic("ENCODER_ITERATIONS_MISSING")
 	}
 	return &PBKDF2Encoder{secret, int(iteration), int(keyLength)}
 }
 
+// NewPBKDF2EncoderFromProvider builds an encoder whose salt is loaded from the
+// given provider instead of the environment
+func NewPBKDF2EncoderFromProvider(ctx context.Context, provider SecretProvider, iteration, keyLength int) (*PBKDF2Encoder, error) {
+	secret, err := provider.GetSecret(ctx, "PBKDF2_ENCODER_SECRET")
+	if err != nil {
+		return nil, err
+	}
+	return &PBKDF2Encoder{secret, iteration, keyLength}, nil
+}
+
 func (P PBKDF2Encoder) GetPasswordHash(password string) (string, error) {
 	hash := pbkdf2.Key([]byte(password), []byte(P.Secret), P.Iteration, P.KeyLength, sha512.New)
 	encoded := base64.StdEncoding.EncodeToString(hash)
@@ -0,0 +1,232 @@
+package ginboot
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type PresenceConfig struct {
+	// TTL is how long a user stays online after a heartbeat, and the longest
+	// window ListOnline can look back
+	TTL time.Duration
+	// KeyPrefix namespaces the cache keys
+	KeyPrefix string
+	// RosterShards splits the roster listing recent users across keys, so a
+	// write rewrites a fraction of it. Zero uses 16.
+	RosterShards int
+	// RosterInterval is the least time between two roster writes for a user;
+	// heartbeats in between only refresh the user's own key. ListOnline is
+	// accurate to it. Zero writes the roster on every heartbeat.
+	RosterInterval time.Duration
+}
+
+// DefaultPresenceConfig keeps users online for two minutes after a heartbeat,
+// listing them in 16 roster shards refreshed every 30 seconds
+func DefaultPresenceConfig() PresenceConfig {
+	return PresenceConfig{
+		TTL:            2 * time.Minute,
+		KeyPrefix:      "presence:",
+		RosterShards:   16,
+		RosterInterval: 30 * time.Second,
+	}
+}
+
+// OnlineUser is a user seen by a PresenceService
+type OnlineUser struct {
+	UserID   string    `json:"userId"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// PresenceService tracks which users are online from their heartbeats, kept
+// in a CacheService with TTLs. Each user has a key of their own, refreshed on
+// every heartbeat. Roster shards list recent users for ListOnline; a user's
+// shard is rewritten at most once per RosterInterval, so the roster costs a
+// write per user and interval whatever the heartbeat rate. With a shared
+// backend, concurrent writes of a shard from several instances may drop a
+// user from ListOnline until their next roster write, which is fine for soft
+// real-time presence.
+type PresenceService struct {
+	cache  CacheService
+	config PresenceConfig
+	mu     sync.Mutex
+}
+
+func NewPresenceService(cache CacheService, config PresenceConfig) *PresenceService {
+	if config.RosterShards <= 0 {
+		config.RosterShards = DefaultPresenceConfig().RosterShards
+	}
+	return &PresenceService{cache: cache, config: config}
+}
+
+func (s *PresenceService) userKey(userID string) string {
+	return s.config.KeyPrefix + "user:" + userID
+}
+
+// listedKey is held while the user's roster entry is fresh
+func (s *PresenceService) listedKey(userID string) string {
+	return s.config.KeyPrefix + "listed:" + userID
+}
+
+func (s *PresenceService) shardKey(shard int) string {
+	return s.config.KeyPrefix + "roster:" + strconv.Itoa(shard)
+}
+
+func (s *PresenceService) shardOf(userID string) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(userID))
+	return int(hash.Sum32() % uint32(s.config.RosterShards))
+}
+
+// Heartbeat marks the user online for the TTL
+func (s *PresenceService) Heartbeat(ctx context.Context, userID string) error {
+	now := time.Now()
+	seen, err := now.MarshalText()
+	if err != nil {
+		return err
+	}
+	if err := s.cache.Set(ctx, s.userKey(userID), seen, s.config.TTL); err != nil {
+		return err
+	}
+	if s.config.RosterInterval > 0 {
+		due, err := s.cache.SetIfAbsent(ctx, s.listedKey(userID), nil, s.config.RosterInterval)
+		if err != nil || !due {
+			return err
+		}
+	}
+	return s.updateShard(ctx, s.shardOf(userID), func(shard map[string]time.Time) {
+		shard[userID] = now
+	})
+}
+
+// Leave marks the user offline right away, e.g. on logout
+func (s *PresenceService) Leave(ctx context.Context, userID string) error {
+	if err := s.cache.Invalidate(ctx, s.userKey(userID), s.listedKey(userID)); err != nil {
+		return err
+	}
+	return s.updateShard(ctx, s.shardOf(userID), func(shard map[string]time.Time) {
+		delete(shard, userID)
+	})
+}
+
+// IsOnline reports whether the user sent a heartbeat within the TTL
+func (s *PresenceService) IsOnline(ctx context.Context, userID string) (bool, error) {
+	_, ok, err := s.cache.Get(ctx, s.userKey(userID))
+	return ok, err
+}
+
+// ListOnline returns the users seen within window, capped at the TTL, most
+// recently seen first. LastSeen is the time of the user's latest roster write,
+// so it and the window are accurate to the RosterInterval.
+func (s *PresenceService) ListOnline(ctx context.Context, window time.Duration) ([]OnlineUser, error) {
+	if window <= 0 || window > s.config.TTL {
+		window = s.config.TTL
+	}
+	since := time.Now().Add(-window - s.config.RosterInterval)
+	users := []OnlineUser{}
+	for i := 0; i < s.config.RosterShards; i++ {
+		shard, err := s.shard(ctx, i)
+		if err != nil {
+			return nil, err
+		}
+		for userID, lastSeen := range shard {
+			if lastSeen.After(since) {
+				users = append(users, OnlineUser{UserID: userID, LastSeen: lastSeen})
+			}
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].LastSeen.Equal(users[j].LastSeen) {
+			return users[i].LastSeen.After(users[j].LastSeen)
+		}
+		return users[i].UserID < users[j].UserID
+	})
+	return users, nil
+}
+
+func (s *PresenceService) shard(ctx context.Context, shard int) (map[string]time.Time, error) {
+	users := map[string]time.Time{}
+	value, ok, err := s.cache.Get(ctx, s.shardKey(shard))
+	if err != nil || !ok {
+		return users, err
+	}
+	if err := json.Unmarshal(value, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// updateShard applies change to a roster shard and drops users not seen
+// within the TTL, so shards don't grow with every user ever seen
+func (s *PresenceService) updateShard(ctx context.Context, shard int, change func(users map[string]time.Time)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users, err := s.shard(ctx, shard)
+	if err != nil {
+		return err
+	}
+	change(users)
+	expired := time.Now().Add(-s.config.TTL - s.config.RosterInterval)
+	for userID, lastSeen := range users {
+		if !lastSeen.After(expired) {
+			delete(users, userID)
+		}
+	}
+	value, err := json.Marshal(users)
+	if err != nil {
+		return err
+	}
+	return s.cache.Set(ctx, s.shardKey(shard), value, s.config.TTL+s.config.RosterInterval)
+}
+
+// PresenceController exposes presence to authenticated users: POST
+// /heartbeat keeps them online, DELETE marks them offline and GET lists the
+// online users, within an optional window such as ?window=30s
+type PresenceController struct {
+	presence *PresenceService
+}
+
+func NewPresenceController(presence *PresenceService) *PresenceController {
+	return &PresenceController{presence: presence}
+}
+
+func (c *PresenceController) Register(group *ControllerGroup) {
+	group.POST("/heartbeat", c.Heartbeat)
+	group.DELETE("", c.Leave)
+	group.GET("", c.ListOnline)
+}
+
+func (c *PresenceController) Heartbeat(ctx *Context) (EmptyResponse, error) {
+	authContext, err := ctx.GetAuthContext()
+	if err != nil {
+		return EmptyResponse{}, err
+	}
+	return EmptyResponse{}, c.presence.Heartbeat(ctx, authContext.UserID)
+}
+
+func (c *PresenceController) Leave(ctx *Context) (EmptyResponse, error) {
+	authContext, err := ctx.GetAuthContext()
+	if err != nil {
+		return EmptyResponse{}, err
+	}
+	return EmptyResponse{}, c.presence.Leave(ctx, authContext.UserID)
+}
+
+func (c *PresenceController) ListOnline(ctx *Context) ([]OnlineUser, error) {
+	if _, err := ctx.GetAuthContext(); err != nil {
+		return nil, err
+	}
+	var window time.Duration
+	if value := ctx.Query("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, InvalidRequest.New("window must be a duration such as 30s")
+		}
+		window = parsed
+	}
+	return c.presence.ListOnline(ctx, window)
+}
@@ -0,0 +1,123 @@
+package ginboot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresenceService(t *testing.T) {
+	ctx := context.Background()
+	presence := NewPresenceService(NewInMemoryCacheService(DefaultInMemoryCacheConfig()), PresenceConfig{
+		TTL:       80 * time.Millisecond,
+		KeyPrefix: "presence:",
+	})
+
+	assert.NoError(t, presence.Heartbeat(ctx, "alice"))
+	time.Sleep(40 * time.Millisecond)
+	assert.NoError(t, presence.Heartbeat(ctx, "bob"))
+	assert.NoError(t, presence.Heartbeat(ctx, "carol"))
+
+	online, err := presence.ListOnline(ctx, 0)
+	assert.NoError(t, err)
+	assert.Len(t, online, 3)
+	assert.Equal(t, "alice", online[2].UserID)
+
+	recent, err := presence.ListOnline(ctx, 20*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Len(t, recent, 2)
+
+	assert.NoError(t, presence.Leave(ctx, "carol"))
+	isOnline, err := presence.IsOnline(ctx, "carol")
+	assert.NoError(t, err)
+	assert.False(t, isOnline)
+
+	time.Sleep(50 * time.Millisecond)
+	isOnline, _ = presence.IsOnline(ctx, "alice")
+	assert.False(t, isOnline)
+	online, err = presence.ListOnline(ctx, 0)
+	assert.NoError(t, err)
+	assert.Len(t, online, 1)
+	assert.Equal(t, "bob", online[0].UserID)
+}
+
+// rosterWriteCounter counts the writes of presence roster shards
+type rosterWriteCounter struct {
+	CacheService
+	writes int
+}
+
+func (c *rosterWriteCounter) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	if strings.Contains(key, ":roster:") {
+		c.writes++
+	}
+	return c.CacheService.Set(ctx, key, value, ttl, tags...)
+}
+
+func TestPresenceService_RosterInterval(t *testing.T) {
+	ctx := context.Background()
+	cache := &rosterWriteCounter{CacheService: NewInMemoryCacheService(DefaultInMemoryCacheConfig())}
+	presence := NewPresenceService(cache, DefaultPresenceConfig())
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, presence.Heartbeat(ctx, "alice"))
+	}
+	assert.NoError(t, presence.Heartbeat(ctx, "bob"))
+	assert.Equal(t, 2, cache.writes, "heartbeats within the interval only refresh the user's key")
+
+	online, err := presence.ListOnline(ctx, 0)
+	assert.NoError(t, err)
+	assert.Len(t, online, 2)
+
+	assert.NoError(t, presence.Leave(ctx, "alice"))
+	online, err = presence.ListOnline(ctx, 0)
+	assert.NoError(t, err)
+	assert.Len(t, online, 1)
+	assert.Equal(t, "bob", online[0].UserID)
+
+	// a user coming back after leaving is listed again right away
+	assert.NoError(t, presence.Heartbeat(ctx, "alice"))
+	online, _ = presence.ListOnline(ctx, 0)
+	assert.Len(t, online, 2)
+}
+
+func TestPresenceController(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	presence := NewPresenceService(NewInMemoryCacheService(DefaultInMemoryCacheConfig()), DefaultPresenceConfig())
+	server := &Server{engine: gin.New()}
+	group := server.Group("/presence", func(c *gin.Context) {
+		c.Set("user_id", "user-1")
+		c.Set("role", "user")
+	})
+	NewPresenceController(presence).Register(group)
+
+	w := httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest("POST", "/presence/heartbeat", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest("GET", "/presence?window=30s", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var online []OnlineUser
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &online))
+	assert.Len(t, online, 1)
+	assert.Equal(t, "user-1", online[0].UserID)
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest("GET", "/presence?window=soon", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest("DELETE", "/presence", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	isOnline, _ := presence.IsOnline(context.Background(), "user-1")
+	assert.False(t, isOnline)
+}
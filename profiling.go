@@ -0,0 +1,104 @@
+package ginboot
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ProfileDurationInvalid = ApiError{"PROFILE_DURATION_INVALID", "Profile duration must be a whole number of seconds up to %s"}
+
+// ProfilingConfig configures ProfilingAdmin
+type ProfilingConfig struct {
+	// Roles may read profiles, see RequireRoles. Requests need an
+	// authenticated user even when empty.
+	Roles []string
+	// CPUDuration is how long CPU profiles and traces run when the request
+	// has no seconds parameter
+	CPUDuration time.Duration
+	// MaxCPUDuration bounds the seconds parameter, e.g. below the Lambda
+	// function's timeout
+	MaxCPUDuration time.Duration
+	// BlockProfileRate and MutexProfileFraction enable the block and mutex
+	// profiles when positive, see runtime.SetBlockProfileRate and
+	// runtime.SetMutexProfileFraction
+	BlockProfileRate     int
+	MutexProfileFraction int
+}
+
+// DefaultProfilingConfig allows admins to capture CPU profiles of 10 seconds,
+// and up to 30 seconds
+func DefaultProfilingConfig() ProfilingConfig {
+	return ProfilingConfig{
+		Roles:          []string{"admin"},
+		CPUDuration:    10 * time.Second,
+		MaxCPUDuration: 30 * time.Second,
+	}
+}
+
+// profiles are the runtime/pprof profiles served by ProfilingAdmin
+var profiles = []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"}
+
+// ProfilingAdmin mounts net/http/pprof under path, restricted to the roles of
+// config. GET path/profile?seconds=5 captures a CPU profile and GET
+// path/heap a heap profile, for go tool pprof:
+//
+//	go tool pprof -http :8081 'https://api.example.com/admin/debug/pprof/profile?seconds=5'
+//
+// Profiling is opt-in: nothing is mounted unless this is called.
+func ProfilingAdmin(g *ControllerGroup, path string, config ProfilingConfig) {
+	defaults := DefaultProfilingConfig()
+	if config.CPUDuration <= 0 {
+		config.CPUDuration = defaults.CPUDuration
+	} else if config.CPUDuration < time.Second {
+		config.CPUDuration = time.Second
+	}
+	if config.MaxCPUDuration <= 0 {
+		config.MaxCPUDuration = defaults.MaxCPUDuration
+	}
+	if config.CPUDuration > config.MaxCPUDuration {
+		config.CPUDuration = config.MaxCPUDuration
+	}
+	if config.BlockProfileRate > 0 {
+		runtime.SetBlockProfileRate(config.BlockProfileRate)
+	}
+	if config.MutexProfileFraction > 0 {
+		runtime.SetMutexProfileFraction(config.MutexProfileFraction)
+	}
+
+	group := g.group.Group(path, RequireRoles(config.Roles...))
+	group.GET("/", gin.WrapF(pprof.Index))
+	group.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+	group.GET("/symbol", gin.WrapF(pprof.Symbol))
+	group.POST("/symbol", gin.WrapF(pprof.Symbol))
+	group.GET("/profile", boundCPUDuration(config), gin.WrapF(pprof.Profile))
+	group.GET("/trace", boundCPUDuration(config), gin.WrapF(pprof.Trace))
+	for _, name := range profiles {
+		group.GET("/"+name, gin.WrapH(pprof.Handler(name)))
+	}
+}
+
+// boundCPUDuration defaults the seconds parameter to config.CPUDuration and
+// rejects durations above config.MaxCPUDuration
+func boundCPUDuration(config ProfilingConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := c.Request.URL.Query()
+		raw := query.Get("seconds")
+		if raw == "" {
+			query.Set("seconds", strconv.Itoa(int(config.CPUDuration/time.Second)))
+			c.Request.URL.RawQuery = query.Encode()
+			c.Next()
+			return
+		}
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds <= 0 || time.Duration(seconds)*time.Second > config.MaxCPUDuration {
+			abortWithApiError(c, http.StatusBadRequest, ProfileDurationInvalid.New(config.MaxCPUDuration.String()))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,54 @@
+package ginboot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfilingAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{engine: gin.New()}
+	admin := server.Group("/admin", func(c *gin.Context) {
+		c.Set("user_id", "user-1")
+		c.Set("role", c.GetHeader("X-Role"))
+	})
+	config := DefaultProfilingConfig()
+	config.CPUDuration = time.Second
+	config.MaxCPUDuration = 2 * time.Second
+	ProfilingAdmin(admin, "/debug/pprof", config)
+
+	request := func(path, role string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-Role", role)
+		w := httptest.NewRecorder()
+		server.engine.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusForbidden, request("/admin/debug/pprof/heap", "user").Code)
+
+	w := request("/admin/debug/pprof/", "admin")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "goroutine")
+
+	w = request("/admin/debug/pprof/heap", "admin")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, w.Body.Bytes())
+
+	w = request("/admin/debug/pprof/goroutine?debug=1", "admin")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "TestProfilingAdmin")
+
+	assert.Equal(t, http.StatusBadRequest, request("/admin/debug/pprof/profile?seconds=60", "admin").Code)
+	assert.Equal(t, http.StatusBadRequest, request("/admin/debug/pprof/profile?seconds=soon", "admin").Code)
+
+	w = request("/admin/debug/pprof/profile", "admin")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, w.Body.Bytes())
+}
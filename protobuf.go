@@ -0,0 +1,148 @@
+package ginboot
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"reflect"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	MIMEProtobuf    = "application/x-protobuf"
+	MIMEOctetStream = "application/octet-stream"
+)
+
+var (
+	protoMessagesMu sync.RWMutex
+	protoMessages   = map[protoreflect.FullName]protoreflect.MessageType{}
+
+	protoMessageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+	bytesType        = reflect.TypeOf([]byte(nil))
+)
+
+// RegisterProtoMessages registers the message types accepted by handlers
+// taking a proto.Message, which learn the type of each request from the proto
+// parameter of its Content-Type, e.g. application/x-protobuf; proto=orders.v1.Order.
+// Handlers taking a concrete message type need no registration.
+func RegisterProtoMessages(messages ...proto.Message) {
+	protoMessagesMu.Lock()
+	defer protoMessagesMu.Unlock()
+	for _, message := range messages {
+		messageType := message.ProtoReflect().Type()
+		protoMessages[messageType.Descriptor().FullName()] = messageType
+	}
+}
+
+func registeredProtoMessage(name string) (proto.Message, error) {
+	protoMessagesMu.RLock()
+	defer protoMessagesMu.RUnlock()
+	messageType, ok := protoMessages[protoreflect.FullName(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown message type %q", name)
+	}
+	return messageType.New().Interface(), nil
+}
+
+// bindBinary decodes application/x-protobuf and application/octet-stream
+// bodies into protobuf messages or []byte. It returns false for other content
+// types and request types, which are bound by gin.
+func bindBinary(c *gin.Context, request interface{}) (bool, error) {
+	contentType, params, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if contentType != MIMEProtobuf && contentType != MIMEOctetStream {
+		return false, nil
+	}
+	target := reflect.ValueOf(request)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return false, nil
+	}
+	elem := target.Elem()
+
+	var decode func(body []byte) error
+	switch {
+	case elem.Type() == bytesType:
+		decode = func(body []byte) error {
+			elem.SetBytes(body)
+			return nil
+		}
+	case elem.Type() == protoMessageType:
+		// handler takes proto.Message, the type comes from the registry
+		decode = func(body []byte) error {
+			message, err := registeredProtoMessage(params["proto"])
+			if err != nil {
+				return err
+			}
+			if err := proto.Unmarshal(body, message); err != nil {
+				return err
+			}
+			elem.Set(reflect.ValueOf(message))
+			return nil
+		}
+	case elem.Kind() == reflect.Ptr && elem.Type().Implements(protoMessageType):
+		// handler takes *pb.Message, allocated here
+		decode = func(body []byte) error {
+			if elem.IsNil() {
+				elem.Set(reflect.New(elem.Type().Elem()))
+			}
+			return proto.Unmarshal(body, elem.Interface().(proto.Message))
+		}
+	case target.Type().Implements(protoMessageType):
+		// GetRequest called with a message
+		decode = func(body []byte) error {
+			return proto.Unmarshal(body, request.(proto.Message))
+		}
+	default:
+		return false, nil
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return true, err
+	}
+	return true, decode(body)
+}
+
+// ProtobufSerializer writes protobuf messages as application/x-protobuf to
+// clients accepting it, or that sent protobuf without an Accept header, and as
+// protojson otherwise. []byte responses are written as
+// application/octet-stream; other responses as JSON.
+type ProtobufSerializer struct{}
+
+func (ProtobufSerializer) Serialize(ctx *Context, status int, response interface{}) {
+	switch body := response.(type) {
+	case proto.Message:
+		if acceptsProtobuf(ctx) {
+			data, err := proto.Marshal(body)
+			if err != nil {
+				ctx.SendError(err)
+				return
+			}
+			name := body.ProtoReflect().Descriptor().FullName()
+			ctx.Data(status, MIMEProtobuf+"; proto="+string(name), data)
+			return
+		}
+		data, err := protojson.Marshal(body)
+		if err != nil {
+			ctx.SendError(err)
+			return
+		}
+		ctx.Data(status, "application/json; charset=utf-8", data)
+	case []byte:
+		ctx.Data(status, MIMEOctetStream, body)
+	default:
+		JSONSerializer{}.Serialize(ctx, status, response)
+	}
+}
+
+func acceptsProtobuf(ctx *Context) bool {
+	offers := []string{gin.MIMEJSON, MIMEProtobuf}
+	if ctx.ContentType() == MIMEProtobuf {
+		offers = []string{MIMEProtobuf, gin.MIMEJSON}
+	}
+	return ctx.NegotiateFormat(offers...) == MIMEProtobuf
+}
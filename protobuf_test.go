@@ -0,0 +1,68 @@
+package ginboot
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestProtobuf(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	RegisterProtoMessages(&wrapperspb.Int64Value{})
+
+	server := &Server{engine: gin.New()}
+	group := server.Group("/rpc", UseSerializer(ProtobufSerializer{}))
+	group.POST("/echo", func(ctx *Context, req *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+		return wrapperspb.String("echo " + req.GetValue()), nil
+	})
+	group.POST("/any", func(ctx *Context, req proto.Message) (string, error) {
+		return string(req.ProtoReflect().Descriptor().FullName()), nil
+	})
+	group.POST("/raw", func(ctx *Context, body []byte) ([]byte, error) {
+		return bytes.ToUpper(body), nil
+	})
+
+	post := func(path, contentType, accept string, body []byte) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		server.engine.ServeHTTP(w, req)
+		return w
+	}
+
+	body, err := proto.Marshal(wrapperspb.String("hi"))
+	assert.NoError(t, err)
+	w := post("/rpc/echo", MIMEProtobuf, "", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-protobuf; proto=google.protobuf.StringValue", w.Header().Get("Content-Type"))
+	var response wrapperspb.StringValue
+	assert.NoError(t, proto.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "echo hi", response.GetValue())
+
+	w = post("/rpc/echo", MIMEProtobuf, "application/json", body)
+	assert.Equal(t, `"echo hi"`, w.Body.String())
+
+	w = post("/rpc/echo", MIMEProtobuf, "", []byte{0xff, 0xff})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_REQUEST")
+
+	body, err = proto.Marshal(wrapperspb.Int64(42))
+	assert.NoError(t, err)
+	w = post("/rpc/any", MIMEProtobuf+"; proto=google.protobuf.Int64Value", "", body)
+	assert.Equal(t, `"google.protobuf.Int64Value"`, w.Body.String())
+	w = post("/rpc/any", MIMEProtobuf+"; proto=google.protobuf.BoolValue", "", body)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = post("/rpc/raw", MIMEOctetStream, "", []byte("abc"))
+	assert.Equal(t, MIMEOctetStream, w.Header().Get("Content-Type"))
+	assert.Equal(t, "ABC", w.Body.String())
+}
@@ -0,0 +1,181 @@
+package ginboot
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var InvalidFilter = ApiError{"INVALID_FILTER", "Invalid filter: %s"}
+
+const filterFieldsKey = "ginboot.filterFields"
+
+// FilterType is the type query filter values are parsed to
+type FilterType int
+
+const (
+	FilterString FilterType = iota
+	FilterInt
+	FilterFloat
+	FilterBool
+	// FilterTime parses RFC 3339 timestamps
+	FilterTime
+)
+
+// FilterField allows clients to filter on a field with filter[Name]=op:value
+type FilterField struct {
+	Name string
+	// Field is the repository field the filter applies to, Name when empty
+	Field string
+	Type  FilterType
+	// Operators restricts the operators clients may use; all comparison
+	// operators, and contains for strings, are allowed when empty
+	Operators []Operator
+}
+
+// Filterable sets the fields a group or route's clients may filter on with
+// GetFilters. Filters on other fields are rejected.
+//
+//	group.GET("", c.ListUsers, ginboot.Filterable(
+//		ginboot.FilterField{Name: "name"},
+//		ginboot.FilterField{Name: "age", Type: ginboot.FilterInt, Operators: []ginboot.Operator{ginboot.OpGte, ginboot.OpLte}},
+//	))
+func Filterable(fields ...FilterField) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(filterFieldsKey, fields)
+		c.Next()
+	}
+}
+
+// GetFilters parses filter[field]=op:value query parameters, e.g.
+// filter[name]=eq:john&filter[age]=gte:18, into a query for the repository's
+// FindByQuery methods. A value without an operator is compared with eq, and in
+// takes comma separated values. Repeated parameters are combined with and.
+// Invalid filters, or filters on fields not made Filterable, are answered with
+// 400 INVALID_FILTER, and the error is returned.
+func (c *Context) GetFilters() (*Query, error) {
+	value, _ := c.Get(filterFieldsKey)
+	fields, _ := value.([]FilterField)
+	criteria, err := parseFilters(c.Request.URL.Query(), fields)
+	if err != nil {
+		SendError(c.Context, err)
+		c.Abort()
+		return nil, err
+	}
+	return Where(criteria...), nil
+}
+
+func parseFilters(values url.Values, fields []FilterField) ([]Criteria, error) {
+	allowed := make(map[string]FilterField, len(fields))
+	for _, field := range fields {
+		allowed[field.Name] = field
+	}
+
+	// parameters are sorted for the criteria to come out in a stable order
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var criteria []Criteria
+	for _, key := range keys {
+		if !strings.HasPrefix(key, "filter[") || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		name := key[len("filter[") : len(key)-1]
+		field, ok := allowed[name]
+		if !ok {
+			return nil, InvalidFilter.New(fmt.Sprintf("filtering on %s is not allowed", name))
+		}
+		for _, value := range values[key] {
+			parsed, err := field.parse(value)
+			if err != nil {
+				return nil, err
+			}
+			criteria = append(criteria, parsed)
+		}
+	}
+	return criteria, nil
+}
+
+func (f FilterField) parse(value string) (Criteria, error) {
+	operator, operand := OpEq, value
+	if op, rest, ok := strings.Cut(value, ":"); ok && isFilterOperator(Operator(op)) {
+		operator, operand = Operator(op), rest
+	}
+	if !f.allows(operator) {
+		return Criteria{}, InvalidFilter.New(fmt.Sprintf("operator %s is not allowed on %s", operator, f.Name))
+	}
+
+	field := f.Field
+	if field == "" {
+		field = f.Name
+	}
+	if operator == OpIn {
+		parts := strings.Split(operand, ",")
+		values := make([]interface{}, len(parts))
+		for i, part := range parts {
+			parsed, err := f.parseValue(part)
+			if err != nil {
+				return Criteria{}, err
+			}
+			values[i] = parsed
+		}
+		return In(field, values...), nil
+	}
+	if operator == OpContains {
+		return Contains(field, operand), nil
+	}
+	parsed, err := f.parseValue(operand)
+	if err != nil {
+		return Criteria{}, err
+	}
+	return Criteria{Operator: operator, Field: field, Value: parsed}, nil
+}
+
+func (f FilterField) allows(operator Operator) bool {
+	if len(f.Operators) == 0 {
+		return operator != OpContains || f.Type == FilterString
+	}
+	for _, allowed := range f.Operators {
+		if allowed == operator {
+			return true
+		}
+	}
+	return false
+}
+
+func (f FilterField) parseValue(value string) (interface{}, error) {
+	var parsed interface{}
+	var err error
+	switch f.Type {
+	case FilterInt:
+		parsed, err = strconv.ParseInt(value, 10, 64)
+	case FilterFloat:
+		parsed, err = strconv.ParseFloat(value, 64)
+	case FilterBool:
+		parsed, err = strconv.ParseBool(value)
+	case FilterTime:
+		parsed, err = time.Parse(time.RFC3339, value)
+	default:
+		parsed = value
+	}
+	if err != nil {
+		return nil, InvalidFilter.New(fmt.Sprintf("%q is not a valid value for %s", value, f.Name))
+	}
+	return parsed, nil
+}
+
+func isFilterOperator(operator Operator) bool {
+	switch operator {
+	case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn, OpContains:
+		return true
+	}
+	return false
+}
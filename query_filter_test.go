@@ -0,0 +1,83 @@
+package ginboot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var testFilterFields = []FilterField{
+	{Name: "name"},
+	{Name: "age", Type: FilterInt},
+	{Name: "active", Field: "is_active", Type: FilterBool, Operators: []Operator{OpEq}},
+	{Name: "created", Field: "created_at", Type: FilterTime},
+}
+
+func TestParseFilters(t *testing.T) {
+	created := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []Criteria
+		wantErr  bool
+	}{
+		{"no filters", "page=2", nil, false},
+		{"implicit eq", "filter[name]=john", []Criteria{Eq("name", "john")}, false},
+		{"typed operator", "filter[age]=gte:18", []Criteria{Gte("age", int64(18))}, false},
+		{"range", "filter[age]=gte:18&filter[age]=lt:65", []Criteria{Gte("age", int64(18)), Lt("age", int64(65))}, false},
+		{"in", "filter[age]=in:18,21", []Criteria{In("age", int64(18), int64(21))}, false},
+		{"contains", "filter[name]=contains:jo", []Criteria{Contains("name", "jo")}, false},
+		{"colon in value", "filter[name]=a:b", []Criteria{Eq("name", "a:b")}, false},
+		{"mapped field", "filter[active]=true&filter[created]=gt:2024-05-01T00:00:00Z",
+			[]Criteria{Eq("is_active", true), Gt("created_at", created)}, false},
+		{"field not allowed", "filter[password]=x", nil, true},
+		{"operator not allowed", "filter[active]=ne:true", nil, true},
+		{"contains on number", "filter[age]=contains:1", nil, true},
+		{"invalid value", "filter[age]=gte:old", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			assert.NoError(t, err)
+
+			criteria, err := parseFilters(values, testFilterFields)
+			if tt.wantErr {
+				var apiErr ApiError
+				assert.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, InvalidFilter.ErrorCode, apiErr.ErrorCode)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, criteria)
+		})
+	}
+}
+
+func TestContext_GetFilters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var query *Query
+	server := &Server{engine: gin.New()}
+	server.Group("/").GET("/users", func(ctx *Context) (*Query, error) {
+		var err error
+		query, err = ctx.GetFilters()
+		return query, err
+	}, Filterable(testFilterFields...))
+
+	w := httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users?filter[name]=john&filter[age]=gte:18", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, And(Gte("age", int64(18)), Eq("name", "john")), query.Criteria)
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users?filter[role]=admin", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_FILTER")
+}
@@ -0,0 +1,69 @@
+package ginboot
+
+import (
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// QueryOptions are per-call options for repository reads. Each backend applies
+// the options it supports and ignores the rest.
+type QueryOptions struct {
+	// ConsistentRead requests a strongly consistent read on DynamoDB and reads
+	// from the primary on MongoDB
+	ConsistentRead bool
+	// ReadPreference and ReadConcern apply to MongoDB
+	ReadPreference *readpref.ReadPref
+	ReadConcern    *readconcern.ReadConcern
+	// ForUpdate locks the selected rows with SELECT ... FOR UPDATE on SQL backends
+	ForUpdate bool
+	// FindOptions are passed through to MongoDB find operations
+	FindOptions []*options.FindOptions
+}
+
+// QueryOption configures QueryOptions, e.g. repo.FindById(id, ginboot.ConsistentRead())
+type QueryOption func(*QueryOptions)
+
+// NewQueryOptions applies the options in order, for use by repository implementations
+func NewQueryOptions(opts ...QueryOption) QueryOptions {
+	var queryOptions QueryOptions
+	for _, opt := range opts {
+		opt(&queryOptions)
+	}
+	return queryOptions
+}
+
+// ConsistentRead reads the latest committed data instead of a possibly stale replica
+func ConsistentRead() QueryOption {
+	return func(o *QueryOptions) {
+		o.ConsistentRead = true
+	}
+}
+
+// WithReadPreference sets the MongoDB read preference, e.g. readpref.SecondaryPreferred()
+func WithReadPreference(pref *readpref.ReadPref) QueryOption {
+	return func(o *QueryOptions) {
+		o.ReadPreference = pref
+	}
+}
+
+// WithReadConcern sets the MongoDB read concern, e.g. readconcern.Majority()
+func WithReadConcern(concern *readconcern.ReadConcern) QueryOption {
+	return func(o *QueryOptions) {
+		o.ReadConcern = concern
+	}
+}
+
+// ForUpdate locks the rows read on SQL backends until the transaction ends
+func ForUpdate() QueryOption {
+	return func(o *QueryOptions) {
+		o.ForUpdate = true
+	}
+}
+
+// WithFindOptions passes MongoDB find options such as sort, limit or projection
+func WithFindOptions(findOpts ...*options.FindOptions) QueryOption {
+	return func(o *QueryOptions) {
+		o.FindOptions = append(o.FindOptions, findOpts...)
+	}
+}
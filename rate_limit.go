@@ -0,0 +1,225 @@
+package ginboot
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var RateLimited = ApiError{"RATE_LIMITED", "Too many requests, please retry later"}
+
+type RateLimitAlgorithm int
+
+const (
+	// SlidingWindow allows Limit requests in any Window, weighting the previous
+	// fixed window's count by how much of it still overlaps
+	SlidingWindow RateLimitAlgorithm = iota
+	// TokenBucket refills Limit tokens per Window, allowing bursts of up to
+	// Burst requests
+	TokenBucket
+)
+
+type RateLimitConfig struct {
+	Algorithm RateLimitAlgorithm
+	Limit     int
+	Window    time.Duration
+	// Burst is the token bucket's capacity, Limit when zero
+	Burst int
+	// Key identifies the client, e.g. RateLimitByIP or RateLimitByUser
+	Key func(c *gin.Context) string
+	// Store keeps the counters; a shared CacheService backend enforces the
+	// limit across instances, approximately, as updates of the same key from
+	// different instances are not atomic
+	Store CacheService
+}
+
+// DefaultRateLimitConfig allows 100 requests per minute per client IP, counted
+// in memory with a sliding window
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Algorithm: SlidingWindow,
+		Limit:     100,
+		Window:    time.Minute,
+		Key:       RateLimitByIP,
+		Store:     NewInMemoryCacheService(DefaultInMemoryCacheConfig()),
+	}
+}
+
+// RateLimitByIP limits each client IP, as returned by gin's ClientIP. It
+// trusts X-Forwarded-For from the proxies set with
+// server.SetTrustedProxies, which gin defaults to every address, so
+// set them to your load balancers or clients can pick their own IP. Use
+// RateLimitByRemoteIP when the server is not behind a proxy.
+func RateLimitByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// RateLimitByRemoteIP limits each address connecting to the server, ignoring
+// forwarding headers
+func RateLimitByRemoteIP(c *gin.Context) string {
+	return "ip:" + c.RemoteIP()
+}
+
+// RateLimitByUser limits each user authenticated by JWTAuthMiddleware, and
+// anonymous requests by IP
+func RateLimitByUser(c *gin.Context) string {
+	if userID := c.GetString("user_id"); userID != "" {
+		return "user:" + userID
+	}
+	return RateLimitByIP(c)
+}
+
+// RateLimitMiddleware rejects requests over the limit with 429 RATE_LIMITED
+// and a Retry-After header. Responses carry X-RateLimit-Limit and
+// X-RateLimit-Remaining. Requests are let through when the store fails.
+func RateLimitMiddleware(config RateLimitConfig) gin.HandlerFunc {
+	config = rateLimitDefaults(config)
+	limiter := &rateLimiter{config: config}
+
+	return func(c *gin.Context) {
+		allowed, remaining, retryAfter, err := limiter.take(c.Request.Context(), config.Key(c), time.Now())
+		if err != nil {
+			LoggerFromContext(c.Request.Context()).Warn("ginboot: rate limit store failed", "error", err)
+			c.Next()
+			return
+		}
+		c.Header("X-RateLimit-Limit", strconv.Itoa(config.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		if !allowed {
+			SetRetryAfter(c, retryAfter)
+			abortWithApiError(c, http.StatusTooManyRequests, RateLimited)
+			return
+		}
+		c.Next()
+	}
+}
+
+func rateLimitDefaults(config RateLimitConfig) RateLimitConfig {
+	defaults := DefaultRateLimitConfig()
+	if config.Limit <= 0 {
+		config.Limit = defaults.Limit
+	}
+	if config.Window <= 0 {
+		config.Window = defaults.Window
+	}
+	if config.Key == nil {
+		config.Key = defaults.Key
+	}
+	if config.Store == nil {
+		config.Store = defaults.Store
+	}
+	return config
+}
+
+type rateLimiter struct {
+	config RateLimitConfig
+	// locks serialize the read-modify-write of a key's counters within the
+	// process; keys share one of them by hash, so a slow store only delays
+	// the keys hashed to the same lock
+	locks [64]sync.Mutex
+}
+
+func (l *rateLimiter) lock(key string) *sync.Mutex {
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return &l.locks[hash.Sum32()%uint32(len(l.locks))]
+}
+
+func (l *rateLimiter) take(ctx context.Context, key string, now time.Time) (bool, int, time.Duration, error) {
+	mu := l.lock(key)
+	mu.Lock()
+	defer mu.Unlock()
+
+	key = "ratelimit:" + key
+	stored, ok, err := l.config.Store.Get(ctx, key)
+	if err != nil {
+		return false, 0, 0, err
+	}
+	var state rateLimitState
+	if ok {
+		// a corrupt entry starts over
+		ok = json.Unmarshal(stored, &state) == nil
+	}
+
+	var allowed bool
+	var remaining int
+	var retryAfter time.Duration
+	if l.config.Algorithm == TokenBucket {
+		allowed, remaining, retryAfter = l.tokenBucket(&state, ok, now)
+	} else {
+		allowed, remaining, retryAfter = l.slidingWindow(&state, now)
+	}
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return false, 0, 0, err
+	}
+	if err := l.config.Store.Set(ctx, key, encoded, 2*l.config.Window); err != nil {
+		return false, 0, 0, err
+	}
+	return allowed, remaining, retryAfter, nil
+}
+
+func (l *rateLimiter) tokenBucket(state *rateLimitState, found bool, now time.Time) (bool, int, time.Duration) {
+	capacity := float64(l.config.Burst)
+	if capacity <= 0 {
+		capacity = float64(l.config.Limit)
+	}
+	perSecond := float64(l.config.Limit) / l.config.Window.Seconds()
+	if !found {
+		state.Tokens = capacity
+	} else {
+		elapsed := now.Sub(time.Unix(0, state.Refilled)).Seconds()
+		state.Tokens = math.Min(capacity, state.Tokens+elapsed*perSecond)
+	}
+	state.Refilled = now.UnixNano()
+
+	if state.Tokens < 1 {
+		return false, 0, time.Duration((1 - state.Tokens) / perSecond * float64(time.Second))
+	}
+	state.Tokens--
+	return true, int(state.Tokens), 0
+}
+
+func (l *rateLimiter) slidingWindow(state *rateLimitState, now time.Time) (bool, int, time.Duration) {
+	window := l.config.Window
+	start := now.Truncate(window)
+	switch stored := time.Unix(0, state.WindowStart); {
+	case stored.Equal(start):
+	case stored.Equal(start.Add(-window)):
+		state.Previous, state.Current = state.Current, 0
+	default:
+		state.Previous, state.Current = 0, 0
+	}
+	state.WindowStart = start.UnixNano()
+
+	elapsed := now.Sub(start)
+	previous, current, limit := float64(state.Previous), float64(state.Current), float64(l.config.Limit)
+	estimated := previous*(1-float64(elapsed)/float64(window)) + current
+	if estimated+1 > limit {
+		if current+1 > limit || previous == 0 {
+			return false, 0, window - elapsed
+		}
+		// wait until enough of the previous window has slid out
+		return false, 0, time.Duration(float64(window)*(1-(limit-current-1)/previous)) - elapsed
+	}
+	state.Current++
+	return true, int(limit - math.Ceil(estimated) - 1), 0
+}
+
+// rateLimitState holds the counters of either algorithm
+type rateLimitState struct {
+	// token bucket
+	Tokens   float64 `json:"tokens,omitempty"`
+	Refilled int64   `json:"refilled,omitempty"`
+	// sliding window
+	Previous    int   `json:"previous,omitempty"`
+	Current     int   `json:"current,omitempty"`
+	WindowStart int64 `json:"window_start,omitempty"`
+}
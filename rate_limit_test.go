@@ -0,0 +1,147 @@
+package ginboot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_SlidingWindow(t *testing.T) {
+	ctx := context.Background()
+	limiter := &rateLimiter{config: RateLimitConfig{
+		Algorithm: SlidingWindow,
+		Limit:     2,
+		Window:    time.Minute,
+		Store:     NewInMemoryCacheService(DefaultInMemoryCacheConfig()),
+	}}
+	start := time.Now().Truncate(time.Minute)
+
+	allowed, remaining, _, err := limiter.take(ctx, "a", start)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 1, remaining)
+	allowed, _, _, _ = limiter.take(ctx, "a", start.Add(time.Second))
+	assert.True(t, allowed)
+	allowed, _, retryAfter, _ := limiter.take(ctx, "a", start.Add(2*time.Second))
+	assert.False(t, allowed)
+	assert.Equal(t, 58*time.Second, retryAfter)
+
+	allowed, _, _, _ = limiter.take(ctx, "b", start)
+	assert.True(t, allowed, "keys are limited separately")
+
+	// a quarter into the next window, the previous two requests weigh 1.5
+	allowed, _, retryAfter, _ = limiter.take(ctx, "a", start.Add(75*time.Second))
+	assert.False(t, allowed)
+	assert.Equal(t, 15*time.Second, retryAfter)
+	allowed, _, _, _ = limiter.take(ctx, "a", start.Add(90*time.Second))
+	assert.True(t, allowed)
+}
+
+func TestRateLimiter_TokenBucket(t *testing.T) {
+	ctx := context.Background()
+	limiter := &rateLimiter{config: RateLimitConfig{
+		Algorithm: TokenBucket,
+		Limit:     60,
+		Window:    time.Minute,
+		Burst:     2,
+		Store:     NewInMemoryCacheService(DefaultInMemoryCacheConfig()),
+	}}
+	now := time.Now()
+
+	allowed, _, _, _ := limiter.take(ctx, "a", now)
+	assert.True(t, allowed)
+	allowed, _, _, _ = limiter.take(ctx, "a", now)
+	assert.True(t, allowed)
+	allowed, _, retryAfter, _ := limiter.take(ctx, "a", now)
+	assert.False(t, allowed)
+	assert.Equal(t, time.Second, retryAfter)
+	allowed, _, _, _ = limiter.take(ctx, "a", now.Add(time.Second))
+	assert.True(t, allowed)
+}
+
+// slowGetCache blocks reads of one key until release is closed
+type slowGetCache struct {
+	CacheService
+	key     string
+	release chan struct{}
+}
+
+func (c *slowGetCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if key == c.key {
+		<-c.release
+	}
+	return c.CacheService.Get(ctx, key)
+}
+
+func TestRateLimiter_Concurrency(t *testing.T) {
+	ctx := context.Background()
+	store := &slowGetCache{
+		CacheService: NewInMemoryCacheService(DefaultInMemoryCacheConfig()),
+		key:          "ratelimit:slow",
+		release:      make(chan struct{}),
+	}
+	limiter := &rateLimiter{config: RateLimitConfig{Limit: 10, Window: time.Minute, Store: store}}
+	assert.NotSame(t, limiter.lock("slow"), limiter.lock("fast"))
+
+	// a slow store read holds back only its own key
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		limiter.take(ctx, "slow", time.Now())
+	}()
+	allowed, _, _, err := limiter.take(ctx, "fast", time.Now())
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	close(store.release)
+	<-done
+
+	// concurrent requests of a key are counted exactly
+	now := time.Now().Truncate(time.Minute)
+	var wg sync.WaitGroup
+	var allowedCount atomic.Int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if allowed, _, _, _ := limiter.take(ctx, "burst", now); allowed {
+				allowedCount.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(10), allowedCount.Load())
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{engine: gin.New()}
+	config := DefaultRateLimitConfig()
+	config.Limit = 1
+	config.Key = RateLimitByUser
+	server.Group("", RateLimitMiddleware(config)).GET("/posts", func() (string, error) {
+		return "ok", nil
+	})
+
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts", nil))
+		return w
+	}
+	w := get()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+	w = get()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"error_code":"RATE_LIMITED","message":"Too many requests, please retry later"}`, w.Body.String())
+}
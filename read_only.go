@@ -0,0 +1,81 @@
+package ginboot
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+var ServiceReadOnly = ApiError{"SERVICE_READ_ONLY", "Service is read-only, please retry later"}
+
+// ErrReadOnly is returned by writes to a repository whose read-only switch is
+// on. SendError answers it with 503 SERVICE_READ_ONLY.
+var ErrReadOnly = errors.New("repository is read-only")
+
+// ReadOnlySwitch rejects the writes of repositories wrapped with WithReadOnly
+// while it is on, e.g. during a database failover or migration
+type ReadOnlySwitch struct {
+	enabled atomic.Bool
+}
+
+// ReadOnlyMode is the global switch, applying to every repository wrapped with
+// WithReadOnly
+var ReadOnlyMode = &ReadOnlySwitch{}
+
+func (s *ReadOnlySwitch) Enable() {
+	s.enabled.Store(true)
+}
+
+func (s *ReadOnlySwitch) Disable() {
+	s.enabled.Store(false)
+}
+
+func (s *ReadOnlySwitch) Enabled() bool {
+	return s.enabled.Load()
+}
+
+var repositoryWrites = map[string]bool{
+	"Save":         true,
+	"SaveOrUpdate": true,
+	"SaveAll":      true,
+	"Update":       true,
+	"Delete":       true,
+}
+
+// WithReadOnly fails writes of the repository with ErrReadOnly while
+// ReadOnlyMode or the repository's own switch is on; reads continue. A nil
+// switch only follows ReadOnlyMode. Transactions start, but writes inside them
+// are rejected by wrapped repositories.
+func WithReadOnly[T any](repo GenericRepository[T], sw *ReadOnlySwitch) GenericRepository[T] {
+	return decorate(repo, func(ctx context.Context, operation string, call func(ctx context.Context) error) error {
+		if repositoryWrites[operation] && (ReadOnlyMode.Enabled() || sw != nil && sw.Enabled()) {
+			return ErrReadOnly
+		}
+		return call(ctx)
+	})
+}
+
+// ReadOnlyState is the body of the read-only admin routes
+type ReadOnlyState struct {
+	Enabled bool `json:"enabled"`
+}
+
+// ReadOnlyAdmin registers GET path, returning the state of the switch, and PUT
+// path, setting it, e.g. to start a migration without a deployment. A nil
+// switch controls ReadOnlyMode. Protect the group, e.g. with RequireRoles.
+func ReadOnlyAdmin(g *ControllerGroup, path string, sw *ReadOnlySwitch) {
+	if sw == nil {
+		sw = ReadOnlyMode
+	}
+	g.GET(path, func() (ReadOnlyState, error) {
+		return ReadOnlyState{Enabled: sw.Enabled()}, nil
+	})
+	g.PUT(path, func(ctx *Context, state ReadOnlyState) (ReadOnlyState, error) {
+		if state.Enabled {
+			sw.Enable()
+		} else {
+			sw.Disable()
+		}
+		return state, nil
+	})
+}
@@ -0,0 +1,62 @@
+package ginboot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithReadOnly(t *testing.T) {
+	ctx := context.Background()
+	inner := newMemoryRepository[decoratedDocument]()
+	sw := &ReadOnlySwitch{}
+	repo := WithReadOnly[decoratedDocument](inner, sw)
+	assert.NoError(t, repo.Save(ctx, decoratedDocument{ID: "1", Name: "first"}))
+
+	sw.Enable()
+	assert.ErrorIs(t, repo.Save(ctx, decoratedDocument{ID: "2"}), ErrReadOnly)
+	assert.ErrorIs(t, repo.Delete(ctx, "1"), ErrReadOnly)
+	doc, err := repo.FindById(ctx, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "first", doc.Name)
+
+	sw.Disable()
+	ReadOnlyMode.Enable()
+	defer ReadOnlyMode.Disable()
+	assert.ErrorIs(t, repo.Update(ctx, doc), ErrReadOnly)
+	ReadOnlyMode.Disable()
+	assert.NoError(t, repo.Update(ctx, doc))
+}
+
+func TestReadOnlyAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	sw := &ReadOnlySwitch{}
+	repo := WithReadOnly[decoratedDocument](newMemoryRepository[decoratedDocument](), sw)
+	server := &Server{engine: gin.New()}
+	ReadOnlyAdmin(server.Group("/admin"), "/read-only", sw)
+	server.Group("").POST("/docs", func(ctx *Context) (EmptyResponse, error) {
+		return EmptyResponse{}, repo.Save(ctx, decoratedDocument{ID: "1"})
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/admin/read-only", strings.NewReader(`{"enabled":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	server.engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, sw.Enabled())
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/read-only", nil))
+	assert.JSONEq(t, `{"enabled":true}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/docs", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "SERVICE_READ_ONLY")
+}
@@ -0,0 +1,351 @@
+package ginboot
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"time"
+)
+
+// RepositoryCall describes a finished repository call, for RepositoryMetrics
+type RepositoryCall struct {
+	// Entity is the name of the document type, e.g. Post
+	Entity    string
+	Operation string
+	Duration  time.Duration
+	Err       error
+}
+
+// RepositoryMetrics records repository calls, e.g. as Prometheus histograms
+type RepositoryMetrics interface {
+	Observe(call RepositoryCall)
+}
+
+// RepositoryMetricsFunc adapts a function to the RepositoryMetrics interface
+type RepositoryMetricsFunc func(call RepositoryCall)
+
+func (f RepositoryMetricsFunc) Observe(call RepositoryCall) {
+	f(call)
+}
+
+// RetryPolicy configures WithRetry
+type RetryPolicy struct {
+	// MaxAttempts includes the first call
+	MaxAttempts int
+	// InitialBackoff is doubled after every attempt, up to MaxBackoff, and
+	// jittered so clients don't retry in lockstep
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Retryable decides which errors are retried
+	Retryable func(err error) bool
+}
+
+// DefaultRetryPolicy makes up to three attempts for errors IsRetryable accepts,
+// backing off from 50ms
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 50 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Retryable:      IsRetryable,
+	}
+}
+
+// repositoryInterceptor runs a repository call, named by operation
+type repositoryInterceptor func(ctx context.Context, operation string, call func(ctx context.Context) error) error
+
+// WithLogging logs every call of the repository with its duration and error.
+// A nil logf logs through the Logger of the call's context, so lines carry the
+// request ID set by RequestLogging.
+func WithLogging[T any](repo GenericRepository[T], logf func(format string, args ...interface{})) GenericRepository[T] {
+	entity := entityName[T]()
+	return decorate(repo, func(ctx context.Context, operation string, call func(ctx context.Context) error) error {
+		start := time.Now()
+		err := call(ctx)
+		if logf == nil {
+			logger := LoggerFromContext(ctx).With("entity", entity, "operation", operation, "duration", time.Since(start))
+			if err != nil {
+				logger.Error("ginboot: repository call failed", "error", err)
+			} else {
+				logger.Debug("ginboot: repository call")
+			}
+			return err
+		}
+		if err != nil {
+			logf("ginboot: %s.%s failed after %s: %v", entity, operation, time.Since(start), err)
+		} else {
+			logf("ginboot: %s.%s took %s", entity, operation, time.Since(start))
+		}
+		return err
+	})
+}
+
+// WithMetrics reports every call of the repository to metrics
+func WithMetrics[T any](repo GenericRepository[T], metrics RepositoryMetrics) GenericRepository[T] {
+	entity := entityName[T]()
+	return decorate(repo, func(ctx context.Context, operation string, call func(ctx context.Context) error) error {
+		start := time.Now()
+		err := call(ctx)
+		metrics.Observe(RepositoryCall{
+			Entity:    entity,
+			Operation: operation,
+			Duration:  time.Since(start),
+			Err:       err,
+		})
+		return err
+	})
+}
+
+// WithRetry retries calls of the repository failing with a retryable error.
+// Writes are retried too, so a write that reached the database before a
+// timeout may fail with ErrDuplicateKey on the next attempt. WithTransaction
+// is not retried, as fn may have side effects outside the database.
+func WithRetry[T any](repo GenericRepository[T], policy RetryPolicy) GenericRepository[T] {
+	defaults := DefaultRetryPolicy()
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = defaults.MaxAttempts
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = defaults.Retryable
+	}
+	return decorate(repo, func(ctx context.Context, operation string, call func(ctx context.Context) error) error {
+		if operation == "WithTransaction" {
+			return call(ctx)
+		}
+		backoff := policy.InitialBackoff
+		for attempt := 1; ; attempt++ {
+			err := call(ctx)
+			if err == nil || attempt >= policy.MaxAttempts || !policy.Retryable(err) {
+				return err
+			}
+			wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(wait):
+			}
+			backoff *= 2
+			if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+				backoff = policy.MaxBackoff
+			}
+		}
+	})
+}
+
+func entityName[T any]() string {
+	return reflect.TypeOf((*T)(nil)).Elem().Name()
+}
+
+// decoratedRepository runs every call of the wrapped repository through an
+// interceptor. Decorators compose, e.g. WithRetry(WithMetrics(repo, m), p),
+// but hide backend specific methods such as MongoRepository.Named.
+type decoratedRepository[T any] struct {
+	// next returns the repository serving the call, which may depend on ctx
+	next      func(ctx context.Context) (GenericRepository[T], error)
+	intercept repositoryInterceptor
+}
+
+func decorate[T any](repo GenericRepository[T], intercept repositoryInterceptor) GenericRepository[T] {
+	return &decoratedRepository[T]{
+		next: func(ctx context.Context) (GenericRepository[T], error) {
+			return repo, nil
+		},
+		intercept: intercept,
+	}
+}
+
+func (r *decoratedRepository[T]) call(ctx context.Context, operation string, fn func(ctx context.Context, next GenericRepository[T]) error) error {
+	return r.intercept(ctx, operation, func(ctx context.Context) error {
+		next, err := r.next(ctx)
+		if err != nil {
+			return err
+		}
+		return fn(ctx, next)
+	})
+}
+
+func (r *decoratedRepository[T]) FindById(ctx context.Context, id string, opts ...QueryOption) (T, error) {
+	var result T
+	err := r.call(ctx, "FindById", func(ctx context.Context, next GenericRepository[T]) (err error) {
+		result, err = next.FindById(ctx, id, opts...)
+		return err
+	})
+	return result, err
+}
+
+func (r *decoratedRepository[T]) FindAllById(ctx context.Context, ids []string, opts ...QueryOption) ([]T, error) {
+	var result []T
+	err := r.call(ctx, "FindAllById", func(ctx context.Context, next GenericRepository[T]) (err error) {
+		result, err = next.FindAllById(ctx, ids, opts...)
+		return err
+	})
+	return result, err
+}
+
+func (r *decoratedRepository[T]) FindOneBy(ctx context.Context, field string, value interface{}, opts ...QueryOption) (T, error) {
+	var result T
+	err := r.call(ctx, "FindOneBy", func(ctx context.Context, next GenericRepository[T]) (err error) {
+		result, err = next.FindOneBy(ctx, field, value, opts...)
+		return err
+	})
+	return result, err
+}
+
+func (r *decoratedRepository[T]) FindOneByFilters(ctx context.Context, filters map[string]interface{}, opts ...QueryOption) (T, error) {
+	var result T
+	err := r.call(ctx, "FindOneByFilters", func(ctx context.Context, next GenericRepository[T]) (err error) {
+		result, err = next.FindOneByFilters(ctx, filters, opts...)
+		return err
+	})
+	return result, err
+}
+
+func (r *decoratedRepository[T]) FindBy(ctx context.Context, field string, value interface{}, opts ...QueryOption) ([]T, error) {
+	var result []T
+	err := r.call(ctx, "FindBy", func(ctx context.Context, next GenericRepository[T]) (err error) {
+		result, err = next.FindBy(ctx, field, value, opts...)
+		return err
+	})
+	return result, err
+}
+
+func (r *decoratedRepository[T]) FindByFilters(ctx context.Context, filters map[string]interface{}, opts ...QueryOption) ([]T, error) {
+	var result []T
+	err := r.call(ctx, "FindByFilters", func(ctx context.Context, next GenericRepository[T]) (err error) {
+		result, err = next.FindByFilters(ctx, filters, opts...)
+		return err
+	})
+	return result, err
+}
+
+func (r *decoratedRepository[T]) FindAll(ctx context.Context, opts ...QueryOption) ([]T, error) {
+	var result []T
+	err := r.call(ctx, "FindAll", func(ctx context.Context, next GenericRepository[T]) (err error) {
+		result, err = next.FindAll(ctx, opts...)
+		return err
+	})
+	return result, err
+}
+
+func (r *decoratedRepository[T]) FindAllPaginated(ctx context.Context, pageRequest PageRequest, opts ...QueryOption) (PageResponse[T], error) {
+	var result PageResponse[T]
+	err := r.call(ctx, "FindAllPaginated", func(ctx context.Context, next GenericRepository[T]) (err error) {
+		result, err = next.FindAllPaginated(ctx, pageRequest, opts...)
+		return err
+	})
+	return result, err
+}
+
+func (r *decoratedRepository[T]) FindByPaginated(ctx context.Context, pageRequest PageRequest, filters map[string]interface{}, opts ...QueryOption) (PageResponse[T], error) {
+	var result PageResponse[T]
+	err := r.call(ctx, "FindByPaginated", func(ctx context.Context, next GenericRepository[T]) (err error) {
+		result, err = next.FindByPaginated(ctx, pageRequest, filters, opts...)
+		return err
+	})
+	return result, err
+}
+
+func (r *decoratedRepository[T]) FindByQuery(ctx context.Context, query *Query, opts ...QueryOption) ([]T, error) {
+	var result []T
+	err := r.call(ctx, "FindByQuery", func(ctx context.Context, next GenericRepository[T]) (err error) {
+		result, err = next.FindByQuery(ctx, query, opts...)
+		return err
+	})
+	return result, err
+}
+
+func (r *decoratedRepository[T]) FindByQueryPaginated(ctx context.Context, pageRequest PageRequest, query *Query, opts ...QueryOption) (PageResponse[T], error) {
+	var result PageResponse[T]
+	err := r.call(ctx, "FindByQueryPaginated", func(ctx context.Context, next GenericRepository[T]) (err error) {
+		result, err = next.FindByQueryPaginated(ctx, pageRequest, query, opts...)
+		return err
+	})
+	return result, err
+}
+
+func (r *decoratedRepository[T]) CountBy(ctx context.Context, field string, value interface{}) (int64, error) {
+	var result int64
+	err := r.call(ctx, "CountBy", func(ctx context.Context, next GenericRepository[T]) (err error) {
+		result, err = next.CountBy(ctx, field, value)
+		return err
+	})
+	return result, err
+}
+
+func (r *decoratedRepository[T]) CountByFilters(ctx context.Context, filters map[string]interface{}) (int64, error) {
+	var result int64
+	err := r.call(ctx, "CountByFilters", func(ctx context.Context, next GenericRepository[T]) (err error) {
+		result, err = next.CountByFilters(ctx, filters)
+		return err
+	})
+	return result, err
+}
+
+func (r *decoratedRepository[T]) CountByQuery(ctx context.Context, query *Query) (int64, error) {
+	var result int64
+	err := r.call(ctx, "CountByQuery", func(ctx context.Context, next GenericRepository[T]) (err error) {
+		result, err = next.CountByQuery(ctx, query)
+		return err
+	})
+	return result, err
+}
+
+func (r *decoratedRepository[T]) ExistsBy(ctx context.Context, field string, value interface{}) (bool, error) {
+	var result bool
+	err := r.call(ctx, "ExistsBy", func(ctx context.Context, next GenericRepository[T]) (err error) {
+		result, err = next.ExistsBy(ctx, field, value)
+		return err
+	})
+	return result, err
+}
+
+func (r *decoratedRepository[T]) ExistsByFilters(ctx context.Context, filters map[string]interface{}) (bool, error) {
+	var result bool
+	err := r.call(ctx, "ExistsByFilters", func(ctx context.Context, next GenericRepository[T]) (err error) {
+		result, err = next.ExistsByFilters(ctx, filters)
+		return err
+	})
+	return result, err
+}
+
+func (r *decoratedRepository[T]) Save(ctx context.Context, doc T) error {
+	return r.call(ctx, "Save", func(ctx context.Context, next GenericRepository[T]) error {
+		return next.Save(ctx, doc)
+	})
+}
+
+func (r *decoratedRepository[T]) SaveOrUpdate(ctx context.Context, doc T) error {
+	return r.call(ctx, "SaveOrUpdate", func(ctx context.Context, next GenericRepository[T]) error {
+		return next.SaveOrUpdate(ctx, doc)
+	})
+}
+
+func (r *decoratedRepository[T]) SaveAll(ctx context.Context, docs []T) error {
+	return r.call(ctx, "SaveAll", func(ctx context.Context, next GenericRepository[T]) error {
+		return next.SaveAll(ctx, docs)
+	})
+}
+
+func (r *decoratedRepository[T]) Update(ctx context.Context, doc T) error {
+	return r.call(ctx, "Update", func(ctx context.Context, next GenericRepository[T]) error {
+		return next.Update(ctx, doc)
+	})
+}
+
+func (r *decoratedRepository[T]) Delete(ctx context.Context, id string) error {
+	return r.call(ctx, "Delete", func(ctx context.Context, next GenericRepository[T]) error {
+		return next.Delete(ctx, id)
+	})
+}
+
+func (r *decoratedRepository[T]) DeleteVersion(ctx context.Context, doc T) error {
+	return r.call(ctx, "DeleteVersion", func(ctx context.Context, next GenericRepository[T]) error {
+		return next.DeleteVersion(ctx, doc)
+	})
+}
+
+func (r *decoratedRepository[T]) WithTransaction(ctx context.Context, fn func(tx Tx) error) error {
+	return r.call(ctx, "WithTransaction", func(ctx context.Context, next GenericRepository[T]) error {
+		return next.WithTransaction(ctx, fn)
+	})
+}
@@ -0,0 +1,84 @@
+package ginboot
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type decoratedDocument struct {
+	ID   string `bson:"_id" ginboot:"_id"`
+	Name string `bson:"name"`
+}
+
+// flakyRepository fails FindById with the given errors before succeeding
+type flakyRepository struct {
+	GenericRepository[decoratedDocument]
+	errs  []error
+	calls int
+}
+
+func (r *flakyRepository) FindById(ctx context.Context, id string, opts ...QueryOption) (decoratedDocument, error) {
+	r.calls++
+	if r.calls <= len(r.errs) {
+		return decoratedDocument{}, r.errs[r.calls-1]
+	}
+	return r.GenericRepository.FindById(ctx, id, opts...)
+}
+
+func TestRepositoryDecorators(t *testing.T) {
+	ctx := context.Background()
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Retryable: IsRetryable}
+
+	t.Run("Retry", func(t *testing.T) {
+		memory := newMemoryRepository[decoratedDocument]()
+		assert.NoError(t, memory.Save(ctx, decoratedDocument{ID: "1", Name: "first"}))
+		flaky := &flakyRepository{GenericRepository: memory, errs: []error{context.DeadlineExceeded, context.DeadlineExceeded}}
+
+		doc, err := WithRetry[decoratedDocument](flaky, policy).FindById(ctx, "1")
+		assert.NoError(t, err)
+		assert.Equal(t, "first", doc.Name)
+		assert.Equal(t, 3, flaky.calls)
+	})
+
+	t.Run("Retry gives up", func(t *testing.T) {
+		flaky := &flakyRepository{errs: []error{context.DeadlineExceeded, context.DeadlineExceeded, context.DeadlineExceeded}}
+		_, err := WithRetry[decoratedDocument](flaky, policy).FindById(ctx, "1")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 3, flaky.calls)
+
+		flaky = &flakyRepository{errs: []error{ErrNotFound}}
+		_, err = WithRetry[decoratedDocument](flaky, policy).FindById(ctx, "1")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Equal(t, 1, flaky.calls)
+	})
+
+	t.Run("Logging and metrics", func(t *testing.T) {
+		var lines []string
+		var calls []RepositoryCall
+		repo := WithMetrics(
+			WithLogging[decoratedDocument](newMemoryRepository[decoratedDocument](), func(format string, args ...interface{}) {
+				lines = append(lines, fmt.Sprintf(format, args...))
+			}),
+			RepositoryMetricsFunc(func(call RepositoryCall) {
+				calls = append(calls, call)
+			}),
+		)
+
+		assert.NoError(t, repo.Save(ctx, decoratedDocument{ID: "1"}))
+		assert.Error(t, repo.Save(ctx, decoratedDocument{ID: "1"}))
+
+		assert.Len(t, lines, 2)
+		assert.Contains(t, lines[0], "ginboot: decoratedDocument.Save took")
+		assert.Contains(t, lines[1], "ginboot: decoratedDocument.Save failed after")
+
+		assert.Len(t, calls, 2)
+		assert.Equal(t, "decoratedDocument", calls[0].Entity)
+		assert.Equal(t, "Save", calls[0].Operation)
+		assert.NoError(t, calls[0].Err)
+		assert.Error(t, calls[1].Err)
+	})
+}
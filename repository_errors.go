@@ -0,0 +1,156 @@
+package ginboot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrNotFound is matched by errors.Is when a repository lookup finds nothing,
+// whatever the backend
+var ErrNotFound = errors.New("not found")
+
+// ErrDuplicateKey is matched by errors.Is when a write violates a unique index
+// or condition, whatever the backend
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// ErrVersionConflict is returned when updating a document whose
+// ginboot:"version" field no longer matches the stored version, because it was
+// modified concurrently
+var ErrVersionConflict = errors.New("version conflict")
+
+// DuplicateKeyError is returned by repositories for unique constraint
+// violations. Field is set when the backend reports the offending field.
+type DuplicateKeyError struct {
+	Field string
+	Err   error
+}
+
+func (e *DuplicateKeyError) Error() string {
+	if e.Field != "" {
+		return "duplicate key on " + e.Field + ": " + e.Err.Error()
+	}
+	return "duplicate key: " + e.Err.Error()
+}
+
+func (e *DuplicateKeyError) Unwrap() []error {
+	return []error{ErrDuplicateKey, e.Err}
+}
+
+var (
+	mongoDupKeyField    = regexp.MustCompile(`dup key: \{ ?"?([^":\s]+)"?\s*:`)
+	postgresDupKeyField = regexp.MustCompile(`Key \(([^)]+)\)=`)
+	mysqlDupKeyField    = regexp.MustCompile(`Duplicate entry '.*' for key '(?:[^.']+\.)?([^']+)'`)
+	sqliteDupKeyField   = regexp.MustCompile(`UNIQUE constraint failed: (?:[^.\s]+\.)?([^,\s]+)`)
+)
+
+// NormalizeError translates driver specific errors from MongoDB, SQL drivers
+// and DynamoDB into ginboot's backend independent errors, so callers can use
+// errors.Is(err, ErrNotFound) or errors.Is(err, ErrDuplicateKey) instead of
+// matching driver errors and messages.
+// Repositories apply it to their results; it is exported for custom queries.
+func NormalizeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var duplicate *DuplicateKeyError
+	if errors.As(err, &duplicate) || errors.Is(err, ErrNotFound) || errors.Is(err, ErrVersionConflict) {
+		return err
+	}
+
+	if errors.Is(err, mongo.ErrNoDocuments) || errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+
+	if mongo.IsDuplicateKeyError(err) {
+		return &DuplicateKeyError{Field: submatch(mongoDupKeyField, err.Error()), Err: err}
+	}
+
+	// pgx and lib/pq expose the SQLSTATE, 23505 is unique_violation
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) && pgErr.SQLState() == "23505" {
+		return &DuplicateKeyError{Field: submatch(postgresDupKeyField, err.Error()), Err: err}
+	}
+	if field := submatch(mysqlDupKeyField, err.Error()); field != "" {
+		return &DuplicateKeyError{Field: field, Err: err}
+	}
+	if field := submatch(sqliteDupKeyField, err.Error()); field != "" {
+		return &DuplicateKeyError{Field: field, Err: err}
+	}
+
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return &DuplicateKeyError{Err: err}
+	}
+	return err
+}
+
+func submatch(pattern *regexp.Regexp, message string) string {
+	if match := pattern.FindStringSubmatch(message); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+var retryableErrorCodes = map[string]bool{
+	// DynamoDB and S3 throttling and transient service errors
+	"ProvisionedThroughputExceededException": true,
+	"ThrottlingException":                    true,
+	"Throttling":                             true,
+	"RequestLimitExceeded":                   true,
+	"TransactionConflictException":           true,
+	"SlowDown":                               true,
+	"InternalError":                          true,
+	"InternalServerError":                    true,
+	"ServiceUnavailable":                     true,
+	// Postgres serialization failure and deadlock
+	"40001": true,
+	"40P01": true,
+}
+
+var mysqlTransientError = regexp.MustCompile(`Error (1205|1213)\b`)
+
+// IsRetryable reports whether err is a transient failure that may succeed when
+// retried: network errors and timeouts, Mongo errors labelled retryable,
+// DynamoDB/S3 throttling and SQL deadlocks or serialization failures.
+// Cancelled requests and ginboot's not found, duplicate key and version conflict
+// errors never are.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) ||
+		errors.Is(err, ErrNotFound) || errors.Is(err, ErrDuplicateKey) || errors.Is(err, ErrVersionConflict) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) {
+		return true
+	}
+	var labeled mongo.LabeledError
+	if errors.As(err, &labeled) &&
+		(labeled.HasErrorLabel("RetryableWriteError") || labeled.HasErrorLabel("TransientTransactionError")) {
+		return true
+	}
+
+	// smithy.APIError for AWS services
+	var apiErr interface{ ErrorCode() string }
+	if errors.As(err, &apiErr) && retryableErrorCodes[apiErr.ErrorCode()] {
+		return true
+	}
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) && retryableErrorCodes[pgErr.SQLState()] {
+		return true
+	}
+	return mysqlTransientError.MatchString(err.Error())
+}
@@ -0,0 +1,168 @@
+package ginboot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type testPgError struct{}
+
+func (testPgError) Error() string {
+	return `ERROR: duplicate key value violates unique constraint "users_email_key" Key (email)=(a@b.c) already exists. (SQLSTATE 23505)`
+}
+
+func (testPgError) SQLState() string {
+	return "23505"
+}
+
+func TestNormalizeError_NotFound(t *testing.T) {
+	for _, driverErr := range []error{mongo.ErrNoDocuments, sql.ErrNoRows} {
+		err := NormalizeError(driverErr)
+
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.ErrorIs(t, err, driverErr)
+		assert.Equal(t, err, NormalizeError(err))
+	}
+}
+
+func TestNormalizeError_DuplicateKey(t *testing.T) {
+	mongoErr := mongo.WriteException{WriteErrors: []mongo.WriteError{{
+		Code:    11000,
+		Message: `E11000 duplicate key error collection: test.users index: email_1 dup key: { email: "a@b.c" }`,
+	}}}
+
+	tests := []struct {
+		name          string
+		err           error
+		expectedField string
+	}{
+		{name: "mongo", err: mongoErr, expectedField: "email"},
+		{name: "postgres", err: testPgError{}, expectedField: "email"},
+		{name: "mysql", err: errors.New("Error 1062 (23000): Duplicate entry 'a@b.c' for key 'users.email'"), expectedField: "email"},
+		{name: "sqlite", err: errors.New("UNIQUE constraint failed: users.email"), expectedField: "email"},
+		{name: "dynamodb", err: &types.ConditionalCheckFailedException{}, expectedField: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NormalizeError(tt.err)
+
+			assert.ErrorIs(t, err, ErrDuplicateKey)
+			var duplicate *DuplicateKeyError
+			assert.True(t, errors.As(err, &duplicate))
+			assert.Equal(t, tt.expectedField, duplicate.Field)
+			assert.Equal(t, tt.err, duplicate.Err)
+		})
+	}
+
+	other := errors.New("connection refused")
+	assert.Equal(t, other, NormalizeError(other))
+	assert.Nil(t, NormalizeError(nil))
+}
+
+func TestSendError_RepositoryErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "not found",
+			err:          NormalizeError(mongo.ErrNoDocuments),
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"error_code":"NOT_FOUND","message":"Resource not found"}`,
+		},
+		{
+			name:         "duplicate key",
+			err:          &DuplicateKeyError{Field: "email", Err: errors.New("E11000")},
+			expectedCode: http.StatusConflict,
+			expectedBody: `{"error_code":"DUPLICATE_KEY","message":"email already exists"}`,
+		},
+		{
+			name:         "version conflict",
+			err:          fmt.Errorf("%w: E11000", ErrVersionConflict),
+			expectedCode: http.StatusConflict,
+			expectedBody: `{"error_code":"VERSION_CONFLICT","message":"Resource was modified concurrently"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			SendError(c, tt.err)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
+
+type testAPIError struct {
+	code string
+}
+
+func (e testAPIError) Error() string {
+	return "api error " + e.code
+}
+
+func (e testAPIError) ErrorCode() string {
+	return e.code
+}
+
+type testSQLStateError struct {
+	state string
+}
+
+func (e testSQLStateError) Error() string {
+	return "sql error " + e.state
+}
+
+func (e testSQLStateError) SQLState() string {
+	return e.state
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "nil", err: nil, expected: false},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, expected: true},
+		{name: "cancelled", err: context.Canceled, expected: false},
+		{name: "network timeout", err: &net.DNSError{IsTimeout: true}, expected: true},
+		{name: "mongo retryable label", err: mongo.CommandError{Labels: []string{"RetryableWriteError"}}, expected: true},
+		{name: "mongo command error", err: mongo.CommandError{Code: 2}, expected: false},
+		{name: "dynamodb throttling", err: testAPIError{code: "ProvisionedThroughputExceededException"}, expected: true},
+		{name: "s3 slow down", err: testAPIError{code: "SlowDown"}, expected: true},
+		{name: "validation error", err: testAPIError{code: "ValidationException"}, expected: false},
+		{name: "postgres deadlock", err: testSQLStateError{state: "40P01"}, expected: true},
+		{name: "postgres syntax error", err: testSQLStateError{state: "42601"}, expected: false},
+		{name: "mysql deadlock", err: errors.New("Error 1213 (40001): Deadlock found when trying to get lock"), expected: true},
+		{name: "not found", err: NormalizeError(mongo.ErrNoDocuments), expected: false},
+		{name: "duplicate key", err: &DuplicateKeyError{Err: errors.New("E11000")}, expected: false},
+		{name: "version conflict", err: ErrVersionConflict, expected: false},
+		{name: "wrapped", err: fmt.Errorf("saving post: %w", testAPIError{code: "ThrottlingException"}), expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsRetryable(tt.err))
+		})
+	}
+}
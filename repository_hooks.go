@@ -0,0 +1,234 @@
+package ginboot
+
+import "context"
+
+// BeforeSaver is implemented by entities preparing themselves before being
+// saved or updated, e.g. stamping timestamps. Implement it on the pointer.
+type BeforeSaver interface {
+	BeforeSave(ctx context.Context) error
+}
+
+// AfterSaver is implemented by entities reacting to being saved or updated
+type AfterSaver interface {
+	AfterSave(ctx context.Context) error
+}
+
+// AfterLoader is implemented by entities completing themselves once read,
+// e.g. computing derived fields. Implement it on the pointer.
+type AfterLoader interface {
+	AfterLoad(ctx context.Context) error
+}
+
+// RepositoryHooks are lifecycle callbacks run by WithHooks, after the
+// entity's own BeforeSave, AfterSave and AfterLoad methods. Every hook is
+// optional, and an error fails the call.
+type RepositoryHooks[T any] struct {
+	// BeforeSave runs before Save, SaveOrUpdate, SaveAll and Update, and may
+	// change the document written
+	BeforeSave func(ctx context.Context, doc *T) error
+	// AfterSave runs once the document was written, e.g. to audit it or
+	// invalidate caches. Its error is returned though the write happened.
+	AfterSave func(ctx context.Context, doc T) error
+	// AfterLoad runs on every document read
+	AfterLoad func(ctx context.Context, doc *T) error
+	// BeforeDelete runs before Delete and can veto it
+	BeforeDelete func(ctx context.Context, id string) error
+}
+
+// WithHooks runs the entity's lifecycle methods and the hooks around the
+// repository's calls, whatever its backend. Changes made by BeforeSave reach
+// the database, but only reach the caller's value when T is a pointer.
+func WithHooks[T any](repo GenericRepository[T], hooks RepositoryHooks[T]) GenericRepository[T] {
+	return &hookedRepository[T]{GenericRepository: repo, hooks: hooks}
+}
+
+type hookedRepository[T any] struct {
+	GenericRepository[T]
+	hooks RepositoryHooks[T]
+}
+
+func (r *hookedRepository[T]) beforeSave(ctx context.Context, doc *T) error {
+	if saver, ok := any(doc).(BeforeSaver); ok {
+		if err := saver.BeforeSave(ctx); err != nil {
+			return err
+		}
+	} else if saver, ok := any(*doc).(BeforeSaver); ok {
+		if err := saver.BeforeSave(ctx); err != nil {
+			return err
+		}
+	}
+	if r.hooks.BeforeSave != nil {
+		return r.hooks.BeforeSave(ctx, doc)
+	}
+	return nil
+}
+
+func (r *hookedRepository[T]) afterSave(ctx context.Context, doc T) error {
+	if saver, ok := any(&doc).(AfterSaver); ok {
+		if err := saver.AfterSave(ctx); err != nil {
+			return err
+		}
+	} else if saver, ok := any(doc).(AfterSaver); ok {
+		if err := saver.AfterSave(ctx); err != nil {
+			return err
+		}
+	}
+	if r.hooks.AfterSave != nil {
+		return r.hooks.AfterSave(ctx, doc)
+	}
+	return nil
+}
+
+func (r *hookedRepository[T]) afterLoad(ctx context.Context, doc *T) error {
+	if loader, ok := any(doc).(AfterLoader); ok {
+		if err := loader.AfterLoad(ctx); err != nil {
+			return err
+		}
+	} else if loader, ok := any(*doc).(AfterLoader); ok {
+		if err := loader.AfterLoad(ctx); err != nil {
+			return err
+		}
+	}
+	if r.hooks.AfterLoad != nil {
+		return r.hooks.AfterLoad(ctx, doc)
+	}
+	return nil
+}
+
+func (r *hookedRepository[T]) loadOne(ctx context.Context, doc T, err error) (T, error) {
+	if err != nil {
+		return doc, err
+	}
+	return doc, r.afterLoad(ctx, &doc)
+}
+
+func (r *hookedRepository[T]) loadAll(ctx context.Context, docs []T, err error) ([]T, error) {
+	if err != nil {
+		return docs, err
+	}
+	for i := range docs {
+		if err := r.afterLoad(ctx, &docs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+func (r *hookedRepository[T]) loadPage(ctx context.Context, page PageResponse[T], err error) (PageResponse[T], error) {
+	page.Contents, err = r.loadAll(ctx, page.Contents, err)
+	return page, err
+}
+
+func (r *hookedRepository[T]) FindById(ctx context.Context, id string, opts ...QueryOption) (T, error) {
+	doc, err := r.GenericRepository.FindById(ctx, id, opts...)
+	return r.loadOne(ctx, doc, err)
+}
+
+func (r *hookedRepository[T]) FindAllById(ctx context.Context, ids []string, opts ...QueryOption) ([]T, error) {
+	docs, err := r.GenericRepository.FindAllById(ctx, ids, opts...)
+	return r.loadAll(ctx, docs, err)
+}
+
+func (r *hookedRepository[T]) FindOneBy(ctx context.Context, field string, value interface{}, opts ...QueryOption) (T, error) {
+	doc, err := r.GenericRepository.FindOneBy(ctx, field, value, opts...)
+	return r.loadOne(ctx, doc, err)
+}
+
+func (r *hookedRepository[T]) FindOneByFilters(ctx context.Context, filters map[string]interface{}, opts ...QueryOption) (T, error) {
+	doc, err := r.GenericRepository.FindOneByFilters(ctx, filters, opts...)
+	return r.loadOne(ctx, doc, err)
+}
+
+func (r *hookedRepository[T]) FindBy(ctx context.Context, field string, value interface{}, opts ...QueryOption) ([]T, error) {
+	docs, err := r.GenericRepository.FindBy(ctx, field, value, opts...)
+	return r.loadAll(ctx, docs, err)
+}
+
+func (r *hookedRepository[T]) FindByFilters(ctx context.Context, filters map[string]interface{}, opts ...QueryOption) ([]T, error) {
+	docs, err := r.GenericRepository.FindByFilters(ctx, filters, opts...)
+	return r.loadAll(ctx, docs, err)
+}
+
+func (r *hookedRepository[T]) FindAll(ctx context.Context, opts ...QueryOption) ([]T, error) {
+	docs, err := r.GenericRepository.FindAll(ctx, opts...)
+	return r.loadAll(ctx, docs, err)
+}
+
+func (r *hookedRepository[T]) FindAllPaginated(ctx context.Context, pageRequest PageRequest, opts ...QueryOption) (PageResponse[T], error) {
+	page, err := r.GenericRepository.FindAllPaginated(ctx, pageRequest, opts...)
+	return r.loadPage(ctx, page, err)
+}
+
+func (r *hookedRepository[T]) FindByPaginated(ctx context.Context, pageRequest PageRequest, filters map[string]interface{}, opts ...QueryOption) (PageResponse[T], error) {
+	page, err := r.GenericRepository.FindByPaginated(ctx, pageRequest, filters, opts...)
+	return r.loadPage(ctx, page, err)
+}
+
+func (r *hookedRepository[T]) FindByQuery(ctx context.Context, query *Query, opts ...QueryOption) ([]T, error) {
+	docs, err := r.GenericRepository.FindByQuery(ctx, query, opts...)
+	return r.loadAll(ctx, docs, err)
+}
+
+func (r *hookedRepository[T]) FindByQueryPaginated(ctx context.Context, pageRequest PageRequest, query *Query, opts ...QueryOption) (PageResponse[T], error) {
+	page, err := r.GenericRepository.FindByQueryPaginated(ctx, pageRequest, query, opts...)
+	return r.loadPage(ctx, page, err)
+}
+
+func (r *hookedRepository[T]) Save(ctx context.Context, doc T) error {
+	return r.write(ctx, doc, r.GenericRepository.Save)
+}
+
+func (r *hookedRepository[T]) SaveOrUpdate(ctx context.Context, doc T) error {
+	return r.write(ctx, doc, r.GenericRepository.SaveOrUpdate)
+}
+
+func (r *hookedRepository[T]) Update(ctx context.Context, doc T) error {
+	return r.write(ctx, doc, r.GenericRepository.Update)
+}
+
+func (r *hookedRepository[T]) SaveAll(ctx context.Context, docs []T) error {
+	prepared := make([]T, len(docs))
+	copy(prepared, docs)
+	for i := range prepared {
+		if err := r.beforeSave(ctx, &prepared[i]); err != nil {
+			return err
+		}
+	}
+	if err := r.GenericRepository.SaveAll(ctx, prepared); err != nil {
+		return err
+	}
+	for _, doc := range prepared {
+		if err := r.afterSave(ctx, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *hookedRepository[T]) write(ctx context.Context, doc T, write func(ctx context.Context, doc T) error) error {
+	if err := r.beforeSave(ctx, &doc); err != nil {
+		return err
+	}
+	if err := write(ctx, doc); err != nil {
+		return err
+	}
+	return r.afterSave(ctx, doc)
+}
+
+func (r *hookedRepository[T]) Delete(ctx context.Context, id string) error {
+	if r.hooks.BeforeDelete != nil {
+		if err := r.hooks.BeforeDelete(ctx, id); err != nil {
+			return err
+		}
+	}
+	return r.GenericRepository.Delete(ctx, id)
+}
+
+func (r *hookedRepository[T]) DeleteVersion(ctx context.Context, doc T) error {
+	if r.hooks.BeforeDelete != nil {
+		if err := r.hooks.BeforeDelete(ctx, getDocumentID(doc)); err != nil {
+			return err
+		}
+	}
+	return r.GenericRepository.DeleteVersion(ctx, doc)
+}
@@ -0,0 +1,81 @@
+package ginboot
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type hookedArticle struct {
+	ID        string    `bson:"_id" ginboot:"_id"`
+	Title     string    `bson:"title"`
+	Slug      string    `bson:"-"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+func (a *hookedArticle) BeforeSave(ctx context.Context) error {
+	if a.Title == "" {
+		return errors.New("title is required")
+	}
+	a.UpdatedAt = time.Now()
+	return nil
+}
+
+func (a *hookedArticle) AfterLoad(ctx context.Context) error {
+	a.Slug = strings.ReplaceAll(strings.ToLower(a.Title), " ", "-")
+	return nil
+}
+
+func TestWithHooks(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryRepository[hookedArticle]()
+	var saved, deleted []string
+	repo := WithHooks[hookedArticle](store, RepositoryHooks[hookedArticle]{
+		AfterSave: func(ctx context.Context, doc hookedArticle) error {
+			saved = append(saved, doc.ID)
+			return nil
+		},
+		BeforeDelete: func(ctx context.Context, id string) error {
+			if id == "pinned" {
+				return errors.New("pinned articles can't be deleted")
+			}
+			deleted = append(deleted, id)
+			return nil
+		},
+	})
+
+	assert.NoError(t, repo.Save(ctx, hookedArticle{ID: "1", Title: "Hello World"}))
+	stored, _ := store.FindById(ctx, "1")
+	assert.False(t, stored.UpdatedAt.IsZero())
+	assert.Empty(t, stored.Slug)
+
+	assert.EqualError(t, repo.Save(ctx, hookedArticle{ID: "2"}), "title is required")
+	_, err := store.FindById(ctx, "2")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, repo.SaveAll(ctx, []hookedArticle{{ID: "3", Title: "Second Post"}, {ID: "pinned", Title: "Rules"}}))
+	assert.Equal(t, []string{"1", "3", "pinned"}, saved)
+
+	found, err := repo.FindById(ctx, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello-world", found.Slug)
+	all, err := repo.FindAll(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, all, 3)
+	for _, article := range all {
+		assert.NotEmpty(t, article.Slug)
+	}
+	page, err := repo.FindAllPaginated(ctx, PageRequest{Page: 1, Size: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "second-post", page.Contents[1].Slug)
+
+	assert.Error(t, repo.Delete(ctx, "pinned"))
+	assert.NoError(t, repo.Delete(ctx, "3"))
+	assert.Equal(t, []string{"3"}, deleted)
+	_, err = store.FindById(ctx, "pinned")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,70 @@
+package ginboot
+
+import "net/http"
+
+// Response lets a typed handler choose the status code and headers of a
+// successful response:
+//
+//	func (c *OrderController) Create(ctx *ginboot.Context, req CreateOrder) (ginboot.Response[Order], error) {
+//		order, err := c.service.Create(ctx, req)
+//		if err != nil {
+//			return ginboot.Response[Order]{}, err
+//		}
+//		return ginboot.Created(order, "/orders/"+order.ID), nil
+//	}
+//
+// A zero Status means 200 OK. 204 and 304 responses are sent without a body.
+type Response[T any] struct {
+	Status int
+	Header http.Header
+	Body   T
+}
+
+// NewResponse responds with the status and body
+func NewResponse[T any](status int, body T) Response[T] {
+	return Response[T]{Status: status, Body: body}
+}
+
+// Created responds with 201 Created and, unless empty, a Location header
+func Created[T any](body T, location string) Response[T] {
+	response := NewResponse(http.StatusCreated, body)
+	if location != "" {
+		response = response.WithHeader("Location", location)
+	}
+	return response
+}
+
+// NoContent responds with 204 No Content
+func NoContent[T any]() Response[T] {
+	return Response[T]{Status: http.StatusNoContent}
+}
+
+// WithHeader returns a copy of the response with the header added
+func (r Response[T]) WithHeader(key, value string) Response[T] {
+	header := r.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Add(key, value)
+	r.Header = header
+	return r
+}
+
+func (r Response[T]) handlerResponse() (int, http.Header, interface{}) {
+	status := r.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return status, r.Header, r.Body
+}
+
+// handlerResponse is implemented by Response, whose status and headers
+// wrapHandler applies before serializing the body
+type handlerResponse interface {
+	handlerResponse() (status int, header http.Header, body interface{})
+}
+
+// bodyAllowed reports whether a response with the status may have a body
+func bodyAllowed(status int) bool {
+	return status != http.StatusNoContent && status != http.StatusNotModified
+}
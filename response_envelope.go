@@ -0,0 +1,99 @@
+package ginboot
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+)
+
+const (
+	MIMEMsgPack  = "application/msgpack"
+	MIMEMsgPack2 = "application/x-msgpack"
+)
+
+// EnvelopeSerializer wraps every response in a standard envelope:
+// {"data": ...} for typed handler responses, with paging in "meta" for a
+// PageResponse, and {"error": {"error_code": ..., "message": ...}} for errors
+// sent by SendError.
+type EnvelopeSerializer struct{}
+
+func (EnvelopeSerializer) Serialize(ctx *Context, status int, response interface{}) {
+	fields, naming := requestedFieldSet(ctx), namingStrategyFor(ctx)
+	envelope := map[string]interface{}{}
+	if page, ok := response.(pagedResponse); ok {
+		pageable, totalElements, totalPages := page.pageInfo()
+		envelope["meta"] = map[string]interface{}{
+			"page":                               pageable.Page,
+			"size":                               pageable.Size,
+			documentKey(naming, "totalElements"): totalElements,
+			documentKey(naming, "totalPages"):    totalPages,
+		}
+		response = page.pageContents()
+	}
+	if fields != nil || naming != nil {
+		reshaped, err := pruneResponse(response, fields, naming)
+		if err != nil {
+			ctx.SendError(err)
+			return
+		}
+		response = reshaped
+	}
+	envelope["data"] = response
+	writeDocument(ctx, status, "application/json; charset=utf-8", envelope)
+}
+
+func (EnvelopeSerializer) SerializeError(ctx *Context, status int, body gin.H) {
+	writeJSON(ctx, status, "application/json; charset=utf-8", gin.H{"error": body})
+}
+
+// NegotiatingSerializer writes responses as XML or MessagePack to clients
+// asking for them in the Accept header, and with Default, JSONSerializer when
+// nil, otherwise. Error responses are negotiated the same way.
+type NegotiatingSerializer struct {
+	Default ResponseSerializer
+}
+
+func (s NegotiatingSerializer) Serialize(ctx *Context, status int, response interface{}) {
+	switch negotiateFormat(ctx) {
+	case gin.MIMEXML:
+		ctx.XML(status, response)
+	case MIMEMsgPack:
+		ctx.Render(status, render.MsgPack{Data: response})
+	default:
+		s.fallback().Serialize(ctx, status, response)
+	}
+}
+
+func (s NegotiatingSerializer) SerializeError(ctx *Context, status int, body gin.H) {
+	switch negotiateFormat(ctx) {
+	case gin.MIMEXML:
+		ctx.XML(status, body)
+	case MIMEMsgPack:
+		ctx.Render(status, render.MsgPack{Data: body})
+	default:
+		if serializer, ok := s.fallback().(ErrorSerializer); ok {
+			serializer.SerializeError(ctx, status, body)
+			return
+		}
+		ctx.JSON(status, body)
+	}
+}
+
+func (s NegotiatingSerializer) fallback() ResponseSerializer {
+	if s.Default == nil {
+		return JSONSerializer{}
+	}
+	return s.Default
+}
+
+// negotiateFormat returns gin.MIMEXML, MIMEMsgPack or gin.MIMEJSON, the
+// default when the client accepts anything
+func negotiateFormat(ctx *Context) string {
+	switch ctx.NegotiateFormat(gin.MIMEJSON, gin.MIMEXML, gin.MIMEXML2, MIMEMsgPack, MIMEMsgPack2) {
+	case gin.MIMEXML, gin.MIMEXML2:
+		return gin.MIMEXML
+	case MIMEMsgPack, MIMEMsgPack2:
+		return MIMEMsgPack
+	default:
+		return gin.MIMEJSON
+	}
+}
@@ -0,0 +1,86 @@
+package ginboot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type envelopedPost struct {
+	ID    string `json:"id" xml:"id"`
+	Title string `json:"title" xml:"title"`
+}
+
+func TestEnvelopeSerializer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{engine: gin.New()}
+	group := server.Group("/posts", UseSerializer(EnvelopeSerializer{}))
+	group.GET("/one", func() (envelopedPost, error) {
+		return envelopedPost{ID: "1", Title: "Hello"}, nil
+	})
+	group.GET("/page", func() (PageResponse[envelopedPost], error) {
+		return PageResponse[envelopedPost]{
+			Contents:      []envelopedPost{{ID: "1", Title: "Hello"}},
+			Pageable:      PageRequest{Page: 1, Size: 10},
+			TotalElements: 1,
+			TotalPages:    1,
+		}, nil
+	})
+	group.GET("/missing", func() (envelopedPost, error) {
+		return envelopedPost{}, ErrNotFound
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	assert.JSONEq(t, `{"data":{"id":"1","title":"Hello"}}`, get("/posts/one").Body.String())
+	assert.JSONEq(t, `{"data":[{"id":"1","title":"Hello"}],"meta":{"page":1,"size":10,"totalElements":1,"totalPages":1}}`,
+		get("/posts/page").Body.String())
+
+	w := get("/posts/missing")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"error":{"error_code":"NOT_FOUND","message":"Resource not found"}}`, w.Body.String())
+}
+
+func TestNegotiatingSerializer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{engine: gin.New()}
+	group := server.Group("/posts", UseSerializer(NegotiatingSerializer{Default: EnvelopeSerializer{}}))
+	group.GET("/one", func() (envelopedPost, error) {
+		return envelopedPost{ID: "1", Title: "Hello"}, nil
+	})
+	group.GET("/missing", func() (envelopedPost, error) {
+		return envelopedPost{}, ErrNotFound
+	})
+
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", accept)
+		server.engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/posts/one", "application/xml")
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "<envelopedPost><id>1</id><title>Hello</title></envelopedPost>", w.Body.String())
+
+	w = get("/posts/one", "application/msgpack")
+	assert.Equal(t, "application/msgpack; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.NotEmpty(t, w.Body.Bytes())
+
+	assert.JSONEq(t, `{"data":{"id":"1","title":"Hello"}}`, get("/posts/one", "*/*").Body.String())
+	assert.JSONEq(t, `{"error":{"error_code":"NOT_FOUND","message":"Resource not found"}}`, get("/posts/missing", "").Body.String())
+
+	w = get("/posts/missing", "text/xml")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "<error_code>NOT_FOUND</error_code>")
+}
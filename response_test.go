@@ -0,0 +1,63 @@
+package ginboot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{engine: gin.New()}
+	group := server.Group("/items")
+	group.POST("", func() (Response[TestResponse], error) {
+		return Created(TestResponse{Message: "created"}, "/items/1").WithHeader("X-Item-Id", "1"), nil
+	})
+	group.DELETE("/:id", func() (Response[TestResponse], error) {
+		return NoContent[TestResponse](), nil
+	})
+	group.PUT("/:id", func(ctx *Context) (*TestResponse, error) {
+		ctx.Status(http.StatusAccepted)
+		ctx.Header("Location", "/jobs/1")
+		return &TestResponse{Message: "queued"}, nil
+	})
+	group.GET("/:id", func() (Response[TestResponse], error) {
+		return Response[TestResponse]{Body: TestResponse{Message: "found"}}, nil
+	})
+
+	w := httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items", nil))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/items/1", w.Header().Get("Location"))
+	assert.Equal(t, "1", w.Header().Get("X-Item-Id"))
+	assert.JSONEq(t, `{"message":"created"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/items/1", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/items/1", nil))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "/jobs/1", w.Header().Get("Location"))
+	assert.JSONEq(t, `{"message":"queued"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items/1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"message":"found"}`, w.Body.String())
+}
+
+func TestResponse_WithHeaderCopies(t *testing.T) {
+	base := Created("body", "/a")
+	derived := base.WithHeader("X-Extra", "1")
+
+	assert.Empty(t, base.Header.Get("X-Extra"))
+	assert.Equal(t, "1", derived.Header.Get("X-Extra"))
+	assert.Equal(t, "/a", derived.Header.Get("Location"))
+}
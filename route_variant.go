@@ -0,0 +1,86 @@
+package ginboot
+
+import (
+	"hash/fnv"
+	"math/rand"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	VariantPrimary   = "primary"
+	VariantCandidate = "candidate"
+)
+
+// VariantConfig decides which of a route's two handlers serves a request. The
+// header wins over Enabled, which wins over the percentage split.
+type VariantConfig struct {
+	// Percentage of requests served by the candidate, from 0 to 100
+	Percentage float64
+	// Header selects a variant explicitly with the value primary or candidate,
+	// e.g. for testing the candidate before rolling it out. It is off unless
+	// set, and any client can send it unless AllowOverride restricts it.
+	Header string
+	// AllowOverride reports whether the request may pick its variant with
+	// Header, e.g. by checking the caller's role
+	AllowOverride func(ctx *Context) bool
+	// Enabled routes a request to the candidate when it returns true, the hook
+	// for feature flag services
+	Enabled func(ctx *Context) bool
+	// Key keeps the percentage split sticky, e.g. by user ID, so a user sees
+	// the same variant on every request; requests are split randomly otherwise
+	Key func(ctx *Context) string
+}
+
+// DefaultVariantConfig sends every request to the primary handler. Set
+// Percentage, Enabled or Header to route requests to the candidate.
+func DefaultVariantConfig() VariantConfig {
+	return VariantConfig{}
+}
+
+// Variant registers a primary and a candidate handler for the same route so a
+// rewrite can be rolled out gradually without a proxy. The served variant is
+// reported in the X-Route-Variant response header.
+func (g *ControllerGroup) Variant(httpMethod, relativePath string, primary, candidate interface{}, config VariantConfig, middleware ...gin.HandlerFunc) {
+	primaryHandler := wrapHandler(primary)
+	candidateHandler := wrapHandler(candidate)
+
+	selector := func(c *gin.Context) {
+		if selectVariant(NewContext(c), config) == VariantCandidate {
+			c.Header("X-Route-Variant", VariantCandidate)
+			candidateHandler(c)
+			return
+		}
+		c.Header("X-Route-Variant", VariantPrimary)
+		primaryHandler(c)
+	}
+	handlers := append(append([]gin.HandlerFunc{}, middleware...), selector)
+	g.group.Handle(httpMethod, relativePath, handlers...)
+}
+
+func selectVariant(ctx *Context, config VariantConfig) string {
+	if config.Header != "" && (config.AllowOverride == nil || config.AllowOverride(ctx)) {
+		switch ctx.GetHeader(config.Header) {
+		case VariantPrimary:
+			return VariantPrimary
+		case VariantCandidate:
+			return VariantCandidate
+		}
+	}
+	if config.Enabled != nil && config.Enabled(ctx) {
+		return VariantCandidate
+	}
+
+	sample := rand.Float64() * 100
+	if config.Key != nil {
+		if key := config.Key(ctx); key != "" {
+			hash := fnv.New32a()
+			hash.Write([]byte(key))
+			sample = float64(hash.Sum32()%10000) / 100
+		}
+	}
+	if sample < config.Percentage {
+		return VariantCandidate
+	}
+	return VariantPrimary
+}
@@ -0,0 +1,90 @@
+package ginboot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestControllerGroup_Variant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	primary := func() (string, error) { return "v1", nil }
+	candidate := func() (string, error) { return "v2", nil }
+
+	newServer := func(config VariantConfig) *Server {
+		server := &Server{engine: gin.New()}
+		server.Group("").Variant(http.MethodGet, "/posts", primary, candidate, config)
+		return server
+	}
+	get := func(server *Server, header, user string) string {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		if header != "" {
+			req.Header.Set("X-Route-Variant", header)
+		}
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+		server.engine.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, []string{VariantPrimary, VariantCandidate}, w.Header().Get("X-Route-Variant"))
+		return w.Body.String()
+	}
+
+	t.Run("header", func(t *testing.T) {
+		server := newServer(DefaultVariantConfig())
+		assert.Equal(t, `"v1"`, get(server, "", ""))
+		assert.Equal(t, `"v1"`, get(server, VariantCandidate, ""), "the header is opt-in")
+
+		config := DefaultVariantConfig()
+		config.Header = "X-Route-Variant"
+		config.AllowOverride = func(ctx *Context) bool { return ctx.GetHeader("X-User") == "tester" }
+		server = newServer(config)
+		assert.Equal(t, `"v2"`, get(server, VariantCandidate, "tester"))
+		assert.Equal(t, `"v1"`, get(server, VariantCandidate, "other"))
+	})
+
+	t.Run("percentage", func(t *testing.T) {
+		config := DefaultVariantConfig()
+		config.Header = "X-Route-Variant"
+		config.Percentage = 100
+		server := newServer(config)
+		assert.Equal(t, `"v2"`, get(server, "", ""))
+		assert.Equal(t, `"v1"`, get(server, VariantPrimary, ""))
+	})
+
+	t.Run("feature flag", func(t *testing.T) {
+		config := DefaultVariantConfig()
+		config.Enabled = func(ctx *Context) bool { return ctx.GetHeader("X-User") == "beta" }
+		server := newServer(config)
+		assert.Equal(t, `"v2"`, get(server, "", "beta"))
+		assert.Equal(t, `"v1"`, get(server, "", "other"))
+	})
+
+	t.Run("shared middleware", func(t *testing.T) {
+		// the selector must not be written into the caller's spare capacity
+		middleware := make([]gin.HandlerFunc, 0, 1)
+		server := &Server{engine: gin.New()}
+		server.Group("").Variant(http.MethodGet, "/posts", primary, candidate, VariantConfig{}, middleware...)
+		assert.Nil(t, middleware[:1][0])
+		assert.Equal(t, `"v1"`, get(server, "", ""))
+	})
+
+	t.Run("sticky key", func(t *testing.T) {
+		config := DefaultVariantConfig()
+		config.Percentage = 50
+		config.Key = func(ctx *Context) string { return ctx.GetHeader("X-User") }
+		server := newServer(config)
+
+		for _, user := range []string{"alice", "bob", "carol"} {
+			first := get(server, "", user)
+			for i := 0; i < 5; i++ {
+				assert.Equal(t, first, get(server, "", user))
+			}
+		}
+	})
+}
@@ -1,17 +1,20 @@
 package ginboot
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"path"
 	"reflect"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 )
 
 // ControllerGroup represents a group of routes with common middleware and path prefix
 type ControllerGroup struct {
-	group *gin.RouterGroup
+	group  *gin.RouterGroup
+	server *Server
 }
 
 // Controller interface defines methods that controllers must implement
@@ -23,10 +26,65 @@ type Controller interface {
 func (s *Server) Group(relativePath string, middleware ...gin.HandlerFunc) *ControllerGroup {
 	fullPath := path.Join(s.basePath, relativePath)
 	return &ControllerGroup{
-		group: s.engine.Group(fullPath, middleware...),
+		group:  s.engine.Group(fullPath, middleware...),
+		server: s,
 	}
 }
 
+var contextType = reflect.TypeOf(&Context{})
+
+// argsPool reuses the argument slices passed to handlers through reflection
+var argsPool = sync.Pool{
+	New: func() interface{} {
+		args := make([]reflect.Value, 0, 3)
+		return &args
+	},
+}
+
+var pageRequestType = reflect.TypeOf(PageRequest{})
+
+// Kinds of handler arguments
+const (
+	contextArg = iota
+	pageArg
+	requestArg
+)
+
+type handlerArg struct {
+	kind int
+	typ  reflect.Type
+}
+
+// handlerArgs validates the arguments of a handler: an optional *Context,
+// which comes first, a PageRequest read from the page, size and sort query
+// parameters and a request model, the last two optional and in either order
+func handlerArgs(handlerType reflect.Type) []handlerArg {
+	if handlerType.NumIn() > 3 {
+		panic("handler must have 0-3 arguments")
+	}
+	args := make([]handlerArg, handlerType.NumIn())
+	counts := map[int]int{}
+	for i := range args {
+		typ := handlerType.In(i)
+		switch {
+		case typ == contextType:
+			if i != 0 {
+				panic("*Context must be the first argument")
+			}
+			args[i] = handlerArg{kind: contextArg, typ: typ}
+		case typ == pageRequestType:
+			args[i] = handlerArg{kind: pageArg, typ: typ}
+		default:
+			args[i] = handlerArg{kind: requestArg, typ: typ}
+		}
+		counts[args[i].kind]++
+	}
+	if counts[pageArg] > 1 || counts[requestArg] > 1 {
+		panic("handler can take one PageRequest and one request model")
+	}
+	return args
+}
+
 // Internal handler wrapper
 func wrapHandler(handler interface{}) gin.HandlerFunc {
 	handlerType := reflect.TypeOf(handler)
@@ -34,84 +92,134 @@ func wrapHandler(handler interface{}) gin.HandlerFunc {
 		panic("handler must be a function")
 	}
 
-	numIn := handlerType.NumIn()
 	numOut := handlerType.NumOut()
 
-	if numOut != 2 {
-		panic("handler must return (response, error)")
+	if numOut != 1 && numOut != 2 {
+		panic("handler must return (response, error) or error")
 	}
 
 	// Validate error type
-	if !handlerType.Out(1).Implements(reflect.TypeOf((*error)(nil)).Elem()) {
-		panic("second return value must be error")
+	if !handlerType.Out(numOut - 1).Implements(reflect.TypeOf((*error)(nil)).Elem()) {
+		panic("last return value must be error")
 	}
+	// Handlers returning only an error write their response, see StreamHandler
+	streaming := numOut == 1
+	args := handlerArgs(handlerType)
+
+	handlerValue := reflect.ValueOf(handler)
 
 	return func(c *gin.Context) {
 		ctx := NewContext(c)
+		defer ctx.stopClientGone()
 
 		// Prepare arguments based on handler signature
-		var args []reflect.Value
-
-		switch numIn {
-		case 0: // func() (Response, error)
-			args = []reflect.Value{}
-
-		case 1: // func(*Context) (Response, error) or func(Request) (Response, error)
-			firstArg := handlerType.In(0)
-			if firstArg == reflect.TypeOf(&Context{}) {
-				// Handler wants context
-				args = []reflect.Value{reflect.ValueOf(ctx)}
-			} else {
-				// Handler wants request
-				reqValue := reflect.New(firstArg)
-				if err := ctx.GetRequest(reqValue.Interface()); err != nil {
-					ctx.SendError(err)
+		valuesPtr := argsPool.Get().(*[]reflect.Value)
+		values := (*valuesPtr)[:0]
+		defer func() {
+			// drop references to the request before returning the slice
+			clear(values)
+			*valuesPtr = values[:0]
+			argsPool.Put(valuesPtr)
+		}()
+
+		for _, arg := range args {
+			switch arg.kind {
+			case contextArg:
+				values = append(values, reflect.ValueOf(ctx))
+			case pageArg:
+				pageRequest := ctx.GetPageRequest()
+				if c.IsAborted() {
 					return
 				}
-				args = []reflect.Value{reqValue.Elem()}
+				values = append(values, reflect.ValueOf(pageRequest))
+			case requestArg:
+				reqValue := reflect.New(arg.typ)
+				if err := ctx.bindRequestModel(reqValue.Interface()); err != nil {
+					return
+				}
+				values = append(values, reqValue.Elem())
 			}
+		}
 
-		case 2: // func(*Context, Request) (Response, error)
-			if handlerType.In(0) != reflect.TypeOf(&Context{}) {
-				panic("first argument must be *Context when using two arguments")
-			}
-			reqType := handlerType.In(1)
-			reqValue := reflect.New(reqType)
-			if err := ctx.GetRequest(reqValue.Interface()); err != nil {
-				ctx.SendError(err)
-				return
-			}
-			args = []reflect.Value{reflect.ValueOf(ctx), reqValue.Elem()}
+		// Call handler
+		results := handlerValue.Call(values)
 
-		default:
-			panic("handler must have 0-2 arguments")
+		// The handler already answered, e.g. when ctx.BindQuery failed
+		if c.IsAborted() && c.Writer.Written() {
+			return
 		}
 
-		// Call handler
-		results := reflect.ValueOf(handler).Call(args)
+		if streaming {
+			finishStream(ctx, results[0])
+			return
+		}
 
 		// Check error
 		if !results[1].IsNil() {
-			err := results[1].Interface().(error)
-			var apiErr ApiError
-			if errors.As(err, &apiErr) {
-				ctx.SendError(apiErr)
-				return
-			}
-			ctx.SendError(err)
+			ctx.SendError(results[1].Interface().(error))
 			return
 		}
 
-		// Send response
+		// Send response, with the status set by the handler through
+		// ctx.Status or a Response
+		status := c.Writer.Status()
 		response := results[0].Interface()
-		if response != nil {
-			ctx.JSON(http.StatusOK, response)
+		if custom, ok := response.(handlerResponse); ok {
+			var header http.Header
+			status, header, response = custom.handlerResponse()
+			for key, values := range header {
+				for _, value := range values {
+					c.Writer.Header().Add(key, value)
+				}
+			}
+		}
+		if status < http.StatusMultipleChoices {
+			setDocumentETag(c, response)
+		}
+		if response != nil && bodyAllowed(status) {
+			writeResponse(ctx, status, response)
 		} else {
-			ctx.Status(http.StatusOK)
+			ctx.Status(status)
 		}
 	}
 }
 
+// bindRequestModel binds the request model of a typed handler, taking the
+// version of PUT and PATCH requests from If-Match
+func (c *Context) bindRequestModel(request interface{}) error {
+	if err := c.GetRequest(request); err != nil {
+		return err
+	}
+	if method := c.Request.Method; method != http.MethodPut && method != http.MethodPatch {
+		return nil
+	}
+	if err := c.ApplyIfMatch(request); err != nil {
+		c.SendError(err)
+		c.Abort()
+		return err
+	}
+	return nil
+}
+
+// finishStream handles the error returned by a StreamHandler
+func finishStream(ctx *Context, result reflect.Value) {
+	if result.IsNil() {
+		return
+	}
+	err := result.Interface().(error)
+	if !ctx.Writer.Written() {
+		ctx.SendError(err)
+		return
+	}
+	if !errors.Is(err, context.Canceled) {
+		ctx.Logger().Error("ginboot: streaming response failed", "error", err)
+	}
+}
+
+func writeResponse(ctx *Context, status int, response interface{}) {
+	serializerFor(ctx).Serialize(ctx, status, response)
+}
+
 // RegisterController registers a controller with the given path
 func (s *Server) RegisterController(path string, controller Controller) {
 	group := s.Group(path)
@@ -160,10 +268,37 @@ func (g *ControllerGroup) HEAD(relativePath string, handler interface{}, middlew
 	g.Handle("HEAD", relativePath, handler, middleware...)
 }
 
+// GETAuth registers a GET handler restricted to users with one of the roles,
+// or to any authenticated user when no roles are given. See RequireRoles.
+func (g *ControllerGroup) GETAuth(path string, handler interface{}, roles ...string) {
+	g.Handle("GET", path, handler, RequireRoles(roles...))
+}
+
+// POSTAuth registers a POST handler restricted like GETAuth
+func (g *ControllerGroup) POSTAuth(path string, handler interface{}, roles ...string) {
+	g.Handle("POST", path, handler, RequireRoles(roles...))
+}
+
+// PUTAuth registers a PUT handler restricted like GETAuth
+func (g *ControllerGroup) PUTAuth(path string, handler interface{}, roles ...string) {
+	g.Handle("PUT", path, handler, RequireRoles(roles...))
+}
+
+// DELETEAuth registers a DELETE handler restricted like GETAuth
+func (g *ControllerGroup) DELETEAuth(path string, handler interface{}, roles ...string) {
+	g.Handle("DELETE", path, handler, RequireRoles(roles...))
+}
+
+// PATCHAuth registers a PATCH handler restricted like GETAuth
+func (g *ControllerGroup) PATCHAuth(path string, handler interface{}, roles ...string) {
+	g.Handle("PATCH", path, handler, RequireRoles(roles...))
+}
+
 // Group creates a new sub-group with the given path and middleware
 func (g *ControllerGroup) Group(relativePath string, middleware ...gin.HandlerFunc) *ControllerGroup {
 	return &ControllerGroup{
-		group: g.group.Group(relativePath, middleware...),
+		group:  g.group.Group(relativePath, middleware...),
+		server: g.server,
 	}
 }
 
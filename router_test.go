@@ -159,6 +159,40 @@ func TestRouter(t *testing.T) {
 		}
 	})
 
+	t.Run("page request binding", func(t *testing.T) {
+		server := &Server{engine: gin.New()}
+		group := server.Group("/test")
+		group.GET("", func(ctx *Context, page PageRequest, req struct {
+			Status string `form:"status"`
+		}) (gin.H, error) {
+			return gin.H{"page": page.Page, "size": page.Size, "sort": page.Sort.Field, "status": req.Status}, nil
+		})
+		group.GET("/defaults", func(page PageRequest) (PageRequest, error) {
+			return page, nil
+		})
+
+		w := httptest.NewRecorder()
+		server.engine.ServeHTTP(w, httptest.NewRequest("GET", "/test?page=2&size=5&sort=name,desc&status=active", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"page":2,"size":5,"sort":"name","status":"active"}`, w.Body.String())
+
+		w = httptest.NewRecorder()
+		server.engine.ServeHTTP(w, httptest.NewRequest("GET", "/test/defaults", nil))
+		var page PageRequest
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		assert.Equal(t, 1, page.Page)
+		assert.Equal(t, 10, page.Size)
+
+		w = httptest.NewRecorder()
+		server.engine.ServeHTTP(w, httptest.NewRequest("GET", "/test?page=first", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		assert.Panics(t, func() { wrapHandler(func(page PageRequest, ctx *Context) error { return nil }) })
+		assert.Panics(t, func() {
+			wrapHandler(func(a TestRouterRequest, b TestRouterRequest) (*TestResponse, error) { return nil, nil })
+		})
+	})
+
 	t.Run("group methods", func(t *testing.T) {
 		server := &Server{engine: gin.New()}
 		group := server.Group("/test")
@@ -263,3 +297,97 @@ type MockController struct {
 func (m *MockController) Register(group *ControllerGroup) {
 	m.registerCalled = true
 }
+
+func TestRouter_SparseFieldsets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type author struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	type post struct {
+		Title  string `json:"title"`
+		Body   string `json:"body"`
+		Author author `json:"author"`
+	}
+	item := post{Title: "Hello", Body: "World", Author: author{Name: "John", Email: "john@example.com"}}
+
+	server := &Server{engine: gin.New()}
+	group := server.Group("/posts")
+	group.GET("/one", func() (post, error) {
+		return item, nil
+	}, SparseFieldsets())
+	group.GET("/page", func() (PageResponse[post], error) {
+		return PageResponse[post]{Contents: []post{item}, NumberOfElements: 1, TotalElements: 1, TotalPages: 1}, nil
+	}, SparseFieldsets())
+	group.GET("/disabled", func() (post, error) {
+		return item, nil
+	})
+	group.GET("/counter", func() (map[string]int64, error) {
+		return map[string]int64{"views": 9007199254740993, "likes": 1}, nil
+	}, SparseFieldsets())
+
+	tests := []struct {
+		name         string
+		path         string
+		expectedBody string
+	}{
+		{
+			name:         "single resource",
+			path:         "/posts/one?fields=title,author.name",
+			expectedBody: `{"title":"Hello","author":{"name":"John"}}`,
+		},
+		{
+			name:         "no fields requested",
+			path:         "/posts/one",
+			expectedBody: `{"title":"Hello","body":"World","author":{"name":"John","email":"john@example.com"}}`,
+		},
+		{
+			name: "page items",
+			path: "/posts/page?fields=title",
+			expectedBody: `{"content":[{"title":"Hello"}],"numberOfElements":1,"totalElements":1,"totalPages":1,
+				"pageable":{"page":0,"size":0,"sort":{"field":"","direction":0}}}`,
+		},
+		{
+			name:         "not enabled on route",
+			path:         "/posts/disabled?fields=title",
+			expectedBody: `{"title":"Hello","body":"World","author":{"name":"John","email":"john@example.com"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			server.engine.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+
+	t.Run("large integers keep their precision", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		server.engine.ServeHTTP(w, httptest.NewRequest("GET", "/posts/counter?fields=views", nil))
+
+		// JSONEq compares numbers as float64, which rounds this one
+		assert.Equal(t, `{"views":9007199254740993}`, w.Body.String())
+	})
+}
+
+func BenchmarkWrapHandler(b *testing.B) {
+	gin.SetMode(gin.TestMode)
+	server := &Server{engine: gin.New()}
+	server.Group("").POST("/echo", func(ctx *Context, req TestRouterRequest) (TestResponse, error) {
+		return TestResponse{Message: req.Name}, nil
+	})
+
+	body := `{"name":"bench"}`
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		server.engine.ServeHTTP(w, req)
+	}
+}
@@ -0,0 +1,185 @@
+package ginboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+const (
+	// DefaultS3PartSize is the size of the parts of multipart uploads
+	DefaultS3PartSize = 8 << 20
+	// MinS3PartSize is the smallest part S3 accepts, except for the last one
+	MinS3PartSize = 5 << 20
+	// DefaultS3Concurrency is the number of parts uploaded at once
+	DefaultS3Concurrency = 4
+	// maxS3Parts is the most parts a multipart upload can have
+	maxS3Parts = 10000
+)
+
+// S3CompletedPart identifies an uploaded part when completing a multipart
+// upload
+type S3CompletedPart struct {
+	PartNumber int32
+	ETag       string
+}
+
+// S3Client stores objects in S3. Adapt *s3.Client from aws-sdk-go-v2 with
+// functions calling PutObject and the multipart upload operations of the same
+// names.
+type S3Client interface {
+	PutObject(ctx context.Context, bucket, key, contentType string, body []byte) error
+	CreateMultipartUpload(ctx context.Context, bucket, key, contentType string) (uploadID string, err error)
+	UploadPart(ctx context.Context, bucket, key, uploadID string, partNumber int32, body []byte) (etag string, err error)
+	CompleteMultipartUpload(ctx context.Context, bucket, key, uploadID string, parts []S3CompletedPart) error
+	AbortMultipartUpload(ctx context.Context, bucket, key, uploadID string) error
+}
+
+// S3FileService is a FileService storing files in an S3 bucket. Large files
+// are streamed as multipart uploads, holding at most Concurrency+1 parts in
+// memory, so they need no local temp file.
+type S3FileService struct {
+	Client S3Client
+	Bucket string
+	// PartSize is the size of each part, DefaultS3PartSize when zero and at
+	// least MinS3PartSize. Uploads are limited to 10000 parts.
+	PartSize int64
+	// Concurrency is the number of parts uploaded at once,
+	// DefaultS3Concurrency when zero
+	Concurrency int
+}
+
+func (s S3FileService) Upload(ctx context.Context, path string, body io.Reader, contentType string) error {
+	return s.upload(ctx, body, path, contentType)
+}
+
+// UploadStream uploads the reader's content to remotePath, as a single
+// object when it fits in one part and as a multipart upload otherwise. A
+// failed multipart upload is aborted.
+func (s S3FileService) UploadStream(ctx context.Context, body io.Reader, remotePath string) error {
+	return s.upload(ctx, body, remotePath, "application/octet-stream")
+}
+
+func (s S3FileService) upload(ctx context.Context, body io.Reader, key, contentType string) error {
+	partSize := s.PartSize
+	if partSize == 0 {
+		partSize = DefaultS3PartSize
+	}
+	if partSize < MinS3PartSize {
+		partSize = MinS3PartSize
+	}
+
+	first, last, err := readS3Part(body, partSize)
+	if err != nil {
+		return err
+	}
+	if last {
+		return s.Client.PutObject(ctx, s.Bucket, key, contentType, first)
+	}
+
+	uploadID, err := s.Client.CreateMultipartUpload(ctx, s.Bucket, key, contentType)
+	if err != nil {
+		return err
+	}
+	parts, err := s.uploadParts(ctx, body, key, uploadID, first, partSize)
+	if err != nil {
+		// the parts uploaded so far are billed until the upload is aborted
+		if abortErr := s.Client.AbortMultipartUpload(context.WithoutCancel(ctx), s.Bucket, key, uploadID); abortErr != nil {
+			return errors.Join(err, abortErr)
+		}
+		return err
+	}
+	return s.Client.CompleteMultipartUpload(ctx, s.Bucket, key, uploadID, parts)
+}
+
+func (s S3FileService) uploadParts(ctx context.Context, body io.Reader, key, uploadID string, first []byte, partSize int64) ([]S3CompletedPart, error) {
+	concurrency := s.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultS3Concurrency
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		parts    []S3CompletedPart
+		firstErr error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+	slots := make(chan struct{}, concurrency)
+
+	part, last := first, false
+	for number := int32(1); ; number++ {
+		if number > maxS3Parts {
+			fail(fmt.Errorf("upload needs more than %d parts, increase PartSize", maxS3Parts))
+			break
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(number int32, data []byte) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			etag, err := s.Client.UploadPart(ctx, s.Bucket, key, uploadID, number, data)
+			if err != nil {
+				fail(err)
+				return
+			}
+			mu.Lock()
+			parts = append(parts, S3CompletedPart{PartNumber: number, ETag: etag})
+			mu.Unlock()
+		}(number, part)
+
+		if last {
+			break
+		}
+		var err error
+		if part, last, err = readS3Part(body, partSize); err != nil {
+			fail(err)
+			break
+		}
+		if len(part) == 0 {
+			break
+		}
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+	return parts, nil
+}
+
+// readS3Part reads up to partSize bytes, reporting whether the reader is
+// exhausted
+func readS3Part(body io.Reader, partSize int64) ([]byte, bool, error) {
+	part := make([]byte, partSize)
+	n, err := io.ReadFull(body, part)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return part[:n], true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return part, false, nil
+}
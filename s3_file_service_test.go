@@ -0,0 +1,115 @@
+package ginboot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeS3Client struct {
+	mu        sync.Mutex
+	objects   map[string][]byte
+	uploads   map[string]map[int32][]byte
+	aborted   []string
+	failPart  int32
+	maxActive int
+	active    int
+}
+
+func newFakeS3Client() *fakeS3Client {
+	return &fakeS3Client{objects: map[string][]byte{}, uploads: map[string]map[int32][]byte{}}
+}
+
+func (c *fakeS3Client) PutObject(ctx context.Context, bucket, key, contentType string, body []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.objects[bucket+"/"+key] = body
+	return nil
+}
+
+func (c *fakeS3Client) CreateMultipartUpload(ctx context.Context, bucket, key, contentType string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.uploads[key] = map[int32][]byte{}
+	return key, nil
+}
+
+func (c *fakeS3Client) UploadPart(ctx context.Context, bucket, key, uploadID string, partNumber int32, body []byte) (string, error) {
+	c.mu.Lock()
+	c.active++
+	c.maxActive = max(c.maxActive, c.active)
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.active--
+		c.mu.Unlock()
+	}()
+	if partNumber == c.failPart {
+		return "", errors.New("connection reset")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.uploads[uploadID][partNumber] = body
+	return fmt.Sprintf("etag-%d", partNumber), nil
+}
+
+func (c *fakeS3Client) CompleteMultipartUpload(ctx context.Context, bucket, key, uploadID string, parts []S3CompletedPart) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var object []byte
+	for i, part := range parts {
+		if part.PartNumber != int32(i+1) || part.ETag != fmt.Sprintf("etag-%d", i+1) {
+			return errors.New("parts out of order")
+		}
+		object = append(object, c.uploads[uploadID][part.PartNumber]...)
+	}
+	c.objects[bucket+"/"+key] = object
+	return nil
+}
+
+func (c *fakeS3Client) AbortMultipartUpload(ctx context.Context, bucket, key, uploadID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.aborted = append(c.aborted, uploadID)
+	return nil
+}
+
+func TestS3FileService_Upload(t *testing.T) {
+	client := newFakeS3Client()
+	service := S3FileService{Client: client, Bucket: "files"}
+
+	err := service.Upload(context.Background(), "notes.txt", bytes.NewReader([]byte("meeting notes")), "text/plain")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("meeting notes"), client.objects["files/notes.txt"])
+	assert.Empty(t, client.uploads)
+}
+
+func TestS3FileService_UploadStreamMultipart(t *testing.T) {
+	client := newFakeS3Client()
+	service := S3FileService{Client: client, Bucket: "files", PartSize: MinS3PartSize, Concurrency: 2}
+
+	content := bytes.Repeat([]byte("0123456789"), (MinS3PartSize*4+100)/10)
+	err := service.UploadStream(context.Background(), bytes.NewReader(content), "backup.tar")
+	assert.NoError(t, err)
+	assert.Len(t, client.uploads["backup.tar"], 5)
+	assert.Equal(t, content, client.objects["files/backup.tar"])
+	assert.LessOrEqual(t, client.maxActive, 2)
+	assert.Empty(t, client.aborted)
+}
+
+func TestS3FileService_UploadStreamAbortsOnFailure(t *testing.T) {
+	client := newFakeS3Client()
+	client.failPart = 2
+	service := S3FileService{Client: client, Bucket: "files", PartSize: MinS3PartSize}
+
+	content := make([]byte, MinS3PartSize*3)
+	err := service.UploadStream(context.Background(), bytes.NewReader(content), "backup.tar")
+	assert.EqualError(t, err, "connection reset")
+	assert.Equal(t, []string{"backup.tar"}, client.aborted)
+	assert.NotContains(t, client.objects, "files/backup.tar")
+}
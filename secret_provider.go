@@ -0,0 +1,94 @@
+package ginboot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// SecretProvider resolves named secrets such as signing keys and encoder salts
+type SecretProvider interface {
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+// SecretProviderFunc adapts a function to the SecretProvider interface, which
+// makes it easy to plug in SSM Parameter Store, Secrets Manager or Vault clients
+type SecretProviderFunc func(ctx context.Context, name string) (string, error)
+
+func (f SecretProviderFunc) GetSecret(ctx context.Context, name string) (string, error) {
+	return f(ctx, name)
+}
+
+// EnvSecretProvider reads secrets from environment variables
+type EnvSecretProvider struct{}
+
+func (EnvSecretProvider) GetSecret(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("secret %s is not set", name)
+	}
+	return value, nil
+}
+
+type cachedSecret struct {
+	value     string
+	expiresAt time.Time
+}
+
+// CachedSecretProvider caches the secrets returned by another provider for the
+// given duration so remote stores are not queried on every request
+type CachedSecretProvider struct {
+	provider SecretProvider
+	ttl      time.Duration
+	mu       sync.RWMutex
+	secrets  map[string]cachedSecret
+}
+
+func NewCachedSecretProvider(provider SecretProvider, ttl time.Duration) *CachedSecretProvider {
+	return &CachedSecretProvider{
+		provider: provider,
+		ttl:      ttl,
+		secrets:  make(map[string]cachedSecret),
+	}
+}
+
+func (p *CachedSecretProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	p.mu.RLock()
+	secret, ok := p.secrets[name]
+	p.mu.RUnlock()
+	if ok && time.Now().Before(secret.expiresAt) {
+		return secret.value, nil
+	}
+
+	value, err := p.provider.GetSecret(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	p.secrets[name] = cachedSecret{value: value, expiresAt: time.Now().Add(p.ttl)}
+	p.mu.Unlock()
+	return value, nil
+}
+
+var (
+	secretProviderMu sync.RWMutex
+	secretProvider   SecretProvider = EnvSecretProvider{}
+)
+
+// SetSecretProvider replaces the provider used by the token helpers and
+// NewPBKDF2Encoder, which read from environment variables by default
+func SetSecretProvider(provider SecretProvider) {
+	secretProviderMu.Lock()
+	defer secretProviderMu.Unlock()
+	secretProvider = provider
+}
+
+func getSecret(name string) (string, error) {
+	secretProviderMu.RLock()
+	provider := secretProvider
+	secretProviderMu.RUnlock()
+	return provider.GetSecret(context.Background(), name)
+}
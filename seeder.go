@@ -0,0 +1,105 @@
+package ginboot
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SeedRecord marks a seeder as applied, so it does not run again
+type SeedRecord struct {
+	ID        string    `bson:"_id" ginboot:"_id" json:"id" db:"id"`
+	AppliedAt time.Time `bson:"applied_at" json:"appliedAt" db:"applied_at"`
+}
+
+type seeder struct {
+	name string
+	seed func(ctx context.Context) error
+}
+
+// seedLockName names the lock serialising the runs of all instances
+const seedLockName = "ginboot:seeders"
+
+// SeedRunner bootstraps reference data such as roles and plans at startup.
+// Seeders run in the order they were added, each once per environment: the
+// applied ones are recorded in the repository and skipped on later runs.
+// Instances starting together must share a lock, see WithLock.
+type SeedRunner struct {
+	repo    GenericRepository[SeedRecord]
+	seeders []seeder
+	locks   LockService
+	lockTTL time.Duration
+}
+
+func NewSeedRunner(repo GenericRepository[SeedRecord]) *SeedRunner {
+	return &SeedRunner{repo: repo}
+}
+
+// WithLock runs the seeders while holding a lock of locks, so instances
+// starting together do not seed twice. An instance finding the lock taken
+// waits for it and then skips the seeders the holder applied. ttl bounds how
+// long a crashed holder blocks the others, a minute when zero; the lock is
+// renewed while seeding.
+func (r *SeedRunner) WithLock(locks LockService, ttl time.Duration) *SeedRunner {
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	r.locks = locks
+	r.lockTTL = ttl
+	return r
+}
+
+// Add appends a seeder. The name identifies it in the seed records, so it must
+// not change once the seeder was applied. Adding a name twice panics.
+func (r *SeedRunner) Add(name string, seed func(ctx context.Context) error) *SeedRunner {
+	for _, s := range r.seeders {
+		if s.name == name {
+			panic("seeder " + name + " is already added")
+		}
+	}
+	r.seeders = append(r.seeders, seeder{name: name, seed: seed})
+	return r
+}
+
+// Run applies the seeders that were not applied yet, stopping at the first
+// one that fails. A failed seeder is not recorded and runs again next time.
+// With a lock, Run waits until it holds the lock or ctx is done.
+func (r *SeedRunner) Run(ctx context.Context) error {
+	if r.locks == nil {
+		return r.run(ctx)
+	}
+	retry := r.lockTTL / 10
+	for {
+		ran, err := WithLock(ctx, r.locks, seedLockName, r.lockTTL, r.run)
+		if err != nil {
+			return err
+		}
+		if ran {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retry):
+		}
+	}
+}
+
+func (r *SeedRunner) run(ctx context.Context) error {
+	for _, s := range r.seeders {
+		records, err := r.repo.FindAllById(ctx, []string{s.name})
+		if err != nil {
+			return fmt.Errorf("seeder %s: %w", s.name, err)
+		}
+		if len(records) > 0 {
+			continue
+		}
+		if err := s.seed(ctx); err != nil {
+			return fmt.Errorf("seeder %s: %w", s.name, err)
+		}
+		if err := r.repo.Save(ctx, SeedRecord{ID: s.name, AppliedAt: time.Now()}); err != nil {
+			return fmt.Errorf("seeder %s: %w", s.name, err)
+		}
+	}
+	return nil
+}
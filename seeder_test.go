@@ -0,0 +1,87 @@
+package ginboot
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeedRunner(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository[SeedRecord]()
+	var ran []string
+	failPlans := true
+
+	newRunner := func() *SeedRunner {
+		return NewSeedRunner(repo).
+			Add("roles", func(ctx context.Context) error {
+				ran = append(ran, "roles")
+				return nil
+			}).
+			Add("plans", func(ctx context.Context) error {
+				ran = append(ran, "plans")
+				if failPlans {
+					return errors.New("plans unavailable")
+				}
+				return nil
+			})
+	}
+
+	err := newRunner().Run(ctx)
+	assert.ErrorContains(t, err, "seeder plans: plans unavailable")
+	assert.Equal(t, []string{"roles", "plans"}, ran)
+
+	// applied seeders are skipped, failed ones run again
+	ran = nil
+	failPlans = false
+	assert.NoError(t, newRunner().Run(ctx))
+	assert.Equal(t, []string{"plans"}, ran)
+
+	ran = nil
+	assert.NoError(t, newRunner().Run(ctx))
+	assert.Empty(t, ran)
+
+	records, err := repo.FindAll(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	assert.Panics(t, func() {
+		NewSeedRunner(repo).Add("roles", nil).Add("roles", nil)
+	})
+}
+
+func TestSeedRunner_Lock(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository[SeedRecord]()
+	locks := NewRepositoryLockService(newMemoryRepository[Lock]())
+	var runs atomic.Int32
+
+	// instances starting together seed once
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = NewSeedRunner(repo).
+				WithLock(locks, 100*time.Millisecond).
+				Add("roles", func(ctx context.Context) error {
+					runs.Add(1)
+					time.Sleep(20 * time.Millisecond)
+					return nil
+				}).
+				Run(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), runs.Load())
+}
@@ -0,0 +1,58 @@
+package ginboot
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SequenceService hands out increasing numbers per named sequence, for human
+// readable identifiers such as invoice numbers. Values are never handed out
+// twice, but a value is lost when the write using it fails, so sequences can
+// have gaps.
+type SequenceService interface {
+	// NextVal increments the sequence and returns its value, 1 for a new
+	// sequence
+	NextVal(ctx context.Context, name string) (int64, error)
+}
+
+// MongoSequenceService keeps each sequence in a document of the collection,
+// incremented atomically with findAndModify
+type MongoSequenceService struct {
+	collection *mongo.Collection
+}
+
+func NewMongoSequenceService(db *mongo.Database, collectionName string) *MongoSequenceService {
+	return &MongoSequenceService{collection: db.Collection(collectionName)}
+}
+
+func (s *MongoSequenceService) NextVal(ctx context.Context, name string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	value, err := s.increment(ctx, name)
+	// concurrent upserts creating the same sequence may collide once
+	if errors.Is(err, ErrDuplicateKey) {
+		value, err = s.increment(ctx, name)
+	}
+	return value, err
+}
+
+func (s *MongoSequenceService) increment(ctx context.Context, name string) (int64, error) {
+	var sequence struct {
+		Value int64 `bson:"value"`
+	}
+	err := s.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": name},
+		bson.M{"$inc": bson.M{"value": int64(1)}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&sequence)
+	if err != nil {
+		return 0, NormalizeError(err)
+	}
+	return sequence.Value, nil
+}
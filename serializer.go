@@ -0,0 +1,407 @@
+package ginboot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+const serializerKey = "ginboot.serializer"
+
+// ResponseSerializer writes the value returned by a typed handler
+type ResponseSerializer interface {
+	Serialize(ctx *Context, status int, response interface{})
+}
+
+// UseSerializer selects the response serializer for a group or route,
+// overriding the server wide one
+func UseSerializer(serializer ResponseSerializer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(serializerKey, serializer)
+		c.Next()
+	}
+}
+
+func serializerFor(ctx *Context) ResponseSerializer {
+	if value, ok := ctx.Get(serializerKey); ok {
+		if serializer, ok := value.(ResponseSerializer); ok {
+			return serializer
+		}
+	}
+	return JSONSerializer{}
+}
+
+// ErrorSerializer is implemented by serializers that also shape the error
+// responses of SendError, e.g. to wrap them in an envelope
+type ErrorSerializer interface {
+	SerializeError(ctx *Context, status int, body gin.H)
+}
+
+// writeError writes an error body through the route's serializer when it
+// implements ErrorSerializer, and as plain JSON otherwise
+func writeError(c *gin.Context, status int, body gin.H) {
+	if value, ok := c.Get(serializerKey); ok {
+		if serializer, ok := value.(ErrorSerializer); ok {
+			serializer.SerializeError(NewContext(c), status, body)
+			return
+		}
+	}
+	c.JSON(status, body)
+}
+
+// JSONSerializer writes responses as plain JSON. It is the default serializer.
+type JSONSerializer struct{}
+
+func (JSONSerializer) Serialize(ctx *Context, status int, response interface{}) {
+	fields, naming := requestedFieldSet(ctx), namingStrategyFor(ctx)
+	if fields != nil || naming != nil {
+		reshaped, err := pruneResponse(response, fields, naming)
+		if err != nil {
+			ctx.SendError(err)
+			return
+		}
+		response = reshaped
+	}
+	writeJSON(ctx, status, "application/json; charset=utf-8", response)
+}
+
+// maxPooledBufferSize keeps unusually large responses from pinning memory in the pool
+const maxPooledBufferSize = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// writeJSON encodes the response into a pooled buffer, avoiding a fresh
+// allocation per response on the hot path
+func writeJSON(ctx *Context, status int, contentType string, response interface{}) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBufferSize {
+			bufferPool.Put(buf)
+		}
+	}()
+
+	if err := json.NewEncoder(buf).Encode(response); err != nil {
+		ctx.SendError(err)
+		return
+	}
+	// Encode terminates the value with a newline that c.JSON does not write
+	ctx.Data(status, contentType, bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+// writeDocument writes a JSON:API or HAL document
+func writeDocument(ctx *Context, status int, contentType string, document map[string]interface{}) {
+	writeJSON(ctx, status, contentType, document)
+}
+
+// ResourceTyper lets an entity choose its JSON:API type or HAL relation name,
+// which otherwise is the lower-cased struct name
+type ResourceTyper interface {
+	ResourceType() string
+}
+
+// JSONAPISerializer writes structs, slices and pages as JSON:API documents.
+// The ID comes from the field tagged ginboot:"_id" (or named ID/Id), and fields
+// tagged jsonapi:"relation,<type>" holding IDs become relationships.
+type JSONAPISerializer struct{}
+
+func (JSONAPISerializer) Serialize(ctx *Context, status int, response interface{}) {
+	fields, naming := requestedFieldSet(ctx), namingStrategyFor(ctx)
+	var document map[string]interface{}
+
+	if page, ok := response.(pagedResponse); ok {
+		data, ok := jsonAPIResources(page.pageContents(), fields, naming)
+		if !ok {
+			JSONSerializer{}.Serialize(ctx, status, response)
+			return
+		}
+		pageable, totalElements, totalPages := page.pageInfo()
+		document = map[string]interface{}{
+			"data": data,
+			"meta": map[string]interface{}{
+				"page":                               pageable.Page,
+				"size":                               pageable.Size,
+				documentKey(naming, "totalElements"): totalElements,
+				documentKey(naming, "totalPages"):    totalPages,
+			},
+			"links": pageLinks(ctx, pageable.Page, totalPages, func(href string) interface{} { return href }),
+		}
+	} else {
+		data, ok := jsonAPIResources(response, fields, naming)
+		if !ok {
+			JSONSerializer{}.Serialize(ctx, status, response)
+			return
+		}
+		document = map[string]interface{}{
+			"data":  data,
+			"links": map[string]interface{}{"self": selfLink(ctx)},
+		}
+	}
+
+	writeDocument(ctx, status, "application/vnd.api+json", document)
+}
+
+func jsonAPIResources(value interface{}, fields fieldSet, naming NamingStrategy) (interface{}, bool) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, true
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Slice {
+		data := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			resource, ok := newResource(v.Index(i), naming)
+			if !ok {
+				return nil, false
+			}
+			data = append(data, resource.jsonAPI(fields))
+		}
+		return data, true
+	}
+
+	resource, ok := newResource(v, naming)
+	if !ok {
+		return nil, false
+	}
+	return resource.jsonAPI(fields), true
+}
+
+// HALSerializer writes structs, slices and pages as HAL documents, adding
+// self links to resources and embedding collection items
+type HALSerializer struct{}
+
+func (HALSerializer) Serialize(ctx *Context, status int, response interface{}) {
+	fields, naming := requestedFieldSet(ctx), namingStrategyFor(ctx)
+	self := selfLink(ctx)
+	var document map[string]interface{}
+
+	if page, ok := response.(pagedResponse); ok {
+		relation, items, ok := halEmbedded(page.pageContents(), ctx.PublicPath(ctx.Request.URL.Path), fields, naming)
+		if !ok {
+			JSONSerializer{}.Serialize(ctx, status, response)
+			return
+		}
+		pageable, totalElements, totalPages := page.pageInfo()
+		document = map[string]interface{}{
+			"_embedded": map[string]interface{}{relation: items},
+			"_links": pageLinks(ctx, pageable.Page, totalPages, func(href string) interface{} {
+				return map[string]string{"href": href}
+			}),
+			"page": map[string]interface{}{
+				"number":                             pageable.Page,
+				"size":                               pageable.Size,
+				documentKey(naming, "totalElements"): totalElements,
+				documentKey(naming, "totalPages"):    totalPages,
+			},
+		}
+	} else {
+		v := reflect.ValueOf(response)
+		for v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+		if v.Kind() == reflect.Slice {
+			relation, items, ok := halEmbedded(v.Interface(), ctx.PublicPath(ctx.Request.URL.Path), fields, naming)
+			if !ok {
+				JSONSerializer{}.Serialize(ctx, status, response)
+				return
+			}
+			document = map[string]interface{}{
+				"_embedded": map[string]interface{}{relation: items},
+				"_links":    map[string]interface{}{"self": map[string]string{"href": self}},
+			}
+		} else {
+			resource, ok := newResource(v, naming)
+			if !ok {
+				JSONSerializer{}.Serialize(ctx, status, response)
+				return
+			}
+			document = resource.hal(self, fields)
+		}
+	}
+
+	writeDocument(ctx, status, "application/hal+json", document)
+}
+
+func halEmbedded(value interface{}, collectionPath string, fields fieldSet, naming NamingStrategy) (string, []interface{}, bool) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice {
+		return "", nil, false
+	}
+	relation := "items"
+	items := make([]interface{}, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		resource, ok := newResource(v.Index(i), naming)
+		if !ok {
+			return "", nil, false
+		}
+		relation = resource.typ
+		items = append(items, resource.hal(strings.TrimSuffix(collectionPath, "/")+"/"+resource.id, fields))
+	}
+	return relation, items, true
+}
+
+// resource is a struct value split into identity, attributes and relationships
+type resource struct {
+	typ           string
+	id            string
+	idKey         string
+	attributes    map[string]interface{}
+	relationships map[string]interface{}
+}
+
+func newResource(v reflect.Value, naming NamingStrategy) (resource, bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return resource{}, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return resource{}, false
+	}
+
+	encoded, err := json.Marshal(v.Interface())
+	if err != nil {
+		return resource{}, false
+	}
+	var attributes map[string]interface{}
+	if err := json.Unmarshal(encoded, &attributes); err != nil {
+		return resource{}, false
+	}
+	if naming != nil {
+		attributes = renameFields(v.Interface(), attributes, naming).(map[string]interface{})
+	}
+
+	r := resource{
+		typ:           strings.ToLower(v.Type().Name()),
+		attributes:    attributes,
+		relationships: make(map[string]interface{}),
+	}
+	if typer, ok := v.Interface().(ResourceTyper); ok {
+		r.typ = typer.ResourceType()
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := jsonFieldName(field)
+		if name == "" {
+			continue
+		}
+		if naming != nil {
+			name = naming(name)
+		}
+		if hasGinbootTag(field, "_id") || (r.idKey == "" && (field.Name == "ID" || field.Name == "Id")) {
+			r.idKey = name
+			r.id = fmt.Sprint(v.Field(i).Interface())
+		}
+		if relation, ok := strings.CutPrefix(field.Tag.Get("jsonapi"), "relation,"); ok {
+			r.relationships[name] = map[string]interface{}{"data": relationLinkage(relation, v.Field(i))}
+		}
+	}
+	return r, true
+}
+
+func relationLinkage(relationType string, v reflect.Value) interface{} {
+	if v.Kind() == reflect.Slice {
+		linkage := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			linkage = append(linkage, map[string]string{"type": relationType, "id": fmt.Sprint(v.Index(i).Interface())})
+		}
+		return linkage
+	}
+	if v.IsZero() {
+		return nil
+	}
+	return map[string]string{"type": relationType, "id": fmt.Sprint(v.Interface())}
+}
+
+func (r resource) jsonAPI(fields fieldSet) map[string]interface{} {
+	attributes := make(map[string]interface{}, len(r.attributes))
+	for key, value := range r.attributes {
+		if _, isRelationship := r.relationships[key]; key != r.idKey && !isRelationship {
+			attributes[key] = value
+		}
+	}
+	object := map[string]interface{}{
+		"type": r.typ,
+		"id":   r.id,
+	}
+	if fields != nil {
+		object["attributes"] = pruneFields(attributes, fields)
+	} else {
+		object["attributes"] = attributes
+	}
+	if len(r.relationships) > 0 {
+		object["relationships"] = r.relationships
+	}
+	return object
+}
+
+func (r resource) hal(self string, fields fieldSet) map[string]interface{} {
+	object := r.attributes
+	if fields != nil {
+		object = pruneFields(r.attributes, fields).(map[string]interface{})
+	}
+	object["_links"] = map[string]interface{}{"self": map[string]string{"href": self}}
+	return object
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func selfLink(ctx *Context) string {
+	link := ctx.PublicPath(ctx.Request.URL.Path)
+	if ctx.Request.URL.RawQuery != "" {
+		link += "?" + ctx.Request.URL.RawQuery
+	}
+	return link
+}
+
+// pageLinks builds self/first/last/prev/next links that keep the request's
+// query parameters and respect the proxy base path
+func pageLinks(ctx *Context, page, totalPages int, link func(href string) interface{}) map[string]interface{} {
+	pageHref := func(number int) string {
+		query := ctx.Request.URL.Query()
+		query.Set("page", strconv.Itoa(number))
+		return ctx.PublicPath(ctx.Request.URL.Path) + "?" + query.Encode()
+	}
+	links := map[string]interface{}{
+		"self":  link(pageHref(page)),
+		"first": link(pageHref(1)),
+	}
+	if totalPages > 0 {
+		links["last"] = link(pageHref(totalPages))
+	}
+	if page > 1 {
+		links["prev"] = link(pageHref(page - 1))
+	}
+	if page < totalPages {
+		links["next"] = link(pageHref(page + 1))
+	}
+	return links
+}
@@ -0,0 +1,156 @@
+package ginboot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type serializerPost struct {
+	ID       string   `ginboot:"_id" json:"id"`
+	Title    string   `json:"title"`
+	AuthorID string   `json:"authorId" jsonapi:"relation,users"`
+	TagIDs   []string `json:"tagIds" jsonapi:"relation,tags"`
+}
+
+func (serializerPost) ResourceType() string {
+	return "posts"
+}
+
+func TestSerializer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	item := serializerPost{ID: "1", Title: "Hello", AuthorID: "u1", TagIDs: []string{"t1"}}
+	page := PageResponse[serializerPost]{
+		Contents:         []serializerPost{item},
+		NumberOfElements: 1,
+		Pageable:         PageRequest{Page: 2, Size: 1},
+		TotalElements:    3,
+		TotalPages:       3,
+	}
+
+	server := &Server{engine: gin.New()}
+	server.SetSerializer(JSONAPISerializer{})
+	server.engine.Use(server.settingsMiddleware())
+
+	api := server.Group("/api")
+	api.GET("/posts/1", func() (serializerPost, error) {
+		return item, nil
+	}, SparseFieldsets())
+	api.GET("/posts", func() (PageResponse[serializerPost], error) {
+		return page, nil
+	})
+	api.GET("/message", func() (TestResponse, error) {
+		return TestResponse{Message: "ok"}, nil
+	})
+	api.GET("/names", func() ([]string, error) {
+		return []string{"a"}, nil
+	})
+
+	hal := server.Group("/hal", UseSerializer(HALSerializer{}))
+	hal.GET("/posts/1", func() (serializerPost, error) {
+		return item, nil
+	})
+	hal.GET("/posts", func() (PageResponse[serializerPost], error) {
+		return page, nil
+	})
+
+	tests := []struct {
+		name                string
+		path                string
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "json api resource",
+			path:                "/api/posts/1",
+			expectedContentType: "application/vnd.api+json",
+			expectedBody: `{"data":{"type":"posts","id":"1","attributes":{"title":"Hello"},
+				"relationships":{"authorId":{"data":{"type":"users","id":"u1"}},"tagIds":{"data":[{"type":"tags","id":"t1"}]}}},
+				"links":{"self":"/api/posts/1"}}`,
+		},
+		{
+			name:                "json api sparse fieldset",
+			path:                "/api/posts/1?fields=id",
+			expectedContentType: "application/vnd.api+json",
+			expectedBody: `{"data":{"type":"posts","id":"1","attributes":{},
+				"relationships":{"authorId":{"data":{"type":"users","id":"u1"}},"tagIds":{"data":[{"type":"tags","id":"t1"}]}}},
+				"links":{"self":"/api/posts/1?fields=id"}}`,
+		},
+		{
+			name:                "json api page",
+			path:                "/api/posts?page=2&size=1",
+			expectedContentType: "application/vnd.api+json",
+			expectedBody: `{"data":[{"type":"posts","id":"1","attributes":{"title":"Hello"},
+				"relationships":{"authorId":{"data":{"type":"users","id":"u1"}},"tagIds":{"data":[{"type":"tags","id":"t1"}]}}}],
+				"meta":{"page":2,"size":1,"totalElements":3,"totalPages":3},
+				"links":{"self":"/api/posts?page=2&size=1","first":"/api/posts?page=1&size=1","last":"/api/posts?page=3&size=1",
+				"prev":"/api/posts?page=1&size=1","next":"/api/posts?page=3&size=1"}}`,
+		},
+		{
+			name:                "struct without id",
+			path:                "/api/message",
+			expectedContentType: "application/vnd.api+json",
+			expectedBody:        `{"data":{"type":"testresponse","id":"","attributes":{"message":"ok"}},"links":{"self":"/api/message"}}`,
+		},
+		{
+			name:                "non resource falls back to json",
+			path:                "/api/names",
+			expectedContentType: "application/json; charset=utf-8",
+			expectedBody:        `["a"]`,
+		},
+		{
+			name:                "hal resource",
+			path:                "/hal/posts/1",
+			expectedContentType: "application/hal+json",
+			expectedBody: `{"id":"1","title":"Hello","authorId":"u1","tagIds":["t1"],
+				"_links":{"self":{"href":"/hal/posts/1"}}}`,
+		},
+		{
+			name:                "hal page",
+			path:                "/hal/posts?page=2",
+			expectedContentType: "application/hal+json",
+			expectedBody: `{"_embedded":{"posts":[{"id":"1","title":"Hello","authorId":"u1","tagIds":["t1"],
+				"_links":{"self":{"href":"/hal/posts/1"}}}]},
+				"_links":{"self":{"href":"/hal/posts?page=2"},"first":{"href":"/hal/posts?page=1"},"last":{"href":"/hal/posts?page=3"},
+				"prev":{"href":"/hal/posts?page=1"},"next":{"href":"/hal/posts?page=3"}},
+				"page":{"number":2,"size":1,"totalElements":3,"totalPages":3}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			server.engine.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expectedContentType, w.Header().Get("Content-Type"))
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func BenchmarkJSONSerializer(b *testing.B) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	page := PageResponse[serializerPost]{
+		Contents:         make([]serializerPost, 50),
+		NumberOfElements: 50,
+		Pageable:         PageRequest{Page: 1, Size: 50},
+		TotalPages:       1,
+		TotalElements:    50,
+	}
+	engine.GET("/posts", func(c *gin.Context) {
+		JSONSerializer{}.Serialize(NewContext(c), http.StatusOK, page)
+	})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts", nil))
+	}
+}
@@ -2,8 +2,15 @@ package ginboot
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -20,11 +27,37 @@ const (
 	RuntimeHTTP   Runtime = "http"
 )
 
+// Lambda initialization types reported through AWS_LAMBDA_INITIALIZATION_TYPE
+const (
+	LambdaInitOnDemand               = "on-demand"
+	LambdaInitProvisionedConcurrency = "provisioned-concurrency"
+	LambdaInitSnapStart              = "snap-start"
+)
+
+// DefaultShutdownTimeout bounds how long StartWithShutdown waits for in-flight
+// requests and shutdown hooks
+const DefaultShutdownTimeout = 30 * time.Second
+
 type Server struct {
-	engine     *gin.Engine
-	runtime    Runtime
-	corsConfig *cors.Config
-	basePath   string
+	engine          *gin.Engine
+	runtime         Runtime
+	corsConfig      *cors.Config
+	basePath        string
+	preWarmHooks    []func(ctx context.Context)
+	lambdaStreaming bool
+	proxyBasePath   string
+	trustedProxies  []*net.IPNet
+	serializer      ResponseSerializer
+	namingStrategy  NamingStrategy
+	strictOrder     bool
+	shutdownTimeout time.Duration
+	startHooks      []func(ctx context.Context) error
+	stopHooks       []func(ctx context.Context) error
+	mu              sync.Mutex
+	httpServer      *http.Server
+	hub             *Hub
+	fileService     FileService
+	diagnostics     map[string]DiagnosticsSource
 }
 
 func New() *Server {
@@ -33,10 +66,43 @@ func New() *Server {
 		runtime = RuntimeLambda
 	}
 
-	return &Server{
+	server := &Server{
 		engine:  gin.Default(),
 		runtime: runtime,
 	}
+	// lets *Context be passed as a context.Context carrying the request's
+	// deadline and cancellation, e.g. to repository methods
+	server.engine.ContextWithFallback = true
+	server.engine.Use(server.settingsMiddleware())
+	registerValidations()
+	return server
+}
+
+// settingsMiddleware exposes server level settings to handlers through the
+// gin context. It is registered before any route so it applies to all of them.
+func (s *Server) settingsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// created here, Memo needs no lock shared between requests
+		c.Set(memoKey, &requestMemo{})
+		if s.proxyBasePath != "" {
+			c.Set(proxyBasePathKey, s.proxyBasePath)
+		}
+		// on Lambda every request comes through API Gateway or a function URL,
+		// which set the forwarded headers themselves
+		if s.runtime == RuntimeLambda || s.isTrustedProxy(c.RemoteIP()) {
+			c.Set(trustedProxyKey, true)
+		}
+		if s.serializer != nil {
+			c.Set(serializerKey, s.serializer)
+		}
+		if s.namingStrategy != nil {
+			c.Set(namingStrategyKey, s.namingStrategy)
+		}
+		if s.fileService != nil {
+			c.Set(fileServiceKey, s.fileService)
+		}
+		c.Next()
+	}
 }
 
 func (s *Server) Engine() *gin.Engine {
@@ -50,22 +116,175 @@ func (s *Server) Start(port int) error {
 	return s.startHTTP(port)
 }
 
+// PreWarm registers a hook that runs once before the server starts serving.
+// On Lambda this happens during the init phase, so connecting databases and
+// warming caches here keeps that work out of the first invocation.
+func (s *Server) PreWarm(hook func(ctx context.Context)) *Server {
+	s.preWarmHooks = append(s.preWarmHooks, hook)
+	return s
+}
+
+// OnStart registers a hook that runs before the server starts serving, in
+// both runtimes, e.g. to connect databases and create repositories. Hooks run
+// in the order they were registered, before the PreWarm hooks; the first error
+// stops the server from starting and is returned by Start.
+func (s *Server) OnStart(hook func(ctx context.Context) error) *Server {
+	s.startHooks = append(s.startHooks, hook)
+	return s
+}
+
+func (s *Server) runStartHooks() error {
+	ctx := context.Background()
+	for _, hook := range s.startHooks {
+		if err := hook(ctx); err != nil {
+			return fmt.Errorf("ginboot: start hook failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *Server) runPreWarm() {
+	ctx := context.Background()
+	for _, hook := range s.preWarmHooks {
+		hook(ctx)
+	}
+}
+
+// LambdaInitializationType returns how the current Lambda execution
+// environment was initialized, or an empty string outside of Lambda.
+func LambdaInitializationType() string {
+	return os.Getenv("AWS_LAMBDA_INITIALIZATION_TYPE")
+}
+
 func (s *Server) startHTTP(port int) error {
-	addr := fmt.Sprintf(":%d", port)
-	return s.engine.Run(addr)
+	if err := s.runStartHooks(); err != nil {
+		return err
+	}
+	s.runPreWarm()
+	s.mu.Lock()
+	s.httpServer = &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: s.engine,
+	}
+	httpServer := s.httpServer
+	s.mu.Unlock()
+
+	if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// StartWithShutdown starts the server like Start and shuts it down gracefully
+// on SIGINT or SIGTERM, see Shutdown. On Lambda it behaves like Start.
+func (s *Server) StartWithShutdown(port int) error {
+	if s.runtime == RuntimeLambda {
+		return s.startLambda()
+	}
+	signals, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- s.startHTTP(port)
+	}()
+	select {
+	case err := <-served:
+		return err
+	case <-signals.Done():
+	}
+	// a second signal terminates the process immediately
+	stop()
+
+	getLogger().Info("ginboot: shutting down, waiting for in-flight requests", "timeout", s.stopTimeout())
+	ctx, cancel := context.WithTimeout(context.Background(), s.stopTimeout())
+	defer cancel()
+	return s.Shutdown(ctx)
+}
+
+func (s *Server) stopTimeout() time.Duration {
+	if s.shutdownTimeout <= 0 {
+		return DefaultShutdownTimeout
+	}
+	return s.shutdownTimeout
+}
+
+// SetShutdownTimeout sets how long StartWithShutdown waits for in-flight
+// requests and shutdown hooks, DefaultShutdownTimeout by default
+func (s *Server) SetShutdownTimeout(timeout time.Duration) *Server {
+	s.shutdownTimeout = timeout
+	return s
+}
+
+// OnStop registers a hook run by Shutdown once in-flight requests are
+// drained, e.g. to disconnect databases or flush caches. Hooks run in the
+// order they were registered. On Lambda they run when the runtime sends
+// SIGTERM, which it only does to functions with an extension registered.
+func (s *Server) OnStop(hook func(ctx context.Context) error) *Server {
+	s.stopHooks = append(s.stopHooks, hook)
+	return s
+}
+
+// Shutdown stops accepting connections, waits for in-flight requests until ctx
+// is done and then runs the shutdown hooks. Start returns nil once the server
+// stopped.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	httpServer := s.httpServer
+	s.mu.Unlock()
+
+	var errs []error
+	if httpServer != nil {
+		if err := httpServer.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, hook := range s.stopHooks {
+		if err := hook(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
 
 func (s *Server) startLambda() error {
+	start := time.Now()
+	if err := s.runStartHooks(); err != nil {
+		return err
+	}
+	s.runPreWarm()
+
+	switch initType := LambdaInitializationType(); initType {
+	case LambdaInitProvisionedConcurrency, LambdaInitSnapStart:
+		getLogger().Info("ginboot: lambda initialized", "init_type", initType, "duration", time.Since(start))
+	default:
+		getLogger().Info("ginboot: lambda cold start initialized", "duration", time.Since(start))
+	}
+
+	options := []lambda.Option{lambda.WithEnableSIGTERM(s.stopLambda)}
+	if s.lambdaStreaming {
+		lambda.StartWithOptions(s.handleStreamingRequest, options...)
+		return nil
+	}
+
 	ginLambda := ginadapter.New(s.engine)
 
 	handler := func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 		return ginLambda.ProxyWithContext(ctx, req)
 	}
 
-	lambda.Start(handler)
+	lambda.StartWithOptions(handler, options...)
 	return nil
 }
 
+func (s *Server) stopLambda() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.stopTimeout())
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		getLogger().Error("ginboot: stop hooks failed", "error", err)
+	}
+}
+
 func (s *Server) SetRuntime(runtime Runtime) {
 	s.runtime = runtime
 }
@@ -75,10 +294,115 @@ func (s *Server) SetBasePath(path string) *Server {
 	return s
 }
 
+// SetProxyBasePath sets the public path prefix added by a proxy in front of
+// the server, such as an API Gateway stage ("/prod") or a custom domain base
+// path mapping. When unset it is detected from the Lambda event.
+func (s *Server) SetProxyBasePath(path string) *Server {
+	s.proxyBasePath = path
+	return s
+}
+
+// SetTrustedProxies sets the addresses or CIDR ranges of the proxies in front
+// of the server, such as load balancers. Forwarded headers like
+// X-Forwarded-Proto are only honoured on requests from them, and the engine's
+// ClientIP only trusts their X-Forwarded-For. Without trusted proxies,
+// forwarded headers are ignored, except on Lambda.
+func (s *Server) SetTrustedProxies(proxies ...string) error {
+	trusted := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return fmt.Errorf("ginboot: invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			trusted = append(trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("ginboot: invalid trusted proxy %q: %w", proxy, err)
+		}
+		trusted = append(trusted, network)
+	}
+	if err := s.engine.SetTrustedProxies(proxies); err != nil {
+		return err
+	}
+	s.trustedProxies = trusted
+	return nil
+}
+
+func (s *Server) isTrustedProxy(remoteIP string) bool {
+	ip := net.ParseIP(remoteIP)
+	if ip == nil {
+		return false
+	}
+	for _, network := range s.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSerializer sets how typed handler responses are written, e.g.
+// JSONAPISerializer{} or HALSerializer{}. Groups and routes can override it
+// with UseSerializer.
+func (s *Server) SetSerializer(serializer ResponseSerializer) *Server {
+	s.serializer = serializer
+	return s
+}
+
+// SetNamingStrategy renames the JSON keys of every typed handler response,
+// e.g. ginboot.SnakeCase or ginboot.CamelCase, whatever the struct tags say.
+// Sparse fieldsets use the renamed keys.
+func (s *Server) SetNamingStrategy(naming NamingStrategy) *Server {
+	s.namingStrategy = naming
+	return s
+}
+
+// SetMode sets gin's mode to gin.ReleaseMode, gin.DebugMode or gin.TestMode.
+// The mode is process wide and can also be set with the GIN_MODE variable.
+func (s *Server) SetMode(mode string) *Server {
+	gin.SetMode(mode)
+	return s
+}
+
+// Use adds middleware to every route. Gin only applies middleware to routes
+// registered after it, so middleware added once routes exist is reported with
+// a warning, or a panic when StrictMiddlewareOrder is enabled.
+func (s *Server) Use(middleware ...gin.HandlerFunc) *Server {
+	s.checkMiddlewareOrder()
+	s.engine.Use(middleware...)
+	return s
+}
+
+// StrictMiddlewareOrder makes adding middleware after routes panic instead of
+// logging a warning, so ordering mistakes fail at startup
+func (s *Server) StrictMiddlewareOrder() *Server {
+	s.strictOrder = true
+	return s
+}
+
+func (s *Server) checkMiddlewareOrder() {
+	routes := s.engine.Routes()
+	if len(routes) == 0 {
+		return
+	}
+	message := fmt.Sprintf("ginboot: middleware added after %d routes were registered will not apply to them (e.g. %s %s); add middleware before registering routes",
+		len(routes), routes[0].Method, routes[0].Path)
+	if s.strictOrder {
+		panic(message)
+	}
+	getLogger().Warn(message)
+}
+
 func (s *Server) WithCORS(config *cors.Config) *Server {
 	s.corsConfig = config
-	s.engine.Use(cors.New(*config))
-	return s
+	return s.Use(cors.New(*config))
 }
 
 func (s *Server) DefaultCORS() *Server {
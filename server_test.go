@@ -1,11 +1,21 @@
 package ginboot
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-lambda-go/events"
+	ginadapter "github.com/awslabs/aws-lambda-go-api-proxy/gin"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
@@ -77,3 +87,192 @@ func TestServer_Start(t *testing.T) {
 	// as it blocks. In a real scenario, you might want to use integration tests
 	// for this functionality.
 }
+
+func TestServer_Shutdown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	server := &Server{engine: gin.New(), runtime: RuntimeHTTP}
+	started := make(chan struct{})
+	server.engine.GET("/slow", func(c *gin.Context) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	})
+	var hooks []string
+	server.OnStop(func(ctx context.Context) error {
+		hooks = append(hooks, "database")
+		return nil
+	}).OnStop(func(ctx context.Context) error {
+		hooks = append(hooks, "cache")
+		return nil
+	})
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Start(port)
+	}()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/slow", port)
+	responses := make(chan *http.Response, 1)
+	go func() {
+		for i := 0; i < 50; i++ {
+			if resp, err := http.Get(url); err == nil {
+				responses <- resp
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		responses <- nil
+	}()
+
+	<-started
+	assert.NoError(t, server.Shutdown(context.Background()))
+	assert.NoError(t, <-stopped)
+	assert.Equal(t, []string{"database", "cache"}, hooks)
+
+	// the in-flight request was drained before shutting down
+	resp := <-responses
+	if assert.NotNil(t, resp) {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "done", string(body))
+	}
+}
+
+func TestServer_OnStart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := New()
+
+	var calls []string
+	server.OnStart(func(ctx context.Context) error {
+		calls = append(calls, "database")
+		return nil
+	}).OnStart(func(ctx context.Context) error {
+		calls = append(calls, "cache")
+		return errors.New("cache unavailable")
+	}).PreWarm(func(ctx context.Context) {
+		calls = append(calls, "prewarm")
+	})
+
+	err := server.Start(-1)
+	assert.ErrorContains(t, err, "cache unavailable")
+	assert.Equal(t, []string{"database", "cache"}, calls)
+}
+
+func TestServer_PreWarm(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := New()
+
+	calls := 0
+	server.PreWarm(func(ctx context.Context) {
+		assert.NotNil(t, ctx)
+		calls++
+	})
+
+	err := server.Start(-1)
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestLambdaInitializationType(t *testing.T) {
+	t.Setenv("AWS_LAMBDA_INITIALIZATION_TYPE", LambdaInitProvisionedConcurrency)
+	assert.Equal(t, LambdaInitProvisionedConcurrency, LambdaInitializationType())
+}
+
+func TestServer_LambdaStreaming(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := New().WithLambdaStreaming()
+
+	server.engine.GET("/stream", func(c *gin.Context) {
+		c.Header("X-Test", "yes")
+		c.SetCookie("session", "abc", 60, "/", "", false, true)
+		c.Status(http.StatusAccepted)
+		c.Writer.WriteString("chunk-1,")
+		c.Writer.Flush()
+		c.Writer.WriteString("chunk-2:" + c.Query("q") + "@" + c.ClientIP())
+	})
+
+	resp, err := server.handleStreamingRequest(context.Background(), events.LambdaFunctionURLRequest{
+		RawPath:        "/stream",
+		RawQueryString: "q=hello",
+		RequestContext: events.LambdaFunctionURLRequestContext{
+			HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{Method: "GET", SourceIP: "203.0.113.7"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, "yes", resp.Headers["X-Test"])
+	assert.Len(t, resp.Cookies, 1)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "chunk-1,chunk-2:hello@203.0.113.7", string(body))
+}
+
+func TestServer_ProxyBasePath(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newServer := func() *Server {
+		server := New()
+		server.Group("/posts").GET("", func(ctx *Context) (string, error) {
+			return ctx.PublicPath("/posts/1"), nil
+		})
+		return server
+	}
+
+	t.Run("configured", func(t *testing.T) {
+		server := newServer().SetProxyBasePath("/api")
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/posts", nil)
+		server.engine.ServeHTTP(w, req)
+
+		assert.Equal(t, `"/api/posts/1"`, w.Body.String())
+	})
+
+	t.Run("detected from api gateway stage", func(t *testing.T) {
+		server := newServer()
+
+		resp, err := ginadapter.New(server.engine).ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			Path:       "/posts",
+			RequestContext: events.APIGatewayProxyRequestContext{
+				Stage: "prod",
+				Path:  "/prod/posts",
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, `"/prod/posts/1"`, resp.Body)
+	})
+}
+
+func TestServer_MiddlewareOrder(t *testing.T) {
+	server := New().SetMode(gin.TestMode)
+	assert.Equal(t, gin.TestMode, gin.Mode())
+
+	server.Use(func(c *gin.Context) {
+		c.Header("X-Middleware", "applied")
+	})
+	server.Group("").GET("/test", func(c *Context) (string, error) {
+		return "test", nil
+	})
+
+	w := httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+	assert.Equal(t, "applied", w.Header().Get("X-Middleware"))
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	server.DefaultCORS()
+	assert.Contains(t, logs.String(), "middleware added after 1 routes were registered")
+
+	assert.Panics(t, func() {
+		server.StrictMiddlewareOrder().Use(func(c *gin.Context) {})
+	})
+}
@@ -0,0 +1,212 @@
+package ginboot
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var (
+	SessionNotFound = ApiError{"SESSION_NOT_FOUND", "Session %s not found"}
+	SessionRevoked  = ApiError{"SESSION_REVOKED", "Session has been revoked"}
+)
+
+// RefreshSession is a login of the user on a device: the refresh tokens
+// rotated from the same login, identified by their family
+type RefreshSession struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"userId"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+func newRefreshSession(token IssuedToken) RefreshSession {
+	return RefreshSession{
+		ID:         token.Family,
+		UserID:     token.UserID,
+		UserAgent:  token.UserAgent,
+		IP:         token.IP,
+		CreatedAt:  token.CreatedAt,
+		LastUsedAt: token.LastUsedAt,
+		ExpiresAt:  token.ExpiresAt,
+	}
+}
+
+// SessionService lets users list their active sessions and revoke them. It
+// reads the refresh tokens recorded by the TokenStore of the JWTService, so
+// revoking a session revokes its tokens and JWTService.RevokeAllTokens ends
+// every session.
+type SessionService struct {
+	store TokenStore
+}
+
+func NewSessionService(store TokenStore) *SessionService {
+	return &SessionService{store: store}
+}
+
+// Track records the device making the request on the session of a newly
+// issued refresh token. Tokens not recorded by JWTService.IssueTokens, e.g.
+// from GenerateTokens, start a session of their own.
+func (s *SessionService) Track(ctx *Context, refreshToken string) (RefreshSession, error) {
+	claims, err := parseRefreshClaims(refreshToken)
+	if err != nil {
+		return RefreshSession{}, err
+	}
+	token, found, err := s.store.Find(ctx, claimString(claims, "jti"))
+	if err != nil {
+		return RefreshSession{}, err
+	}
+	if !found {
+		now := time.Now()
+		token = IssuedToken{
+			ID:         claimString(claims, "jti"),
+			UserID:     claimString(claims, "sub"),
+			Family:     claimString(claims, "jti"),
+			CreatedAt:  now,
+			LastUsedAt: now,
+			UserAgent:  ctx.Request.UserAgent(),
+			IP:         ctx.ClientIP(),
+		}
+		if exp, ok := claims["exp"].(float64); ok {
+			token.ExpiresAt = time.Unix(int64(exp), 0)
+		}
+		return newRefreshSession(token), s.store.Save(ctx, token)
+	}
+	return s.recordUse(ctx, token, claimString(claims, "sub"))
+}
+
+// Validate checks that the refresh token has not been revoked, by revoking
+// its session, logging out everywhere or rotating it, and records its use
+func (s *SessionService) Validate(ctx *Context, refreshToken string) (RefreshSession, error) {
+	claims, err := parseRefreshClaims(refreshToken)
+	if err != nil {
+		return RefreshSession{}, err
+	}
+	token, found, err := s.store.Find(ctx, claimString(claims, "jti"))
+	if err != nil {
+		return RefreshSession{}, err
+	}
+	if !found {
+		return RefreshSession{}, SessionRevoked
+	}
+	return s.recordUse(ctx, token, claimString(claims, "sub"))
+}
+
+func (s *SessionService) recordUse(ctx *Context, token IssuedToken, userID string) (RefreshSession, error) {
+	if token.Revoked || token.UserID != userID {
+		return RefreshSession{}, SessionRevoked
+	}
+	token.LastUsedAt = time.Now()
+	token.UserAgent = ctx.Request.UserAgent()
+	token.IP = ctx.ClientIP()
+	if err := s.store.Update(ctx, token); err != nil {
+		// revoked or used concurrently; only a revocation fails the request
+		if !errors.Is(err, ErrVersionConflict) {
+			return RefreshSession{}, err
+		}
+		current, found, err := s.store.Find(ctx, token.ID)
+		if err != nil {
+			return RefreshSession{}, err
+		}
+		if !found || current.Revoked {
+			return RefreshSession{}, SessionRevoked
+		}
+	}
+	return newRefreshSession(token), nil
+}
+
+// List returns the user's sessions that have not been revoked or expired
+func (s *SessionService) List(ctx context.Context, userID string) ([]RefreshSession, error) {
+	tokens, err := s.store.FindByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	active := make([]RefreshSession, 0, len(tokens))
+	seen := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		if token.Revoked || seen[token.Family] || (!token.ExpiresAt.IsZero() && !now.Before(token.ExpiresAt)) {
+			continue
+		}
+		seen[token.Family] = true
+		active = append(active, newRefreshSession(token))
+	}
+	return active, nil
+}
+
+// Revoke revokes every token of one of the user's sessions
+func (s *SessionService) Revoke(ctx context.Context, userID, sessionID string) error {
+	tokens, err := s.store.FindByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, token := range tokens {
+		if token.Family == sessionID {
+			return s.store.RevokeFamily(ctx, sessionID)
+		}
+	}
+	return SessionNotFound.New(sessionID)
+}
+
+// RevokeAll revokes every session of the user
+func (s *SessionService) RevokeAll(ctx context.Context, userID string) error {
+	return s.store.RevokeUser(ctx, userID)
+}
+
+func parseRefreshClaims(refreshToken string) (jwt.MapClaims, error) {
+	token, err := ParseRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	return ExtractClaims(token)
+}
+
+func claimString(claims jwt.MapClaims, key string) string {
+	value, _ := claims[key].(string)
+	return value
+}
+
+// SessionController exposes the authenticated user's sessions:
+// GET lists them, DELETE /:id revokes one and DELETE revokes all
+type SessionController struct {
+	sessions *SessionService
+}
+
+func NewSessionController(sessions *SessionService) *SessionController {
+	return &SessionController{sessions: sessions}
+}
+
+func (c *SessionController) Register(group *ControllerGroup) {
+	group.GET("", c.ListSessions)
+	group.DELETE("", c.RevokeAllSessions)
+	group.DELETE("/:id", c.RevokeSession)
+}
+
+func (c *SessionController) ListSessions(ctx *Context) ([]RefreshSession, error) {
+	authContext, err := ctx.GetAuthContext()
+	if err != nil {
+		return nil, err
+	}
+	return c.sessions.List(ctx, authContext.UserID)
+}
+
+func (c *SessionController) RevokeSession(ctx *Context) (EmptyResponse, error) {
+	authContext, err := ctx.GetAuthContext()
+	if err != nil {
+		return EmptyResponse{}, err
+	}
+	return EmptyResponse{}, c.sessions.Revoke(ctx, authContext.UserID, ctx.Param("id"))
+}
+
+func (c *SessionController) RevokeAllSessions(ctx *Context) (EmptyResponse, error) {
+	authContext, err := ctx.GetAuthContext()
+	if err != nil {
+		return EmptyResponse{}, err
+	}
+	return EmptyResponse{}, c.sessions.RevokeAll(ctx, authContext.UserID)
+}
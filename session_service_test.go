@@ -0,0 +1,133 @@
+package ginboot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func useTestSecrets(t *testing.T) {
+	SetSecretProvider(SecretProviderFunc(func(ctx context.Context, name string) (string, error) {
+		return "test-" + name, nil
+	}))
+	t.Cleanup(func() { SetSecretProvider(EnvSecretProvider{}) })
+}
+
+func newTestContext(userAgent string) *Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/", nil)
+	c.Request.Header.Set("User-Agent", userAgent)
+	return NewContext(c)
+}
+
+func TestSessionService(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	useTestSecrets(t)
+
+	sessions := NewSessionService(NewRepositoryTokenStore(newMemoryRepository[IssuedToken]()))
+
+	_, phoneToken, err := GenerateTokens("user-1", "user")
+	assert.NoError(t, err)
+	_, laptopToken, err := GenerateTokens("user-1", "user")
+	assert.NoError(t, err)
+
+	phone, err := sessions.Track(newTestContext("phone"), phoneToken)
+	assert.NoError(t, err)
+	_, err = sessions.Track(newTestContext("laptop"), laptopToken)
+	assert.NoError(t, err)
+
+	active, err := sessions.List(context.Background(), "user-1")
+	assert.NoError(t, err)
+	assert.Len(t, active, 2)
+
+	_, err = sessions.Validate(newTestContext("phone"), phoneToken)
+	assert.NoError(t, err)
+
+	assert.Error(t, sessions.Revoke(context.Background(), "user-2", phone.ID))
+	assert.NoError(t, sessions.Revoke(context.Background(), "user-1", phone.ID))
+
+	_, err = sessions.Validate(newTestContext("phone"), phoneToken)
+	assert.ErrorIs(t, err, SessionRevoked)
+
+	assert.NoError(t, sessions.RevokeAll(context.Background(), "user-1"))
+	active, err = sessions.List(context.Background(), "user-1")
+	assert.NoError(t, err)
+	assert.Empty(t, active)
+}
+
+func TestSessionService_TokenStore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	useTestSecrets(t)
+	ctx := context.Background()
+
+	store := NewRepositoryTokenStore(newMemoryRepository[IssuedToken]())
+	tokens := NewJWTService().WithTokenStore(store)
+	sessions := NewSessionService(store)
+
+	_, refreshToken, err := tokens.IssueTokens(ctx, "user-1", "user")
+	assert.NoError(t, err)
+	session, err := sessions.Track(newTestContext("phone"), refreshToken)
+	assert.NoError(t, err)
+
+	// rotation keeps the session and its device
+	_, rotated, err := tokens.RotateRefreshToken(ctx, refreshToken)
+	assert.NoError(t, err)
+	active, err := sessions.List(ctx, "user-1")
+	assert.NoError(t, err)
+	assert.Len(t, active, 1)
+	assert.Equal(t, session.ID, active[0].ID)
+	assert.Equal(t, "phone", active[0].UserAgent)
+
+	_, err = sessions.Validate(newTestContext("phone"), refreshToken)
+	assert.ErrorIs(t, err, SessionRevoked)
+	_, err = sessions.Validate(newTestContext("phone"), rotated)
+	assert.NoError(t, err)
+
+	// logging out everywhere ends the sessions
+	assert.NoError(t, tokens.RevokeAllTokens(ctx, "user-1"))
+	_, err = sessions.Validate(newTestContext("phone"), rotated)
+	assert.ErrorIs(t, err, SessionRevoked)
+	active, err = sessions.List(ctx, "user-1")
+	assert.NoError(t, err)
+	assert.Empty(t, active)
+}
+
+func TestSessionController(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	useTestSecrets(t)
+
+	sessions := NewSessionService(NewRepositoryTokenStore(newMemoryRepository[IssuedToken]()))
+	_, refreshToken, err := GenerateTokens("user-1", "user")
+	assert.NoError(t, err)
+	_, err = sessions.Track(newTestContext("phone"), refreshToken)
+	assert.NoError(t, err)
+
+	server := &Server{engine: gin.New()}
+	group := server.Group("/sessions", func(c *gin.Context) {
+		c.Set("user_id", "user-1")
+		c.Set("role", "user")
+	})
+	NewSessionController(sessions).Register(group)
+
+	w := httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest("GET", "/sessions", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var listed []RefreshSession
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	assert.Len(t, listed, 1)
+	assert.Equal(t, "phone", listed[0].UserAgent)
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest("DELETE", "/sessions/"+listed[0].ID, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	active, err := sessions.List(context.Background(), "user-1")
+	assert.NoError(t, err)
+	assert.Empty(t, active)
+}
@@ -0,0 +1,129 @@
+package ginboot
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const sparseFieldsetsKey = "ginboot.sparse_fieldsets"
+
+// SparseFieldsets enables ?fields=title,author.name on a route or group. The
+// typed handler's response is pruned to the requested fields before it is
+// written; for a PageResponse the fields apply to each item of the page.
+func SparseFieldsets() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(sparseFieldsetsKey, true)
+		c.Next()
+	}
+}
+
+// pagedResponse is implemented by PageResponse so its items can be pruned or
+// serialized without touching the paging metadata
+type pagedResponse interface {
+	pageContents() interface{}
+	pageInfo() (pageable PageRequest, totalElements, totalPages int)
+}
+
+// fieldSet is a tree of requested fields, a nil subtree keeps the whole field
+type fieldSet map[string]fieldSet
+
+func parseFieldSet(fields string) fieldSet {
+	set := fieldSet{}
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		node := set
+		parts := strings.Split(field, ".")
+		for i, part := range parts {
+			child, exists := node[part]
+			if exists && child == nil {
+				break
+			}
+			if i == len(parts)-1 {
+				node[part] = nil
+				break
+			}
+			if !exists {
+				child = fieldSet{}
+				node[part] = child
+			}
+			node = child
+		}
+	}
+	return set
+}
+
+func requestedFieldSet(ctx *Context) fieldSet {
+	if !ctx.GetBool(sparseFieldsetsKey) {
+		return nil
+	}
+	fields := ctx.Query("fields")
+	if fields == "" {
+		return nil
+	}
+	set := parseFieldSet(fields)
+	if len(set) == 0 {
+		return nil
+	}
+	return set
+}
+
+// pruneResponse converts the response to its JSON form, renames its keys with
+// the naming strategy when one is set and keeps only the requested fields
+func pruneResponse(response interface{}, set fieldSet, naming NamingStrategy) (interface{}, error) {
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	// numbers are kept as written, as float64 would round large IDs
+	var decoded interface{}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+	if naming != nil {
+		decoded = renameFields(response, decoded, naming)
+	}
+	if set == nil {
+		return decoded, nil
+	}
+
+	if _, ok := response.(pagedResponse); ok {
+		if page, ok := decoded.(map[string]interface{}); ok {
+			page["content"] = pruneFields(page["content"], set)
+			return page, nil
+		}
+	}
+	return pruneFields(decoded, set), nil
+}
+
+func pruneFields(value interface{}, set fieldSet) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		pruned := make(map[string]interface{}, len(set))
+		for key, subset := range set {
+			field, ok := v[key]
+			if !ok {
+				continue
+			}
+			if subset != nil {
+				field = pruneFields(field, subset)
+			}
+			pruned[key] = field
+		}
+		return pruned
+	case []interface{}:
+		for i, item := range v {
+			v[i] = pruneFields(item, set)
+		}
+		return v
+	default:
+		return value
+	}
+}
@@ -0,0 +1,205 @@
+package ginboot
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SQLDialect hides the syntax differences between SQL engines, so the same
+// SQL code works on PostgreSQL, MySQL and SQLite
+type SQLDialect interface {
+	Name() string
+	// Placeholder returns the n-th bind parameter, counting from 1
+	Placeholder(n int) string
+	// Quote quotes an identifier such as a table or column name; each part of
+	// a schema qualified name like app.posts is quoted separately
+	Quote(identifier string) string
+	// Upsert returns an INSERT of columns into table that updates the row
+	// whose keys conflict instead, with one placeholder per column
+	Upsert(table string, columns, keys []string) string
+	// ColumnType returns the column type storing values of typ
+	ColumnType(typ reflect.Type) string
+}
+
+// DialectFor returns the dialect of a database/sql driver name, such as
+// SQLConfig.Driver
+func DialectFor(driver string) (SQLDialect, error) {
+	switch driver {
+	case "postgres", "pgx":
+		return PostgresDialect{}, nil
+	case "mysql":
+		return MySQLDialect{}, nil
+	case "sqlite3", "sqlite":
+		return SQLiteDialect{}, nil
+	default:
+		return nil, fmt.Errorf("ginboot: no SQL dialect for driver %q", driver)
+	}
+}
+
+// Dialect returns the dialect of the configured driver
+func (c *SQLConfig) Dialect() (SQLDialect, error) {
+	return DialectFor(c.Driver)
+}
+
+type PostgresDialect struct{}
+
+func (PostgresDialect) Name() string { return "postgres" }
+
+func (PostgresDialect) Placeholder(n int) string { return "$" + strconv.Itoa(n) }
+
+func (PostgresDialect) Quote(identifier string) string { return quoteIdentifier(identifier, `"`) }
+
+func (d PostgresDialect) Upsert(table string, columns, keys []string) string {
+	return onConflictUpsert(d, table, columns, keys, "EXCLUDED")
+}
+
+func (PostgresDialect) ColumnType(typ reflect.Type) string {
+	return columnType(typ, map[reflect.Kind]string{
+		reflect.String:  "TEXT",
+		reflect.Bool:    "BOOLEAN",
+		reflect.Int:     "BIGINT",
+		reflect.Int32:   "INTEGER",
+		reflect.Float64: "DOUBLE PRECISION",
+	}, "TIMESTAMP WITH TIME ZONE", "BYTEA", "JSONB")
+}
+
+type MySQLDialect struct{}
+
+func (MySQLDialect) Name() string { return "mysql" }
+
+func (MySQLDialect) Placeholder(int) string { return "?" }
+
+func (MySQLDialect) Quote(identifier string) string { return quoteIdentifier(identifier, "`") }
+
+// Upsert uses ON DUPLICATE KEY UPDATE, which updates on any unique key
+// conflict, not only on keys
+func (d MySQLDialect) Upsert(table string, columns, keys []string) string {
+	var updates []string
+	for _, column := range columns {
+		if !containsString(keys, column) {
+			updates = append(updates, fmt.Sprintf("%s = VALUES(%s)", d.Quote(column), d.Quote(column)))
+		}
+	}
+	if len(updates) == 0 {
+		// nothing to update; keep the row without failing
+		updates = append(updates, fmt.Sprintf("%s = %s", d.Quote(keys[0]), d.Quote(keys[0])))
+	}
+	return insertStatement(d, table, columns) + " ON DUPLICATE KEY UPDATE " + strings.Join(updates, ", ")
+}
+
+// ColumnType uses VARCHAR(255) for strings, as MySQL cannot index TEXT
+// columns without a prefix length
+func (MySQLDialect) ColumnType(typ reflect.Type) string {
+	return columnType(typ, map[reflect.Kind]string{
+		reflect.String:  "VARCHAR(255)",
+		reflect.Bool:    "BOOLEAN",
+		reflect.Int:     "BIGINT",
+		reflect.Int32:   "INT",
+		reflect.Float64: "DOUBLE",
+	}, "DATETIME(6)", "BLOB", "JSON")
+}
+
+type SQLiteDialect struct{}
+
+func (SQLiteDialect) Name() string { return "sqlite" }
+
+func (SQLiteDialect) Placeholder(int) string { return "?" }
+
+func (SQLiteDialect) Quote(identifier string) string { return quoteIdentifier(identifier, `"`) }
+
+// Upsert needs SQLite 3.24 or later
+func (d SQLiteDialect) Upsert(table string, columns, keys []string) string {
+	return onConflictUpsert(d, table, columns, keys, "excluded")
+}
+
+// ColumnType returns SQLite's type affinities; times are stored as text and
+// structured values as JSON text
+func (SQLiteDialect) ColumnType(typ reflect.Type) string {
+	return columnType(typ, map[reflect.Kind]string{
+		reflect.String:  "TEXT",
+		reflect.Bool:    "INTEGER",
+		reflect.Int:     "INTEGER",
+		reflect.Int32:   "INTEGER",
+		reflect.Float64: "REAL",
+	}, "TIMESTAMP", "BLOB", "TEXT")
+}
+
+// quoteIdentifier quotes each part of a dotted identifier, so schema
+// qualified tables like app.posts are quoted as "app"."posts"
+func quoteIdentifier(identifier, quote string) string {
+	parts := strings.Split(identifier, ".")
+	for i, part := range parts {
+		parts[i] = quote + strings.ReplaceAll(part, quote, quote+quote) + quote
+	}
+	return strings.Join(parts, ".")
+}
+
+func insertStatement(d SQLDialect, table string, columns []string) string {
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = d.Quote(column)
+		placeholders[i] = d.Placeholder(i + 1)
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", d.Quote(table), strings.Join(quoted, ", "), strings.Join(placeholders, ", "))
+}
+
+// onConflictUpsert builds the INSERT ... ON CONFLICT upsert shared by
+// PostgreSQL and SQLite, where excluded names the row proposed for insertion
+func onConflictUpsert(d SQLDialect, table string, columns, keys []string, excluded string) string {
+	quotedKeys := make([]string, len(keys))
+	for i, key := range keys {
+		quotedKeys[i] = d.Quote(key)
+	}
+	var updates []string
+	for _, column := range columns {
+		if !containsString(keys, column) {
+			updates = append(updates, fmt.Sprintf("%s = %s.%s", d.Quote(column), excluded, d.Quote(column)))
+		}
+	}
+	statement := insertStatement(d, table, columns) + " ON CONFLICT (" + strings.Join(quotedKeys, ", ") + ")"
+	if len(updates) == 0 {
+		return statement + " DO NOTHING"
+	}
+	return statement + " DO UPDATE SET " + strings.Join(updates, ", ")
+}
+
+// columnType maps typ through kinds, keyed by String, Bool, Int, Int32 and
+// Float64, and the types of times, bytes and structured values
+func columnType(typ reflect.Type, kinds map[reflect.Kind]string, timeType, bytesType, structuredType string) string {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == reflect.TypeOf(time.Time{}) {
+		return timeType
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		return kinds[reflect.String]
+	case reflect.Bool:
+		return kinds[reflect.Bool]
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return kinds[reflect.Int]
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return kinds[reflect.Int32]
+	case reflect.Float32, reflect.Float64:
+		return kinds[reflect.Float64]
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return bytesType
+		}
+	}
+	return structuredType
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package ginboot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLDialects(t *testing.T) {
+	columns, keys := []string{"id", "name", "age"}, []string{"id"}
+
+	tests := []struct {
+		driver      string
+		placeholder string
+		quoted      string
+		upsert      string
+		keysOnly    string
+		types       []string
+	}{
+		{
+			driver:      "postgres",
+			placeholder: "$2",
+			quoted:      `"user ""x"""`,
+			upsert:      `INSERT INTO "users" ("id", "name", "age") VALUES ($1, $2, $3) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name", "age" = EXCLUDED."age"`,
+			keysOnly:    `INSERT INTO "tags" ("id") VALUES ($1) ON CONFLICT ("id") DO NOTHING`,
+			types:       []string{"TEXT", "BIGINT", "BOOLEAN", "DOUBLE PRECISION", "TIMESTAMP WITH TIME ZONE", "BYTEA", "JSONB", "TEXT"},
+		},
+		{
+			driver:      "mysql",
+			placeholder: "?",
+			quoted:      "`user \"x\"`",
+			upsert:      "INSERT INTO `users` (`id`, `name`, `age`) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`), `age` = VALUES(`age`)",
+			keysOnly:    "INSERT INTO `tags` (`id`) VALUES (?) ON DUPLICATE KEY UPDATE `id` = `id`",
+			types:       []string{"VARCHAR(255)", "BIGINT", "BOOLEAN", "DOUBLE", "DATETIME(6)", "BLOB", "JSON", "VARCHAR(255)"},
+		},
+		{
+			driver:      "sqlite3",
+			placeholder: "?",
+			quoted:      `"user ""x"""`,
+			upsert:      `INSERT INTO "users" ("id", "name", "age") VALUES (?, ?, ?) ON CONFLICT ("id") DO UPDATE SET "name" = excluded."name", "age" = excluded."age"`,
+			keysOnly:    `INSERT INTO "tags" ("id") VALUES (?) ON CONFLICT ("id") DO NOTHING`,
+			types:       []string{"TEXT", "INTEGER", "INTEGER", "REAL", "TIMESTAMP", "BLOB", "TEXT", "TEXT"},
+		},
+	}
+	values := []interface{}{"", int64(0), false, 0.5, time.Time{}, []byte{}, map[string]string{}, new(string)}
+
+	for _, test := range tests {
+		t.Run(test.driver, func(t *testing.T) {
+			config := NewSQLConfig().WithDriver(test.driver)
+			dialect, err := config.Dialect()
+			assert.NoError(t, err)
+			assert.Equal(t, test.placeholder, dialect.Placeholder(2))
+			assert.Equal(t, test.quoted, dialect.Quote(`user "x"`))
+			assert.Equal(t, test.upsert, dialect.Upsert("users", columns, keys))
+			assert.Equal(t, test.keysOnly, dialect.Upsert("tags", []string{"id"}, keys))
+			for i, value := range values {
+				assert.Equal(t, test.types[i], dialect.ColumnType(reflect.TypeOf(value)), "%T", value)
+			}
+		})
+	}
+
+	_, err := DialectFor("oracle")
+	assert.Error(t, err)
+}
@@ -0,0 +1,335 @@
+package ginboot
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sqlColumn maps a column to a struct field
+type sqlColumn struct {
+	name  string
+	index []int
+	// json stores the field as JSON text, see db:"col,json"
+	json bool
+}
+
+var (
+	sqlColumnCache sync.Map
+	timeType       = reflect.TypeOf(time.Time{})
+	scannerType    = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	valuerType     = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// sqlColumns returns the columns of a struct type. A field's column is its
+// db tag, or its lower-cased name. db:"-" skips the field, db:"col,json"
+// stores it as JSON and embedded structs contribute their own columns.
+// Other struct fields must be time.Time, a sql.Scanner or tagged json.
+func sqlColumns(typ reflect.Type) ([]sqlColumn, error) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if cached, ok := sqlColumnCache.Load(typ); ok {
+		return cached.([]sqlColumn), nil
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("ginboot: %s is not a struct", typ)
+	}
+
+	var columns []sqlColumn
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("db")
+		// embedded structs of unexported types still promote their fields
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		asJSON := options == "json"
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if field.Anonymous && tag == "" && fieldType.Kind() == reflect.Struct {
+			embedded, err := sqlColumns(fieldType)
+			if err != nil {
+				return nil, err
+			}
+			if field.Type.Kind() == reflect.Ptr {
+				return nil, fmt.Errorf("ginboot: %s.%s: embedded pointers are not supported", typ.Name(), field.Name)
+			}
+			for _, column := range embedded {
+				column.index = append([]int{i}, column.index...)
+				columns = append(columns, column)
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if fieldType.Kind() == reflect.Struct && !asJSON && fieldType != timeType &&
+			!reflect.PointerTo(fieldType).Implements(scannerType) {
+			return nil, fmt.Errorf("ginboot: %s.%s: struct fields need a db:\"%s,json\" tag", typ.Name(), field.Name, sqlColumnName(field, name))
+		}
+		columns = append(columns, sqlColumn{name: sqlColumnName(field, name), index: []int{i}, json: asJSON})
+	}
+	sqlColumnCache.Store(typ, columns)
+	return columns, nil
+}
+
+func sqlColumnName(field reflect.StructField, name string) string {
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// SQLValues returns the columns of doc, a struct or a pointer to one, and the
+// values to write to them. Nil pointers are written as NULL and json columns
+// as JSON text.
+func SQLValues(doc interface{}) ([]string, []interface{}, error) {
+	v := reflect.ValueOf(doc)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil, fmt.Errorf("ginboot: cannot map a nil %s", v.Type())
+		}
+		v = v.Elem()
+	}
+	columns, err := sqlColumns(v.Type())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := make([]string, len(columns))
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		names[i] = column.name
+		field := v.FieldByIndex(column.index)
+		if column.json {
+			if isNilValue(field) {
+				continue
+			}
+			data, err := json.Marshal(field.Interface())
+			if err != nil {
+				return nil, nil, fmt.Errorf("ginboot: column %s: %w", column.name, err)
+			}
+			values[i] = string(data)
+			continue
+		}
+		if field.Type().Implements(valuerType) {
+			if !isNilValue(field) {
+				values[i] = field.Interface()
+			}
+			continue
+		}
+		for field.Kind() == reflect.Ptr && !field.IsNil() {
+			field = field.Elem()
+		}
+		if field.Kind() != reflect.Ptr {
+			values[i] = field.Interface()
+		}
+	}
+	return names, values, nil
+}
+
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// ScanSQLRow scans the current row of rows into dest, a pointer to a struct,
+// matching columns to fields by name. Columns without a field are skipped and
+// fields without a column keep their value. NULL sets pointer fields to nil
+// and other fields to their zero value.
+func ScanSQLRow(rows *sql.Rows, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("ginboot: scan destination must be a non-nil pointer, got %T", dest)
+	}
+	v = v.Elem()
+	columns, err := sqlColumns(v.Type())
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]sqlColumn, len(columns))
+	for _, column := range columns {
+		byName[column.name] = column
+	}
+
+	names, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	targets := make([]interface{}, len(names))
+	for i, name := range names {
+		column, ok := byName[name]
+		if !ok {
+			targets[i] = new(interface{})
+			continue
+		}
+		targets[i] = &sqlField{value: v.FieldByIndex(column.index), column: column}
+	}
+	return rows.Scan(targets...)
+}
+
+// ScanSQLRows scans the remaining rows into a slice, see ScanSQLRow. It does
+// not close rows.
+func ScanSQLRows[T any](rows *sql.Rows) ([]T, error) {
+	var results []T
+	for rows.Next() {
+		var item T
+		target := interface{}(&item)
+		if typ := reflect.TypeOf(item); typ != nil && typ.Kind() == reflect.Ptr {
+			// T is a pointer; scan into a new struct
+			value := reflect.New(typ.Elem())
+			reflect.ValueOf(&item).Elem().Set(value)
+			target = value.Interface()
+		}
+		if err := ScanSQLRow(rows, target); err != nil {
+			return nil, err
+		}
+		results = append(results, item)
+	}
+	return results, rows.Err()
+}
+
+// sqlField scans a column into a struct field
+type sqlField struct {
+	value  reflect.Value
+	column sqlColumn
+}
+
+func (f *sqlField) Scan(src interface{}) error {
+	if src == nil {
+		f.value.Set(reflect.Zero(f.value.Type()))
+		return nil
+	}
+	// allocate pointer fields, then scan into what they point to
+	target := f.value
+	for target.Kind() == reflect.Ptr {
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		target = target.Elem()
+	}
+
+	if err := f.scanValue(target, src); err != nil {
+		return fmt.Errorf("ginboot: column %s: %w", f.column.name, err)
+	}
+	return nil
+}
+
+func (f *sqlField) scanValue(target reflect.Value, src interface{}) error {
+	switch {
+	case f.column.json:
+		data, err := sqlBytes(src)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, target.Addr().Interface())
+	case target.Addr().Type().Implements(scannerType):
+		return target.Addr().Interface().(sql.Scanner).Scan(src)
+	case target.Type() == timeType:
+		t, err := sqlTime(src)
+		if err != nil {
+			return err
+		}
+		target.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	return convertSQLValue(target, src)
+}
+
+// convertSQLValue converts a driver value to the kind of target
+func convertSQLValue(target reflect.Value, src interface{}) error {
+	if b, ok := src.([]byte); ok {
+		if target.Kind() == reflect.Slice && target.Type().Elem().Kind() == reflect.Uint8 {
+			target.SetBytes(append([]byte(nil), b...))
+			return nil
+		}
+		src = string(b)
+	}
+	sv := reflect.ValueOf(src)
+	if s, ok := src.(string); ok && target.Kind() != reflect.String {
+		return sqlParseString(target, s)
+	}
+	switch {
+	case target.Kind() == reflect.Bool && sv.CanInt():
+		// MySQL and SQLite store booleans as integers
+		target.SetBool(sv.Int() != 0)
+	case sv.Type().AssignableTo(target.Type()):
+		target.Set(sv)
+	case sv.Type().ConvertibleTo(target.Type()) && sqlKindsCompatible(sv.Kind(), target.Kind()):
+		target.Set(sv.Convert(target.Type()))
+	default:
+		return fmt.Errorf("cannot store %T in %s", src, target.Type())
+	}
+	return nil
+}
+
+// sqlKindsCompatible rejects conversions that reflect allows but that would
+// corrupt values, like an int64 becoming a one-rune string
+func sqlKindsCompatible(src, dst reflect.Kind) bool {
+	if dst == reflect.String {
+		return src == reflect.String
+	}
+	return dst != reflect.Bool || src == reflect.Bool
+}
+
+// sqlParseString parses text returned by drivers such as MySQL into numbers
+// and booleans
+func sqlParseString(target reflect.Value, s string) error {
+	if _, err := fmt.Sscan(s, target.Addr().Interface()); err != nil {
+		return fmt.Errorf("cannot parse %q as %s", s, target.Type())
+	}
+	return nil
+}
+
+func sqlBytes(src interface{}) ([]byte, error) {
+	switch v := src.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+	return nil, fmt.Errorf("cannot read JSON from %T", src)
+}
+
+// sqlTimeLayouts are the text formats of times stored by SQLite and MySQL
+var sqlTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+func sqlTime(src interface{}) (time.Time, error) {
+	switch v := src.(type) {
+	case time.Time:
+		return v, nil
+	case []byte:
+		return parseSQLTime(string(v))
+	case string:
+		return parseSQLTime(v)
+	}
+	return time.Time{}, fmt.Errorf("cannot store %T in time.Time", src)
+}
+
+func parseSQLTime(s string) (time.Time, error) {
+	for _, layout := range sqlTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as a time", s)
+}
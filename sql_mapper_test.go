@@ -0,0 +1,177 @@
+package ginboot
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rowsDriver is a fake database/sql driver answering every query with the
+// rows registered under the data source name
+type rowsDriver struct{}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+var (
+	fakeRowsMu sync.Mutex
+	fakeRowSet = map[string]fakeRows{}
+)
+
+func init() {
+	sql.Register("ginboot-rows", rowsDriver{})
+}
+
+func (rowsDriver) Open(name string) (driver.Conn, error) { return rowsConn{name: name}, nil }
+
+type rowsConn struct{ name string }
+
+func (rowsConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+
+func (rowsConn) Close() error { return nil }
+
+func (rowsConn) Begin() (driver.Tx, error) { return nil, errors.New("transactions are not supported") }
+
+func (c rowsConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	fakeRowsMu.Lock()
+	defer fakeRowsMu.Unlock()
+	set := fakeRowSet[c.name]
+	return &fakeRowsIterator{columns: set.columns, values: set.values}, nil
+}
+
+type fakeRowsIterator struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRowsIterator) Columns() []string { return r.columns }
+
+func (r *fakeRowsIterator) Close() error { return nil }
+
+func (r *fakeRowsIterator) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func queryFakeRows(t *testing.T, columns []string, values ...[]driver.Value) *sql.Rows {
+	fakeRowsMu.Lock()
+	fakeRowSet[t.Name()] = fakeRows{columns: columns, values: values}
+	fakeRowsMu.Unlock()
+	db, err := sql.Open("ginboot-rows", t.Name())
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	rows, err := db.Query("SELECT")
+	assert.NoError(t, err)
+	t.Cleanup(func() { rows.Close() })
+	return rows
+}
+
+type sqlAudit struct {
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type sqlAddress struct {
+	City string `json:"city"`
+}
+
+type sqlUser struct {
+	ID       string `db:"id"`
+	Name     string
+	Nickname *string         `db:"nickname"`
+	Age      int             `db:"age"`
+	Score    *float64        `db:"score"`
+	Active   bool            `db:"active"`
+	Email    sql.NullString  `db:"email"`
+	Address  *sqlAddress     `db:"address,json"`
+	Tags     []string        `db:"tags,json"`
+	Deleted  *time.Time      `db:"deleted_at"`
+	Secret   string          `db:"-"`
+	Extra    map[string]bool `db:"extra,json"`
+	sqlAudit
+}
+
+func TestScanSQLRows(t *testing.T) {
+	created := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	rows := queryFakeRows(t,
+		[]string{"age", "id", "name", "nickname", "score", "active", "email", "address", "tags", "deleted_at", "created_at", "unknown"},
+		[]driver.Value{int64(42), "u1", "Ada", "ada", 9.5, true, "ada@example.com", []byte(`{"city":"London"}`), `["a","b"]`, created, created, "ignored"},
+		// NULL columns and text values as returned by SQLite and MySQL
+		[]driver.Value{[]byte("7"), []byte("u2"), nil, nil, nil, int64(1), nil, nil, nil, nil, "2024-01-31 12:00:00", nil},
+	)
+
+	users, err := ScanSQLRows[*sqlUser](rows)
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+
+	ada := users[0]
+	assert.Equal(t, "u1", ada.ID)
+	assert.Equal(t, "Ada", ada.Name)
+	assert.Equal(t, 42, ada.Age)
+	assert.Equal(t, "ada", *ada.Nickname)
+	assert.Equal(t, 9.5, *ada.Score)
+	assert.True(t, ada.Active)
+	assert.Equal(t, sql.NullString{String: "ada@example.com", Valid: true}, ada.Email)
+	assert.Equal(t, &sqlAddress{City: "London"}, ada.Address)
+	assert.Equal(t, []string{"a", "b"}, ada.Tags)
+	assert.True(t, ada.Deleted.Equal(created))
+	assert.True(t, ada.CreatedAt.Equal(created))
+
+	nulls := users[1]
+	assert.Equal(t, "u2", nulls.ID)
+	assert.Equal(t, 7, nulls.Age)
+	assert.Equal(t, "", nulls.Name)
+	assert.Nil(t, nulls.Nickname)
+	assert.Nil(t, nulls.Score)
+	assert.True(t, nulls.Active)
+	assert.False(t, nulls.Email.Valid)
+	assert.Nil(t, nulls.Address)
+	assert.Nil(t, nulls.Tags)
+	assert.Nil(t, nulls.Deleted)
+	assert.True(t, nulls.CreatedAt.Equal(created))
+}
+
+func TestScanSQLRow_Errors(t *testing.T) {
+	rows := queryFakeRows(t, []string{"age"}, []driver.Value{"old"})
+	assert.True(t, rows.Next())
+	var user sqlUser
+	assert.ErrorContains(t, ScanSQLRow(rows, &user), "column age")
+	assert.Error(t, ScanSQLRow(rows, user))
+
+	type untagged struct {
+		Address sqlAddress
+	}
+	_, _, err := SQLValues(untagged{})
+	assert.ErrorContains(t, err, `db:"address,json"`)
+}
+
+func TestSQLValues(t *testing.T) {
+	nickname := "ada"
+	created := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	columns, values, err := SQLValues(&sqlUser{
+		ID:       "u1",
+		Name:     "Ada",
+		Nickname: &nickname,
+		Address:  &sqlAddress{City: "London"},
+		Tags:     []string{"a"},
+		Secret:   "hidden",
+		sqlAudit: sqlAudit{CreatedAt: created},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id", "name", "nickname", "age", "score", "active", "email", "address", "tags", "deleted_at", "extra", "created_at"}, columns)
+	assert.Equal(t, []interface{}{"u1", "Ada", "ada", 0, nil, false, sql.NullString{}, `{"city":"London"}`, `["a"]`, nil, nil, created}, values)
+}
@@ -0,0 +1,348 @@
+package ginboot
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ErrIrreversibleMigration is returned by Migrator.Down for migrations
+// without a Down step
+var ErrIrreversibleMigration = errors.New("migration cannot be reverted")
+
+// Migration is a versioned schema change. Up and Down run in a transaction,
+// together with the bookkeeping in the migrations table.
+type Migration struct {
+	// Version orders migrations, e.g. 1, 2, 3 or a timestamp like 20240131120000
+	Version int64
+	Name    string
+	Up      func(ctx context.Context, tx *sql.Tx) error
+	// Down reverts Up; nil when the migration is irreversible
+	Down func(ctx context.Context, tx *sql.Tx) error
+}
+
+// SQLMigration returns a migration running SQL statements. An empty down
+// makes the migration irreversible.
+func SQLMigration(version int64, name, up, down string) Migration {
+	migration := Migration{Version: version, Name: name, Up: execMigration(up)}
+	if down != "" {
+		migration.Down = execMigration(down)
+	}
+	return migration
+}
+
+func execMigration(statement string) func(ctx context.Context, tx *sql.Tx) error {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, statement)
+		return err
+	}
+}
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// LoadMigrations reads the migrations in dir of fsys, e.g. an embed.FS, from
+// files named like 0001_create_users.up.sql and 0001_create_users.down.sql.
+// The down file is optional. Each file is sent to the database as one
+// statement, so drivers must accept several statements per Exec when a file
+// has more than one.
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	type files struct{ name, up, down string }
+	byVersion := map[int64]*files{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("ginboot: migration %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &files{name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.name != match[2] {
+			return nil, fmt.Errorf("ginboot: migration %d is named both %s and %s", version, migration.name, match[2])
+		}
+		if match[3] == "up" {
+			migration.up = string(content)
+		} else {
+			migration.down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for version, files := range byVersion {
+		if files.up == "" {
+			return nil, fmt.Errorf("ginboot: migration %d_%s has no up file", version, files.name)
+		}
+		migrations = append(migrations, SQLMigration(version, files.name, files.up, files.down))
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// MigratorConfig configures a Migrator
+type MigratorConfig struct {
+	// Table records the applied migrations
+	Table string
+	// Dialect writes the bookkeeping statements, see SQLConfig.Dialect
+	Dialect SQLDialect
+}
+
+// DefaultMigratorConfig records migrations in schema_migrations on PostgreSQL
+func DefaultMigratorConfig() MigratorConfig {
+	return MigratorConfig{
+		Table:   "schema_migrations",
+		Dialect: PostgresDialect{},
+	}
+}
+
+// MigrationStatus describes a known or applied migration
+type MigrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+}
+
+// Migrator applies migrations to a SQL database and records them in a table,
+// so the schema evolves the same way on every environment. Run it before
+// serving with server.OnStart(migrator.Up).
+type Migrator struct {
+	db         *sql.DB
+	config     MigratorConfig
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB, config MigratorConfig) *Migrator {
+	defaults := DefaultMigratorConfig()
+	if config.Table == "" {
+		config.Table = defaults.Table
+	}
+	if config.Dialect == nil {
+		config.Dialect = defaults.Dialect
+	}
+	return &Migrator{db: db, config: config}
+}
+
+// Add registers migrations, in any order
+func (m *Migrator) Add(migrations ...Migration) *Migrator {
+	m.migrations = append(m.migrations, migrations...)
+	return m
+}
+
+// Up applies the pending migrations in version order, each in its own
+// transaction. It stops at the first failure, leaving the earlier ones applied.
+// On PostgreSQL and MySQL, Up and Down hold a session lock on the database,
+// so instances starting together apply each migration once; the others wait
+// and skip it. SQLite serialises writers itself, so a concurrent runner fails
+// to record the same version and rolls back its copy.
+func (m *Migrator) Up(ctx context.Context) error {
+	migrations, err := m.sorted()
+	if err != nil {
+		return err
+	}
+	return m.locked(ctx, func() error { return m.up(ctx, migrations) })
+}
+
+func (m *Migrator) up(ctx context.Context, migrations []Migration) error {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		err := m.inTx(ctx, func(tx *sql.Tx) error {
+			if err := migration.Up(ctx, tx); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES (%s, %s, %s)",
+				m.config.Dialect.Quote(m.config.Table), m.config.Dialect.Placeholder(1), m.config.Dialect.Placeholder(2), m.config.Dialect.Placeholder(3)),
+				migration.Version, migration.Name, time.Now().UTC())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("ginboot: migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		LoggerFromContext(ctx).Info("ginboot: applied migration", "version", migration.Version, "name", migration.Name)
+	}
+	return nil
+}
+
+// Down reverts the latest applied migration
+func (m *Migrator) Down(ctx context.Context) error {
+	migrations, err := m.sorted()
+	if err != nil {
+		return err
+	}
+	return m.locked(ctx, func() error { return m.down(ctx, migrations) })
+}
+
+func (m *Migrator) down(ctx context.Context, migrations []Migration) error {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == nil {
+			return fmt.Errorf("ginboot: migration %d_%s: %w", migration.Version, migration.Name, ErrIrreversibleMigration)
+		}
+		err := m.inTx(ctx, func(tx *sql.Tx) error {
+			if err := migration.Down(ctx, tx); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE version = %s", m.config.Dialect.Quote(m.config.Table), m.config.Dialect.Placeholder(1)), migration.Version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("ginboot: reverting migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		LoggerFromContext(ctx).Info("ginboot: reverted migration", "version", migration.Version, "name", migration.Name)
+		return nil
+	}
+	return nil
+}
+
+// Status lists the registered migrations and whether they were applied, in
+// version order. Applied versions that are no longer registered are listed
+// with the name they were applied with.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := m.sorted()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if record, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = record.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range applied {
+		statuses = append(statuses, record)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// sorted returns the registered migrations in version order, rejecting
+// duplicate versions
+func (m *Migrator) sorted() ([]Migration, error) {
+	migrations := make([]Migration, len(m.migrations))
+	copy(migrations, m.migrations)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("ginboot: migrations %s and %s share version %d",
+				migrations[i-1].Name, migrations[i].Name, migrations[i].Version)
+		}
+	}
+	return migrations, nil
+}
+
+// applied creates the migrations table when missing and returns its records
+func (m *Migrator) applied(ctx context.Context) (map[int64]MigrationStatus, error) {
+	_, err := m.db.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (version BIGINT PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at TIMESTAMP NOT NULL)",
+		m.config.Dialect.Quote(m.config.Table)))
+	if err != nil {
+		return nil, fmt.Errorf("ginboot: creating %s: %w", m.config.Table, err)
+	}
+	rows, err := m.db.QueryContext(ctx, fmt.Sprintf("SELECT version, name, applied_at FROM %s", m.config.Dialect.Quote(m.config.Table)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]MigrationStatus{}
+	for rows.Next() {
+		status := MigrationStatus{Applied: true}
+		var appliedAt time.Time
+		if err := rows.Scan(&status.Version, &status.Name, &appliedAt); err != nil {
+			return nil, err
+		}
+		status.AppliedAt = &appliedAt
+		applied[status.Version] = status
+	}
+	return applied, rows.Err()
+}
+
+// locked runs fn while holding the migration lock of the table, an advisory
+// lock on PostgreSQL and a named lock on MySQL. Session locks belong to a
+// connection, so one is held aside until fn returns. Other dialects run fn
+// unlocked.
+func (m *Migrator) locked(ctx context.Context, fn func() error) error {
+	name := "ginboot:migrations:" + m.config.Table
+	var lock, unlock string
+	var key interface{}
+	switch m.config.Dialect.Name() {
+	case "postgres":
+		hash := fnv.New64a()
+		hash.Write([]byte(name))
+		lock, unlock, key = "SELECT pg_advisory_lock($1)", "SELECT pg_advisory_unlock($1)", int64(hash.Sum64())
+	case "mysql":
+		lock, unlock, key = "SELECT GET_LOCK(?, -1)", "SELECT RELEASE_LOCK(?)", name
+	default:
+		return fn()
+	}
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, lock, key); err != nil {
+		return fmt.Errorf("ginboot: locking %s: %w", m.config.Table, err)
+	}
+	err = fn()
+	if _, unlockErr := conn.ExecContext(context.WithoutCancel(ctx), unlock, key); unlockErr != nil {
+		// discard the connection, ending the session and its lock
+		conn.Raw(func(any) error { return driver.ErrBadConn })
+		LoggerFromContext(ctx).Warn("ginboot: releasing migration lock failed", "error", unlockErr)
+	}
+	return err
+}
+
+func (m *Migrator) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,285 @@
+package ginboot
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// migrationDB is the state of a fake database/sql driver understanding the
+// statements of Migrator. Other statements are recorded as executed, and fail
+// when they contain FAIL.
+type migrationDB struct {
+	mu sync.Mutex
+	// lock is the advisory lock taken by Migrator
+	lock     sync.Mutex
+	applied  map[int64]migrationRecord
+	executed []string
+}
+
+type migrationRecord struct {
+	name      string
+	appliedAt time.Time
+}
+
+var (
+	migrationDBsMu sync.Mutex
+	migrationDBs   = map[string]*migrationDB{}
+)
+
+type migrationDriver struct{}
+
+func init() {
+	sql.Register("ginboot-migrations", migrationDriver{})
+}
+
+func (migrationDriver) Open(name string) (driver.Conn, error) {
+	migrationDBsMu.Lock()
+	defer migrationDBsMu.Unlock()
+	db, ok := migrationDBs[name]
+	if !ok {
+		db = &migrationDB{applied: map[int64]migrationRecord{}}
+		migrationDBs[name] = db
+	}
+	return &migrationConn{db: db}, nil
+}
+
+type migrationConn struct {
+	db *migrationDB
+	// pending holds the changes of the open transaction
+	pending []func()
+	inTx    bool
+}
+
+func (c *migrationConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+
+func (c *migrationConn) Close() error { return nil }
+
+func (c *migrationConn) Begin() (driver.Tx, error) {
+	c.inTx = true
+	return c, nil
+}
+
+func (c *migrationConn) Commit() error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	for _, change := range c.pending {
+		change()
+	}
+	c.pending, c.inTx = nil, false
+	return nil
+}
+
+func (c *migrationConn) Rollback() error {
+	c.pending, c.inTx = nil, false
+	return nil
+}
+
+func (c *migrationConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	var change func()
+	switch {
+	case strings.HasPrefix(query, "SELECT pg_advisory_lock"):
+		c.db.lock.Lock()
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(query, "SELECT pg_advisory_unlock"):
+		c.db.lock.Unlock()
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(query, `CREATE TABLE IF NOT EXISTS "schema_migrations"`):
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(query, `INSERT INTO "schema_migrations"`):
+		version := args[0].Value.(int64)
+		record := migrationRecord{name: args[1].Value.(string), appliedAt: args[2].Value.(time.Time)}
+		change = func() { c.db.applied[version] = record }
+	case strings.HasPrefix(query, `DELETE FROM "schema_migrations"`):
+		version := args[0].Value.(int64)
+		change = func() { delete(c.db.applied, version) }
+	case strings.Contains(query, "FAIL"):
+		return nil, errors.New("syntax error")
+	default:
+		change = func() { c.db.executed = append(c.db.executed, query) }
+	}
+	if c.inTx {
+		c.pending = append(c.pending, change)
+	} else {
+		c.db.mu.Lock()
+		change()
+		c.db.mu.Unlock()
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *migrationConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	rows := &migrationRows{}
+	for version, record := range c.db.applied {
+		rows.values = append(rows.values, []driver.Value{version, record.name, record.appliedAt})
+	}
+	sort.Slice(rows.values, func(i, j int) bool { return rows.values[i][0].(int64) < rows.values[j][0].(int64) })
+	return rows, nil
+}
+
+type migrationRows struct {
+	values [][]driver.Value
+}
+
+func (r *migrationRows) Columns() []string { return []string{"version", "name", "applied_at"} }
+
+func (r *migrationRows) Close() error { return nil }
+
+func (r *migrationRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func openMigrationDB(t *testing.T) (*sql.DB, *migrationDB) {
+	db, err := sql.Open("ginboot-migrations", t.Name())
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	assert.NoError(t, db.Ping())
+	migrationDBsMu.Lock()
+	defer migrationDBsMu.Unlock()
+	return db, migrationDBs[t.Name()]
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	db, state := openMigrationDB(t)
+
+	migrator := NewMigrator(db, DefaultMigratorConfig()).Add(
+		SQLMigration(2, "add_email", "ALTER TABLE users ADD email TEXT", "ALTER TABLE users DROP email"),
+		Migration{
+			Version: 1,
+			Name:    "create_users",
+			Up: func(ctx context.Context, tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, "CREATE TABLE users (id TEXT)")
+				return err
+			},
+		},
+	)
+
+	assert.NoError(t, migrator.Up(ctx))
+	assert.Equal(t, []string{"CREATE TABLE users (id TEXT)", "ALTER TABLE users ADD email TEXT"}, state.executed)
+
+	// applied migrations are skipped
+	assert.NoError(t, migrator.Up(ctx))
+	assert.Len(t, state.executed, 2)
+
+	statuses, err := migrator.Status(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 2)
+	assert.Equal(t, "create_users", statuses[0].Name)
+	assert.True(t, statuses[1].Applied)
+	assert.NotNil(t, statuses[1].AppliedAt)
+
+	assert.NoError(t, migrator.Down(ctx))
+	assert.Equal(t, "ALTER TABLE users DROP email", state.executed[2])
+	statuses, _ = migrator.Status(ctx)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[1].Applied)
+
+	err = migrator.Down(ctx)
+	assert.ErrorIs(t, err, ErrIrreversibleMigration)
+}
+
+func TestMigrator_FailedMigrationRollsBack(t *testing.T) {
+	ctx := context.Background()
+	db, state := openMigrationDB(t)
+
+	migrator := NewMigrator(db, MigratorConfig{}).Add(
+		SQLMigration(1, "create_users", "CREATE TABLE users (id TEXT)", ""),
+		Migration{
+			Version: 2,
+			Name:    "broken",
+			Up: func(ctx context.Context, tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, "CREATE INDEX users_id ON users (id)"); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, "FAIL")
+				return err
+			},
+		},
+		SQLMigration(3, "never", "CREATE TABLE posts (id TEXT)", ""),
+	)
+
+	err := migrator.Up(ctx)
+	assert.ErrorContains(t, err, "migration 2_broken failed")
+	assert.Equal(t, []string{"CREATE TABLE users (id TEXT)"}, state.executed)
+	statuses, err := migrator.Status(ctx)
+	assert.NoError(t, err)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[1].Applied)
+	assert.False(t, statuses[2].Applied)
+
+	duplicate := NewMigrator(db, MigratorConfig{}).Add(
+		SQLMigration(1, "a", "SELECT 1", ""),
+		SQLMigration(1, "b", "SELECT 1", ""),
+	)
+	assert.ErrorContains(t, duplicate.Up(ctx), "share version 1")
+}
+
+func TestMigrator_ConcurrentUp(t *testing.T) {
+	ctx := context.Background()
+	db, state := openMigrationDB(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			migrator := NewMigrator(db, DefaultMigratorConfig()).Add(Migration{
+				Version: 1,
+				Name:    "create_users",
+				Up: func(ctx context.Context, tx *sql.Tx) error {
+					time.Sleep(10 * time.Millisecond)
+					_, err := tx.ExecContext(ctx, "CREATE TABLE users (id TEXT)")
+					return err
+				},
+			})
+			assert.NoError(t, migrator.Up(ctx))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, []string{"CREATE TABLE users (id TEXT)"}, state.executed)
+}
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0002_add_email.up.sql":      {Data: []byte("ALTER TABLE users ADD email TEXT")},
+		"migrations/0002_add_email.down.sql":    {Data: []byte("ALTER TABLE users DROP email")},
+		"migrations/0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id TEXT)")},
+		"migrations/README.md":                  {Data: []byte("notes")},
+		"migrations/0003_orphan_down.down.sql":  {Data: []byte("DROP TABLE x")},
+		"migrations/nested/0004_ignored.up.sql": {Data: []byte("SELECT 1")},
+	}
+
+	_, err := LoadMigrations(fsys, "migrations")
+	assert.ErrorContains(t, err, "has no up file")
+
+	delete(fsys, "migrations/0003_orphan_down.down.sql")
+	migrations, err := LoadMigrations(fsys, "migrations")
+	assert.NoError(t, err)
+	assert.Len(t, migrations, 2)
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "create_users", migrations[0].Name)
+	assert.Nil(t, migrations[0].Down)
+	assert.Equal(t, "add_email", migrations[1].Name)
+	assert.NotNil(t, migrations[1].Down)
+}
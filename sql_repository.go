@@ -1,400 +1,672 @@
 package ginboot
 
-//
-//import (
-//	"database/sql"
-//	"fmt"
-//	"reflect"
-//	"strings"
-//)
-//
-//type SQLRepository[T Document] struct {
-//	db        *sql.DB
-//	tableName string
-//}
-//
-//func NewSQLRepository[T Document](db *sql.DB) *SQLRepository[T] {
-//	var doc T
-//	return &SQLRepository[T]{
-//		db:        db,
-//		tableName: doc.GetCollectionName(),
-//	}
-//}
-//
-//func (r *SQLRepository[T]) FindById(id string) (T, error) {
-//	var result T
-//	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1", r.tableName)
-//	row := r.db.QueryRow(query, id)
-//	err := row.Scan(&result)
-//	return result, err
-//}
-//
-//func (r *SQLRepository[T]) FindAllById(ids []string) ([]T, error) {
-//	var results []T
-//	placeholders := make([]string, len(ids))
-//	args := make([]interface{}, len(ids))
-//	for i, id := range ids {
-//		placeholders[i] = fmt.Sprintf("$%d", i+1)
-//		args[i] = id
-//	}
-//
-//	query := fmt.Sprintf("SELECT * FROM %s WHERE id IN (%s)",
-//		r.tableName, strings.Join(placeholders, ","))
-//
-//	rows, err := r.db.Query(query, args...)
-//	if err != nil {
-//		return nil, err
-//	}
-//	defer rows.Close()
-//
-//	for rows.Next() {
-//		var result T
-//		if err := rows.Scan(&result); err != nil {
-//			return nil, err
-//		}
-//		results = append(results, result)
-//	}
-//
-//	if err = rows.Err(); err != nil {
-//		return nil, err
-//	}
-//
-//	return results, nil
-//}
-//
-//func (r *SQLRepository[T]) Save(doc T) error {
-//	fields, values := r.extractFieldsAndValues(doc)
-//	placeholders := make([]string, len(values))
-//	for i := range values {
-//		placeholders[i] = fmt.Sprintf("$%d", i+1)
-//	}
-//
-//	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
-//		r.tableName,
-//		strings.Join(fields, ","),
-//		strings.Join(placeholders, ","))
-//
-//	_, err := r.db.Exec(query, values...)
-//	return err
-//}
-//
-//func (r *SQLRepository[T]) SaveOrUpdate(doc T) error {
-//	fields, values := r.extractFieldsAndValues(doc)
-//	placeholders := make([]string, len(values))
-//	updates := make([]string, len(fields))
-//
-//	for i := range values {
-//		placeholders[i] = fmt.Sprintf("$%d", i+1)
-//		updates[i] = fmt.Sprintf("%s = $%d", fields[i], i+1)
-//	}
-//
-//	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (id) DO UPDATE SET %s",
-//		r.tableName,
-//		strings.Join(fields, ","),
-//		strings.Join(placeholders, ","),
-//		strings.Join(updates, ","))
-//
-//	_, err := r.db.Exec(query, values...)
-//	return err
-//}
-//
-//func (r *SQLRepository[T]) SaveAll(docs []T) error {
-//	if len(docs) == 0 {
-//		return nil
-//	}
-//
-//	tx, err := r.db.Begin()
-//	if err != nil {
-//		return err
-//	}
-//
-//	for _, doc := range docs {
-//		if err := r.Save(doc); err != nil {
-//			tx.Rollback()
-//			return err
-//		}
-//	}
-//
-//	return tx.Commit()
-//}
-//
-//func (r *SQLRepository[T]) Update(doc T) error {
-//	fields, values := r.extractFieldsAndValues(doc)
-//	updates := make([]string, len(fields))
-//
-//	for i := range fields {
-//		updates[i] = fmt.Sprintf("%s = $%d", fields[i], i+1)
-//	}
-//
-//	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d",
-//		r.tableName,
-//		strings.Join(updates, ","),
-//		len(values))
-//
-//	_, err := r.db.Exec(query, values...)
-//	return err
-//}
-//
-//func (r *SQLRepository[T]) Delete(id string) error {
-//	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", r.tableName)
-//	_, err := r.db.Exec(query, id)
-//	return err
-//}
-//
-//func (r *SQLRepository[T]) FindOneBy(field string, value interface{}) (T, error) {
-//	var result T
-//	query := fmt.Sprintf("SELECT * FROM %s WHERE %s = $1", r.tableName, field)
-//	row := r.db.QueryRow(query, value)
-//	err := row.Scan(&result)
-//	return result, err
-//}
-//
-//func (r *SQLRepository[T]) FindOneByFilters(filters map[string]interface{}) (T, error) {
-//	var result T
-//	conditions, values := r.buildWhereClause(filters)
-//	query := fmt.Sprintf("SELECT * FROM %s WHERE %s", r.tableName, conditions)
-//	row := r.db.QueryRow(query, values...)
-//	err := row.Scan(&result)
-//	return result, err
-//}
-//
-//func (r *SQLRepository[T]) FindBy(field string, value interface{}) ([]T, error) {
-//	query := fmt.Sprintf("SELECT * FROM %s WHERE %s = $1", r.tableName, field)
-//	rows, err := r.db.Query(query, value)
-//	if err != nil {
-//		return nil, err
-//	}
-//	defer rows.Close()
-//	var results []T
-//	for rows.Next() {
-//		var result T
-//		if err := rows.Scan(&result); err != nil {
-//			return nil, err
-//		}
-//		results = append(results, result)
-//	}
-//	if err = rows.Err(); err != nil {
-//		return nil, err
-//	}
-//	return results, nil
-//}
-//
-//func (r *SQLRepository[T]) FindByFilters(filters map[string]interface{}) ([]T, error) {
-//	conditions, values := r.buildWhereClause(filters)
-//	query := fmt.Sprintf("SELECT * FROM %s WHERE %s", r.tableName, conditions)
-//	rows, err := r.db.Query(query, values...)
-//	if err != nil {
-//		return nil, err
-//	}
-//	defer rows.Close()
-//	var results []T
-//	for rows.Next() {
-//		var result T
-//		if err := rows.Scan(&result); err != nil {
-//			return nil, err
-//		}
-//		results = append(results, result)
-//	}
-//	if err = rows.Err(); err != nil {
-//		return nil, err
-//	}
-//	return results, nil
-//}
-//
-//func (r *SQLRepository[T]) FindAll(options ...interface{}) ([]T, error) {
-//	query := fmt.Sprintf("SELECT * FROM %s", r.tableName)
-//	rows, err := r.db.Query(query)
-//	if err != nil {
-//		return nil, err
-//	}
-//	defer rows.Close()
-//	var results []T
-//	for rows.Next() {
-//		var result T
-//		if err := rows.Scan(&result); err != nil {
-//			return nil, err
-//		}
-//		results = append(results, result)
-//	}
-//	if err = rows.Err(); err != nil {
-//		return nil, err
-//	}
-//	return results, nil
-//}
-//
-//func (r *SQLRepository[T]) FindAllPaginated(pageRequest PageRequest) (PageResponse[T], error) {
-//	offset := (pageRequest.Page - 1) * pageRequest.Size
-//	query := fmt.Sprintf("SELECT * FROM %s LIMIT $1 OFFSET $2", r.tableName)
-//
-//	rows, err := r.db.Query(query, pageRequest.Size, offset)
-//	if err != nil {
-//		return PageResponse[T]{}, err
-//	}
-//	defer rows.Close()
-//
-//	var results []T
-//	for rows.Next() {
-//		var result T
-//		if err := rows.Scan(&result); err != nil {
-//			return PageResponse[T]{}, err
-//		}
-//		results = append(results, result)
-//	}
-//	if err = rows.Err(); err != nil {
-//		return PageResponse[T]{}, err
-//	}
-//
-//	var total int
-//	err = r.db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", r.tableName)).Scan(&total)
-//	if err != nil {
-//		return PageResponse[T]{}, err
-//	}
-//
-//	return PageResponse[T]{
-//		Contents:         results,
-//		NumberOfElements: pageRequest.Size,
-//		Pageable:         pageRequest,
-//		TotalElements:    total,
-//		TotalPages:       (total + pageRequest.Size - 1) / pageRequest.Size,
-//	}, nil
-//}
-//
-//func (r *SQLRepository[T]) FindByPaginated(pageRequest PageRequest, filters map[string]interface{}) (PageResponse[T], error) {
-//	conditions, values := r.buildWhereClause(filters)
-//	offset := (pageRequest.Page - 1) * pageRequest.Size
-//
-//	query := fmt.Sprintf("SELECT * FROM %s WHERE %s LIMIT $%d OFFSET $%d",
-//		r.tableName, conditions, len(values)+1, len(values)+2)
-//
-//	queryValues := append(values, pageRequest.Size, offset)
-//	rows, err := r.db.Query(query, queryValues...)
-//	if err != nil {
-//		return PageResponse[T]{}, err
-//	}
-//	defer rows.Close()
-//
-//	var results []T
-//	for rows.Next() {
-//		var result T
-//		if err := rows.Scan(&result); err != nil {
-//			return PageResponse[T]{}, err
-//		}
-//		results = append(results, result)
-//	}
-//	if err = rows.Err(); err != nil {
-//		return PageResponse[T]{}, err
-//	}
-//
-//	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", r.tableName, conditions)
-//	var total int
-//	err = r.db.QueryRow(countQuery, values...).Scan(&total)
-//	if err != nil {
-//		return PageResponse[T]{}, err
-//	}
-//
-//	return PageResponse[T]{
-//		Contents:         results,
-//		NumberOfElements: pageRequest.Size,
-//		Pageable:         pageRequest,
-//		TotalElements:    total,
-//		TotalPages:       (total + pageRequest.Size - 1) / pageRequest.Size,
-//	}, nil
-//}
-//
-//func (r *SQLRepository[T]) CountBy(field string, value interface{}) (int64, error) {
-//	var count int64
-//	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = $1", r.tableName, field)
-//	err := r.db.QueryRow(query, value).Scan(&count)
-//	return count, err
-//}
-//
-//func (r *SQLRepository[T]) CountByFilters(filters map[string]interface{}) (int64, error) {
-//	conditions, values := r.buildWhereClause(filters)
-//	var count int64
-//	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", r.tableName, conditions)
-//	err := r.db.QueryRow(query, values...).Scan(&count)
-//	return count, err
-//}
-//
-//func (r *SQLRepository[T]) ExistsBy(field string, value interface{}) (bool, error) {
-//	count, err := r.CountBy(field, value)
-//	return count > 0, err
-//}
-//
-//func (r *SQLRepository[T]) ExistsByFilters(filters map[string]interface{}) (bool, error) {
-//	count, err := r.CountByFilters(filters)
-//	return count > 0, err
-//}
-//
-//func (r *SQLRepository[T]) scanRow(row *sql.Row, dest *T) error {
-//	var item T
-//	fields, _ := r.extractFieldsAndValues(item)
-//	values := make([]interface{}, len(fields))
-//	scanValues := make([]interface{}, len(fields))
-//	for i := range values {
-//		scanValues[i] = &values[i]
-//	}
-//	if err := row.Scan(scanValues...); err != nil {
-//		return err
-//	}
-//	// TODO: Map values back to dest
-//	return nil
-//}
-//
-//func (r *SQLRepository[T]) scanSingleRow(rows *sql.Rows, dest *T) error {
-//	fields, _ := r.extractFieldsAndValues(*dest)
-//	values := make([]interface{}, len(fields))
-//	scanValues := make([]interface{}, len(fields))
-//	for i := range values {
-//		scanValues[i] = &values[i]
-//	}
-//	if err := rows.Scan(scanValues...); err != nil {
-//		return err
-//	}
-//	// TODO: Map values back to dest
-//	return nil
-//}
-//
-//func (r *SQLRepository[T]) scanRows(rows *sql.Rows) ([]T, error) {
-//	var results []T
-//	for rows.Next() {
-//		var item T
-//		if err := r.scanSingleRow(rows, &item); err != nil {
-//			return nil, err
-//		}
-//		results = append(results, item)
-//	}
-//	return results, rows.Err()
-//}
-//
-//func (r *SQLRepository[T]) extractFieldsAndValues(doc T) ([]string, []interface{}) {
-//	v := reflect.ValueOf(doc)
-//	t := v.Type()
-//	var fields []string
-//	var values []interface{}
-//
-//	for i := 0; i < v.NumField(); i++ {
-//		field := t.Field(i)
-//		tag := field.Tag.Get("db")
-//		if tag == "" {
-//			tag = strings.ToLower(field.Name)
-//		}
-//		fields = append(fields, tag)
-//		values = append(values, v.Field(i).Interface())
-//	}
-//	return fields, values
-//}
-//
-//func (r *SQLRepository[T]) buildWhereClause(filters map[string]interface{}) (string, []interface{}) {
-//	var conditions []string
-//	var values []interface{}
-//	i := 1
-//
-//	for field, value := range filters {
-//		conditions = append(conditions, fmt.Sprintf("%s = $%d", field, i))
-//		values = append(values, value)
-//		i++
-//	}
-//
-//	return strings.Join(conditions, " AND "), values
-//}
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SQLRepository is a GenericRepository storing documents as rows of a table.
+// Columns are mapped like ScanSQLRow's, the ID column is the field tagged
+// ginboot:"_id" and statements are written for the dialect, so the same
+// repository works on PostgreSQL, MySQL and SQLite. Field names in filters
+// and criteria are column names.
+type SQLRepository[T any] struct {
+	db      *sql.DB
+	dialect SQLDialect
+	table   string
+	columns []sqlColumn
+	// id, version and created are the columns of the ginboot tagged fields;
+	// version and created are empty when T has none
+	id, version, created string
+}
+
+// NewSQLRepository returns a repository for table, failing when T cannot be
+// mapped to columns, e.g. when it is not a struct
+func NewSQLRepository[T any](db *sql.DB, dialect SQLDialect, table string) (*SQLRepository[T], error) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	columns, err := sqlColumns(typ)
+	if err != nil {
+		return nil, err
+	}
+	r := &SQLRepository[T]{db: db, dialect: dialect, table: table, columns: columns, id: "id"}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	for _, column := range columns {
+		if len(column.index) != 1 {
+			continue
+		}
+		field := typ.Field(column.index[0])
+		switch {
+		case hasGinbootTag(field, "_id"):
+			r.id = column.name
+		case hasGinbootTag(field, "version"):
+			r.version = column.name
+		case hasGinbootTag(field, "created_at"):
+			r.created = column.name
+		}
+	}
+	return r, nil
+}
+
+type sqlTxKey struct{}
+
+// sqlExecutor runs statements on the database or the transaction of a context
+type sqlExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func (r *SQLRepository[T]) executor(ctx context.Context) sqlExecutor {
+	if tx, ok := ctx.Value(sqlTxKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return r.db
+}
+
+// sqlArgs collects the arguments of a statement, returning their placeholders
+type sqlArgs struct {
+	dialect SQLDialect
+	values  []interface{}
+}
+
+func (a *sqlArgs) bind(value interface{}) string {
+	a.values = append(a.values, value)
+	return a.dialect.Placeholder(len(a.values))
+}
+
+func (r *SQLRepository[T]) args() *sqlArgs {
+	return &sqlArgs{dialect: r.dialect}
+}
+
+// selectSQL returns a SELECT of the repository's columns
+func (r *SQLRepository[T]) selectSQL(where string, orderBy string, limit, offset int, queryOptions QueryOptions) string {
+	columns := make([]string, len(r.columns))
+	for i, column := range r.columns {
+		columns[i] = r.dialect.Quote(column.name)
+	}
+	statement := fmt.Sprintf("SELECT %s FROM %s", strings.Join(columns, ", "), r.dialect.Quote(r.table))
+	if where != "" {
+		statement += " WHERE " + where
+	}
+	if orderBy != "" {
+		statement += " ORDER BY " + orderBy
+	}
+	if limit > 0 {
+		statement += " LIMIT " + strconv.Itoa(limit)
+	}
+	if offset > 0 {
+		statement += " OFFSET " + strconv.Itoa(offset)
+	}
+	// SQLite locks the whole database on write instead
+	if queryOptions.ForUpdate && r.dialect.Name() != "sqlite" {
+		statement += " FOR UPDATE"
+	}
+	return statement
+}
+
+func (r *SQLRepository[T]) find(ctx context.Context, statement string, args []interface{}) ([]T, error) {
+	rows, err := r.executor(ctx).QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, NormalizeError(err)
+	}
+	defer rows.Close()
+	results, err := ScanSQLRows[T](rows)
+	return results, NormalizeError(err)
+}
+
+func (r *SQLRepository[T]) findOne(ctx context.Context, where string, args *sqlArgs, opts []QueryOption) (T, error) {
+	var result T
+	results, err := r.find(ctx, r.selectSQL(where, "", 1, 0, NewQueryOptions(opts...)), args.values)
+	if err != nil {
+		return result, err
+	}
+	if len(results) == 0 {
+		return result, NormalizeError(sql.ErrNoRows)
+	}
+	return results[0], nil
+}
+
+func (r *SQLRepository[T]) FindById(ctx context.Context, id string, opts ...QueryOption) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	args := r.args()
+	return r.findOne(ctx, r.dialect.Quote(r.id)+" = "+args.bind(id), args, opts)
+}
+
+func (r *SQLRepository[T]) FindAllById(ctx context.Context, ids []string, opts ...QueryOption) ([]T, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	values := make([]interface{}, len(ids))
+	for i, id := range ids {
+		values[i] = id
+	}
+	args := r.args()
+	where := r.where(In(r.id, values...), args)
+	return r.find(ctx, r.selectSQL(where, "", 0, 0, NewQueryOptions(opts...)), args.values)
+}
+
+func (r *SQLRepository[T]) FindOneBy(ctx context.Context, field string, value interface{}, opts ...QueryOption) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	args := r.args()
+	return r.findOne(ctx, r.where(Eq(field, value), args), args, opts)
+}
+
+func (r *SQLRepository[T]) FindOneByFilters(ctx context.Context, filters map[string]interface{}, opts ...QueryOption) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	args := r.args()
+	return r.findOne(ctx, r.where(filterCriteria(filters), args), args, opts)
+}
+
+func (r *SQLRepository[T]) FindBy(ctx context.Context, field string, value interface{}, opts ...QueryOption) ([]T, error) {
+	return r.FindByQuery(ctx, Where(Eq(field, value)), opts...)
+}
+
+func (r *SQLRepository[T]) FindByFilters(ctx context.Context, filters map[string]interface{}, opts ...QueryOption) ([]T, error) {
+	return r.FindByQuery(ctx, Where(filterCriteria(filters)), opts...)
+}
+
+func (r *SQLRepository[T]) FindAll(ctx context.Context, opts ...QueryOption) ([]T, error) {
+	return r.FindByQuery(ctx, nil, opts...)
+}
+
+func (r *SQLRepository[T]) FindAllPaginated(ctx context.Context, pageRequest PageRequest, opts ...QueryOption) (PageResponse[T], error) {
+	return r.FindByQueryPaginated(ctx, pageRequest, nil, opts...)
+}
+
+func (r *SQLRepository[T]) FindByPaginated(ctx context.Context, pageRequest PageRequest, filters map[string]interface{}, opts ...QueryOption) (PageResponse[T], error) {
+	return r.FindByQueryPaginated(ctx, pageRequest, Where(filterCriteria(filters)), opts...)
+}
+
+func (r *SQLRepository[T]) FindByQuery(ctx context.Context, query *Query, opts ...QueryOption) ([]T, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	args := r.args()
+	where, err := r.queryWhere(query, args)
+	if err != nil {
+		return nil, err
+	}
+	var orderBy string
+	var limit int
+	if query != nil {
+		orderBy = r.orderBy(query.Sorts)
+		limit = query.MaxResults
+	}
+	return r.find(ctx, r.selectSQL(where, orderBy, limit, 0, NewQueryOptions(opts...)), args.values)
+}
+
+// FindByQueryPaginated pages through the rows matching the query. The page
+// request's sort takes precedence over the query's, then T's DefaultSort and
+// the ID column; the query's limit is ignored.
+func (r *SQLRepository[T]) FindByQueryPaginated(ctx context.Context, pageRequest PageRequest, query *Query, opts ...QueryOption) (PageResponse[T], error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	total, err := r.CountByQuery(ctx, query)
+	if err != nil {
+		return PageResponse[T]{}, err
+	}
+
+	args := r.args()
+	where, err := r.queryWhere(query, args)
+	if err != nil {
+		return PageResponse[T]{}, err
+	}
+	sorts := pageRequest.SortFields()
+	if len(sorts) == 0 && query != nil {
+		sorts = query.Sorts
+	}
+	if len(sorts) == 0 {
+		sort, ok := defaultSortOf[T]()
+		if !ok {
+			sort = SortField{Field: r.id, Direction: 1}
+		}
+		sorts = []SortField{sort}
+	}
+	statement := r.selectSQL(where, r.orderBy(sorts), pageRequest.Size, (pageRequest.Page-1)*pageRequest.Size, NewQueryOptions(opts...))
+	items, err := r.find(ctx, statement, args.values)
+	if err != nil {
+		return PageResponse[T]{}, err
+	}
+
+	return PageResponse[T]{
+		Contents:         items,
+		NumberOfElements: len(items),
+		Pageable:         pageRequest,
+		TotalElements:    int(total),
+		TotalPages:       int(math.Ceil(float64(total) / float64(pageRequest.Size))),
+	}, nil
+}
+
+func (r *SQLRepository[T]) CountBy(ctx context.Context, field string, value interface{}) (int64, error) {
+	return r.CountByQuery(ctx, Where(Eq(field, value)))
+}
+
+func (r *SQLRepository[T]) CountByFilters(ctx context.Context, filters map[string]interface{}) (int64, error) {
+	return r.CountByQuery(ctx, Where(filterCriteria(filters)))
+}
+
+func (r *SQLRepository[T]) CountByQuery(ctx context.Context, query *Query) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	args := r.args()
+	where, err := r.queryWhere(query, args)
+	if err != nil {
+		return 0, err
+	}
+	statement := "SELECT COUNT(*) FROM " + r.dialect.Quote(r.table)
+	if where != "" {
+		statement += " WHERE " + where
+	}
+	rows, err := r.executor(ctx).QueryContext(ctx, statement, args.values...)
+	if err != nil {
+		return 0, NormalizeError(err)
+	}
+	defer rows.Close()
+	var count int64
+	if rows.Next() {
+		err = rows.Scan(&count)
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	return count, NormalizeError(err)
+}
+
+func (r *SQLRepository[T]) ExistsBy(ctx context.Context, field string, value interface{}) (bool, error) {
+	count, err := r.CountBy(ctx, field, value)
+	return count > 0, err
+}
+
+func (r *SQLRepository[T]) ExistsByFilters(ctx context.Context, filters map[string]interface{}) (bool, error) {
+	count, err := r.CountByFilters(ctx, filters)
+	return count > 0, err
+}
+
+func (r *SQLRepository[T]) Save(ctx context.Context, doc T) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	doc, err := r.stamp(ctx, doc, false)
+	if err != nil {
+		return err
+	}
+	return r.insert(ctx, doc)
+}
+
+func (r *SQLRepository[T]) insert(ctx context.Context, doc interface{}) error {
+	columns, values, err := SQLValues(doc)
+	if err != nil {
+		return err
+	}
+	args := r.args()
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = r.dialect.Quote(column)
+		placeholders[i] = args.bind(values[i])
+	}
+	statement := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		r.dialect.Quote(r.table), strings.Join(quoted, ", "), strings.Join(placeholders, ", "))
+	_, err = r.executor(ctx).ExecContext(ctx, statement, args.values...)
+	return NormalizeError(err)
+}
+
+// stamp sets the fields of doc tagged ginboot:"created_at", when unset, and
+// ginboot:"updated_at" to the current time. A replacement without a creation
+// time keeps the stored one.
+func (r *SQLRepository[T]) stamp(ctx context.Context, doc T, replacing bool) (T, error) {
+	timestamps, ok := timestampsOf(doc)
+	if !ok {
+		return doc, nil
+	}
+	now := time.Now().UTC()
+	createdAt := now
+	if replacing && r.created != "" && timestamps.createdAt(doc).IsZero() {
+		args := r.args()
+		statement := fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s", r.dialect.Quote(r.created),
+			r.dialect.Quote(r.table), r.dialect.Quote(r.id), args.bind(getDocumentID(doc)))
+		rows, err := r.executor(ctx).QueryContext(ctx, statement, args.values...)
+		if err != nil {
+			return doc, NormalizeError(err)
+		}
+		defer rows.Close()
+		if rows.Next() {
+			var stored sqlTimeValue
+			if err := rows.Scan(&stored); err != nil {
+				return doc, err
+			}
+			if !stored.Time.IsZero() {
+				createdAt = stored.Time
+			}
+		}
+	}
+	return stampTimestamps(doc, timestamps, createdAt, now), nil
+}
+
+// sqlTimeValue scans a time stored natively or as text
+type sqlTimeValue struct {
+	Time time.Time
+}
+
+func (t *sqlTimeValue) Scan(src interface{}) error {
+	if src == nil {
+		return nil
+	}
+	var err error
+	t.Time, err = sqlTime(src)
+	return err
+}
+
+// SaveOrUpdate inserts or replaces the row with an upsert. Documents with a
+// ginboot:"version" field are only replaced when the stored version matches,
+// and fail with ErrVersionConflict otherwise.
+func (r *SQLRepository[T]) SaveOrUpdate(ctx context.Context, doc T) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return r.saveOrUpdate(ctx, doc)
+}
+
+func (r *SQLRepository[T]) saveOrUpdate(ctx context.Context, doc T) error {
+	doc, err := r.stamp(ctx, doc, true)
+	if err != nil {
+		return err
+	}
+	version, versioned := documentVersion(doc)
+	if !versioned || r.version == "" {
+		columns, values, err := SQLValues(doc)
+		if err != nil {
+			return err
+		}
+		_, err = r.executor(ctx).ExecContext(ctx, r.dialect.Upsert(r.table, columns, []string{r.id}), values...)
+		return NormalizeError(err)
+	}
+
+	updated, err := r.update(ctx, doc, version)
+	if err != nil || updated {
+		return err
+	}
+	// no row with the expected version: insert one, which fails on the ID
+	// when a row with another version exists
+	if err := r.insert(ctx, version.next); err != nil {
+		if errors.Is(err, ErrDuplicateKey) {
+			return fmt.Errorf("%w: %w", ErrVersionConflict, err)
+		}
+		return err
+	}
+	version.commit(doc)
+	return nil
+}
+
+// update writes doc over the row with its ID, and with its version when
+// versioned, reporting whether a row was written
+func (r *SQLRepository[T]) update(ctx context.Context, doc T, version versionedDocument) (bool, error) {
+	var row interface{} = doc
+	versioned := version.next != nil && r.version != ""
+	if versioned {
+		row = version.next
+	}
+	columns, values, err := SQLValues(row)
+	if err != nil {
+		return false, err
+	}
+	args := r.args()
+	var updates []string
+	for i, column := range columns {
+		if column != r.id {
+			updates = append(updates, r.dialect.Quote(column)+" = "+args.bind(values[i]))
+		}
+	}
+	where := r.dialect.Quote(r.id) + " = " + args.bind(getDocumentID(doc))
+	if versioned {
+		where += " AND " + r.dialect.Quote(r.version) + " = " + args.bind(version.current)
+	}
+	statement := fmt.Sprintf("UPDATE %s SET %s WHERE %s", r.dialect.Quote(r.table), strings.Join(updates, ", "), where)
+	result, err := r.executor(ctx).ExecContext(ctx, statement, args.values...)
+	if err != nil {
+		return false, NormalizeError(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected > 0 && versioned {
+		version.commit(doc)
+	}
+	return affected > 0, nil
+}
+
+// SaveAll upserts the documents in one transaction
+func (r *SQLRepository[T]) SaveAll(ctx context.Context, docs []T) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return r.WithTransaction(ctx, func(tx Tx) error {
+		for _, doc := range docs {
+			if err := r.saveOrUpdate(tx, doc); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Update replaces an existing row. Documents with a ginboot:"version" field
+// fail with ErrVersionConflict when the stored version differs and with
+// ErrNotFound when there is no stored row; on success the stored version is
+// incremented, and so is the caller's when doc is a pointer.
+func (r *SQLRepository[T]) Update(ctx context.Context, doc T) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	doc, err := r.stamp(ctx, doc, true)
+	if err != nil {
+		return err
+	}
+	version, versioned := documentVersion(doc)
+	if !versioned || r.version == "" {
+		_, err := r.update(ctx, doc, versionedDocument{})
+		return err
+	}
+	updated, err := r.update(ctx, doc, version)
+	if err != nil || updated {
+		return err
+	}
+	return r.versionConflict(ctx, getDocumentID(doc))
+}
+
+// versionConflict tells why a statement conditioned on a version matched no row
+func (r *SQLRepository[T]) versionConflict(ctx context.Context, id string) error {
+	exists, err := r.ExistsBy(ctx, r.id, id)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return ErrVersionConflict
+}
+
+func (r *SQLRepository[T]) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	args := r.args()
+	statement := fmt.Sprintf("DELETE FROM %s WHERE %s = %s", r.dialect.Quote(r.table), r.dialect.Quote(r.id), args.bind(id))
+	_, err := r.executor(ctx).ExecContext(ctx, statement, args.values...)
+	return NormalizeError(err)
+}
+
+func (r *SQLRepository[T]) DeleteVersion(ctx context.Context, doc T) error {
+	id := getDocumentID(doc)
+	version, versioned := documentVersion(doc)
+	if !versioned || r.version == "" {
+		return r.Delete(ctx, id)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	args := r.args()
+	statement := fmt.Sprintf("DELETE FROM %s WHERE %s = %s AND %s = %s", r.dialect.Quote(r.table),
+		r.dialect.Quote(r.id), args.bind(id), r.dialect.Quote(r.version), args.bind(version.current))
+	result, err := r.executor(ctx).ExecContext(ctx, statement, args.values...)
+	if err != nil {
+		return NormalizeError(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return r.versionConflict(ctx, id)
+	}
+	return nil
+}
+
+// WithTransaction runs fn in a SQL transaction, committing when fn returns nil
+// and rolling back otherwise. Repository calls made with tx as their context
+// take part in the transaction, including calls on other SQL repositories of
+// the same *sql.DB. Calls made inside a transaction join it.
+func (r *SQLRepository[T]) WithTransaction(ctx context.Context, fn func(tx Tx) error) error {
+	if _, ok := ctx.Value(sqlTxKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return NormalizeError(err)
+	}
+	if err := fn(context.WithValue(ctx, sqlTxKey{}, tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return NormalizeError(tx.Commit())
+}
+
+// EnsureTable creates the table when missing, with a column per field typed by
+// the dialect and the ID column as primary key. Existing tables are left as
+// they are; evolve them with a Migrator.
+func (r *SQLRepository[T]) EnsureTable(ctx context.Context) error {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	definitions := make([]string, len(r.columns))
+	for i, column := range r.columns {
+		columnType := r.dialect.ColumnType(typ.FieldByIndex(column.index).Type)
+		if column.json {
+			columnType = r.dialect.ColumnType(reflect.TypeOf(map[string]interface{}{}))
+		}
+		definitions[i] = r.dialect.Quote(column.name) + " " + columnType
+		if column.name == r.id {
+			definitions[i] += " PRIMARY KEY"
+		}
+	}
+	statement := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", r.dialect.Quote(r.table), strings.Join(definitions, ", "))
+	_, err := r.executor(ctx).ExecContext(ctx, statement)
+	return NormalizeError(err)
+}
+
+// filterCriteria matches rows whose columns equal the filters, in column
+// order so statements are stable
+func filterCriteria(filters map[string]interface{}) Criteria {
+	fields := make([]string, 0, len(filters))
+	for field := range filters {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	criteria := make([]Criteria, len(fields))
+	for i, field := range fields {
+		criteria[i] = Eq(field, filters[field])
+	}
+	return And(criteria...)
+}
+
+func (r *SQLRepository[T]) queryWhere(query *Query, args *sqlArgs) (string, error) {
+	if query == nil {
+		return "", nil
+	}
+	if err := query.Criteria.Validate(); err != nil {
+		return "", err
+	}
+	return r.where(query.Criteria, args), nil
+}
+
+// where translates validated criteria to a SQL condition, binding values to
+// args; empty when the criteria match every row
+func (r *SQLRepository[T]) where(c Criteria, args *sqlArgs) string {
+	column := r.dialect.Quote(c.Field)
+	switch c.Operator {
+	case OpAnd, OpOr:
+		var operands []string
+		for _, child := range c.Criteria {
+			if operand := r.where(child, args); operand != "" {
+				operands = append(operands, "("+operand+")")
+			}
+		}
+		if len(operands) == 0 {
+			if c.Operator == OpOr {
+				return "1 = 1"
+			}
+			return ""
+		}
+		return strings.Join(operands, " "+strings.ToUpper(string(c.Operator))+" ")
+	case OpEq:
+		if c.Value == nil {
+			return column + " IS NULL"
+		}
+		return column + " = " + args.bind(c.Value)
+	case OpNe:
+		if c.Value == nil {
+			return column + " IS NOT NULL"
+		}
+		return column + " <> " + args.bind(c.Value)
+	case OpGt:
+		return column + " > " + args.bind(c.Value)
+	case OpGte:
+		return column + " >= " + args.bind(c.Value)
+	case OpLt:
+		return column + " < " + args.bind(c.Value)
+	case OpLte:
+		return column + " <= " + args.bind(c.Value)
+	case OpIn:
+		values := reflect.ValueOf(c.Value)
+		if values.Kind() != reflect.Slice || values.Len() == 0 {
+			return "1 = 0"
+		}
+		placeholders := make([]string, values.Len())
+		for i := range placeholders {
+			placeholders[i] = args.bind(values.Index(i).Interface())
+		}
+		return column + " IN (" + strings.Join(placeholders, ", ") + ")"
+	case OpContains:
+		// ! escapes the wildcards, as a backslash is itself an escape in MySQL
+		escaped := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(c.Value.(string))
+		return column + " LIKE " + args.bind("%"+escaped+"%") + " ESCAPE '!'"
+	}
+	return ""
+}
+
+func (r *SQLRepository[T]) orderBy(sorts []SortField) string {
+	order := make([]string, len(sorts))
+	for i, field := range sorts {
+		order[i] = r.dialect.Quote(field.Field)
+		if field.Direction < 0 {
+			order[i] += " DESC"
+		}
+	}
+	return strings.Join(order, ", ")
+}
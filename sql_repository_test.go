@@ -0,0 +1,282 @@
+package ginboot
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingDB is the state of a fake database/sql driver recording the
+// statements it receives. Queries answer the queued rows in order, or no rows,
+// and Execs the queued results, or one affected row.
+type recordingDB struct {
+	mu         sync.Mutex
+	statements []recordedStatement
+	rows       []fakeRows
+	results    []recordedResult
+}
+
+type recordedStatement struct {
+	query string
+	args  []interface{}
+}
+
+type recordedResult struct {
+	affected int64
+	err      error
+}
+
+var (
+	recordingDBsMu sync.Mutex
+	recordingDBs   = map[string]*recordingDB{}
+)
+
+type recordingDriver struct{}
+
+func init() {
+	sql.Register("ginboot-recording", recordingDriver{})
+}
+
+func (recordingDriver) Open(name string) (driver.Conn, error) {
+	recordingDBsMu.Lock()
+	defer recordingDBsMu.Unlock()
+	return &recordingConn{db: recordingDBs[name]}, nil
+}
+
+type recordingConn struct{ db *recordingDB }
+
+func (c *recordingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+
+func (c *recordingConn) Close() error { return nil }
+
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	c.record("BEGIN", nil)
+	return c, nil
+}
+
+func (c *recordingConn) Commit() error {
+	c.record("COMMIT", nil)
+	return nil
+}
+
+func (c *recordingConn) Rollback() error {
+	c.record("ROLLBACK", nil)
+	return nil
+}
+
+func (c *recordingConn) record(query string, args []driver.NamedValue) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	statement := recordedStatement{query: query}
+	for _, arg := range args {
+		statement.args = append(statement.args, arg.Value)
+	}
+	c.db.statements = append(c.db.statements, statement)
+}
+
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.record(query, args)
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	result := recordedResult{affected: 1}
+	if len(c.db.results) > 0 {
+		result, c.db.results = c.db.results[0], c.db.results[1:]
+	}
+	return driver.RowsAffected(result.affected), result.err
+}
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.record(query, args)
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	var rows fakeRows
+	if len(c.db.rows) > 0 {
+		rows, c.db.rows = c.db.rows[0], c.db.rows[1:]
+	}
+	return &fakeRowsIterator{columns: rows.columns, values: rows.values}, nil
+}
+
+func openRecordingDB(t *testing.T) (*sql.DB, *recordingDB) {
+	state := &recordingDB{}
+	recordingDBsMu.Lock()
+	recordingDBs[t.Name()] = state
+	recordingDBsMu.Unlock()
+	db, err := sql.Open("ginboot-recording", t.Name())
+	assert.NoError(t, err)
+	// one connection keeps transactions and statements in order
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db, state
+}
+
+func (d *recordingDB) queries() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	queries := make([]string, len(d.statements))
+	for i, statement := range d.statements {
+		queries[i] = statement.query
+	}
+	d.statements = nil
+	return queries
+}
+
+type sqlPost struct {
+	ID        string    `db:"id" ginboot:"_id"`
+	Title     string    `db:"title"`
+	CreatedAt time.Time `db:"created_at" ginboot:"created_at"`
+	Version   int64     `db:"version" ginboot:"version"`
+}
+
+const sqlPostColumns = `"id", "title", "created_at", "version"`
+
+func mustSQLRepository[T any](t *testing.T, db *sql.DB, dialect SQLDialect, table string) *SQLRepository[T] {
+	repo, err := NewSQLRepository[T](db, dialect, table)
+	if err != nil {
+		t.Fatalf("creating repository: %v", err)
+	}
+	return repo
+}
+
+func TestNewSQLRepository_Unmappable(t *testing.T) {
+	db, _ := openRecordingDB(t)
+	_, err := NewSQLRepository[string](db, PostgresDialect{}, "names")
+	assert.EqualError(t, err, "ginboot: string is not a struct")
+
+	type embedded struct{ Name string }
+	type post struct {
+		*embedded
+		ID string `db:"id" ginboot:"_id"`
+	}
+	_, err = NewSQLRepository[post](db, PostgresDialect{}, "posts")
+	assert.ErrorContains(t, err, "embedded pointers are not supported")
+}
+
+func TestSQLRepository(t *testing.T) {
+	ctx := context.Background()
+	db, state := openRecordingDB(t)
+	repo := mustSQLRepository[*sqlPost](t, db, PostgresDialect{}, "posts")
+
+	post := &sqlPost{ID: "p1", Title: "Hello"}
+	assert.NoError(t, repo.Save(ctx, post))
+	assert.False(t, post.CreatedAt.IsZero())
+	assert.Equal(t, []string{`INSERT INTO "posts" (` + sqlPostColumns + `) VALUES ($1, $2, $3, $4)`}, state.queries())
+
+	created := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	state.rows = []fakeRows{{
+		columns: []string{"id", "title", "created_at", "version"},
+		values:  [][]driver.Value{{"p1", "Hello", created, int64(3)}},
+	}}
+	found, err := repo.FindById(ctx, "p1", ForUpdate())
+	assert.NoError(t, err)
+	assert.Equal(t, &sqlPost{ID: "p1", Title: "Hello", CreatedAt: created, Version: 3}, found)
+	assert.Equal(t, []string{`SELECT ` + sqlPostColumns + ` FROM "posts" WHERE "id" = $1 LIMIT 1 FOR UPDATE`}, state.queries())
+
+	_, err = repo.FindById(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	state.queries()
+
+	// the update keeps the stored creation time and checks the version
+	found.CreatedAt = time.Time{}
+	state.rows = []fakeRows{{columns: []string{"created_at"}, values: [][]driver.Value{{created}}}}
+	assert.NoError(t, repo.Update(ctx, found))
+	assert.Equal(t, int64(4), found.Version)
+	assert.Equal(t, created, found.CreatedAt)
+	assert.Equal(t, []string{
+		`SELECT "created_at" FROM "posts" WHERE "id" = $1`,
+		`UPDATE "posts" SET "title" = $1, "created_at" = $2, "version" = $3 WHERE "id" = $4 AND "version" = $5`,
+	}, state.queries())
+
+	state.results = []recordedResult{{affected: 0}}
+	state.rows = []fakeRows{{columns: []string{"count"}, values: [][]driver.Value{{int64(1)}}}}
+	assert.ErrorIs(t, repo.Update(ctx, &sqlPost{ID: "p1", CreatedAt: created, Version: 1}), ErrVersionConflict)
+	state.queries()
+
+	state.results = []recordedResult{{affected: 0}, {err: errors.New("UNIQUE constraint failed: posts.id")}}
+	err = repo.SaveOrUpdate(ctx, &sqlPost{ID: "p1", CreatedAt: created, Version: 1})
+	assert.Equal(t, []string{
+		`UPDATE "posts" SET "title" = $1, "created_at" = $2, "version" = $3 WHERE "id" = $4 AND "version" = $5`,
+		`INSERT INTO "posts" (` + sqlPostColumns + `) VALUES ($1, $2, $3, $4)`,
+	}, state.queries())
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	assert.NoError(t, repo.Delete(ctx, "p1"))
+	assert.Equal(t, []string{`DELETE FROM "posts" WHERE "id" = $1`}, state.queries())
+
+	state.results = []recordedResult{{affected: 0}}
+	state.rows = []fakeRows{{columns: []string{"count"}, values: [][]driver.Value{{int64(1)}}}}
+	assert.ErrorIs(t, repo.DeleteVersion(ctx, &sqlPost{ID: "p1", Version: 2}), ErrVersionConflict)
+	assert.Equal(t, `DELETE FROM "posts" WHERE "id" = $1 AND "version" = $2`, state.queries()[0])
+}
+
+func TestSQLRepository_Queries(t *testing.T) {
+	ctx := context.Background()
+	db, state := openRecordingDB(t)
+	repo := mustSQLRepository[sqlPost](t, db, PostgresDialect{}, "posts")
+
+	state.rows = []fakeRows{{columns: []string{"count"}, values: [][]driver.Value{{int64(21)}}}}
+	page, err := repo.FindByQueryPaginated(ctx, PageRequest{Page: 3, Size: 10},
+		Where(Contains("title", "50%_off"), Or(Eq("version", 1), In("id", "a", "b"))).Sort("created_at", -1))
+	assert.NoError(t, err)
+	assert.Equal(t, 21, page.TotalElements)
+	assert.Equal(t, 3, page.TotalPages)
+	where := `("title" LIKE $1 ESCAPE '!') AND (("version" = $2) OR ("id" IN ($3, $4)))`
+	assert.Equal(t, []string{
+		`SELECT COUNT(*) FROM "posts" WHERE ` + where,
+		`SELECT ` + sqlPostColumns + ` FROM "posts" WHERE ` + where + ` ORDER BY "created_at" DESC LIMIT 10 OFFSET 20`,
+	}, state.queries())
+
+	_, err = repo.FindByFilters(ctx, map[string]interface{}{"title": "a", "id": nil})
+	assert.NoError(t, err)
+	_, err = repo.FindByQuery(ctx, Where(Contains("title", "50%_off")))
+	assert.NoError(t, err)
+	state.mu.Lock()
+	assert.Equal(t, "%50!%!_off%", state.statements[1].args[0])
+	state.mu.Unlock()
+	assert.Equal(t, []string{
+		`SELECT ` + sqlPostColumns + ` FROM "posts" WHERE ("id" IS NULL) AND ("title" = $1)`,
+		`SELECT ` + sqlPostColumns + ` FROM "posts" WHERE "title" LIKE $1 ESCAPE '!'`,
+	}, state.queries())
+
+	_, err = repo.FindByQuery(ctx, Where(Criteria{Operator: "regex", Field: "title"}))
+	assert.Error(t, err)
+}
+
+func TestSQLRepository_TransactionsAndDialects(t *testing.T) {
+	ctx := context.Background()
+	db, state := openRecordingDB(t)
+	type setting struct {
+		Key   string `db:"key" ginboot:"_id"`
+		Value string `db:"value"`
+	}
+	repo := mustSQLRepository[setting](t, db, MySQLDialect{}, "settings")
+
+	assert.NoError(t, repo.SaveAll(ctx, []setting{{Key: "a", Value: "1"}, {Key: "b", Value: "2"}}))
+	upsert := "INSERT INTO `settings` (`key`, `value`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `value` = VALUES(`value`)"
+	assert.Equal(t, []string{"BEGIN", upsert, upsert, "COMMIT"}, state.queries())
+
+	failed := errors.New("failed")
+	err := repo.WithTransaction(ctx, func(tx Tx) error {
+		if err := repo.Delete(tx, "a"); err != nil {
+			return err
+		}
+		return failed
+	})
+	assert.ErrorIs(t, err, failed)
+	assert.Equal(t, []string{"BEGIN", "DELETE FROM `settings` WHERE `key` = ?", "ROLLBACK"}, state.queries())
+
+	assert.NoError(t, repo.EnsureTable(ctx))
+	assert.Equal(t, []string{"CREATE TABLE IF NOT EXISTS `settings` (`key` VARCHAR(255) PRIMARY KEY, `value` VARCHAR(255))"}, state.queries())
+
+	sqlite := mustSQLRepository[setting](t, db, SQLiteDialect{}, "settings")
+	_, err = sqlite.FindById(ctx, "a", ForUpdate())
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, []string{`SELECT "key", "value" FROM "settings" WHERE "key" = ? LIMIT 1`}, state.queries())
+}
@@ -0,0 +1,85 @@
+package ginboot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// ValueIterator yields values one at a time, such as the documents of a
+// MongoIterator. Next returns false once the values are exhausted or an error
+// occurred, which Err then reports.
+type ValueIterator interface {
+	Next(ctx context.Context) bool
+	Value() interface{}
+	Err() error
+	Close(ctx context.Context) error
+}
+
+// StreamJSONArray writes the values of items as a JSON array, encoding one
+// value at a time and flushing as the response grows, so exports of millions
+// of documents are never held in memory. Use it from a StreamHandler:
+//
+//	func (c *ExportController) Orders(ctx *ginboot.Context) error {
+//		orders, err := c.repo.Iterate(ctx, ginboot.Where(ginboot.Eq("status", "paid")))
+//		if err != nil {
+//			return err
+//		}
+//		return ctx.StreamJSONArray(orders)
+//	}
+//
+// items is closed when done. An error of the first value is returned before
+// anything is written, so it is sent with SendError; later errors end the
+// response with an incomplete array, which clients fail to parse. Values are
+// encoded with encoding/json, without the server's serializer.
+func (c *Context) StreamJSONArray(items ValueIterator) error {
+	defer items.Close(context.WithoutCancel(c))
+
+	if !items.Next(c) {
+		if err := items.Err(); err != nil {
+			return err
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", []byte("[]"))
+		return nil
+	}
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	buf := make([]byte, 0, streamChunkSize)
+	buf = append(buf, '[')
+	for first := true; ; first = false {
+		if err := c.Err(); err != nil {
+			return err
+		}
+		value, err := json.Marshal(items.Value())
+		if err != nil {
+			return err
+		}
+		if !first {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, value...)
+		if len(buf) >= streamChunkSize {
+			if _, err := c.Writer.Write(buf); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+			buf = buf[:0]
+		}
+		if !items.Next(c) {
+			break
+		}
+	}
+	if err := items.Err(); err != nil {
+		if _, writeErr := c.Writer.Write(buf); writeErr == nil {
+			c.Writer.Flush()
+		}
+		return err
+	}
+	buf = append(buf, ']')
+	if _, err := c.Writer.Write(buf); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
@@ -0,0 +1,99 @@
+package ginboot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// sliceIterator yields values, failing with err after failAfter of them, or
+// never when failAfter is -1
+type sliceIterator struct {
+	values    []interface{}
+	index     int
+	failAfter int
+	err       error
+	closed    bool
+}
+
+func (it *sliceIterator) Next(ctx context.Context) bool {
+	if it.index == it.failAfter || it.index >= len(it.values) {
+		return false
+	}
+	it.index++
+	return true
+}
+
+func (it *sliceIterator) Value() interface{} {
+	return it.values[it.index-1]
+}
+
+func (it *sliceIterator) Err() error {
+	if it.index == it.failAfter {
+		return it.err
+	}
+	return nil
+}
+
+func (it *sliceIterator) Close(ctx context.Context) error {
+	it.closed = true
+	return nil
+}
+
+func TestContext_StreamJSONArray(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type row struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	values := make([]interface{}, 5000)
+	for i := range values {
+		values[i] = row{ID: i, Name: fmt.Sprintf("row %d", i)}
+	}
+
+	var iterator *sliceIterator
+	server := &Server{engine: gin.New()}
+	server.Group("").GET("/export", func(ctx *Context) error {
+		return ctx.StreamJSONArray(iterator)
+	})
+	export := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.engine.ServeHTTP(w, httptest.NewRequest("GET", "/export", nil))
+		return w
+	}
+
+	iterator = &sliceIterator{values: values, failAfter: -1}
+	w := export()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	var rows []row
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &rows))
+	assert.Len(t, rows, 5000)
+	assert.Equal(t, row{ID: 4999, Name: "row 4999"}, rows[4999])
+	assert.True(t, iterator.closed)
+
+	iterator = &sliceIterator{failAfter: -1}
+	w = export()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "[]", w.Body.String())
+
+	// a failure before the first value is sent as an error response
+	iterator = &sliceIterator{values: values, failAfter: 0, err: errors.New("cursor failed")}
+	w = export()
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	// a later failure leaves an incomplete array
+	iterator = &sliceIterator{values: values, failAfter: 3000, err: errors.New("cursor failed")}
+	w = export()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Error(t, json.Unmarshal(w.Body.Bytes(), &rows))
+	assert.True(t, iterator.closed)
+}
@@ -0,0 +1,111 @@
+package ginboot
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+
+	"github.com/gin-contrib/sse"
+)
+
+// StreamHandler writes its response itself, e.g. with Context.SSE or
+// Context.StreamFile, instead of returning a value to serialize. Handlers
+// returning only an error can also take a request model:
+//
+//	func(ctx *ginboot.Context) error
+//	func(request ExportRequest) error
+//	func(ctx *ginboot.Context, request ExportRequest) error
+//
+// An error returned before anything was written is sent with SendError; once
+// the response has started it can only be logged.
+type StreamHandler func(ctx *Context) error
+
+// streamChunkSize is the amount of data StreamFile writes between flushes
+const streamChunkSize = 32 << 10
+
+// ServerSentEvent is an event sent by Context.SSE. Structs, maps and slices
+// in Data are sent as JSON, other values as text.
+type ServerSentEvent struct {
+	ID    string
+	Event string
+	Data  interface{}
+	// Retry asks the client to wait this many milliseconds before reconnecting
+	Retry uint
+}
+
+// SSE streams the events to the client as Server-Sent Events, flushing each
+// one, until the channel is closed or the client disconnects. It returns the
+// request context's error in the latter case.
+func (c *Context) SSE(events <-chan ServerSentEvent) error {
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// keep proxies such as nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Header("Content-Type", sse.ContentType)
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+
+	done := c.Request.Context().Done()
+	for {
+		select {
+		case <-done:
+			return c.Request.Context().Err()
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			err := sse.Encode(c.Writer, sse.Event{
+				Id:    event.ID,
+				Event: event.Event,
+				Data:  event.Data,
+				Retry: event.Retry,
+			})
+			if err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// StreamFile sends the reader as a download named name, in chunks flushed as
+// they are read, so large files and exports are never held in memory. The
+// reader is closed when it is an io.Closer. An empty contentType is derived
+// from the name's extension.
+func (c *Context) StreamFile(name, contentType string, reader io.Reader) error {
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+		if byExtension := mime.TypeByExtension(path.Ext(name)); byExtension != "" {
+			contentType = byExtension
+		}
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	c.Status(http.StatusOK)
+
+	buf := make([]byte, streamChunkSize)
+	for {
+		if err := c.Request.Context().Err(); err != nil {
+			return err
+		}
+		n, err := reader.Read(buf)
+		if n > 0 {
+			if _, writeErr := c.Writer.Write(buf[:n]); writeErr != nil {
+				return writeErr
+			}
+			c.Writer.Flush()
+		}
+		if errors.Is(err, io.EOF) {
+			c.Writer.WriteHeaderNow()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package ginboot
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext_SSE(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{engine: gin.New()}
+	server.Group("/").GET("/events", func(ctx *Context) error {
+		events := make(chan ServerSentEvent, 2)
+		events <- ServerSentEvent{ID: "1", Event: "progress", Data: map[string]int{"done": 50}}
+		events <- ServerSentEvent{Event: "message", Data: "finished"}
+		close(events)
+		return ctx.SSE(events)
+	})
+
+	w := httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "id:1\nevent:progress\ndata:{\"done\":50}\n\nevent:message\ndata:finished\n\n", w.Body.String())
+	assert.True(t, w.Flushed)
+}
+
+func TestContext_StreamFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	content := strings.Repeat("id,name\n", streamChunkSize/4)
+	server := &Server{engine: gin.New()}
+	server.Group("/").GET("/export", func(ctx *Context) error {
+		return ctx.StreamFile("orders.csv", "text/csv", strings.NewReader(content))
+	})
+
+	w := httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=orders.csv`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, content, w.Body.String())
+}
+
+func TestStreamHandler_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{engine: gin.New()}
+	group := server.Group("/")
+	group.GET("/missing", StreamHandler(func(ctx *Context) error {
+		return ErrNotFound
+	}))
+	group.POST("/export", func(request TestRouterRequest) error {
+		return errors.New("never called with an invalid body")
+	})
+
+	w := httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/export", strings.NewReader("{"))
+	req.Header.Set("Content-Type", "application/json")
+	server.engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	assert.Panics(t, func() {
+		group.GET("/invalid", func() {})
+	})
+}
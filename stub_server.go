@@ -0,0 +1,191 @@
+package ginboot
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// StubServer stands in for third-party HTTP APIs in integration tests. Point
+// the service under test at URL() and declare the responses it should get.
+type StubServer struct {
+	server    *httptest.Server
+	mu        sync.Mutex
+	stubs     []*Stub
+	unmatched []string
+}
+
+// Stub describes the response for one method and path on a StubServer
+type Stub struct {
+	method        string
+	path          string
+	status        int
+	body          []byte
+	headers       map[string]string
+	latency       time.Duration
+	failFirst     int
+	failRate      float64
+	failureStatus int
+	calls         atomic.Int64
+}
+
+func NewStubServer() *StubServer {
+	s := &StubServer{}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// URL returns the base URL of the stub server
+func (s *StubServer) URL() string {
+	return s.server.URL
+}
+
+// Close shuts the stub server down
+func (s *StubServer) Close() {
+	s.server.Close()
+}
+
+// CloseOnCleanup closes the server when the test (or suite) finishes, e.g.
+// server.CloseOnCleanup(t)
+func (s *StubServer) CloseOnCleanup(t interface{ Cleanup(func()) }) *StubServer {
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Reset removes all stubs and recorded calls
+func (s *StubServer) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stubs = nil
+	s.unmatched = nil
+}
+
+// On declares a stub for the method and path. A path ending in "*" matches
+// every path with that prefix. Later declarations take precedence.
+func (s *StubServer) On(method, path string) *Stub {
+	stub := &Stub{
+		method:        method,
+		path:          path,
+		status:        http.StatusOK,
+		headers:       make(map[string]string),
+		failureStatus: http.StatusInternalServerError,
+	}
+	s.mu.Lock()
+	s.stubs = append(s.stubs, stub)
+	s.mu.Unlock()
+	return stub
+}
+
+// Unmatched returns the requests that did not match any stub as "METHOD /path"
+func (s *StubServer) Unmatched() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.unmatched...)
+}
+
+// Respond sets the status and body. Strings and byte slices are sent as is,
+// any other value is encoded as JSON.
+func (st *Stub) Respond(status int, body interface{}) *Stub {
+	st.status = status
+	switch b := body.(type) {
+	case nil:
+		st.body = nil
+	case string:
+		st.body = []byte(b)
+	case []byte:
+		st.body = b
+	default:
+		encoded, err := json.Marshal(b)
+		if err != nil {
+			panic("stub body cannot be encoded: " + err.Error())
+		}
+		st.body = encoded
+		st.headers["Content-Type"] = "application/json"
+	}
+	return st
+}
+
+// WithHeader adds a response header
+func (st *Stub) WithHeader(key, value string) *Stub {
+	st.headers[key] = value
+	return st
+}
+
+// WithLatency delays every response by the given duration
+func (st *Stub) WithLatency(latency time.Duration) *Stub {
+	st.latency = latency
+	return st
+}
+
+// FailFirst answers the first n calls with the given status before responding normally
+func (st *Stub) FailFirst(n int, status int) *Stub {
+	st.failFirst = n
+	st.failureStatus = status
+	return st
+}
+
+// FailRate answers the given fraction of calls (0..1) with the given status
+func (st *Stub) FailRate(rate float64, status int) *Stub {
+	st.failRate = rate
+	st.failureStatus = status
+	return st
+}
+
+// Calls returns how many requests the stub has answered
+func (st *Stub) Calls() int {
+	return int(st.calls.Load())
+}
+
+func (s *StubServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	stub := s.match(r)
+	if stub == nil {
+		s.unmatched = append(s.unmatched, r.Method+" "+r.URL.Path)
+		s.mu.Unlock()
+		http.Error(w, "no stub for "+r.Method+" "+r.URL.Path, http.StatusNotFound)
+		return
+	}
+	calls := stub.calls.Add(1)
+	fail := calls <= int64(stub.failFirst) || (stub.failRate > 0 && rand.Float64() < stub.failRate)
+	s.mu.Unlock()
+
+	if stub.latency > 0 {
+		select {
+		case <-time.After(stub.latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if fail {
+		w.WriteHeader(stub.failureStatus)
+		return
+	}
+	for key, value := range stub.headers {
+		w.Header().Set(key, value)
+	}
+	w.WriteHeader(stub.status)
+	w.Write(stub.body)
+}
+
+func (s *StubServer) match(r *http.Request) *Stub {
+	for i := len(s.stubs) - 1; i >= 0; i-- {
+		stub := s.stubs[i]
+		if stub.method != r.Method {
+			continue
+		}
+		if prefix, ok := strings.CutSuffix(stub.path, "*"); ok {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				return stub
+			}
+		} else if stub.path == r.URL.Path {
+			return stub
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package ginboot
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStubServer(t *testing.T) {
+	stubs := NewStubServer().CloseOnCleanup(t)
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(stubs.URL() + path)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	t.Run("json response", func(t *testing.T) {
+		stub := stubs.On("GET", "/users/1").Respond(http.StatusOK, map[string]string{"name": "john"})
+
+		status, body := get("/users/1")
+		assert.Equal(t, http.StatusOK, status)
+		assert.JSONEq(t, `{"name":"john"}`, body)
+		assert.Equal(t, 1, stub.Calls())
+	})
+
+	t.Run("prefix match and failure injection", func(t *testing.T) {
+		stubs.On("GET", "/payments/*").Respond(http.StatusCreated, "ok").FailFirst(2, http.StatusServiceUnavailable)
+
+		status, _ := get("/payments/1")
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+		status, _ = get("/payments/2")
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+		status, body := get("/payments/3")
+		assert.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, "ok", body)
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		stubs.On("GET", "/slow").Respond(http.StatusOK, "late").WithLatency(200 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, "GET", stubs.URL()+"/slow", nil)
+		_, err := http.DefaultClient.Do(req)
+		assert.Error(t, err)
+	})
+
+	t.Run("unmatched", func(t *testing.T) {
+		status, _ := get("/unknown")
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Contains(t, stubs.Unmatched(), "GET /unknown")
+	})
+}
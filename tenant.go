@@ -0,0 +1,205 @@
+package ginboot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	TenantMissing   = ApiError{"TENANT_MISSING", "Tenant is required"}
+	TenantInvalid   = ApiError{"TENANT_INVALID", "Tenant is invalid"}
+	TenantForbidden = ApiError{"TENANT_FORBIDDEN", "Access to the tenant is not permitted"}
+)
+
+// ErrNoTenant is returned by tenant repositories called without a tenant in
+// the context
+var ErrNoTenant = errors.New("no tenant in context")
+
+// ErrInvalidTenant is returned by tenant repositories called with a tenant ID
+// that ValidTenantID rejects
+var ErrInvalidTenant = errors.New("invalid tenant")
+
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,48}$`)
+
+// ValidTenantID reports whether tenant is at most 48 letters, digits,
+// underscores or dashes, which makes it safe in database and schema names
+func ValidTenantID(tenant string) bool {
+	return tenantIDPattern.MatchString(tenant)
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a context for the tenant, e.g. for background jobs
+// working on a tenant's data outside of a request
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant set by TenantMiddleware or WithTenant,
+// or "" when there is none. *Context works as the context.
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+type TenantConfig struct {
+	// Resolve returns the request's tenant, e.g. from a JWT claim with
+	// TenantFromClaim or from the subdomain
+	Resolve func(c *gin.Context) string
+	// Allow reports whether the request may act for the tenant, e.g. whether
+	// the authenticated user is a member of it. Nil allows every tenant, which
+	// is only safe when Resolve reads a value the client cannot choose.
+	Allow func(c *gin.Context, tenant string) bool
+	// Optional lets requests without a tenant through
+	Optional bool
+}
+
+// DefaultTenantConfig takes the tenant from the "tenant" claim of the token
+// verified by JWTAuthMiddleware, which must run first
+func DefaultTenantConfig() TenantConfig {
+	return TenantConfig{Resolve: TenantFromClaim("tenant")}
+}
+
+// TenantFromClaim resolves the tenant from a string claim of the token
+// verified by JWTAuthMiddleware
+func TenantFromClaim(claim string) func(c *gin.Context) string {
+	return func(c *gin.Context) string {
+		claims, _ := c.Get("claims")
+		claimsMap, _ := claims.(map[string]interface{})
+		tenant, _ := claimsMap[claim].(string)
+		return tenant
+	}
+}
+
+// TenantFromHeader resolves the tenant from a request header. Clients can send
+// any tenant, so use it behind a gateway that sets the header or together with
+// TenantConfig.Allow.
+func TenantFromHeader(header string) func(c *gin.Context) string {
+	return func(c *gin.Context) string {
+		return c.GetHeader(header)
+	}
+}
+
+// TenantMiddleware stores the request's tenant in its context, where tenant
+// repositories find it. Requests without a tenant fail with 400 TENANT_MISSING
+// unless the config is Optional, tenant IDs rejected by ValidTenantID with 400
+// TENANT_INVALID and tenants refused by Allow with 403 TENANT_FORBIDDEN.
+func TenantMiddleware(config TenantConfig) gin.HandlerFunc {
+	if config.Resolve == nil {
+		panic("ginboot: TenantMiddleware needs TenantConfig.Resolve")
+	}
+	return func(c *gin.Context) {
+		tenant := config.Resolve(c)
+		if tenant == "" {
+			if !config.Optional {
+				abortWithApiError(c, http.StatusBadRequest, TenantMissing)
+				return
+			}
+			c.Next()
+			return
+		}
+		if !ValidTenantID(tenant) {
+			abortWithApiError(c, http.StatusBadRequest, TenantInvalid)
+			return
+		}
+		if config.Allow != nil && !config.Allow(c, tenant) {
+			abortWithApiError(c, http.StatusForbidden, TenantForbidden)
+			return
+		}
+		c.Request = c.Request.WithContext(WithTenant(c.Request.Context(), tenant))
+		c.Next()
+	}
+}
+
+// ConnectionResolver returns the MongoDB database holding a tenant's data
+type ConnectionResolver interface {
+	Database(ctx context.Context, tenant string) (*mongo.Database, error)
+}
+
+// ConnectionResolverFunc adapts a function to the ConnectionResolver interface,
+// e.g. to look up each tenant's cluster in a catalog
+type ConnectionResolverFunc func(ctx context.Context, tenant string) (*mongo.Database, error)
+
+func (f ConnectionResolverFunc) Database(ctx context.Context, tenant string) (*mongo.Database, error) {
+	return f(ctx, tenant)
+}
+
+// DatabasePerTenant keeps each tenant's data in the database named prefix
+// followed by the tenant ID, on the same client. Tenant IDs must pass
+// ValidTenantID.
+func DatabasePerTenant(client *mongo.Client, prefix string) ConnectionResolver {
+	return ConnectionResolverFunc(func(ctx context.Context, tenant string) (*mongo.Database, error) {
+		if !ValidTenantID(tenant) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidTenant, tenant)
+		}
+		return client.Database(prefix + tenant), nil
+	})
+}
+
+// NewTenantMongoRepository returns a repository storing documents in the
+// collection of the database the resolver returns for the tenant of each
+// call's context. Calls without a tenant fail with ErrNoTenant.
+func NewTenantMongoRepository[T any](resolver ConnectionResolver, collectionName string) GenericRepository[T] {
+	return NewTenantRepository(func(ctx context.Context, tenant string) (GenericRepository[T], error) {
+		db, err := resolver.Database(ctx, tenant)
+		if err != nil {
+			return nil, err
+		}
+		return NewMongoRepository[T](db, collectionName), nil
+	})
+}
+
+// NewTenantSQLRepository returns a repository storing rows in the table of
+// the schema named prefix followed by the tenant ID of each call's context,
+// e.g. "app_acme"."posts", on the same database. On MySQL schemas are
+// databases. Create each tenant's schema and table when provisioning it.
+func NewTenantSQLRepository[T any](db *sql.DB, dialect SQLDialect, prefix, table string) GenericRepository[T] {
+	return NewTenantRepository(func(ctx context.Context, tenant string) (GenericRepository[T], error) {
+		repo, err := NewSQLRepository[T](db, dialect, prefix+tenant+"."+table)
+		if err != nil {
+			return nil, err
+		}
+		return repo, nil
+	})
+}
+
+// NewTenantRepository routes each call to the repository returned by
+// repository for the tenant of the call's context. The repository of each
+// tenant is created once; tenant IDs rejected by ValidTenantID fail with
+// ErrInvalidTenant before repository is called. Repositories are kept for
+// every tenant seen, so repository should fail for tenants that do not exist,
+// e.g. by looking them up in a catalog; failures are not kept.
+func NewTenantRepository[T any](repository func(ctx context.Context, tenant string) (GenericRepository[T], error)) GenericRepository[T] {
+	var repositories sync.Map
+	return &decoratedRepository[T]{
+		next: func(ctx context.Context) (GenericRepository[T], error) {
+			tenant := TenantFromContext(ctx)
+			if tenant == "" {
+				return nil, ErrNoTenant
+			}
+			if !ValidTenantID(tenant) {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidTenant, tenant)
+			}
+			if repo, ok := repositories.Load(tenant); ok {
+				return repo.(GenericRepository[T]), nil
+			}
+			repo, err := repository(ctx, tenant)
+			if err != nil {
+				return nil, err
+			}
+			actual, _ := repositories.LoadOrStore(tenant, repo)
+			return actual.(GenericRepository[T]), nil
+		},
+		intercept: func(ctx context.Context, operation string, call func(ctx context.Context) error) error {
+			return call(ctx)
+		},
+	}
+}
@@ -0,0 +1,100 @@
+package ginboot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantRepository(t *testing.T) {
+	ctx := context.Background()
+	stores := map[string]*memoryRepository[decoratedDocument]{}
+	created := 0
+	repo := NewTenantRepository(func(ctx context.Context, tenant string) (GenericRepository[decoratedDocument], error) {
+		created++
+		stores[tenant] = newMemoryRepository[decoratedDocument]()
+		return stores[tenant], nil
+	})
+
+	acme := WithTenant(ctx, "acme")
+	globex := WithTenant(ctx, "globex")
+	assert.NoError(t, repo.Save(acme, decoratedDocument{ID: "1", Name: "acme post"}))
+	assert.NoError(t, repo.Save(globex, decoratedDocument{ID: "1", Name: "globex post"}))
+
+	doc, err := repo.FindById(acme, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "acme post", doc.Name)
+	doc, err = repo.FindById(globex, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "globex post", doc.Name)
+	assert.Equal(t, 2, created)
+
+	_, err = repo.FindAll(ctx)
+	assert.ErrorIs(t, err, ErrNoTenant)
+	_, err = repo.FindAll(WithTenant(ctx, "../admin"))
+	assert.ErrorIs(t, err, ErrInvalidTenant)
+	assert.Equal(t, 2, created)
+}
+
+func TestTenantSQLRepository(t *testing.T) {
+	db, state := openRecordingDB(t)
+	repo := NewTenantSQLRepository[sqlPost](db, PostgresDialect{}, "app_", "posts")
+
+	assert.NoError(t, repo.Delete(WithTenant(context.Background(), "acme"), "p1"))
+	assert.Equal(t, []string{`DELETE FROM "app_acme"."posts" WHERE "id" = $1`}, state.queries())
+}
+
+func TestTenantMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{engine: gin.New()}
+	server.engine.ContextWithFallback = true
+	// stands in for JWTAuthMiddleware
+	claims := func(c *gin.Context) {
+		if tenant := c.Query("claim"); tenant != "" {
+			c.Set("claims", map[string]interface{}{"tenant": tenant})
+		}
+	}
+	handler := func(ctx *Context) (string, error) {
+		return TenantFromContext(ctx), nil
+	}
+	server.Group("/claim", claims, TenantMiddleware(DefaultTenantConfig())).GET("", handler)
+	server.Group("/header", TenantMiddleware(TenantConfig{
+		Resolve: TenantFromHeader("X-Tenant-ID"),
+		Allow: func(c *gin.Context, tenant string) bool {
+			return tenant == "acme"
+		},
+	})).GET("", handler)
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		status int
+		body   string
+	}{
+		{"claim", "/claim?claim=acme", "", http.StatusOK, `"acme"`},
+		{"header is not trusted by default", "/claim", "acme", http.StatusBadRequest, "TENANT_MISSING"},
+		{"invalid tenant", "/claim?claim=a.b", "", http.StatusBadRequest, "TENANT_INVALID"},
+		{"allowed header", "/header", "acme", http.StatusOK, `"acme"`},
+		{"forbidden header", "/header", "globex", http.StatusForbidden, "TENANT_FORBIDDEN"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.header != "" {
+				req.Header.Set("X-Tenant-ID", test.header)
+			}
+			server.engine.ServeHTTP(w, req)
+			assert.Equal(t, test.status, w.Code)
+			assert.Contains(t, w.Body.String(), test.body)
+		})
+	}
+
+	assert.Panics(t, func() { TenantMiddleware(TenantConfig{}) })
+}
@@ -0,0 +1,31 @@
+package ginboot
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var RequestTimedOut = ApiError{"REQUEST_TIMEOUT", "Request timed out"}
+
+// Timeout sets a deadline on the request context. Repositories and cache
+// services receiving the *Context give up once it passes, and the resulting
+// context.DeadlineExceeded error is sent as a 504 Gateway Timeout. Add it to a
+// server, group or single route:
+//
+//	group.GET("/reports", controller.Report, ginboot.Timeout(2*time.Second))
+func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if !c.Writer.Written() && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			SendError(c, ctx.Err())
+		}
+	}
+}
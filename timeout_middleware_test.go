@@ -0,0 +1,45 @@
+package ginboot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := New()
+	cache := NewInMemoryCacheService(DefaultInMemoryCacheConfig())
+	group := server.Group("")
+	group.GET("/slow", func(ctx *Context) (string, error) {
+		<-ctx.Done()
+		_, _, err := cache.Get(ctx, "report")
+		return "", err
+	}, Timeout(10*time.Millisecond))
+	group.GET("/fast", func(ctx *Context) (string, error) {
+		_, hasDeadline := ctx.Deadline()
+		assert.True(t, hasDeadline)
+		return "done", nil
+	}, Timeout(time.Second))
+
+	w := httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Contains(t, w.Body.String(), "REQUEST_TIMEOUT")
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest("GET", "/fast", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"done"`, w.Body.String())
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := cache.Get(canceled, "report")
+	assert.ErrorIs(t, err, context.Canceled)
+}
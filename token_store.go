@@ -0,0 +1,218 @@
+package ginboot
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var TokenRevoked = ApiError{"TOKEN_REVOKED", "Refresh token has been revoked"}
+
+// IssuedToken records a refresh token, keyed by its jti claim. Tokens rotated
+// from the same login share a Family, which SessionService lists as a session
+// along with the device that last used it.
+type IssuedToken struct {
+	ID      string `bson:"_id" ginboot:"_id" json:"id" db:"id"`
+	UserID  string `bson:"user_id" json:"userId" db:"user_id"`
+	Family  string `bson:"family" json:"family" db:"family"`
+	Revoked bool   `bson:"revoked" json:"revoked" db:"revoked"`
+	// CreatedAt is the time of the login, kept by rotated tokens
+	CreatedAt  time.Time `bson:"created_at" json:"createdAt" db:"created_at"`
+	LastUsedAt time.Time `bson:"last_used_at" json:"lastUsedAt" db:"last_used_at"`
+	UserAgent  string    `bson:"user_agent" json:"userAgent" db:"user_agent"`
+	IP         string    `bson:"ip" json:"ip" db:"ip"`
+	ExpiresAt  time.Time `bson:"expires_at" json:"expiresAt" db:"expires_at"`
+	Version    int64     `bson:"version" json:"version" db:"version" ginboot:"version"`
+}
+
+// TokenStore keeps track of issued refresh tokens so they can be rotated and
+// revoked before they expire
+type TokenStore interface {
+	Save(ctx context.Context, token IssuedToken) error
+	// Update fails with ErrVersionConflict when the token changed since it was
+	// read
+	Update(ctx context.Context, token IssuedToken) error
+	// Find reports false when the token was never recorded
+	Find(ctx context.Context, id string) (IssuedToken, bool, error)
+	FindByUser(ctx context.Context, userID string) ([]IssuedToken, error)
+	// Revoke reports false when the token was already revoked, possibly by a
+	// concurrent call
+	Revoke(ctx context.Context, id string) (bool, error)
+	RevokeFamily(ctx context.Context, family string) error
+	RevokeUser(ctx context.Context, userID string) error
+}
+
+// RepositoryTokenStore is a TokenStore backed by any GenericRepository, e.g.
+// NewMongoRepository[IssuedToken](db, "refresh_tokens")
+type RepositoryTokenStore struct {
+	repo GenericRepository[IssuedToken]
+}
+
+func NewRepositoryTokenStore(repo GenericRepository[IssuedToken]) *RepositoryTokenStore {
+	return &RepositoryTokenStore{repo: repo}
+}
+
+func (s *RepositoryTokenStore) Save(ctx context.Context, token IssuedToken) error {
+	return s.repo.Save(ctx, token)
+}
+
+func (s *RepositoryTokenStore) Update(ctx context.Context, token IssuedToken) error {
+	return s.repo.Update(ctx, token)
+}
+
+func (s *RepositoryTokenStore) Find(ctx context.Context, id string) (IssuedToken, bool, error) {
+	tokens, err := s.repo.FindAllById(ctx, []string{id})
+	if err != nil || len(tokens) == 0 {
+		return IssuedToken{}, false, err
+	}
+	return tokens[0], true, nil
+}
+
+func (s *RepositoryTokenStore) FindByUser(ctx context.Context, userID string) ([]IssuedToken, error) {
+	return s.repo.FindBy(ctx, "user_id", userID)
+}
+
+func (s *RepositoryTokenStore) Revoke(ctx context.Context, id string) (bool, error) {
+	token, found, err := s.Find(ctx, id)
+	if err != nil || !found || token.Revoked {
+		return false, err
+	}
+	token.Revoked = true
+	// the version check makes concurrent revocations of the same token fail
+	if err := s.repo.Update(ctx, token); err != nil {
+		if errors.Is(err, ErrVersionConflict) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *RepositoryTokenStore) RevokeFamily(ctx context.Context, family string) error {
+	return s.revokeBy(ctx, "family", family)
+}
+
+func (s *RepositoryTokenStore) RevokeUser(ctx context.Context, userID string) error {
+	return s.revokeBy(ctx, "user_id", userID)
+}
+
+func (s *RepositoryTokenStore) revokeBy(ctx context.Context, field, value string) error {
+	tokens, err := s.repo.FindByFilters(ctx, map[string]interface{}{field: value, "revoked": false})
+	if err != nil {
+		return err
+	}
+	for _, token := range tokens {
+		if _, err := s.Revoke(ctx, token.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IssueTokens generates an access and refresh token pair for a new login and
+// records the refresh token in the token store
+func (s *JWTService) IssueTokens(ctx context.Context, userId string, role string) (string, string, error) {
+	return s.issue(ctx, userId, role, nil)
+}
+
+// RotateRefreshToken exchanges a refresh token for a new token pair and
+// revokes it. Presenting a revoked refresh token again means it was stolen, so
+// every token rotated from the same login is revoked and TokenRevoked returned.
+func (s *JWTService) RotateRefreshToken(ctx context.Context, refreshToken string) (string, string, error) {
+	store, err := s.store()
+	if err != nil {
+		return "", "", err
+	}
+	token, err := s.ParseRefreshToken(refreshToken)
+	if err != nil {
+		return "", "", err
+	}
+	claims, err := ExtractClaims(token)
+	if err != nil {
+		return "", "", err
+	}
+
+	issued, found, err := store.Find(ctx, claimString(claims, "jti"))
+	if err != nil {
+		return "", "", err
+	}
+	if !found || issued.UserID != claimString(claims, "sub") {
+		return "", "", TokenRevoked
+	}
+	revoked, err := store.Revoke(ctx, issued.ID)
+	if err != nil {
+		return "", "", err
+	}
+	if !revoked {
+		if err := store.RevokeFamily(ctx, issued.Family); err != nil {
+			return "", "", err
+		}
+		return "", "", TokenRevoked
+	}
+	return s.issue(ctx, issued.UserID, claimString(claims, "role"), &issued)
+}
+
+// RevokeToken revokes the refresh token with the given jti, e.g. on logout
+func (s *JWTService) RevokeToken(ctx context.Context, jti string) error {
+	store, err := s.store()
+	if err != nil {
+		return err
+	}
+	_, err = store.Revoke(ctx, jti)
+	return err
+}
+
+// RevokeAllTokens revokes every refresh token of the user, ending their
+// sessions and logging them out everywhere once their access tokens expire
+func (s *JWTService) RevokeAllTokens(ctx context.Context, userID string) error {
+	store, err := s.store()
+	if err != nil {
+		return err
+	}
+	return store.RevokeUser(ctx, userID)
+}
+
+// issue signs a token pair and records the refresh token, in the family of the
+// rotated token when there is one
+func (s *JWTService) issue(ctx context.Context, userId string, role string, rotated *IssuedToken) (string, string, error) {
+	store, err := s.store()
+	if err != nil {
+		return "", "", err
+	}
+	accessToken, err := s.sign(s.newClaims(userId, role, s.accessDuration), s.accessKey)
+	if err != nil {
+		return "", "", err
+	}
+	claims := s.newClaims(userId, role, s.refreshDuration)
+	refreshToken, err := s.sign(claims, s.refreshKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	issued := IssuedToken{
+		ID:         claims.Id,
+		UserID:     userId,
+		Family:     claims.Id,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  time.Unix(claims.ExpiresAt, 0),
+	}
+	if rotated != nil {
+		issued.Family = rotated.Family
+		issued.CreatedAt = rotated.CreatedAt
+		issued.UserAgent = rotated.UserAgent
+		issued.IP = rotated.IP
+	}
+	if err := store.Save(ctx, issued); err != nil {
+		return "", "", err
+	}
+	return accessToken, refreshToken, nil
+}
+
+func (s *JWTService) store() (TokenStore, error) {
+	if s.tokenStore == nil {
+		return nil, errors.New("no token store configured, see JWTService.WithTokenStore")
+	}
+	return s.tokenStore, nil
+}
@@ -0,0 +1,73 @@
+package ginboot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWTService_RefreshTokenRotation(t *testing.T) {
+	ctx := context.Background()
+	newService := func() *JWTService {
+		store := NewRepositoryTokenStore(newMemoryRepository[IssuedToken]())
+		return NewJWTService().WithSecrets("access-secret", "refresh-secret").WithTokenStore(store)
+	}
+
+	t.Run("Rotation", func(t *testing.T) {
+		service := newService()
+		_, refreshToken, err := service.IssueTokens(ctx, "user-1", "admin")
+		assert.NoError(t, err)
+
+		accessToken, rotated, err := service.RotateRefreshToken(ctx, refreshToken)
+		assert.NoError(t, err)
+		token, err := service.ParseAccessToken(accessToken)
+		assert.NoError(t, err)
+		claims, _ := ExtractClaims(token)
+		assert.Equal(t, "admin", ExtractRole(claims))
+
+		_, rotated, err = service.RotateRefreshToken(ctx, rotated)
+		assert.NoError(t, err)
+
+		// reusing a rotated token revokes the whole family
+		_, _, err = service.RotateRefreshToken(ctx, refreshToken)
+		assert.ErrorIs(t, err, TokenRevoked)
+		_, _, err = service.RotateRefreshToken(ctx, rotated)
+		assert.ErrorIs(t, err, TokenRevoked)
+	})
+
+	t.Run("Revocation", func(t *testing.T) {
+		service := newService()
+		_, phoneToken, err := service.IssueTokens(ctx, "user-1", "user")
+		assert.NoError(t, err)
+		_, laptopToken, err := service.IssueTokens(ctx, "user-1", "user")
+		assert.NoError(t, err)
+		_, otherToken, err := service.IssueTokens(ctx, "user-2", "user")
+		assert.NoError(t, err)
+
+		token, _ := service.ParseRefreshToken(phoneToken)
+		claims, _ := ExtractClaims(token)
+		assert.NoError(t, service.RevokeToken(ctx, claims["jti"].(string)))
+		_, _, err = service.RotateRefreshToken(ctx, phoneToken)
+		assert.ErrorIs(t, err, TokenRevoked)
+
+		assert.NoError(t, service.RevokeAllTokens(ctx, "user-1"))
+		_, _, err = service.RotateRefreshToken(ctx, laptopToken)
+		assert.ErrorIs(t, err, TokenRevoked)
+		_, _, err = service.RotateRefreshToken(ctx, otherToken)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Unknown token", func(t *testing.T) {
+		service := newService()
+		_, refreshToken, err := service.GenerateTokens("user-1", "user")
+		assert.NoError(t, err)
+		_, _, err = service.RotateRefreshToken(ctx, refreshToken)
+		assert.ErrorIs(t, err, TokenRevoked)
+	})
+
+	t.Run("No token store", func(t *testing.T) {
+		_, _, err := NewJWTService().WithSecrets("a", "r").IssueTokens(ctx, "user-1", "user")
+		assert.Error(t, err)
+	})
+}
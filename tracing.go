@@ -0,0 +1,160 @@
+package ginboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/klass-lk/ginboot"
+
+func tracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(tracerName)
+}
+
+// WithTracing starts a span for every request, continuing the trace of the
+// incoming traceparent header. The span is in the request's context, so spans
+// of traced repositories and caches called with *Context become its children.
+// A nil provider uses the global one set with otel.SetTracerProvider.
+func (s *Server) WithTracing(provider trace.TracerProvider) *Server {
+	return s.Use(TracingMiddleware(provider))
+}
+
+// TracingMiddleware starts a server span per request, named by method and
+// route, e.g. "GET /api/v1/posts/:id". 5xx responses mark the span as failed.
+func TracingMiddleware(provider trace.TracerProvider) gin.HandlerFunc {
+	tr := tracer(provider)
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx, span := tr.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", c.Request.URL.Path),
+			))
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		for _, err := range c.Errors {
+			span.RecordError(err.Err)
+		}
+		if status >= 500 {
+			span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", status))
+		}
+	}
+}
+
+// WithRepositoryTracing starts a client span for every call of the repository,
+// e.g. "Post.FindById". ErrNotFound is not treated as a failure.
+func WithRepositoryTracing[T any](repo GenericRepository[T], provider trace.TracerProvider) GenericRepository[T] {
+	tr := tracer(provider)
+	entity := entityName[T]()
+	return decorate(repo, func(ctx context.Context, operation string, call func(ctx context.Context) error) error {
+		ctx, span := tr.Start(ctx, entity+"."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.operation", operation),
+				attribute.String("ginboot.entity", entity),
+			))
+		defer span.End()
+		err := call(ctx)
+		endSpan(span, err)
+		return err
+	})
+}
+
+func endSpan(span trace.Span, err error) {
+	if err == nil || errors.Is(err, ErrNotFound) {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// WithCacheTracing starts a span for every call of the cache, recording
+// whether gets hit
+func WithCacheTracing(cache CacheService, provider trace.TracerProvider) CacheService {
+	return &tracedCache{next: cache, tracer: tracer(provider)}
+}
+
+type tracedCache struct {
+	next   CacheService
+	tracer trace.Tracer
+}
+
+func (c *tracedCache) start(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return c.tracer.Start(ctx, "cache."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+}
+
+func (c *tracedCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	ctx, span := c.start(ctx, "Set", attribute.String("cache.key", key))
+	defer span.End()
+	err := c.next.Set(ctx, key, value, ttl, tags...)
+	endSpan(span, err)
+	return err
+}
+
+func (c *tracedCache) SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) (bool, error) {
+	ctx, span := c.start(ctx, "SetIfAbsent", attribute.String("cache.key", key))
+	defer span.End()
+	stored, err := c.next.SetIfAbsent(ctx, key, value, ttl, tags...)
+	span.SetAttributes(attribute.Bool("cache.stored", stored))
+	endSpan(span, err)
+	return stored, err
+}
+
+func (c *tracedCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	ctx, span := c.start(ctx, "Get", attribute.String("cache.key", key))
+	defer span.End()
+	value, ok, err := c.next.Get(ctx, key)
+	span.SetAttributes(attribute.Bool("cache.hit", ok))
+	endSpan(span, err)
+	return value, ok, err
+}
+
+func (c *tracedCache) Touch(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ctx, span := c.start(ctx, "Touch", attribute.String("cache.key", key))
+	defer span.End()
+	touched, err := c.next.Touch(ctx, key, ttl)
+	span.SetAttributes(attribute.Bool("cache.hit", touched))
+	endSpan(span, err)
+	return touched, err
+}
+
+func (c *tracedCache) Invalidate(ctx context.Context, keys ...string) error {
+	ctx, span := c.start(ctx, "Invalidate", attribute.StringSlice("cache.keys", keys))
+	defer span.End()
+	err := c.next.Invalidate(ctx, keys...)
+	endSpan(span, err)
+	return err
+}
+
+func (c *tracedCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	ctx, span := c.start(ctx, "InvalidateTags", attribute.StringSlice("cache.tags", tags))
+	defer span.End()
+	err := c.next.InvalidateTags(ctx, tags...)
+	endSpan(span, err)
+	return err
+}
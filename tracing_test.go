@@ -0,0 +1,100 @@
+package ginboot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+type recordedSpan struct {
+	name   string
+	parent string
+	failed bool
+}
+
+// recordingProvider records started spans with the name of their parent
+type recordingProvider struct {
+	embedded.TracerProvider
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (p *recordingProvider) Tracer(name string, _ ...trace.TracerOption) trace.Tracer {
+	return &recordingTracer{provider: p}
+}
+
+func (p *recordingProvider) names() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var names []string
+	for _, span := range p.spans {
+		names = append(names, span.name)
+	}
+	return names
+}
+
+type recordingTracer struct {
+	embedded.Tracer
+	provider *recordingProvider
+}
+
+type recordedSpanKey struct{}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, _ ...trace.SpanStartOption) (context.Context, trace.Span) {
+	record := &recordedSpan{name: name}
+	if parent, ok := ctx.Value(recordedSpanKey{}).(*recordedSpan); ok {
+		record.parent = parent.name
+	}
+	t.provider.mu.Lock()
+	t.provider.spans = append(t.provider.spans, record)
+	t.provider.mu.Unlock()
+	ctx, span := noop.NewTracerProvider().Tracer("").Start(ctx, name)
+	return context.WithValue(ctx, recordedSpanKey{}, record), &recordingSpan{Span: span, record: record}
+}
+
+type recordingSpan struct {
+	trace.Span
+	record *recordedSpan
+}
+
+func (s *recordingSpan) SetStatus(code codes.Code, _ string) {
+	s.record.failed = code == codes.Error
+}
+
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	provider := &recordingProvider{}
+
+	server := &Server{engine: gin.New()}
+	server.engine.ContextWithFallback = true
+	server.WithTracing(provider)
+	repo := WithRepositoryTracing[decoratedDocument](newMemoryRepository[decoratedDocument](), provider)
+	cache := WithCacheTracing(NewInMemoryCacheService(DefaultInMemoryCacheConfig()), provider)
+	server.Group("").GET("/posts/:id", func(ctx *Context) (decoratedDocument, error) {
+		if _, ok, _ := cache.Get(ctx, "post:"+ctx.Param("id")); ok {
+			return decoratedDocument{}, nil
+		}
+		return repo.FindById(ctx, ctx.Param("id"))
+	})
+
+	w := httptest.NewRecorder()
+	server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/1", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	assert.Equal(t, []string{"GET /posts/:id", "cache.Get", "decoratedDocument.FindById"}, provider.names())
+	assert.Equal(t, "GET /posts/:id", provider.spans[1].parent)
+	assert.Equal(t, "GET /posts/:id", provider.spans[2].parent)
+	// a missing document is a 404, which fails neither span
+	assert.False(t, provider.spans[0].failed)
+	assert.False(t, provider.spans[1].failed)
+	assert.False(t, provider.spans[2].failed)
+}
@@ -0,0 +1,188 @@
+package ginboot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	UploadMissing        = ApiError{"UPLOAD_MISSING", "File %s is required"}
+	UploadTooLarge       = ApiError{"UPLOAD_TOO_LARGE", "File exceeds the maximum size of %s bytes"}
+	UploadTypeNotAllowed = ApiError{"UPLOAD_TYPE_NOT_ALLOWED", "File type %s is not allowed"}
+)
+
+const fileServiceKey = "ginboot.fileService"
+
+// FileService stores files, e.g. in S3, for UploadToFileService
+type FileService interface {
+	Upload(ctx context.Context, path string, body io.Reader, contentType string) error
+}
+
+// SetFileService sets the FileService UploadToFileService streams uploads to
+func (s *Server) SetFileService(fileService FileService) *Server {
+	s.fileService = fileService
+	return s
+}
+
+type UploadConfig struct {
+	// MaxSize is the largest file accepted, in bytes
+	MaxSize int64
+	// AllowedTypes lists the accepted MIME types, such as image/png or
+	// image/*, detected from the file's content rather than trusted from the
+	// client. Any type is accepted when empty.
+	AllowedTypes []string
+	// Progress is called as the file is read with the bytes read so far and
+	// the request's Content-Length, which includes the other form fields, or
+	// -1 when unknown
+	Progress func(read, total int64)
+}
+
+// DefaultUploadConfig accepts files of any type up to 10MB
+func DefaultUploadConfig() UploadConfig {
+	return UploadConfig{MaxSize: 10 << 20}
+}
+
+// UploadedFile describes a file received by SaveUpload or UploadToFileService
+type UploadedFile struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+}
+
+// SaveUpload streams the multipart file field to destPath without buffering
+// it in memory. Files over the size limit, or of a type not allowed, fail with
+// UploadTooLarge or UploadTypeNotAllowed and leave no file behind. The request
+// body is consumed, so the form's other fields must come before the file.
+func (c *Context) SaveUpload(field, destPath string, config UploadConfig) (UploadedFile, error) {
+	file, body, err := c.openUpload(field, config)
+	if err != nil {
+		return file, err
+	}
+	dest, err := os.Create(destPath)
+	if err != nil {
+		return file, err
+	}
+	file.Size, err = io.Copy(dest, body)
+	if closeErr := dest.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(destPath)
+		return file, err
+	}
+	return file, nil
+}
+
+// UploadToFileService streams the multipart file field to the server's
+// FileService at remotePath, checked like SaveUpload
+func (c *Context) UploadToFileService(field, remotePath string, config UploadConfig) (UploadedFile, error) {
+	value, _ := c.Get(fileServiceKey)
+	fileService, ok := value.(FileService)
+	if !ok {
+		return UploadedFile{}, errors.New("ginboot: no FileService set, see Server.SetFileService")
+	}
+	file, body, err := c.openUpload(field, config)
+	if err != nil {
+		return file, err
+	}
+	counter := &countingReader{reader: body}
+	if err := fileService.Upload(c, remotePath, counter, file.ContentType); err != nil {
+		return file, err
+	}
+	file.Size = counter.read
+	return file, nil
+}
+
+// openUpload finds the file field in the multipart body and returns its
+// content, limited and reporting progress as configured
+func (c *Context) openUpload(field string, config UploadConfig) (UploadedFile, io.Reader, error) {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return UploadedFile{}, nil, UploadMissing.New(field)
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return UploadedFile{}, nil, UploadMissing.New(field)
+		}
+		if err != nil {
+			return UploadedFile{}, nil, InvalidRequest.New(err.Error())
+		}
+		if part.FormName() != field || part.FileName() == "" {
+			continue
+		}
+
+		head := make([]byte, 512)
+		n, err := io.ReadFull(part, head)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			return UploadedFile{}, nil, InvalidRequest.New(err.Error())
+		}
+		head = head[:n]
+		contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+		if !uploadTypeAllowed(contentType, config.AllowedTypes) {
+			return UploadedFile{}, nil, UploadTypeNotAllowed.New(contentType)
+		}
+
+		file := UploadedFile{Filename: part.FileName(), ContentType: contentType}
+		return file, &uploadReader{
+			reader:   io.MultiReader(bytes.NewReader(head), part),
+			config:   config,
+			total:    c.Request.ContentLength,
+			tooLarge: UploadTooLarge.New(strconv.FormatInt(config.MaxSize, 10)),
+		}, nil
+	}
+}
+
+func uploadTypeAllowed(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, pattern := range allowed {
+		if pattern == contentType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadReader fails once more than MaxSize bytes were read
+type uploadReader struct {
+	reader   io.Reader
+	config   UploadConfig
+	read     int64
+	total    int64
+	tooLarge error
+}
+
+func (r *uploadReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if r.config.MaxSize > 0 && r.read > r.config.MaxSize {
+		return n, r.tooLarge
+	}
+	if r.config.Progress != nil && n > 0 {
+		r.config.Progress(r.read, r.total)
+	}
+	return n, err
+}
+
+type countingReader struct {
+	reader io.Reader
+	read   int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	return n, err
+}
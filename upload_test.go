@@ -0,0 +1,108 @@
+package ginboot
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryFileService struct {
+	files map[string][]byte
+	types map[string]string
+}
+
+func (s *memoryFileService) Upload(ctx context.Context, path string, body io.Reader, contentType string) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	s.files[path], s.types[path] = data, contentType
+	return nil
+}
+
+// pngHeader is enough for http.DetectContentType to see an image/png
+var pngHeader = []byte("\x89PNG\x0D\x0A\x1A\x0A")
+
+func multipartRequest(t *testing.T, path, field, filename string, content []byte) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	assert.NoError(t, writer.WriteField("title", "avatar"))
+	part, err := writer.CreateFormFile(field, filename)
+	assert.NoError(t, err)
+	part.Write(content)
+	assert.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestContext_SaveUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	var progress []int64
+	config := UploadConfig{
+		MaxSize:      1024,
+		AllowedTypes: []string{"image/*"},
+		Progress:     func(read, total int64) { progress = append(progress, read) },
+	}
+	server := &Server{engine: gin.New()}
+	server.Group("/").POST("/avatars/:name", func(ctx *Context) (UploadedFile, error) {
+		return ctx.SaveUpload("file", filepath.Join(dir, ctx.Param("name")), config)
+	})
+
+	image := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{1}, 600)...)
+	w := httptest.NewRecorder()
+	server.engine.ServeHTTP(w, multipartRequest(t, "/avatars/ok.png", "file", "me.png", image))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"filename":"me.png","contentType":"image/png","size":608}`, w.Body.String())
+	saved, err := os.ReadFile(filepath.Join(dir, "ok.png"))
+	assert.NoError(t, err)
+	assert.Equal(t, image, saved)
+	assert.NotEmpty(t, progress)
+	assert.Equal(t, int64(608), progress[len(progress)-1])
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, multipartRequest(t, "/avatars/big.png", "file", "big.png", append(image, image...)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), UploadTooLarge.ErrorCode)
+	assert.NoFileExists(t, filepath.Join(dir, "big.png"))
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, multipartRequest(t, "/avatars/script.png", "file", "script.png", []byte("<html><script>")))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), UploadTypeNotAllowed.ErrorCode)
+
+	w = httptest.NewRecorder()
+	server.engine.ServeHTTP(w, multipartRequest(t, "/avatars/other.png", "other", "me.png", image))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), UploadMissing.ErrorCode)
+}
+
+func TestContext_UploadToFileService(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	files := &memoryFileService{files: map[string][]byte{}, types: map[string]string{}}
+	server := (&Server{engine: gin.New()}).SetFileService(files)
+	server.engine.Use(server.settingsMiddleware())
+	server.Group("/").POST("/documents", func(ctx *Context) (UploadedFile, error) {
+		return ctx.UploadToFileService("document", "documents/notes.txt", DefaultUploadConfig())
+	})
+
+	w := httptest.NewRecorder()
+	server.engine.ServeHTTP(w, multipartRequest(t, "/documents", "document", "notes.txt", []byte("meeting notes")))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"filename":"notes.txt","contentType":"text/plain","size":13}`, w.Body.String())
+	assert.Equal(t, []byte("meeting notes"), files.files["documents/notes.txt"])
+	assert.Equal(t, "text/plain", files.types["documents/notes.txt"])
+}
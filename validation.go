@@ -0,0 +1,134 @@
+package ginboot
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	ValidationFailed = ApiError{"VALIDATION_FAILED", "Request validation failed"}
+	InvalidRequest   = ApiError{"INVALID_REQUEST", "Request could not be parsed: %s"}
+)
+
+// FieldError describes a request field failing a binding rule
+type FieldError struct {
+	// Field is the JSON path of the field, e.g. author.email or tags[1]
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned by Context.GetRequest when the request fails its
+// binding rules, and is sent as a 400 VALIDATION_FAILED listing every field
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Field + " " + field.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// RegisterValidator adds a custom rule usable in binding tags, e.g.
+// binding:"required,slug". It panics when the tag is invalid.
+func (s *Server) RegisterValidator(tag string, fn validator.Func) *Server {
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		panic("ginboot: gin's validator is not go-playground/validator")
+	}
+	if err := engine.RegisterValidation(tag, fn); err != nil {
+		panic("ginboot: registering validator " + tag + ": " + err.Error())
+	}
+	return s
+}
+
+// requestError turns a binding error into a ValidationError, or InvalidRequest
+// when the request could not be decoded at all
+func requestError(request interface{}, err error) error {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return InvalidRequest.New(err.Error())
+	}
+	requestType := reflect.TypeOf(request)
+	fields := make([]FieldError, len(validationErrors))
+	for i, fieldErr := range validationErrors {
+		fields[i] = FieldError{
+			Field:   jsonFieldPath(requestType, fieldErr.StructNamespace()),
+			Rule:    fieldErr.Tag(),
+			Param:   fieldErr.Param(),
+			Message: ruleMessage(fieldErr.Tag(), fieldErr.Param()),
+		}
+	}
+	return &ValidationError{Fields: fields}
+}
+
+// jsonFieldPath translates a namespace such as CreatePost.Author.Email into
+// the names clients send, author.email, using the json, form, header or uri
+// tags
+func jsonFieldPath(typ reflect.Type, namespace string) string {
+	segments := strings.Split(namespace, ".")[1:]
+	path := make([]string, len(segments))
+	for i, segment := range segments {
+		name, index, _ := strings.Cut(segment, "[")
+		if index != "" {
+			index = "[" + index
+		}
+		for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array || typ.Kind() == reflect.Map {
+			typ = typ.Elem()
+		}
+		path[i] = name + index
+		if typ.Kind() != reflect.Struct {
+			continue
+		}
+		field, ok := typ.FieldByName(name)
+		if !ok {
+			continue
+		}
+		if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+			path[i] = tag + index
+		} else if tag := strings.Split(field.Tag.Get("form"), ",")[0]; tag != "" && tag != "-" {
+			path[i] = tag + index
+		} else if tag := field.Tag.Get("header"); tag != "" && tag != "-" {
+			path[i] = tag + index
+		} else if tag := field.Tag.Get("uri"); tag != "" && tag != "-" {
+			path[i] = tag + index
+		}
+		typ = field.Type
+	}
+	return strings.Join(path, ".")
+}
+
+func ruleMessage(rule, param string) string {
+	switch rule {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "uuid":
+		return "must be a valid UUID"
+	case "min":
+		return fmt.Sprintf("must be at least %s", param)
+	case "max":
+		return fmt.Sprintf("must be at most %s", param)
+	case "len":
+		return fmt.Sprintf("must have length %s", param)
+	case "gt", "gte", "lt", "lte":
+		operators := map[string]string{"gt": ">", "gte": ">=", "lt": "<", "lte": "<="}
+		return fmt.Sprintf("must be %s %s", operators[rule], param)
+	case "oneof":
+		return fmt.Sprintf("must be one of %s", strings.Join(strings.Fields(param), ", "))
+	default:
+		return fmt.Sprintf("failed the %s rule", rule)
+	}
+}
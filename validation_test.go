@@ -0,0 +1,63 @@
+package ginboot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+type validatedAuthor struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type validatedRequest struct {
+	Title  string          `json:"title" binding:"required,max=10"`
+	Slug   string          `json:"slug" binding:"omitempty,slug"`
+	Author validatedAuthor `json:"author"`
+	Tags   []string        `json:"tags" binding:"dive,min=2"`
+}
+
+func TestRequestValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{engine: gin.New()}
+	server.RegisterValidator("slug", func(fl validator.FieldLevel) bool {
+		return !strings.ContainsAny(fl.Field().String(), " /")
+	})
+	server.Group("").POST("/posts", func(ctx *Context, req validatedRequest) (EmptyResponse, error) {
+		return EmptyResponse{}, nil
+	})
+
+	send := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/posts", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		server.engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(`{"title":"Hello","slug":"hello","author":{"email":"john@example.com"},"tags":["go"]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = send(`{"title":"Hello world!","slug":"hello world","author":{"email":"john"},"tags":["go","x"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"error_code": "VALIDATION_FAILED",
+		"message": "Request validation failed",
+		"errors": [
+			{"field": "title", "rule": "max", "param": "10", "message": "must be at most 10"},
+			{"field": "slug", "rule": "slug", "message": "failed the slug rule"},
+			{"field": "author.email", "rule": "email", "message": "must be a valid email address"},
+			{"field": "tags[1]", "rule": "min", "param": "2", "message": "must be at least 2"}
+		]
+	}`, w.Body.String())
+
+	w = send(`{"title":`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"error_code":"INVALID_REQUEST"`)
+}
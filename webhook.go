@@ -0,0 +1,323 @@
+package ginboot
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	WebhookSignatureInvalid = ApiError{"WEBHOOK_SIGNATURE_INVALID", "Webhook signature is missing or invalid"}
+	WebhookPayloadInvalid   = ApiError{"WEBHOOK_PAYLOAD_INVALID", "Webhook payload could not be parsed"}
+	WebhookTooLarge         = ApiError{"WEBHOOK_TOO_LARGE", "Webhook payload is too large"}
+	WebhookInProgress       = ApiError{"WEBHOOK_IN_PROGRESS", "Webhook delivery is being processed"}
+)
+
+var errWebhookSignature = errors.New("webhook signature mismatch")
+
+// errWebhookSecretMissing is returned by the providers when their Secret is
+// empty, which would let anyone sign deliveries
+var errWebhookSecretMissing = errors.New("webhook secret is not configured")
+
+// webhook delivery states stored in the dedup cache
+var (
+	webhookProcessing = []byte{0}
+	webhookProcessed  = []byte{1}
+)
+
+// WebhookProvider verifies and identifies the deliveries of a webhook sender
+type WebhookProvider interface {
+	// Verify checks the delivery's signature over the raw body
+	Verify(req *http.Request, body []byte) error
+	// DeliveryID identifies the delivery, so a redelivery is processed once.
+	// An empty ID disables deduplication for the delivery.
+	DeliveryID(req *http.Request, body []byte) string
+	// EventType names the kind of event delivered, e.g. push or invoice.paid
+	EventType(req *http.Request, body []byte) string
+}
+
+// WebhookEvent is a verified delivery with its payload bound to T
+type WebhookEvent[T any] struct {
+	ID      string
+	Type    string
+	Payload T
+}
+
+type WebhookConfig struct {
+	// Cache remembers processed deliveries; without it every delivery is
+	// processed, including redeliveries
+	Cache CacheService
+	// DedupTTL is how long processed delivery IDs are remembered, which should
+	// cover the sender's retry period
+	DedupTTL time.Duration
+	// ProcessingTTL is how long a delivery being processed holds off its
+	// redeliveries, which should cover the handler's run time. A delivery whose
+	// instance died is processed again once it passes.
+	ProcessingTTL time.Duration
+	// MaxBodyBytes rejects larger payloads with 413
+	MaxBodyBytes int64
+}
+
+// DefaultWebhookConfig remembers deliveries for three days, the period Stripe
+// retries for, holds redeliveries off for five minutes while processing and
+// accepts payloads up to 1MB. Set Cache to deduplicate.
+func DefaultWebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		DedupTTL:      72 * time.Hour,
+		ProcessingTTL: 5 * time.Minute,
+		MaxBodyBytes:  1 << 20,
+	}
+}
+
+// Webhook registers a POST route receiving deliveries from provider. Deliveries
+// with an invalid signature are rejected with 401, and all deliveries with 500
+// when the provider has no secret. Verified ones are bound to T and passed to
+// handler; a handler error is sent as usual, so the sender retries on 5xx.
+// Successful and already processed deliveries are answered with 200 so the
+// sender stops retrying. The delivery ID is claimed in the cache before the
+// handler runs, so concurrent redeliveries get 409 WEBHOOK_IN_PROGRESS.
+func Webhook[T any](g *ControllerGroup, path string, provider WebhookProvider, config WebhookConfig, handler func(ctx *Context, event WebhookEvent[T]) error, middleware ...gin.HandlerFunc) {
+	defaults := DefaultWebhookConfig()
+	if config.DedupTTL <= 0 {
+		config.DedupTTL = defaults.DedupTTL
+	}
+	if config.ProcessingTTL <= 0 {
+		config.ProcessingTTL = defaults.ProcessingTTL
+	}
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = defaults.MaxBodyBytes
+	}
+
+	receive := func(c *gin.Context) {
+		ctx := NewContext(c)
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, config.MaxBodyBytes+1))
+		if err != nil {
+			ctx.SendError(err)
+			return
+		}
+		if int64(len(body)) > config.MaxBodyBytes {
+			abortWithApiError(c, http.StatusRequestEntityTooLarge, WebhookTooLarge)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if err := provider.Verify(c.Request, body); err != nil {
+			if errors.Is(err, errWebhookSecretMissing) {
+				LoggerFromContext(ctx).Error("ginboot: webhook provider has no secret", "path", c.FullPath())
+				ctx.SendError(err)
+				return
+			}
+			abortWithApiError(c, http.StatusUnauthorized, WebhookSignatureInvalid)
+			return
+		}
+
+		event := WebhookEvent[T]{
+			ID:   provider.DeliveryID(c.Request, body),
+			Type: provider.EventType(c.Request, body),
+		}
+		if err := json.Unmarshal(body, &event.Payload); err != nil {
+			abortWithApiError(c, http.StatusBadRequest, WebhookPayloadInvalid)
+			return
+		}
+
+		cacheKey := "ginboot:webhook:" + c.FullPath() + ":" + event.ID
+		dedup := config.Cache != nil && event.ID != ""
+		if dedup {
+			claimed, err := config.Cache.SetIfAbsent(ctx, cacheKey, webhookProcessing, config.ProcessingTTL)
+			if err != nil {
+				ctx.SendError(err)
+				return
+			}
+			if !claimed {
+				state, _, err := config.Cache.Get(ctx, cacheKey)
+				if err != nil {
+					ctx.SendError(err)
+					return
+				}
+				if bytes.Equal(state, webhookProcessed) {
+					c.JSON(http.StatusOK, gin.H{"received": true, "duplicate": true})
+					return
+				}
+				SetRetryAfter(c, config.ProcessingTTL)
+				abortWithApiError(c, http.StatusConflict, WebhookInProgress)
+				return
+			}
+		}
+
+		if err := handler(ctx, event); err != nil {
+			if dedup {
+				// release the claim so the sender's retry is processed
+				if err := config.Cache.Invalidate(ctx, cacheKey); err != nil {
+					LoggerFromContext(ctx).Warn("ginboot: releasing webhook delivery failed", "error", err)
+				}
+			}
+			ctx.SendError(err)
+			return
+		}
+		if dedup {
+			if err := config.Cache.Set(ctx, cacheKey, webhookProcessed, config.DedupTTL); err != nil {
+				ctx.SendError(err)
+				return
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"received": true})
+	}
+	handlers := append(append([]gin.HandlerFunc{}, middleware...), receive)
+	g.group.POST(path, handlers...)
+}
+
+// StripeWebhookProvider verifies the Stripe-Signature header of Stripe events
+type StripeWebhookProvider struct {
+	// Secret is the endpoint's signing secret, whsec_...
+	Secret string
+	// Tolerance rejects deliveries signed longer ago, five minutes by default
+	Tolerance time.Duration
+}
+
+func (p StripeWebhookProvider) Verify(req *http.Request, body []byte) error {
+	if p.Secret == "" {
+		return errWebhookSecretMissing
+	}
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(req.Header.Get("Stripe-Signature"), ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errWebhookSignature
+	}
+	tolerance := p.Tolerance
+	if tolerance <= 0 {
+		tolerance = 5 * time.Minute
+	}
+	if age := time.Since(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+		return errWebhookSignature
+	}
+
+	expected := hmacSHA256([]byte(p.Secret), []byte(timestamp+"."), body)
+	for _, signature := range signatures {
+		if decoded, err := hex.DecodeString(signature); err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return errWebhookSignature
+}
+
+func (p StripeWebhookProvider) DeliveryID(req *http.Request, body []byte) string {
+	return jsonString(body, "id")
+}
+
+func (p StripeWebhookProvider) EventType(req *http.Request, body []byte) string {
+	return jsonString(body, "type")
+}
+
+// GitHubWebhookProvider verifies the X-Hub-Signature-256 header of GitHub
+// deliveries
+type GitHubWebhookProvider struct {
+	Secret string
+}
+
+func (p GitHubWebhookProvider) Verify(req *http.Request, body []byte) error {
+	if p.Secret == "" {
+		return errWebhookSecretMissing
+	}
+	signature, ok := strings.CutPrefix(req.Header.Get("X-Hub-Signature-256"), "sha256=")
+	decoded, err := hex.DecodeString(signature)
+	if !ok || err != nil || !hmac.Equal(decoded, hmacSHA256([]byte(p.Secret), body)) {
+		return errWebhookSignature
+	}
+	return nil
+}
+
+func (p GitHubWebhookProvider) DeliveryID(req *http.Request, body []byte) string {
+	return req.Header.Get("X-GitHub-Delivery")
+}
+
+func (p GitHubWebhookProvider) EventType(req *http.Request, body []byte) string {
+	return req.Header.Get("X-GitHub-Event")
+}
+
+// HMACWebhookProvider verifies a hex or base64 HMAC-SHA256 of the body sent in
+// a header, the scheme most other senders use
+type HMACWebhookProvider struct {
+	Secret string
+	// SignatureHeader carries the signature, X-Signature by default
+	SignatureHeader string
+	// Prefix is stripped from the signature, e.g. sha256=
+	Prefix string
+	// IDHeader and TypeHeader carry the delivery ID and event type, if sent
+	IDHeader   string
+	TypeHeader string
+}
+
+func (p HMACWebhookProvider) Verify(req *http.Request, body []byte) error {
+	if p.Secret == "" {
+		return errWebhookSecretMissing
+	}
+	header := p.SignatureHeader
+	if header == "" {
+		header = "X-Signature"
+	}
+	signature, ok := strings.CutPrefix(req.Header.Get(header), p.Prefix)
+	if !ok || signature == "" {
+		return errWebhookSignature
+	}
+	expected := hmacSHA256([]byte(p.Secret), body)
+	if decoded, err := hex.DecodeString(signature); err == nil && hmac.Equal(decoded, expected) {
+		return nil
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(signature); err == nil && hmac.Equal(decoded, expected) {
+		return nil
+	}
+	return errWebhookSignature
+}
+
+func (p HMACWebhookProvider) DeliveryID(req *http.Request, body []byte) string {
+	if p.IDHeader == "" {
+		return ""
+	}
+	return req.Header.Get(p.IDHeader)
+}
+
+func (p HMACWebhookProvider) EventType(req *http.Request, body []byte) string {
+	if p.TypeHeader == "" {
+		return ""
+	}
+	return req.Header.Get(p.TypeHeader)
+}
+
+func hmacSHA256(secret []byte, parts ...[]byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	for _, part := range parts {
+		mac.Write(part)
+	}
+	return mac.Sum(nil)
+}
+
+// jsonString returns a top level string field of a JSON object
+func jsonString(body []byte, field string) string {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(body, &object); err != nil {
+		return ""
+	}
+	var value string
+	json.Unmarshal(object[field], &value)
+	return value
+}
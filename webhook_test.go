@@ -0,0 +1,158 @@
+package ginboot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type stripeEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+type githubPush struct {
+	Ref string `json:"ref"`
+}
+
+func signWebhook(secret string, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{engine: gin.New()}
+	group := server.Group("/webhooks")
+	config := DefaultWebhookConfig()
+	config.Cache = NewInMemoryCacheService(DefaultInMemoryCacheConfig())
+
+	var stripeEvents []WebhookEvent[stripeEvent]
+	failStripe := true
+	Webhook(group, "/stripe", StripeWebhookProvider{Secret: "whsec_test"}, config, func(ctx *Context, event WebhookEvent[stripeEvent]) error {
+		if failStripe {
+			return errors.New("database unavailable")
+		}
+		stripeEvents = append(stripeEvents, event)
+		return nil
+	})
+	var pushes []WebhookEvent[githubPush]
+	Webhook(group, "/github", GitHubWebhookProvider{Secret: "gh-secret"}, config, func(ctx *Context, event WebhookEvent[githubPush]) error {
+		pushes = append(pushes, event)
+		return nil
+	})
+	var generic []WebhookEvent[map[string]interface{}]
+	Webhook(group, "/generic", HMACWebhookProvider{Secret: "secret", IDHeader: "X-Webhook-ID"}, config, func(ctx *Context, event WebhookEvent[map[string]interface{}]) error {
+		generic = append(generic, event)
+		return nil
+	})
+
+	send := func(path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		server.engine.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Stripe", func(t *testing.T) {
+		body := `{"id":"evt_1","type":"invoice.paid"}`
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers := map[string]string{"Stripe-Signature": "t=" + timestamp + ",v1=" + signWebhook("whsec_test", timestamp+"."+body)}
+
+		// a failed delivery is not remembered, so the retry is processed
+		assert.Equal(t, http.StatusInternalServerError, send("/webhooks/stripe", body, headers).Code)
+		failStripe = false
+		assert.Equal(t, http.StatusOK, send("/webhooks/stripe", body, headers).Code)
+		w := send("/webhooks/stripe", body, headers)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"duplicate":true`)
+
+		assert.Len(t, stripeEvents, 1)
+		assert.Equal(t, "evt_1", stripeEvents[0].ID)
+		assert.Equal(t, "invoice.paid", stripeEvents[0].Type)
+		assert.Equal(t, "invoice.paid", stripeEvents[0].Payload.Type)
+
+		old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+		headers["Stripe-Signature"] = "t=" + old + ",v1=" + signWebhook("whsec_test", old+"."+body)
+		assert.Equal(t, http.StatusUnauthorized, send("/webhooks/stripe", body, headers).Code)
+	})
+
+	t.Run("GitHub", func(t *testing.T) {
+		body := `{"ref":"refs/heads/main"}`
+		headers := map[string]string{
+			"X-Hub-Signature-256": "sha256=" + signWebhook("gh-secret", body),
+			"X-GitHub-Delivery":   "d-1",
+			"X-GitHub-Event":      "push",
+		}
+		assert.Equal(t, http.StatusOK, send("/webhooks/github", body, headers).Code)
+		assert.Equal(t, []WebhookEvent[githubPush]{{ID: "d-1", Type: "push", Payload: githubPush{Ref: "refs/heads/main"}}}, pushes)
+
+		headers["X-Hub-Signature-256"] = "sha256=" + signWebhook("wrong", body)
+		w := send("/webhooks/github", body, headers)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "WEBHOOK_SIGNATURE_INVALID")
+	})
+
+	t.Run("Generic HMAC", func(t *testing.T) {
+		body := `{"status":"done"}`
+		headers := map[string]string{"X-Signature": signWebhook("secret", body), "X-Webhook-ID": "1"}
+		assert.Equal(t, http.StatusOK, send("/webhooks/generic", body, headers).Code)
+		assert.Equal(t, "done", generic[0].Payload["status"])
+
+		assert.Equal(t, http.StatusUnauthorized, send("/webhooks/generic", body, nil).Code)
+		headers = map[string]string{"X-Signature": signWebhook("secret", "not json"), "X-Webhook-ID": "2"}
+		assert.Equal(t, http.StatusBadRequest, send("/webhooks/generic", "not json", headers).Code)
+	})
+
+	t.Run("Empty secret", func(t *testing.T) {
+		Webhook(group, "/unconfigured", HMACWebhookProvider{}, config, func(ctx *Context, event WebhookEvent[map[string]interface{}]) error {
+			t.Fatal("handler ran for a delivery signed with an empty secret")
+			return nil
+		})
+		body := `{"status":"done"}`
+		headers := map[string]string{"X-Signature": signWebhook("", body)}
+		assert.Equal(t, http.StatusInternalServerError, send("/webhooks/unconfigured", body, headers).Code)
+	})
+
+	t.Run("In progress", func(t *testing.T) {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		Webhook(group, "/slow", HMACWebhookProvider{Secret: "secret", IDHeader: "X-Webhook-ID"}, config, func(ctx *Context, event WebhookEvent[map[string]interface{}]) error {
+			close(started)
+			<-release
+			return nil
+		})
+		body := `{"status":"done"}`
+		headers := map[string]string{"X-Signature": signWebhook("secret", body), "X-Webhook-ID": "slow-1"}
+
+		first := make(chan int)
+		go func() { first <- send("/webhooks/slow", body, headers).Code }()
+		<-started
+
+		w := send("/webhooks/slow", body, headers)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "WEBHOOK_IN_PROGRESS")
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+		close(release)
+		assert.Equal(t, http.StatusOK, <-first)
+		w = send("/webhooks/slow", body, headers)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"duplicate":true`)
+	})
+}
@@ -0,0 +1,354 @@
+package ginboot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+type HubConfig struct {
+	// AllowedOrigins lists the browser origins allowed to connect, e.g.
+	// https://app.example.com, or "*" for any. When empty only pages served
+	// from the same host can connect. Requests without an Origin header, sent
+	// by non-browser clients, are always allowed.
+	AllowedOrigins []string
+	// WriteTimeout bounds each send, so a stalled client cannot hold up
+	// broadcasts; it is then disconnected
+	WriteTimeout time.Duration
+}
+
+// DefaultHubConfig allows same-host origins and a 10 second write timeout
+func DefaultHubConfig() HubConfig {
+	return HubConfig{WriteTimeout: 10 * time.Second}
+}
+
+// Hub keeps track of the WebSocket connections of a server, grouped in rooms
+// and by authenticated user, to send messages to them from anywhere in the
+// application.
+type Hub struct {
+	config HubConfig
+	mu     sync.RWMutex
+	conns  map[*WSConn]struct{}
+	rooms  map[string]map[*WSConn]struct{}
+	users  map[string]map[*WSConn]struct{}
+	closed bool
+}
+
+func NewHub(config HubConfig) *Hub {
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = DefaultHubConfig().WriteTimeout
+	}
+	return &Hub{
+		config: config,
+		conns:  map[*WSConn]struct{}{},
+		rooms:  map[string]map[*WSConn]struct{}{},
+		users:  map[string]map[*WSConn]struct{}{},
+	}
+}
+
+// WSHandler serves a WebSocket connection, typically reading messages with
+// conn.Receive until it fails. The connection is closed when it returns.
+type WSHandler func(conn *WSConn) error
+
+// WSConn is a WebSocket connection registered with a Hub
+type WSConn struct {
+	conn *websocket.Conn
+	hub  *Hub
+	ctx  *Context
+	// UserID is the AuthContext.UserID of the user who opened the
+	// connection, empty for anonymous connections
+	UserID    string
+	closeOnce sync.Once
+}
+
+// WithWebSockets configures the hub serving the WS routes, see Hub
+func (s *Server) WithWebSockets(config HubConfig) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hub = NewHub(config)
+	s.OnStop(s.hub.Close)
+	return s
+}
+
+// Hub returns the server's WebSocket hub. Its connections are closed on
+// Shutdown.
+func (s *Server) Hub() *Hub {
+	s.mu.Lock()
+	hub := s.hub
+	s.mu.Unlock()
+	if hub == nil {
+		s.WithWebSockets(DefaultHubConfig())
+		return s.Hub()
+	}
+	return hub
+}
+
+// WS registers a WebSocket endpoint. The connection is upgraded after the
+// middleware ran, so JWTAuthMiddleware can authenticate it, and registered
+// with the server's Hub for the duration of the handler:
+//
+//	group.WS("/notifications", func(conn *ginboot.WSConn) error {
+//		conn.Join("news")
+//		var message string
+//		for {
+//			if err := conn.Receive(&message); err != nil {
+//				return err
+//			}
+//		}
+//	}, ginboot.JWTAuthMiddleware(jwtConfig))
+//
+// WebSockets are not available on Lambda.
+func (g *ControllerGroup) WS(path string, handler WSHandler, middleware ...gin.HandlerFunc) {
+	handlers := append(append([]gin.HandlerFunc{}, middleware...), g.server.Hub().serve(handler))
+	g.group.GET(path, handlers...)
+}
+
+func (h *Hub) serve(handler WSHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := NewContext(c)
+		server := websocket.Server{
+			Handshake: func(config *websocket.Config, req *http.Request) error {
+				if !h.allowsOrigin(req) {
+					return errors.New("origin not allowed")
+				}
+				return nil
+			},
+			Handler: func(ws *websocket.Conn) {
+				conn := &WSConn{conn: ws, hub: h, ctx: ctx, UserID: c.GetString("user_id")}
+				if !h.add(conn) {
+					ws.Close()
+					return
+				}
+				defer conn.Close()
+				if err := handler(conn); err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+					ctx.Logger().Warn("ginboot: websocket handler failed", "error", err)
+				}
+			},
+		}
+		server.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+func (h *Hub) allowsOrigin(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range h.config.AllowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	if len(h.config.AllowedOrigins) > 0 {
+		return false
+	}
+	parsed, err := url.Parse(origin)
+	return err == nil && parsed.Host == req.Host
+}
+
+func (h *Hub) add(conn *WSConn) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return false
+	}
+	h.conns[conn] = struct{}{}
+	if conn.UserID != "" {
+		addMember(h.users, conn.UserID, conn)
+	}
+	return true
+}
+
+func (h *Hub) remove(conn *WSConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.conns, conn)
+	removeMember(h.users, conn.UserID, conn)
+	for room := range h.rooms {
+		removeMember(h.rooms, room, conn)
+	}
+}
+
+func addMember(groups map[string]map[*WSConn]struct{}, key string, conn *WSConn) {
+	members, ok := groups[key]
+	if !ok {
+		members = map[*WSConn]struct{}{}
+		groups[key] = members
+	}
+	members[conn] = struct{}{}
+}
+
+func removeMember(groups map[string]map[*WSConn]struct{}, key string, conn *WSConn) {
+	if members, ok := groups[key]; ok {
+		delete(members, conn)
+		if len(members) == 0 {
+			delete(groups, key)
+		}
+	}
+}
+
+// Join adds the connection to a room, see BroadcastToRoom
+func (h *Hub) Join(conn *WSConn, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.conns[conn]; ok {
+		addMember(h.rooms, room, conn)
+	}
+}
+
+// Leave removes the connection from a room
+func (h *Hub) Leave(conn *WSConn, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	removeMember(h.rooms, room, conn)
+}
+
+// Broadcast sends the message to every connection. Strings are sent as text
+// messages, []byte as binary messages and other values as JSON. Connections
+// failing to receive it are closed.
+func (h *Hub) Broadcast(message interface{}) error {
+	h.mu.RLock()
+	conns := make([]*WSConn, 0, len(h.conns))
+	for conn := range h.conns {
+		conns = append(conns, conn)
+	}
+	h.mu.RUnlock()
+	return h.sendAll(conns, message)
+}
+
+// BroadcastToRoom sends the message to the connections in the room, like
+// Broadcast
+func (h *Hub) BroadcastToRoom(room string, message interface{}) error {
+	return h.sendAll(h.members(h.rooms, room), message)
+}
+
+// SendToUser sends the message to every connection of the user, like
+// Broadcast
+func (h *Hub) SendToUser(userID string, message interface{}) error {
+	return h.sendAll(h.members(h.users, userID), message)
+}
+
+// Count returns the number of open connections
+func (h *Hub) Count() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.conns)
+}
+
+// Close closes every connection and refuses new ones. It is registered as a
+// shutdown hook of the server owning the hub.
+func (h *Hub) Close(ctx context.Context) error {
+	h.mu.Lock()
+	h.closed = true
+	conns := make([]*WSConn, 0, len(h.conns))
+	for conn := range h.conns {
+		conns = append(conns, conn)
+	}
+	h.mu.Unlock()
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+	return nil
+}
+
+func (h *Hub) members(groups map[string]map[*WSConn]struct{}, key string) []*WSConn {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	conns := make([]*WSConn, 0, len(groups[key]))
+	for conn := range groups[key] {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+func (h *Hub) sendAll(conns []*WSConn, message interface{}) error {
+	payload, binary, err := encodeWSMessage(message)
+	if err != nil {
+		return err
+	}
+	for _, conn := range conns {
+		if err := conn.write(payload, binary); err != nil {
+			conn.Close()
+		}
+	}
+	return nil
+}
+
+func encodeWSMessage(message interface{}) ([]byte, bool, error) {
+	switch m := message.(type) {
+	case string:
+		return []byte(m), false, nil
+	case []byte:
+		return m, true, nil
+	}
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return nil, false, fmt.Errorf("encoding websocket message: %w", err)
+	}
+	return payload, false, nil
+}
+
+// Context returns the context of the request that opened the connection
+func (c *WSConn) Context() *Context {
+	return c.ctx
+}
+
+// Receive reads the next message into v: a *string or *[]byte receives it as
+// is, other values are decoded from JSON
+func (c *WSConn) Receive(v interface{}) error {
+	switch v.(type) {
+	case *string, *[]byte:
+		return websocket.Message.Receive(c.conn, v)
+	}
+	return websocket.JSON.Receive(c.conn, v)
+}
+
+// Send sends the message to this connection, encoded like Hub.Broadcast
+func (c *WSConn) Send(message interface{}) error {
+	payload, binary, err := encodeWSMessage(message)
+	if err != nil {
+		return err
+	}
+	return c.write(payload, binary)
+}
+
+func (c *WSConn) write(payload []byte, binary bool) error {
+	if err := c.conn.SetWriteDeadline(time.Now().Add(c.hub.config.WriteTimeout)); err != nil {
+		return err
+	}
+	if binary {
+		return websocket.Message.Send(c.conn, payload)
+	}
+	return websocket.Message.Send(c.conn, string(payload))
+}
+
+// Join adds the connection to a room of its hub
+func (c *WSConn) Join(room string) {
+	c.hub.Join(c, room)
+}
+
+// Leave removes the connection from a room of its hub
+func (c *WSConn) Leave(room string) {
+	c.hub.Leave(c, room)
+}
+
+// Close unregisters and closes the connection
+func (c *WSConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.hub.remove(c)
+		err = c.conn.Close()
+	})
+	return err
+}
@@ -0,0 +1,95 @@
+package ginboot
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+func TestWebSocketHub(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{engine: gin.New()}
+	joined := make(chan struct{}, 2)
+	server.Group("/").WS("/ws", func(conn *WSConn) error {
+		conn.Join(conn.Context().Query("room"))
+		joined <- struct{}{}
+		var message map[string]string
+		for {
+			if err := conn.Receive(&message); err != nil {
+				return err
+			}
+			if err := conn.Send(map[string]string{"echo": message["text"]}); err != nil {
+				return err
+			}
+		}
+	}, func(c *gin.Context) {
+		c.Set("user_id", c.Query("user"))
+		c.Next()
+	})
+
+	httpServer := httptest.NewServer(server.engine)
+	defer httpServer.Close()
+	wsURL := "ws" + strings.TrimPrefix(httpServer.URL, "http") + "/ws"
+
+	alice, err := websocket.Dial(wsURL+"?user=alice&room=news", "", httpServer.URL)
+	assert.NoError(t, err)
+	defer alice.Close()
+	bob, err := websocket.Dial(wsURL+"?user=bob&room=sports", "", httpServer.URL)
+	assert.NoError(t, err)
+	defer bob.Close()
+	<-joined
+	<-joined
+
+	hub := server.Hub()
+	assert.Equal(t, 2, hub.Count())
+
+	receive := func(ws *websocket.Conn) string {
+		ws.SetReadDeadline(time.Now().Add(time.Second))
+		var message string
+		assert.NoError(t, websocket.Message.Receive(ws, &message))
+		return message
+	}
+
+	assert.NoError(t, websocket.JSON.Send(alice, map[string]string{"text": "hi"}))
+	assert.Equal(t, `{"echo":"hi"}`, receive(alice))
+
+	assert.NoError(t, hub.BroadcastToRoom("news", "breaking"))
+	assert.Equal(t, "breaking", receive(alice))
+
+	assert.NoError(t, hub.SendToUser("bob", map[string]int{"score": 2}))
+	assert.Equal(t, `{"score":2}`, receive(bob))
+
+	assert.NoError(t, hub.Broadcast("all"))
+	assert.Equal(t, "all", receive(alice))
+	assert.Equal(t, "all", receive(bob))
+
+	assert.NoError(t, hub.Close(context.Background()))
+	assert.Equal(t, 0, hub.Count())
+	var message string
+	bob.SetReadDeadline(time.Now().Add(time.Second))
+	assert.Error(t, websocket.Message.Receive(bob, &message))
+}
+
+func TestHub_AllowsOrigin(t *testing.T) {
+	request := httptest.NewRequest("GET", "http://api.example.com/ws", nil)
+
+	sameHost := NewHub(DefaultHubConfig())
+	assert.True(t, sameHost.allowsOrigin(request))
+	request.Header.Set("Origin", "http://api.example.com")
+	assert.True(t, sameHost.allowsOrigin(request))
+	request.Header.Set("Origin", "https://evil.example.org")
+	assert.False(t, sameHost.allowsOrigin(request))
+
+	listed := NewHub(HubConfig{AllowedOrigins: []string{"https://app.example.com"}})
+	request.Header.Set("Origin", "https://app.example.com")
+	assert.True(t, listed.allowsOrigin(request))
+	request.Header.Set("Origin", "http://api.example.com")
+	assert.False(t, listed.allowsOrigin(request))
+}